@@ -0,0 +1,341 @@
+package anomaly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// Kinds of anomalous publish activity.
+const (
+	KindNewPublisher   = "new_publisher"
+	KindBurstPublish   = "burst_publish"
+	KindRepoOrgChanged = "repo_org_changed"
+)
+
+// Config defines the thresholds of the publish anomaly analyzer.
+type Config struct {
+	// StableAge defines how long a module must have existed before a publish
+	// by a user who never published it before is flagged.
+	StableAge time.Duration `yaml:"stable_age"`
+
+	// BurstCount and BurstWindow flag modules publishing at least BurstCount
+	// versions within BurstWindow. A zero BurstCount disables the check.
+	BurstCount  int           `yaml:"burst_count"`
+	BurstWindow time.Duration `yaml:"burst_window"`
+
+	// Lookback defines how far back each run of the analyzer looks for
+	// activity. Activity already flagged is not flagged again.
+	Lookback time.Duration `yaml:"lookback"`
+}
+
+type (
+	// Publish defines the publish of a module version.
+	Publish struct {
+		VersionID   int
+		Version     string
+		PublishedBy int
+		PublishedAt time.Time
+	}
+
+	// Anomaly defines anomalous publish activity flagged on a module. Each
+	// anomaly files a Report in the moderation queue and notifies the owners
+	// of the module.
+	Anomaly struct {
+		ID          int       `json:"id" yaml:"id" db:"id"`
+		ModuleID    int       `json:"-" yaml:"-" db:"module_id"`
+		Kind        string    `json:"kind" yaml:"kind" db:"kind"`
+		Fingerprint string    `json:"-" yaml:"-" db:"fingerprint"`
+		Details     string    `json:"details" yaml:"details" db:"details"`
+		ReportID    int       `json:"report_id,omitempty" yaml:"report_id,omitempty" db:"report_id"`
+		CreatedAt   time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+	}
+)
+
+// DefaultConfig returns the default analyzer thresholds.
+func DefaultConfig() Config {
+	return Config{
+		StableAge:   180 * 24 * time.Hour,
+		BurstCount:  10,
+		BurstWindow: time.Hour,
+		Lookback:    24 * time.Hour,
+	}
+}
+
+// NewPublisher returns true if p was published by a user who published none
+// of the earlier versions of a module whose first version is older than
+// stableAge. Publishes by unknown users, e.g. predating publisher tracking,
+// are never flagged and are not counted as previous publishers, so at least
+// one earlier publish by a known user is required.
+func NewPublisher(history []Publish, p Publish, stableAge time.Duration) bool {
+	if p.PublishedBy == 0 {
+		return false
+	}
+
+	var (
+		first time.Time
+		known bool
+	)
+
+	for _, h := range history {
+		if !h.PublishedAt.Before(p.PublishedAt) {
+			continue
+		}
+
+		if h.PublishedBy == p.PublishedBy {
+			return false
+		}
+
+		known = known || h.PublishedBy != 0
+		if first.IsZero() || h.PublishedAt.Before(first) {
+			first = h.PublishedAt
+		}
+	}
+
+	return known && p.PublishedAt.Sub(first) >= stableAge
+}
+
+// Burst returns the first publish of the earliest window of the given length
+// holding at least count publishes.
+func Burst(publishes []Publish, window time.Duration, count int) (Publish, bool) {
+	if count <= 0 || len(publishes) < count {
+		return Publish{}, false
+	}
+
+	sorted := make([]Publish, len(publishes))
+	copy(sorted, publishes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].PublishedAt.Before(sorted[j].PublishedAt) })
+
+	for i := 0; i+count <= len(sorted); i++ {
+		if sorted[i+count-1].PublishedAt.Sub(sorted[i].PublishedAt) <= window {
+			return sorted[i], true
+		}
+	}
+
+	return Publish{}, false
+}
+
+// RepoOrgChanged returns true if a module repository moved to a different
+// host or owner, e.g. from github.com/cosmos to github.com/someone-else.
+// Renames within the same owner are not flagged.
+func RepoOrgChanged(oldRepo, newRepo string) bool {
+	oldOwner, newOwner := repoOwner(oldRepo), repoOwner(newRepo)
+	return oldOwner != "" && newOwner != "" && oldOwner != newOwner
+}
+
+// repoOwner returns the lower-cased host and owner of a repository URL.
+func repoOwner(repo string) string {
+	path := module.GoModulePath(repo)
+	if i := strings.Index(path, "/"); i >= 0 {
+		if j := strings.Index(path[i+1:], "/"); j >= 0 {
+			return path[:i+1+j]
+		}
+	}
+
+	return path
+}
+
+// Analyzer flags anomalous publish activity as an early warning of account
+// takeovers.
+type Analyzer struct {
+	db  *sql.DB
+	cfg Config
+}
+
+// NewAnalyzer returns an Analyzer using the given thresholds.
+func NewAnalyzer(db *sql.DB, cfg Config) *Analyzer {
+	return &Analyzer{db: db, cfg: cfg}
+}
+
+// Run analyzes the publish activity within the lookback window, flagging new
+// anomalies. It returns the number of anomalies flagged and is run by the
+// scheduled detect_anomalies job.
+func (a *Analyzer) Run(ctx context.Context) (int, error) {
+	since := time.Now().UTC().Add(-a.cfg.Lookback)
+
+	anomalies, err := a.detectPublishes(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	repoAnomalies, err := a.detectRepoChanges(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, an := range append(anomalies, repoAnomalies...) {
+		flagged, err := a.flag(ctx, an)
+		if err != nil {
+			return n, fmt.Errorf("failed to flag %s anomaly on module %d: %w", an.Kind, an.ModuleID, err)
+		}
+
+		if flagged {
+			log.Printf("flagged %s anomaly on module %d: %s", an.Kind, an.ModuleID, an.Details)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (a *Analyzer) detectPublishes(ctx context.Context, since time.Time) ([]Anomaly, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT mv.module_id, mv.id, mv.version, COALESCE(mv.published_by, 0), mv.created_at
+		FROM module_versions mv
+		WHERE mv.module_id IN (SELECT module_id FROM module_versions WHERE created_at >= $1)
+		ORDER BY mv.module_id, mv.created_at`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[int][]Publish)
+	for rows.Next() {
+		var (
+			moduleID int
+			p        Publish
+		)
+
+		if err := rows.Scan(&moduleID, &p.VersionID, &p.Version, &p.PublishedBy, &p.PublishedAt); err != nil {
+			return nil, err
+		}
+
+		history[moduleID] = append(history[moduleID], p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var anomalies []Anomaly
+	for moduleID, publishes := range history {
+		var recent []Publish
+
+		for _, p := range publishes {
+			if p.PublishedAt.Before(since) {
+				continue
+			}
+
+			recent = append(recent, p)
+
+			if NewPublisher(publishes, p, a.cfg.StableAge) {
+				anomalies = append(anomalies, Anomaly{
+					ModuleID:    moduleID,
+					Kind:        KindNewPublisher,
+					Fingerprint: fmt.Sprintf("version:%d", p.VersionID),
+					Details:     fmt.Sprintf("version %s was published by a user who never published this module before", p.Version),
+				})
+			}
+		}
+
+		if p, ok := Burst(recent, a.cfg.BurstWindow, a.cfg.BurstCount); ok {
+			anomalies = append(anomalies, Anomaly{
+				ModuleID:    moduleID,
+				Kind:        KindBurstPublish,
+				Fingerprint: "burst:" + p.PublishedAt.Format("2006-01-02"),
+				Details:     fmt.Sprintf("%d or more versions were published within %s, starting with %s", a.cfg.BurstCount, a.cfg.BurstWindow, p.Version),
+			})
+		}
+	}
+
+	return anomalies, nil
+}
+
+func (a *Analyzer) detectRepoChanges(ctx context.Context, since time.Time) ([]Anomaly, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT id, module_id, COALESCE(old_repo, ''), COALESCE(new_repo, '')
+		FROM module_repo_history
+		WHERE changed_at >= $1`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var anomalies []Anomaly
+	for rows.Next() {
+		var (
+			id, moduleID     int
+			oldRepo, newRepo string
+		)
+
+		if err := rows.Scan(&id, &moduleID, &oldRepo, &newRepo); err != nil {
+			return nil, err
+		}
+
+		if RepoOrgChanged(oldRepo, newRepo) {
+			anomalies = append(anomalies, Anomaly{
+				ModuleID:    moduleID,
+				Kind:        KindRepoOrgChanged,
+				Fingerprint: fmt.Sprintf("repo_history:%d", id),
+				Details:     fmt.Sprintf("repository changed from %s to %s", oldRepo, newRepo),
+			})
+		}
+	}
+
+	return anomalies, rows.Err()
+}
+
+// flag records an anomaly, files a report in the moderation queue and
+// notifies the owners of the module. It returns false if the anomaly was
+// already flagged.
+func (a *Analyzer) flag(ctx context.Context, an Anomaly) (bool, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO anomalies (module_id, kind, fingerprint, details)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (module_id, kind, fingerprint) DO NOTHING
+		RETURNING id`,
+		an.ModuleID, an.Kind, an.Fingerprint, an.Details,
+	).Scan(&an.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO reports (module_id, reason, details, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		an.ModuleID, module.ReportReasonSuspicious, an.Details, module.ReportStatusOpen,
+	).Scan(&an.ReportID); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE anomalies SET report_id = $1 WHERE id = $2`, an.ReportID, an.ID); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, kind, module_id, anomaly_id)
+		SELECT m.author, $2, m.id, $3
+		FROM modules m
+		WHERE m.id = $1 AND m.author IS NOT NULL
+		UNION
+		SELECT mu.user_id, $2, mu.module_id, $3
+		FROM modules_users mu
+		WHERE mu.module_id = $1`,
+		an.ModuleID, module.NotificationAnomaly, an.ID,
+	); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
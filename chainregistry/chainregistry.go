@@ -0,0 +1,329 @@
+package chainregistry
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// DefaultRepo defines the GitHub repository of the public Cosmos chain
+// registry.
+const DefaultRepo = "cosmos/chain-registry"
+
+// DefaultTimeout defines the default timeout of a single chain registry
+// request.
+const DefaultTimeout = 30 * time.Second
+
+// maxFileSize bounds the size of a file fetched from a repository.
+const maxFileSize = 1 << 20
+
+// majorSuffixRegex matches the major version suffix of a Go module path.
+var majorSuffixRegex = regexp.MustCompile(`/v[0-9]+$`)
+
+type (
+	// ChainInfo defines the subset of a chain registry chain.json used to
+	// record module usage.
+	ChainInfo struct {
+		ChainName   string   `json:"chain_name"`
+		ChainID     string   `json:"chain_id"`
+		PrettyName  string   `json:"pretty_name"`
+		NetworkType string   `json:"network_type"`
+		Status      string   `json:"status"`
+		Codebase    Codebase `json:"codebase"`
+	}
+
+	// Codebase defines the source repository and version a chain runs.
+	Codebase struct {
+		GitRepo            string `json:"git_repo"`
+		RecommendedVersion string `json:"recommended_version"`
+	}
+
+	// Requirement defines a Go module required by a chain's codebase.
+	Requirement struct {
+		Path    string
+		Version string
+	}
+
+	contentEntry struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+)
+
+// Client fetches chains and their codebases from the chain registry hosted on
+// GitHub.
+type Client struct {
+	client *http.Client
+	token  string
+	repo   string
+	apiURL string
+	rawURL string
+}
+
+// NewClient returns a Client of the chain registry in the given GitHub
+// repository (DefaultRepo if empty). The token, if non-empty, authenticates
+// GitHub API requests to raise rate limits.
+func NewClient(client *http.Client, repo, token string) *Client {
+	if repo == "" {
+		repo = DefaultRepo
+	}
+
+	return &Client{
+		client: client,
+		token:  token,
+		repo:   repo,
+		apiURL: "https://api.github.com",
+		rawURL: "https://raw.githubusercontent.com",
+	}
+}
+
+// Chains returns the chains listed at the root of the chain registry. Chains
+// without a chain.json or which are no longer live are skipped.
+func (c *Client) Chains(ctx context.Context) ([]ChainInfo, error) {
+	code, bz, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/contents/", c.apiURL, c.repo))
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("failed to list chain registry: GitHub responded %d", code)
+	}
+
+	var entries []contentEntry
+	if err := json.Unmarshal(bz, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode chain registry listing: %w", err)
+	}
+
+	var chains []ChainInfo
+	for _, e := range entries {
+		if e.Type != "dir" || strings.HasPrefix(e.Name, ".") || strings.HasPrefix(e.Name, "_") {
+			continue
+		}
+
+		code, bz, err := c.get(ctx, fmt.Sprintf("%s/%s/master/%s/chain.json", c.rawURL, c.repo, url.PathEscape(e.Name)))
+		if err != nil {
+			return nil, err
+		}
+		if code != http.StatusOK {
+			continue
+		}
+
+		var ci ChainInfo
+		if err := json.Unmarshal(bz, &ci); err != nil {
+			log.Printf("skipping chain %s: invalid chain.json: %v", e.Name, err)
+			continue
+		}
+
+		if ci.ChainName == "" || ci.ChainID == "" || (ci.Status != "" && ci.Status != "live") {
+			continue
+		}
+
+		chains = append(chains, ci)
+	}
+
+	return chains, nil
+}
+
+// Requirements returns the Go modules required by the go.mod of the chain's
+// codebase at its recommended version. Replaced requirements are reported at
+// the replacement version when the replacement is itself versioned.
+func (c *Client) Requirements(ctx context.Context, ci ChainInfo) ([]Requirement, error) {
+	path := module.GoModulePath(ci.Codebase.GitRepo)
+	if !strings.HasPrefix(path, "github.com/") || ci.Codebase.RecommendedVersion == "" {
+		return nil, nil
+	}
+
+	slug := strings.TrimPrefix(path, "github.com/")
+	u := fmt.Sprintf("%s/%s/%s/go.mod", c.rawURL, slug, url.PathEscape(ci.Codebase.RecommendedVersion))
+
+	code, bz, err := c.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if code == http.StatusNotFound {
+		return nil, nil
+	} else if code != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch go.mod of %s: GitHub responded %d", ci.ChainName, code)
+	}
+
+	f, err := modfile.ParseLax("go.mod", bz, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod of %s: %w", ci.ChainName, err)
+	}
+
+	replaced := make(map[string]string, len(f.Replace))
+	for _, r := range f.Replace {
+		if r.New.Version != "" {
+			replaced[r.Old.Path] = r.New.Version
+		}
+	}
+
+	reqs := make([]Requirement, 0, len(f.Require))
+	for _, r := range f.Require {
+		version := r.Mod.Version
+		if v, ok := replaced[r.Mod.Path]; ok {
+			version = v
+		}
+
+		reqs = append(reqs, Requirement{Path: r.Mod.Path, Version: version})
+	}
+
+	return reqs, nil
+}
+
+func (c *Client) get(ctx context.Context, u string) (int, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if c.token != "" && strings.HasPrefix(u, c.apiURL) {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	bz, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+	return resp.StatusCode, bz, err
+}
+
+// Sync ingests every chain of the chain registry, recording which public
+// modules each chain includes and at which version. Usage previously ingested
+// from the registry is replaced; self-reported usage is left untouched. A
+// chain whose codebase cannot be fetched is skipped and keeps its previous
+// usage.
+func Sync(ctx context.Context, db *sql.DB, c *Client) error {
+	modules, err := modulePaths(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	chains, err := c.Chains(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ci := range chains {
+		reqs, err := c.Requirements(ctx, ci)
+		if err != nil {
+			log.Printf("skipping chain %s: %v", ci.ChainName, err)
+			continue
+		}
+
+		if err := syncChain(ctx, db, ci, Match(reqs, modules)); err != nil {
+			return fmt.Errorf("failed to sync chain %s: %w", ci.ChainName, err)
+		}
+	}
+
+	return nil
+}
+
+// Match returns the versions of the registered modules, keyed by module ID,
+// required by a chain. The modules map registered Go module paths, as returned
+// by module.GoModulePath, to module IDs. Major version suffixes of required
+// paths are ignored.
+func Match(reqs []Requirement, modules map[string]int) map[int]string {
+	matched := make(map[int]string)
+
+	for _, r := range reqs {
+		path := majorSuffixRegex.ReplaceAllString(strings.ToLower(r.Path), "")
+		if id, ok := modules[path]; ok {
+			matched[id] = r.Version
+		}
+	}
+
+	return matched
+}
+
+// modulePaths returns the IDs of public modules keyed by the Go module path of
+// their repository.
+func modulePaths(ctx context.Context, db *sql.DB) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, repo
+		FROM modules
+		WHERE visibility = 'public'`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	modules := make(map[string]int)
+	for rows.Next() {
+		var (
+			id   int
+			repo string
+		)
+
+		if err := rows.Scan(&id, &repo); err != nil {
+			return nil, err
+		}
+
+		if path := module.GoModulePath(repo); path != "" {
+			modules[path] = id
+		}
+	}
+
+	return modules, rows.Err()
+}
+
+func syncChain(ctx context.Context, db *sql.DB, ci ChainInfo, usage map[int]string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	var chainID int
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO chains (name, network_id, pretty_name, network_type, repo)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name) DO UPDATE
+		SET network_id = EXCLUDED.network_id, pretty_name = EXCLUDED.pretty_name,
+			network_type = EXCLUDED.network_type, repo = EXCLUDED.repo, updated_at = NOW()
+		RETURNING id`,
+		ci.ChainName, ci.ChainID, ci.PrettyName, ci.NetworkType, ci.Codebase.GitRepo,
+	).Scan(&chainID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM chain_modules
+		WHERE chain_id = $1 AND source = $2`,
+		chainID, module.ChainSourceRegistry,
+	); err != nil {
+		return err
+	}
+
+	for moduleID, version := range usage {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO chain_modules (chain_id, module_id, version, source)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (chain_id, module_id) DO NOTHING`,
+			chainID, moduleID, version, module.ChainSourceRegistry,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
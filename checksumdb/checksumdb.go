@@ -0,0 +1,316 @@
+package checksumdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// TileHeight defines the height of the tiles served by the checksum database,
+// matching the Go checksum database.
+const TileHeight = 8
+
+var (
+	// ErrChecksumRewrite is returned when appending a checksum for a module
+	// version already logged with a different checksum. Published versions are
+	// immutable, so this indicates a rewrite attempt.
+	ErrChecksumRewrite = errors.New("module version already logged with a different checksum")
+
+	// ErrNotLogged is returned when looking up a module version that is not in
+	// the log.
+	ErrNotLogged = errors.New("module version not in checksum database")
+)
+
+// Record returns the log record of a module version checksum, in the form
+// "<module> <version> sha256:<checksum>\n".
+func Record(name, version, checksum string) []byte {
+	return []byte(fmt.Sprintf("%s %s sha256:%s\n", name, version, checksum))
+}
+
+// Log defines the append-only transparency log of module version checksums,
+// stored in Postgres and signed with a note signer. Every tree head is
+// signed, so clients caching a tree head can detect if the registry ever
+// rewrites or removes a logged checksum.
+type Log struct {
+	db     *sql.DB
+	signer note.Signer
+}
+
+// NewLog returns a Log signing tree heads with the given note signer key, as
+// generated by note.GenerateKey.
+func NewLog(db *sql.DB, signerKey string) (*Log, error) {
+	signer, err := note.NewSigner(signerKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum database signer key: %w", err)
+	}
+
+	return &Log{db: db, signer: signer}, nil
+}
+
+// Append logs the checksum of a module version, returning its record ID. It
+// is a no-op if the version is already logged with the same checksum, and
+// returns ErrChecksumRewrite if it is logged with a different one. Appends are
+// serialized by an advisory lock.
+func (l *Log) Append(ctx context.Context, name, version, checksum string) (int64, error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('checksum_log'))`); err != nil {
+		return 0, err
+	}
+
+	var (
+		id     int64
+		logged string
+	)
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, checksum FROM checksum_records WHERE module = $1 AND version = $2`,
+		name, version,
+	).Scan(&id, &logged)
+	switch {
+	case err == nil && logged == checksum:
+		return id, nil
+	case err == nil:
+		return 0, fmt.Errorf("%w: %s@%s", ErrChecksumRewrite, name, version)
+	case !errors.Is(err, sql.ErrNoRows):
+		return 0, err
+	}
+
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM checksum_records`).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	data := Record(name, version, checksum)
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO checksum_records (id, module, version, checksum, data)
+		VALUES ($1, $2, $3, $4, $5)`,
+		id, name, version, checksum, data,
+	); err != nil {
+		return 0, err
+	}
+
+	reader := hashReader(ctx, tx)
+
+	hashes, err := tlog.StoredHashes(id, data, reader)
+	if err != nil {
+		return 0, err
+	}
+
+	base := tlog.StoredHashIndex(0, id)
+	for i, h := range hashes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO checksum_hashes (idx, hash) VALUES ($1, $2)`,
+			base+int64(i), h[:],
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	tree := tlog.Tree{N: id + 1}
+	if tree.Hash, err = tlog.TreeHash(tree.N, reader); err != nil {
+		return 0, err
+	}
+
+	signed, err := note.Sign(&note.Note{Text: string(tlog.FormatTree(tree))}, l.signer)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO checksum_tree_heads (size, hash, signed) VALUES ($1, $2, $3)`,
+		tree.N, tree.Hash[:], signed,
+	); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// Sync appends every published module version that is not yet logged, in
+// publish order, returning the number of records appended. Versions remain
+// logged once appended, even if later yanked, quarantined or deleted.
+func (l *Log) Sync(ctx context.Context) (int, error) {
+	rows, err := l.db.QueryContext(ctx, `
+		SELECT m.name, mv.version, mv.checksum
+		FROM module_versions mv
+		JOIN modules m ON m.id = mv.module_id
+		WHERE mv.status = 'published'
+			AND NOT EXISTS (
+				SELECT 1 FROM checksum_records cr
+				WHERE cr.module = m.name AND cr.version = mv.version
+			)
+		ORDER BY mv.created_at, mv.id`,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	type entry struct{ name, version, checksum string }
+
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.name, &e.version, &e.checksum); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, e := range entries {
+		if _, err := l.Append(ctx, e.name, e.version, e.checksum); err != nil {
+			return n, fmt.Errorf("failed to log %s@%s: %w", e.name, e.version, err)
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// Latest returns the size of the log and the signed note of its latest tree
+// head. An empty log has no tree head and returns ErrNotLogged.
+func Latest(ctx context.Context, db *sql.DB) (int64, []byte, error) {
+	var (
+		size   int64
+		signed []byte
+	)
+
+	err := db.QueryRowContext(ctx, `
+		SELECT size, signed FROM checksum_tree_heads ORDER BY size DESC LIMIT 1`,
+	).Scan(&size, &signed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, ErrNotLogged
+	}
+
+	return size, signed, err
+}
+
+// Lookup returns the record ID of a logged module version.
+func Lookup(ctx context.Context, db *sql.DB, name, version string) (int64, error) {
+	var id int64
+
+	err := db.QueryRowContext(ctx, `
+		SELECT id FROM checksum_records WHERE module = $1 AND version = $2`,
+		name, version,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s@%s", ErrNotLogged, name, version)
+	}
+
+	return id, err
+}
+
+// ReadRecords returns the data of the n records id through id+n-1.
+func ReadRecords(ctx context.Context, db *sql.DB, id, n int64) ([][]byte, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT data FROM checksum_records WHERE id >= $1 AND id < $2 ORDER BY id`,
+		id, id+n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records [][]byte
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		records = append(records, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if int64(len(records)) != n {
+		return nil, fmt.Errorf("%w: records %d through %d", ErrNotLogged, id, id+n-1)
+	}
+
+	return records, nil
+}
+
+// ReadTileData returns the data of a hash tile of the log.
+func ReadTileData(ctx context.Context, db *sql.DB, t tlog.Tile) ([]byte, error) {
+	return tlog.ReadTileData(t, hashReader(ctx, db))
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// hashReader returns a tlog.HashReader reading stored hashes from the
+// checksum_hashes table.
+func hashReader(ctx context.Context, q querier) tlog.HashReader {
+	return tlog.HashReaderFunc(func(indexes []int64) ([]tlog.Hash, error) {
+		if len(indexes) == 0 {
+			return nil, nil
+		}
+
+		args := make([]interface{}, len(indexes))
+		params := make([]string, len(indexes))
+		for i, idx := range indexes {
+			args[i] = idx
+			params[i] = fmt.Sprintf("$%d", i+1)
+		}
+
+		rows, err := q.QueryContext(ctx, fmt.Sprintf(
+			`SELECT idx, hash FROM checksum_hashes WHERE idx IN (%s)`, strings.Join(params, ", "),
+		), args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		found := make(map[int64]tlog.Hash, len(indexes))
+		for rows.Next() {
+			var (
+				idx int64
+				bz  []byte
+				h   tlog.Hash
+			)
+
+			if err := rows.Scan(&idx, &bz); err != nil {
+				return nil, err
+			}
+
+			copy(h[:], bz)
+			found[idx] = h
+		}
+
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		hashes := make([]tlog.Hash, len(indexes))
+		for i, idx := range indexes {
+			h, ok := found[idx]
+			if !ok {
+				return nil, fmt.Errorf("missing stored hash %d", idx)
+			}
+
+			hashes[i] = h
+		}
+
+		return hashes, nil
+	})
+}
@@ -0,0 +1,158 @@
+package checksumdb_test
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/mod/sumdb/tlog"
+
+	"github.com/cosmos/atlas/checksumdb"
+	"github.com/cosmos/atlas/testutil"
+)
+
+func TestRecord(t *testing.T) {
+	got := string(checksumdb.Record("x/liquidity", "v1.2.0", "8e2f2c0a"))
+	if want := "x/liquidity v1.2.0 sha256:8e2f2c0a\n"; got != want {
+		t.Errorf("expected record %q, got %q", want, got)
+	}
+}
+
+func TestNewLogInvalidKey(t *testing.T) {
+	if _, err := checksumdb.NewLog(nil, "PRIVATE+KEY+atlas+invalid"); err == nil {
+		t.Error("expected an invalid signer key to be rejected")
+	}
+}
+
+func TestLog(t *testing.T) {
+	sqlDB, _ := testutil.NewDB(t)
+	ctx := context.Background()
+
+	skey, vkey, err := note.GenerateKey(rand.Reader, "atlas.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := checksumdb.NewLog(sqlDB, skey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := checksumdb.Latest(ctx, sqlDB); !errors.Is(err, checksumdb.ErrNotLogged) {
+		t.Errorf("expected %v for an empty log, got %v", checksumdb.ErrNotLogged, err)
+	}
+
+	entries := [][3]string{
+		{"x/liquidity", "v1.0.0", "8e2f2c0a"},
+		{"x/liquidity", "v1.2.0", "5b1d7e93"},
+		{"x/oracle", "v0.2.0", "c3a9d4f1"},
+	}
+
+	for i, e := range entries {
+		id, err := log.Append(ctx, e[0], e[1], e[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if id != int64(i) {
+			t.Errorf("expected record %d, got %d", i, id)
+		}
+	}
+
+	if id, err := log.Append(ctx, "x/liquidity", "v1.2.0", "5b1d7e93"); err != nil || id != 1 {
+		t.Errorf("expected re-logging the same checksum to return record 1, got %d, %v", id, err)
+	}
+
+	if _, err := log.Append(ctx, "x/liquidity", "v1.2.0", "00000000"); !errors.Is(err, checksumdb.ErrChecksumRewrite) {
+		t.Errorf("expected %v rewriting a checksum, got %v", checksumdb.ErrChecksumRewrite, err)
+	}
+
+	size, signed, err := checksumdb.Latest(ctx, sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size != int64(len(entries)) {
+		t.Fatalf("expected a tree of %d records, got %d", len(entries), size)
+	}
+
+	n, err := note.Open(signed, note.VerifierList(verifier))
+	if err != nil {
+		t.Fatalf("expected a tree head signed by the log, got %v", err)
+	}
+
+	tree, err := tlog.ParseTree([]byte(n.Text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := checksumdb.ReadRecords(ctx, sqlDB, 0, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := checksumdb.ReadRecords(ctx, sqlDB, 0, size+1); !errors.Is(err, checksumdb.ErrNotLogged) {
+		t.Errorf("expected %v reading past the end of the log, got %v", checksumdb.ErrNotLogged, err)
+	}
+
+	// rebuild the tree from the records alone and check that it matches the
+	// signed tree head
+	var hashes []tlog.Hash
+	reader := tlog.HashReaderFunc(func(indexes []int64) ([]tlog.Hash, error) {
+		out := make([]tlog.Hash, len(indexes))
+		for i, idx := range indexes {
+			out[i] = hashes[idx]
+		}
+
+		return out, nil
+	})
+
+	for id, data := range records {
+		stored, err := tlog.StoredHashes(int64(id), data, reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hashes = append(hashes, stored...)
+	}
+
+	th, err := tlog.TreeHash(size, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if th != tree.Hash {
+		t.Errorf("expected tree hash %s, got %s", th, tree.Hash)
+	}
+
+	id, err := checksumdb.Lookup(ctx, sqlDB, "x/oracle", "v0.2.0")
+	if err != nil || id != 2 {
+		t.Errorf("expected x/oracle@v0.2.0 to be record 2, got %d, %v", id, err)
+	}
+
+	if _, err := checksumdb.Lookup(ctx, sqlDB, "x/oracle", "v9.9.9"); !errors.Is(err, checksumdb.ErrNotLogged) {
+		t.Errorf("expected %v for an unlogged version, got %v", checksumdb.ErrNotLogged, err)
+	}
+
+	tile := tlog.TileForIndex(checksumdb.TileHeight, tlog.StoredHashIndex(0, size-1))
+	data, err := checksumdb.ReadTileData(ctx, sqlDB, tile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := tlog.ReadTileData(tile, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != string(expected) {
+		t.Error("expected the served tile to match the rebuilt tree")
+	}
+}
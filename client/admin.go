@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/cosmos/atlas/jobs"
+	"github.com/cosmos/atlas/policy"
+)
+
+const (
+	modePath          = "/api/v1/admin/mode"
+	mergeUsersPath    = "/api/v1/admin/users/merge"
+	restoreModulePath = "/api/v1/admin/modules/restore"
+	mergeKeywordsPath = "/api/v1/admin/keywords/merge"
+	transferPath      = "/api/v1/admin/modules/transfer"
+	jobsPathPrefix    = "/api/v1/admin/jobs/"
+	usersPathPrefix   = "/api/v1/admin/users/"
+	quarantinePath    = "/api/v1/admin/versions/quarantined"
+	clearPath         = "/api/v1/admin/versions/clear"
+)
+
+type (
+	// operatingMode defines the request and response of the operating mode
+	// endpoint.
+	operatingMode struct {
+		Mode string `json:"mode"`
+	}
+
+	// mergeUsersRequest defines the request of the user merge endpoint.
+	mergeUsersRequest struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+
+	// mergeKeywordsRequest defines the request of the keyword merge endpoint.
+	mergeKeywordsRequest struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+
+	// transferRequest defines the request of the ownership transfer endpoint.
+	transferRequest struct {
+		Module string `json:"module"`
+		To     string `json:"to"`
+	}
+
+	// clearRequest defines the request of the quarantine clearing endpoint.
+	clearRequest struct {
+		Module  string `json:"module"`
+		Version string `json:"version"`
+	}
+
+	// restoreModuleRequest defines the request of the module restore endpoint.
+	restoreModuleRequest struct {
+		Name string `json:"name"`
+	}
+)
+
+// Mode returns the operating mode of the registry. The client's user must be
+// an administrator.
+func (c *Client) Mode(ctx context.Context) (string, error) {
+	var out operatingMode
+	err := c.getJSON(ctx, modePath, nil, &out)
+	return out.Mode, err
+}
+
+// SetMode switches the operating mode of the registry, e.g. back to normal
+// from maintenance. The client's user must be an administrator.
+func (c *Client) SetMode(ctx context.Context, mode string) error {
+	return c.sendJSON(ctx, http.MethodPut, modePath, operatingMode{Mode: mode}, nil)
+}
+
+// MergeUsers merges the duplicate user named source into the user named
+// target, reassigning everything the duplicate owns before deleting it. The
+// client's user must be an administrator.
+func (c *Client) MergeUsers(ctx context.Context, source, target string) error {
+	return c.sendJSON(ctx, http.MethodPost, mergeUsersPath, mergeUsersRequest{Source: source, Target: target}, nil)
+}
+
+// RestoreModule restores a soft-deleted module that has not been purged yet.
+// The client's user must be an administrator.
+func (c *Client) RestoreModule(ctx context.Context, name string) error {
+	return c.sendJSON(ctx, http.MethodPost, restoreModulePath, restoreModuleRequest{Name: name}, nil)
+}
+
+// MergeKeywords merges the synonym keyword source into the canonical keyword
+// target, rewriting the modules tagged with the synonym. Later publishes
+// tagged with the synonym resolve to the canonical keyword. The client's user
+// must be an administrator.
+func (c *Client) MergeKeywords(ctx context.Context, source, target string) error {
+	return c.sendJSON(ctx, http.MethodPost, mergeKeywordsPath, mergeKeywordsRequest{Source: source, Target: target}, nil)
+}
+
+// TransferModule forces the transfer of a module's ownership to the user
+// named to. The client's user must be an administrator.
+func (c *Client) TransferModule(ctx context.Context, name, to string) error {
+	return c.sendJSON(ctx, http.MethodPost, transferPath, transferRequest{Module: name, To: to}, nil)
+}
+
+// DeadJobs returns up to limit of the most recent jobs that exhausted their
+// attempts. The client's user must be an administrator.
+func (c *Client) DeadJobs(ctx context.Context, limit int) ([]jobs.Job, error) {
+	var out []jobs.Job
+	err := c.getJSON(ctx, jobsPathPrefix+"dead", url.Values{"limit": {strconv.Itoa(limit)}}, &out)
+	return out, err
+}
+
+// RetryJob moves a dead job back to the queue with a fresh set of attempts.
+// The client's user must be an administrator.
+func (c *Client) RetryJob(ctx context.Context, id int64) error {
+	return c.sendJSON(ctx, http.MethodPost, jobsPathPrefix+strconv.FormatInt(id, 10)+"/retry", struct{}{}, nil)
+}
+
+// Quota returns the publish quota override of the user named name. The
+// client's user must be an administrator.
+func (c *Client) Quota(ctx context.Context, name string) (policy.QuotaOverride, error) {
+	var out policy.QuotaOverride
+	err := c.getJSON(ctx, usersPathPrefix+url.PathEscape(name)+"/quota", nil, &out)
+	return out, err
+}
+
+// SetQuota overrides the publish quotas of the user named name. A nil limit
+// falls back to the configured limit and zero lifts the limit. The client's
+// user must be an administrator.
+func (c *Client) SetQuota(ctx context.Context, name string, override policy.QuotaOverride) (policy.QuotaOverride, error) {
+	var out policy.QuotaOverride
+	err := c.sendJSON(ctx, http.MethodPut, usersPathPrefix+url.PathEscape(name)+"/quota", override, &out)
+	return out, err
+}
+
+// ClearQuota removes the publish quota override of the user named name,
+// restoring the configured limits. The client's user must be an
+// administrator.
+func (c *Client) ClearQuota(ctx context.Context, name string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: usersPathPrefix + url.PathEscape(name) + "/quota"}, nil)
+}
+
+// QuarantinedVersion defines a version quarantined by blocking artifact scan
+// findings.
+type QuarantinedVersion struct {
+	Module  string `json:"module"`
+	Version string `json:"version"`
+	Reason  string `json:"reason"`
+}
+
+// QuarantinedVersions returns the quarantined versions of every module. The
+// client's user must be an administrator.
+func (c *Client) QuarantinedVersions(ctx context.Context) ([]QuarantinedVersion, error) {
+	var out []QuarantinedVersion
+	err := c.getJSON(ctx, quarantinePath, nil, &out)
+	return out, err
+}
+
+// ClearVersion lifts the quarantine of a version of a module. The client's
+// user must be an administrator.
+func (c *Client) ClearVersion(ctx context.Context, name, version string) error {
+	return c.sendJSON(ctx, http.MethodPost, clearPath, clearRequest{Module: name, Version: version}, nil)
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// Advisories returns the security advisories published against a module,
+// including resolved ones, oldest first.
+func (c *Client) Advisories(ctx context.Context, name string) ([]module.Advisory, error) {
+	var out []module.Advisory
+	err := c.getJSON(ctx, modulePath(name)+"/advisories", nil, &out)
+	return out, err
+}
+
+// PublishAdvisory publishes a security advisory against a range of a module's
+// versions as an owner of the module.
+func (c *Client) PublishAdvisory(ctx context.Context, name string, a module.Advisory) (module.Advisory, error) {
+	var out module.Advisory
+	err := c.sendJSON(ctx, http.MethodPost, modulePath(name)+"/advisories", a, &out)
+	return out, err
+}
+
+// ResolveAdvisory marks an advisory of a module resolved, so that it no longer
+// warns about the versions it affects.
+func (c *Client) ResolveAdvisory(ctx context.Context, name, identifier string) error {
+	return c.do(ctx, request{method: http.MethodPut, path: advisoryPath(name, identifier) + "/resolve"}, nil)
+}
+
+// ReopenAdvisory lifts the resolution of an advisory of a module.
+func (c *Client) ReopenAdvisory(ctx context.Context, name, identifier string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: advisoryPath(name, identifier) + "/resolve"}, nil)
+}
+
+func advisoryPath(name, identifier string) string {
+	return modulePath(name) + "/advisories/" + url.PathEscape(identifier)
+}
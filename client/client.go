@@ -0,0 +1,329 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/hmacauth"
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	// DefaultMaxRetries defines the default number of times a failed
+	// idempotent request is retried.
+	DefaultMaxRetries = 3
+
+	defaultUserAgent  = "atlas-go-client"
+	minRetryBackoff   = time.Second
+	maxRetryBackoff   = 30 * time.Second
+	maxErrorBodyBytes = 1 << 20
+	headerOTP         = "X-Atlas-OTP"
+	headerChecksum    = "X-Atlas-Checksum"
+)
+
+// Error defines an error returned by the registry API, decoded from its
+// {"error": {...}} envelope.
+type Error struct {
+	Status    int                 `json:"-"`
+	Code      string              `json:"code"`
+	Message   string              `json:"message"`
+	Details   []module.FieldError `json:"details,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("atlas: %s (%d %s, request %s)", e.Message, e.Status, e.Code, e.RequestID)
+	}
+
+	return fmt.Sprintf("atlas: %s (%d %s)", e.Message, e.Status, e.Code)
+}
+
+// HasCode returns true if err is an API Error with the given code, e.g.
+// "MODULE_NOT_FOUND".
+func HasCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// Option defines a functional option of a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithToken authenticates requests with the API token sent as a bearer token.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithSignedRequests authenticates requests by signing them with the API
+// token instead of sending it, as expected from machine publishers. Each
+// attempt of a request is signed anew, so retries are not rejected as
+// replays.
+func WithSignedRequests(token string) Option {
+	return func(c *Client) {
+		c.token = token
+		c.sign = true
+	}
+}
+
+// WithOTP sends the TOTP or recovery code proving the second factor of the
+// client's user with every request, as required to publish when the registry
+// requires two-factor authentication.
+func WithOTP(code string) Option {
+	return func(c *Client) { c.otp = code }
+}
+
+// WithMaxRetries sets the number of times a failed idempotent request is
+// retried. Zero disables retries.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) { c.maxRetries = n }
+}
+
+// WithUserAgent sets the User-Agent header of requests.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// Client defines a typed client of the Atlas registry HTTP API. It is safe
+// for concurrent use.
+//
+// Idempotent requests (GET, PUT and DELETE) that fail with a network error, a
+// 429 or a 502, 503 or 504 response are retried with jittered exponential
+// backoff, honoring any Retry-After header. Requests are bound to the context
+// passed to each method, including while waiting to retry.
+type Client struct {
+	baseURL    string
+	http       *http.Client
+	token      string
+	sign       bool
+	otp        string
+	maxRetries int
+	userAgent  string
+}
+
+// New returns a Client of the registry at baseURL, e.g.
+// "https://atlas.cosmos.network".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid registry URL: %s", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimSuffix(u.String(), "/"),
+		http:       http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		userAgent:  defaultUserAgent,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// request defines an API request.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	header      http.Header
+}
+
+// getJSON sends a GET request and decodes its JSON response into out.
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.do(ctx, request{method: http.MethodGet, path: path, query: query}, out)
+}
+
+// sendJSON sends a request with a JSON body and decodes its JSON response
+// into out, if not nil.
+func (c *Client) sendJSON(ctx context.Context, method, path string, in, out interface{}) error {
+	bz, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, request{method: method, path: path, body: bz, contentType: "application/json"}, out)
+}
+
+// do sends the request, retrying idempotent requests on transient failures,
+// and decodes its JSON response into out, if not nil.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	retries := 0
+	if req.method != http.MethodPost && req.method != http.MethodPatch {
+		retries = c.maxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if attempt < retries && retryable(resp, err) {
+			wait := backoff(attempt, resp)
+			if resp != nil {
+				drain(resp)
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return decodeResponse(resp, out)
+	}
+}
+
+// send sends a single attempt of the request.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+
+	for k, v := range req.header {
+		httpReq.Header[k] = v
+	}
+
+	if c.otp != "" {
+		httpReq.Header.Set(headerOTP, c.otp)
+	}
+
+	switch {
+	case c.token != "" && c.sign:
+		hmacauth.SignRequest(httpReq, c.token, req.body, time.Now())
+
+	case c.token != "":
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	return c.http.Do(httpReq)
+}
+
+// retryable returns true if an attempt failed transiently.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before retrying an attempt, honoring the
+// Retry-After header of the response, if any. The minimum delay of a second
+// guarantees that a re-signed retry carries a new timestamp.
+func backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			if d := time.Duration(secs) * time.Second; d < maxRetryBackoff {
+				return d
+			}
+
+			return maxRetryBackoff
+		}
+	}
+
+	d := minRetryBackoff << uint(attempt)
+	if d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+
+	// add up to 50% jitter so that concurrent clients do not retry in lockstep
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// decodeResponse decodes a successful JSON response into out, if not nil, or
+// returns the Error of an unsuccessful one. The body of a successful response
+// is copied as is into out if it is an io.Writer.
+func decodeResponse(resp *http.Response, out interface{}) error {
+	defer drain(resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &Error{Status: resp.StatusCode}
+
+		var envelope struct {
+			Error *Error `json:"error"`
+		}
+
+		bz, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		if err := json.Unmarshal(bz, &envelope); err == nil && envelope.Error != nil {
+			apiErr = envelope.Error
+			apiErr.Status = resp.StatusCode
+		} else {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+
+		return apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if w, ok := out.(io.Writer); ok {
+		_, err := io.Copy(w, resp.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// drain discards the rest of a response body and closes it, so that its
+// connection may be reused.
+func drain(resp *http.Response) {
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxErrorBodyBytes)) // nolint: errcheck
+	resp.Body.Close()
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/cosmos/atlas/module"
+)
+
+type (
+	// AnswerThread defines an answer to a question and the name of its author.
+	AnswerThread struct {
+		module.Answer
+		Author string `json:"author"`
+	}
+
+	// QuestionThread defines a question of a module and its answers, the
+	// accepted answer first, followed by maintainer answers.
+	QuestionThread struct {
+		module.Question
+		Asker   string         `json:"asker"`
+		Answers []AnswerThread `json:"answers"`
+	}
+)
+
+// Question returns a question of a module along with its answers.
+func (c *Client) Question(ctx context.Context, name string, questionID int) (QuestionThread, error) {
+	var out QuestionThread
+	err := c.getJSON(ctx, questionPath(name, questionID), nil, &out)
+	return out, err
+}
+
+// AskQuestion asks a support question about a module as the client's user.
+// The owners and subscribers of the module are notified.
+func (c *Client) AskQuestion(ctx context.Context, name, title, body string) (module.Question, error) {
+	var out module.Question
+	err := c.sendJSON(ctx, http.MethodPost, modulePath(name)+"/questions", module.Question{Title: title, Body: body}, &out)
+	return out, err
+}
+
+// AnswerQuestion answers a question of a module as the client's user. Answers
+// of module owners are flagged as maintainer answers.
+func (c *Client) AnswerQuestion(ctx context.Context, name string, questionID int, body string) (module.Answer, error) {
+	in := struct {
+		Body string `json:"body"`
+	}{Body: body}
+
+	var out module.Answer
+	err := c.sendJSON(ctx, http.MethodPost, questionPath(name, questionID)+"/answers", in, &out)
+	return out, err
+}
+
+// AcceptAnswer accepts an answer to a question of a module on behalf of the
+// client's user, who must be the asker or an owner of the module.
+func (c *Client) AcceptAnswer(ctx context.Context, name string, questionID, answerID int) (module.Question, error) {
+	in := struct {
+		AnswerID int `json:"answer_id"`
+	}{AnswerID: answerID}
+
+	var out module.Question
+	err := c.sendJSON(ctx, http.MethodPut, questionPath(name, questionID)+"/accepted", in, &out)
+	return out, err
+}
+
+// Subscribe subscribes the client's user to notifications of the discussions
+// of a module.
+func (c *Client) Subscribe(ctx context.Context, name string) error {
+	return c.do(ctx, request{method: http.MethodPut, path: modulePath(name) + "/subscription"}, nil)
+}
+
+// Unsubscribe unsubscribes the client's user from the discussions of a module.
+func (c *Client) Unsubscribe(ctx context.Context, name string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: modulePath(name) + "/subscription"}, nil)
+}
+
+func questionPath(name string, id int) string {
+	return modulePath(name) + "/questions/" + strconv.Itoa(id)
+}
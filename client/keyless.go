@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// PublishCredential defines a short-lived credential publishing versions of a
+// single module, minted in exchange for the OIDC ID token of a CI workflow.
+type PublishCredential struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// keylessTokenRequest defines the request of the keyless token exchange.
+type keylessTokenRequest struct {
+	Module  string `json:"module"`
+	IDToken string `json:"id_token"`
+}
+
+// ExchangeIDToken exchanges the OIDC ID token of a CI workflow for a
+// credential publishing versions of the named module, permitted by one of the
+// module's trust policies. Clients created WithToken of the credential's token
+// may then publish the module until it expires.
+func (c *Client) ExchangeIDToken(ctx context.Context, name, idToken string) (PublishCredential, error) {
+	var out PublishCredential
+	err := c.sendJSON(ctx, http.MethodPost, "/api/v1/keyless/token", keylessTokenRequest{Module: name, IDToken: idToken}, &out)
+	return out, err
+}
+
+// TrustPolicies returns the trust policies of a module. The client's user must
+// own the module.
+func (c *Client) TrustPolicies(ctx context.Context, name string) ([]module.TrustPolicy, error) {
+	var out []module.TrustPolicy
+	err := c.getJSON(ctx, modulePath(name)+"/trust-policies", nil, &out)
+	return out, err
+}
+
+// AddTrustPolicy permits the CI workflows matching the trust policy to publish
+// a module without an API token. The client's user must own the module.
+func (c *Client) AddTrustPolicy(ctx context.Context, name string, tp module.TrustPolicy) (module.TrustPolicy, error) {
+	var out module.TrustPolicy
+	err := c.sendJSON(ctx, http.MethodPost, modulePath(name)+"/trust-policies", tp, &out)
+	return out, err
+}
+
+// DeleteTrustPolicy removes a trust policy of a module. The client's user must
+// own the module.
+func (c *Client) DeleteTrustPolicy(ctx context.Context, name string, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: modulePath(name) + "/trust-policies/" + strconv.Itoa(id)}, nil)
+}
@@ -0,0 +1,411 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/cosmos/atlas/docs"
+	"github.com/cosmos/atlas/module"
+)
+
+// DefaultPageSize defines the default number of modules fetched per page by
+// a ModuleIterator.
+const DefaultPageSize = 20
+
+// modulesPage defines a page of modules returned by the list and search
+// endpoints.
+type modulesPage struct {
+	Modules []module.Module `json:"modules"`
+}
+
+// ModuleIterator iterates over a paginated list of modules, fetching pages
+// lazily as it advances:
+//
+//	it := c.SearchModules("staking", 0)
+//	for it.Next(ctx) {
+//		m := it.Module()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ModuleIterator struct {
+	c        *Client
+	path     string
+	query    url.Values
+	pageSize int
+
+	page   []module.Module
+	offset int
+	cur    module.Module
+	done   bool
+	err    error
+}
+
+func newModuleIterator(c *Client, path string, query url.Values, pageSize int) *ModuleIterator {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	if query == nil {
+		query = url.Values{}
+	}
+
+	return &ModuleIterator{c: c, path: path, query: query, pageSize: pageSize}
+}
+
+// Next advances the iterator to the next module, fetching the next page if
+// needed. It returns false when the modules are exhausted or fetching a page
+// failed, in which case Err returns the error.
+func (it *ModuleIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+
+		it.query.Set("limit", strconv.Itoa(it.pageSize))
+		it.query.Set("offset", strconv.Itoa(it.offset))
+
+		var page modulesPage
+		if err := it.c.getJSON(ctx, it.path, it.query, &page); err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page = page.Modules
+		it.offset += len(page.Modules)
+		it.done = len(page.Modules) < it.pageSize
+
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Module returns the current module of the iterator.
+func (it *ModuleIterator) Module() module.Module {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ModuleIterator) Err() error {
+	return it.err
+}
+
+// ListModules returns an iterator over the registry's modules, fetching
+// pageSize modules per request (DefaultPageSize if zero).
+func (c *Client) ListModules(pageSize int) *ModuleIterator {
+	return newModuleIterator(c, "/api/v1/modules", nil, pageSize)
+}
+
+// SearchModules returns an iterator over the modules matching the query,
+// fetching pageSize modules per request (DefaultPageSize if zero).
+func (c *Client) SearchModules(query string, pageSize int) *ModuleIterator {
+	return newModuleIterator(c, "/api/v1/modules/search", url.Values{"q": {query}}, pageSize)
+}
+
+// SearchModulesByLicense returns an iterator over the modules matching the
+// query, if not empty, whose SPDX license expression equals license.
+func (c *Client) SearchModulesByLicense(query, license string, pageSize int) *ModuleIterator {
+	return newModuleIterator(c, "/api/v1/modules/search", url.Values{"q": {query}, "license": {license}}, pageSize)
+}
+
+// GetModule returns a module by name.
+func (c *Client) GetModule(ctx context.Context, name string) (module.Module, error) {
+	var m module.Module
+	err := c.getJSON(ctx, modulePath(name), nil, &m)
+	return m, err
+}
+
+// ListVersions returns the published versions of a module.
+func (c *Client) ListVersions(ctx context.Context, name string) ([]module.ModuleVersion, error) {
+	var versions []module.ModuleVersion
+	err := c.getJSON(ctx, modulePath(name)+"/versions", nil, &versions)
+	return versions, err
+}
+
+// Dependencies returns the registered modules a module depends on, along with
+// its version constraints on them.
+func (c *Client) Dependencies(ctx context.Context, name string) ([]module.ModuleDependency, error) {
+	var deps []module.ModuleDependency
+	err := c.getJSON(ctx, modulePath(name)+"/dependencies", nil, &deps)
+	return deps, err
+}
+
+// Dependents returns the registered modules depending on a module, along with
+// their version constraints on it.
+func (c *Client) Dependents(ctx context.Context, name string) ([]module.ModuleDependency, error) {
+	var deps []module.ModuleDependency
+	err := c.getJSON(ctx, modulePath(name)+"/dependents", nil, &deps)
+	return deps, err
+}
+
+// Impact returns the registered modules whose version constraints on a module
+// resolve to the given version of it, to evaluate the blast radius of yanking
+// the version.
+func (c *Client) Impact(ctx context.Context, name, version string) ([]module.ModuleDependency, error) {
+	var out struct {
+		Dependents []module.ModuleDependency `json:"dependents"`
+	}
+
+	err := c.getJSON(ctx, versionPath(name, version)+"/impact", nil, &out)
+	return out.Dependents, err
+}
+
+// Yank yanks a version of a module as an owner of the module, so that it is
+// no longer resolved.
+func (c *Client) Yank(ctx context.Context, name, version string) error {
+	return c.do(ctx, request{method: http.MethodPut, path: versionPath(name, version) + "/yank"}, nil)
+}
+
+// Unyank lifts the yanking of a version of a module.
+func (c *Client) Unyank(ctx context.Context, name, version string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: versionPath(name, version) + "/yank"}, nil)
+}
+
+// DeleteVersion deletes a version of a module as an owner of the module. It
+// fails with VERSION_IN_USE while registered modules depend on the version,
+// unless it was yanked first.
+func (c *Client) DeleteVersion(ctx context.Context, name, version string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: versionPath(name, version)}, nil)
+}
+
+// Promote promotes a pre-release version of a module to the stable version of
+// the same major, minor and patch, returning the promoted version. The
+// client's user must own the module.
+func (c *Client) Promote(ctx context.Context, name, version string) (module.ModuleVersion, error) {
+	var out module.ModuleVersion
+	err := c.sendJSON(ctx, http.MethodPost, versionPath(name, version)+"/promote", struct{}{}, &out)
+	return out, err
+}
+
+// Readme returns the raw Markdown readme of a module version along with its
+// sanitized HTML rendering.
+func (c *Client) Readme(ctx context.Context, name, version string) (markdown, html string, err error) {
+	var out struct {
+		Readme         string `json:"readme"`
+		RenderedReadme string `json:"rendered_readme"`
+	}
+
+	err = c.getJSON(ctx, versionPath(name, version)+"/readme", nil, &out)
+	return out.Readme, out.RenderedReadme, err
+}
+
+// Docs returns the package documentation extracted from the tagged release of
+// a module version, empty until the registry has extracted it.
+func (c *Client) Docs(ctx context.Context, name, version string) ([]docs.Package, error) {
+	var out struct {
+		Packages []docs.Package `json:"packages"`
+	}
+
+	err := c.getJSON(ctx, versionPath(name, version)+"/docs", nil, &out)
+	return out.Packages, err
+}
+
+// Compare returns the changes in dependencies, authors and changelog between
+// two published versions of a module.
+func (c *Client) Compare(ctx context.Context, name, from, to string) (module.VersionDiff, error) {
+	var out module.VersionDiff
+	err := c.getJSON(ctx, versionPath(name, from)+"/compare/"+url.PathEscape(to), nil, &out)
+	return out, err
+}
+
+// RelatedModule defines a module related to another module.
+type RelatedModule struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Score       float64 `json:"score"`
+}
+
+// Related returns the modules related to a module by shared keywords, authors
+// or dependency edges, most related first.
+func (c *Client) Related(ctx context.Context, name string) ([]RelatedModule, error) {
+	var out []RelatedModule
+	err := c.getJSON(ctx, modulePath(name)+"/related", nil, &out)
+	return out, err
+}
+
+// LatestVersion returns the greatest resolvable version of a module in the
+// release channel or any more stable channel. An empty channel defaults to
+// the stable channel.
+func (c *Client) LatestVersion(ctx context.Context, name, channel string) (module.ModuleVersion, error) {
+	query := url.Values{}
+	if channel != "" {
+		query.Set("channel", channel)
+	}
+
+	var mv module.ModuleVersion
+	err := c.getJSON(ctx, modulePath(name)+"/versions/latest", query, &mv)
+	return mv, err
+}
+
+// Publish publishes the module version described by an encoded manifest,
+// returning the published module. The format is one of module.FormatTOML,
+// module.FormatYAML or module.FormatJSON. Publishing requires an API token,
+// along with a second factor set with WithOTP if the registry requires
+// two-factor authentication. Publishes are never retried, although
+// republishing identical contents is a no-op.
+func (c *Client) Publish(ctx context.Context, manifest []byte, format string) (module.Module, error) {
+	var m module.Module
+	err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/api/v1/modules",
+		body:        manifest,
+		contentType: manifestContentTypes[format],
+	}, &m)
+	return m, err
+}
+
+// PublishManifest encodes and publishes a manifest.
+func (c *Client) PublishManifest(ctx context.Context, manifest module.Manifest) (module.Module, error) {
+	bz, err := module.EncodeManifest(manifest, module.FormatJSON)
+	if err != nil {
+		return module.Module{}, err
+	}
+
+	return c.Publish(ctx, bz, module.FormatJSON)
+}
+
+// StageManifest encodes and stages a new version of an existing module,
+// visible to its owners alone until it is released with Release or discarded
+// with DeleteVersion.
+func (c *Client) StageManifest(ctx context.Context, manifest module.Manifest) (module.Module, error) {
+	bz, err := module.EncodeManifest(manifest, module.FormatJSON)
+	if err != nil {
+		return module.Module{}, err
+	}
+
+	var m module.Module
+	err = c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/api/v1/modules",
+		query:       url.Values{"stage": {"true"}},
+		body:        bz,
+		contentType: manifestContentTypes[module.FormatJSON],
+	}, &m)
+	return m, err
+}
+
+// Release releases a staged version of a module, making it resolvable and
+// updating the module with the version's manifest. The client's user must own
+// the module.
+func (c *Client) Release(ctx context.Context, name, version string) (module.Module, error) {
+	var out module.Module
+	err := c.sendJSON(ctx, http.MethodPost, versionPath(name, version)+"/release", struct{}{}, &out)
+	return out, err
+}
+
+// UploadArtifact uploads the source tarball of a published version of a
+// module, along with its SHA-256 checksum. The client's user must own the
+// module.
+func (c *Client) UploadArtifact(ctx context.Context, name, version string, artifact []byte) (module.ModuleVersion, error) {
+	sum := sha256.Sum256(artifact)
+
+	var mv module.ModuleVersion
+	err := c.do(ctx, request{
+		method:      http.MethodPut,
+		path:        versionPath(name, version) + "/artifact",
+		body:        artifact,
+		contentType: "application/gzip",
+		header:      http.Header{headerChecksum: {hex.EncodeToString(sum[:])}},
+	}, &mv)
+	return mv, err
+}
+
+// DownloadArtifact writes the source tarball of a version of a module to w.
+func (c *Client) DownloadArtifact(ctx context.Context, name, version string, w io.Writer) error {
+	return c.getJSON(ctx, versionPath(name, version)+"/artifact", nil, w)
+}
+
+// ClientDownloads returns the downloads of a module by the client that fetched
+// them, most downloads first.
+func (c *Client) ClientDownloads(ctx context.Context, name string) ([]module.ClientDownloads, error) {
+	var out []module.ClientDownloads
+	err := c.getJSON(ctx, modulePath(name)+"/stats/clients", nil, &out)
+	return out, err
+}
+
+// ValidateManifest dry-runs publishing an encoded manifest as the client's
+// user, without writing anything, returning the manifest as parsed by the
+// registry. Violations are returned as an Error with code VALIDATION_FAILED
+// whose Details list each offending field, so that CI can gate releases.
+func (c *Client) ValidateManifest(ctx context.Context, manifest []byte, format string) (module.Manifest, error) {
+	var m module.Manifest
+	err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/api/v1/modules/validate",
+		body:        manifest,
+		contentType: manifestContentTypes[format],
+	}, &m)
+	return m, err
+}
+
+// PatchModule updates the metadata fields of a module set in the patch as
+// an owner of the module. Setting the patch's lock version to that of the
+// module read fails the update with MODULE_CONFLICT if the module was updated
+// since.
+func (c *Client) PatchModule(ctx context.Context, name string, patch module.ModulePatch) (module.Module, error) {
+	var out module.Module
+	err := c.sendJSON(ctx, http.MethodPatch, modulePath(name), patch, &out)
+	return out, err
+}
+
+// DeleteModule soft-deletes a module as an owner of the module.
+// Administrators may restore it until it is purged.
+func (c *Client) DeleteModule(ctx context.Context, name string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: modulePath(name)}, nil)
+}
+
+// Deprecate deprecates a module owned by the client's user, optionally naming
+// the module that replaces it in the Deprecation's ReplacedBy.
+func (c *Client) Deprecate(ctx context.Context, name string, d module.Deprecation) (module.Deprecation, error) {
+	var out module.Deprecation
+	err := c.sendJSON(ctx, http.MethodPut, modulePath(name)+"/deprecation", d, &out)
+	return out, err
+}
+
+// Undeprecate lifts the deprecation of a module owned by the client's user.
+func (c *Client) Undeprecate(ctx context.Context, name string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: modulePath(name) + "/deprecation"}, nil)
+}
+
+// Archive archives a module owned by the client's user, making it read-only
+// while its versions remain resolvable.
+func (c *Client) Archive(ctx context.Context, name string) error {
+	return c.do(ctx, request{method: http.MethodPut, path: modulePath(name) + "/archive"}, nil)
+}
+
+// Unarchive lifts the archival of a module owned by the client's user.
+func (c *Client) Unarchive(ctx context.Context, name string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: modulePath(name) + "/archive"}, nil)
+}
+
+// manifestContentTypes maps manifest formats to their Content-Type, as
+// understood by module.FormatFromContentType.
+var manifestContentTypes = map[string]string{
+	module.FormatTOML: "application/toml",
+	module.FormatYAML: "application/yaml",
+	module.FormatJSON: "application/json",
+}
+
+// modulePath returns the API path of a module. Names are path-escaped, since
+// they may contain slashes.
+func modulePath(name string) string {
+	return "/api/v1/modules/" + url.PathEscape(name)
+}
+
+func versionPath(name, version string) string {
+	return modulePath(name) + "/versions/" + url.PathEscape(version)
+}
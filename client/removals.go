@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// removalAction defines the body of a removal request transition.
+type removalAction struct {
+	CounterNotice string `json:"counter_notice,omitempty"`
+	Resolution    string `json:"resolution,omitempty"`
+}
+
+// ListRemovalRequests returns the removal requests filed against a module,
+// newest first. The client's user must own the module or moderate the
+// registry.
+func (c *Client) ListRemovalRequests(ctx context.Context, name string) ([]module.RemovalRequest, error) {
+	var out []module.RemovalRequest
+	err := c.getJSON(ctx, modulePath(name)+"/removal-requests", nil, &out)
+	return out, err
+}
+
+// FileRemovalRequest files a legal or DMCA takedown request against a module.
+func (c *Client) FileRemovalRequest(ctx context.Context, name string, rr module.RemovalRequest) (module.RemovalRequest, error) {
+	var out module.RemovalRequest
+	err := c.sendJSON(ctx, http.MethodPost, modulePath(name)+"/removal-requests", rr, &out)
+	return out, err
+}
+
+// DisputeRemovalRequest acknowledges a removal request as a moderator,
+// blocking the module's downloads until the request is resolved.
+func (c *Client) DisputeRemovalRequest(ctx context.Context, name string, id int) (module.RemovalRequest, error) {
+	return c.transitionRemovalRequest(ctx, name, id, "dispute", removalAction{})
+}
+
+// CounterRemovalRequest files a counter-notice against a disputed removal
+// request as an owner of the module.
+func (c *Client) CounterRemovalRequest(ctx context.Context, name string, id int, notice string) (module.RemovalRequest, error) {
+	return c.transitionRemovalRequest(ctx, name, id, "counter-notice", removalAction{CounterNotice: notice})
+}
+
+// ResolveRemovalRequest records the final resolution of a removal request as
+// a moderator, either module.RemovalResolutionRemoved or
+// module.RemovalResolutionReinstated.
+func (c *Client) ResolveRemovalRequest(ctx context.Context, name string, id int, resolution string) (module.RemovalRequest, error) {
+	return c.transitionRemovalRequest(ctx, name, id, "resolution", removalAction{Resolution: resolution})
+}
+
+func (c *Client) transitionRemovalRequest(ctx context.Context, name string, id int, action string, in removalAction) (module.RemovalRequest, error) {
+	var out module.RemovalRequest
+	err := c.sendJSON(ctx, http.MethodPost, modulePath(name)+"/removal-requests/"+strconv.Itoa(id)+"/"+action, in, &out)
+	return out, err
+}
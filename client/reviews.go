@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// CreateReview rates and reviews a module as the client's user, who may
+// review a module once and may not review their own modules.
+func (c *Client) CreateReview(ctx context.Context, name string, rating int, body string) (module.Review, error) {
+	var out module.Review
+	err := c.sendJSON(ctx, http.MethodPost, modulePath(name)+"/reviews", module.Review{Rating: rating, Body: body}, &out)
+	return out, err
+}
+
+// RespondToReview records the response of the client's user, an owner of the
+// module, to one of its reviews.
+func (c *Client) RespondToReview(ctx context.Context, name string, reviewID int, response string) (module.Review, error) {
+	in := struct {
+		Response string `json:"response"`
+	}{Response: response}
+
+	var out module.Review
+	err := c.sendJSON(ctx, http.MethodPut, reviewPath(name, reviewID)+"/response", in, &out)
+	return out, err
+}
+
+// ReportReview files an abuse report against a review of a module.
+func (c *Client) ReportReview(ctx context.Context, name string, reviewID int, reason, details string) (module.Report, error) {
+	var out module.Report
+	err := c.sendJSON(ctx, http.MethodPost, reviewPath(name, reviewID)+"/reports", module.Report{Reason: reason, Details: details}, &out)
+	return out, err
+}
+
+func reviewPath(name string, id int) string {
+	return modulePath(name) + "/reviews/" + strconv.Itoa(id)
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/cosmos/atlas/module"
+)
+
+const sessionsPath = "/api/v1/user/sessions"
+
+// Session defines an active session of the client's user, marking the session
+// the client is authenticated by, if any.
+type Session struct {
+	module.Session
+	Current bool `json:"current"`
+}
+
+// Sessions returns the active sessions of the client's user, most recently
+// seen first.
+func (c *Client) Sessions(ctx context.Context) ([]Session, error) {
+	var out []Session
+	err := c.getJSON(ctx, sessionsPath, nil, &out)
+	return out, err
+}
+
+// RevokeSession revokes a session of the client's user by ID.
+func (c *Client) RevokeSession(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: sessionsPath + "/" + strconv.Itoa(id)}, nil)
+}
+
+// RevokeOtherSessions revokes every session of the client's user but the one
+// the client is authenticated by, e.g. after a device was lost.
+func (c *Client) RevokeOtherSessions(ctx context.Context) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: sessionsPath}, nil)
+}
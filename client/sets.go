@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// CreateSet creates a module set owned by the client's user, returning it with
+// its public ID and resolved pins.
+func (c *Client) CreateSet(ctx context.Context, set module.ModuleSet) (module.ModuleSet, error) {
+	var out module.ModuleSet
+	err := c.sendJSON(ctx, http.MethodPost, "/api/v1/sets", set, &out)
+	return out, err
+}
+
+// GetSet returns the module set with the given public ID, resolving each
+// pinned version in a single request.
+func (c *Client) GetSet(ctx context.Context, id string) (module.ModuleSet, error) {
+	var out module.ModuleSet
+	err := c.getJSON(ctx, setPath(id), nil, &out)
+	return out, err
+}
+
+// UpdateSet replaces the name, description, visibility and pins of a module
+// set owned by the client's user.
+func (c *Client) UpdateSet(ctx context.Context, id string, set module.ModuleSet) (module.ModuleSet, error) {
+	var out module.ModuleSet
+	err := c.sendJSON(ctx, http.MethodPut, setPath(id), set, &out)
+	return out, err
+}
+
+// DeleteSet deletes a module set owned by the client's user.
+func (c *Client) DeleteSet(ctx context.Context, id string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: setPath(id)}, nil)
+}
+
+func setPath(id string) string {
+	return "/api/v1/sets/" + url.PathEscape(id)
+}
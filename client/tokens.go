@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Token defines the API token of the authenticated user and its restrictions.
+type Token struct {
+	Token string   `json:"token,omitempty"`
+	CIDRs []string `json:"cidrs"`
+}
+
+// RotateToken replaces the authenticated user's API token, returning the new
+// token. The Client keeps authenticating with the token it was created with;
+// callers should create a new Client with the returned token.
+func (c *Client) RotateToken(ctx context.Context) (Token, error) {
+	var t Token
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/me/token"}, &t)
+	return t, err
+}
+
+// SetTokenCIDRs restricts the authenticated user's API token to the given
+// CIDR ranges. An empty list lifts the restriction.
+func (c *Client) SetTokenCIDRs(ctx context.Context, cidrs []string) (Token, error) {
+	if cidrs == nil {
+		cidrs = []string{}
+	}
+
+	var t Token
+	err := c.sendJSON(ctx, http.MethodPut, "/api/v1/me/token/cidrs", Token{CIDRs: cidrs}, &t)
+	return t, err
+}
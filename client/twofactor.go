@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+const twoFactorPath = "/api/v1/me/two-factor"
+
+// TwoFactorEnrollment defines a pending TOTP secret of the client's user along
+// with the otpauth URI enrolling it in an authenticator app.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// twoFactorVerifyRequest defines the request verifying a pending TOTP secret.
+type twoFactorVerifyRequest struct {
+	Code string `json:"code"`
+}
+
+// EnrollTwoFactor generates a pending TOTP secret of the client's user. It
+// takes effect once a code of it is passed to VerifyTwoFactor.
+func (c *Client) EnrollTwoFactor(ctx context.Context) (TwoFactorEnrollment, error) {
+	var out TwoFactorEnrollment
+	err := c.do(ctx, request{method: http.MethodPost, path: twoFactorPath}, &out)
+	return out, err
+}
+
+// VerifyTwoFactor enables two-factor authentication of the client's user with
+// a code of its pending TOTP secret, returning the single-use recovery codes.
+// They are only returned once.
+func (c *Client) VerifyTwoFactor(ctx context.Context, code string) ([]string, error) {
+	var out struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	err := c.sendJSON(ctx, http.MethodPost, twoFactorPath+"/verify", twoFactorVerifyRequest{Code: code}, &out)
+	return out.RecoveryCodes, err
+}
+
+// DisableTwoFactor disables two-factor authentication of the client's user.
+// The client must be created WithOTP of a valid code.
+func (c *Client) DisableTwoFactor(ctx context.Context) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: twoFactorPath}, nil)
+}
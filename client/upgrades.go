@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cosmos/atlas/module"
+)
+
+type (
+	// Dependency defines a dependency pinned to its current version. The
+	// module is identified by its name or by a Go module path, which the
+	// registry matches against the repositories of registered modules.
+	Dependency struct {
+		Name    string `json:"name,omitempty"`
+		Path    string `json:"path,omitempty"`
+		Version string `json:"version"`
+	}
+
+	// UpgradeResult defines the upgrade advice of a dependency. Error is set
+	// instead if the registry could not advise it, e.g. for an unknown module.
+	UpgradeResult struct {
+		Name    string `json:"name,omitempty"`
+		Path    string `json:"path,omitempty"`
+		Version string `json:"version"`
+		Error   *Error `json:"error,omitempty"`
+		module.UpgradeAdvice
+	}
+)
+
+// upgradesBatchSize bounds the dependencies of a single upgrades request.
+const upgradesBatchSize = 500
+
+// Upgrades returns the available patch, minor and major upgrades of the given
+// dependencies, in order. Large dependency sets are sent in batches.
+func (c *Client) Upgrades(ctx context.Context, deps []Dependency) ([]UpgradeResult, error) {
+	results := make([]UpgradeResult, 0, len(deps))
+
+	for start := 0; start < len(deps); start += upgradesBatchSize {
+		end := start + upgradesBatchSize
+		if end > len(deps) {
+			end = len(deps)
+		}
+
+		var resp struct {
+			Results []UpgradeResult `json:"results"`
+		}
+
+		if err := c.sendJSON(ctx, http.MethodPost, "/api/v1/upgrades", struct {
+			Dependencies []Dependency `json:"dependencies"`
+		}{deps[start:end]}, &resp); err != nil {
+			return nil, err
+		}
+
+		results = append(results, resp.Results...)
+	}
+
+	return results, nil
+}
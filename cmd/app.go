@@ -22,6 +22,17 @@ func NewApp() *cli.App {
 	app.Name = "Atlas CLI"
 	app.Usage = "A Cosmos SDK module registry framework"
 	app.Version = getVersion()
+	app.Commands = []*cli.Command{
+		InitCommand(),
+		StartCommand(),
+		MigrateCommand(),
+		ConfigCommand(),
+		ExportCommand(),
+		ImportCommand(),
+		SumDBCommand(),
+		OutdatedCommand(),
+		SeedCommand(),
+	}
 
 	return app
 }
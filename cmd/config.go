@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cosmos/atlas/config"
+)
+
+const (
+	flagConfig     = "config"
+	flagListenAddr = "listen-addr"
+	flagTenant     = "tenant"
+)
+
+// serverFlags returns the flags shared by commands that load the server
+// configuration. Flags take precedence over the environment and config file.
+func serverFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: flagConfig, Usage: "path to the YAML server config file", EnvVars: []string{"ATLAS_CONFIG"}},
+		&cli.StringFlag{Name: flagListenAddr, Usage: "the address to serve on"},
+		&cli.StringFlag{Name: flagDatabaseURL, Usage: "the Postgres connection URL"},
+		&cli.StringFlag{Name: flagMigrationsDir, Usage: "the directory containing the SQL migrations"},
+		&cli.StringFlag{Name: flagTenant, Usage: "the tenant to operate on in a multi-tenant deployment"},
+	}
+}
+
+// loadConfig resolves and validates the server configuration from the config
+// file, environment and any flags that were set. With --tenant, it returns the
+// configuration of the tenant's registry.
+func loadConfig(ctx *cli.Context) (config.Config, error) {
+	cfg, err := loadDeploymentConfig(ctx)
+	if err != nil || !ctx.IsSet(flagTenant) {
+		return cfg, err
+	}
+
+	return cfg.ForTenant(cfg.Tenants[0])
+}
+
+// loadDeploymentConfig resolves and validates the configuration of the whole
+// deployment. With --tenant, its tenants are narrowed to the given tenant.
+func loadDeploymentConfig(ctx *cli.Context) (config.Config, error) {
+	cfg, err := config.Load(ctx.String(flagConfig))
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	if ctx.IsSet(flagListenAddr) {
+		cfg.ListenAddr = ctx.String(flagListenAddr)
+	}
+
+	if ctx.IsSet(flagDatabaseURL) {
+		cfg.Database.URL = ctx.String(flagDatabaseURL)
+	}
+
+	if ctx.IsSet(flagMigrationsDir) {
+		cfg.Database.MigrationsDir = ctx.String(flagMigrationsDir)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return config.Config{}, err
+	}
+
+	if ctx.IsSet(flagTenant) {
+		name := ctx.String(flagTenant)
+
+		var tenants []config.Tenant
+		for _, t := range cfg.Tenants {
+			if t.Name == name {
+				tenants = append(tenants, t)
+			}
+		}
+
+		if len(tenants) == 0 {
+			return config.Config{}, fmt.Errorf("unknown tenant: %s", name)
+		}
+
+		cfg.Tenants = tenants
+	}
+
+	return cfg, nil
+}
+
+// ConfigCommand returns a CLI command for inspecting the server configuration.
+func ConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Inspect the server configuration",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "print",
+				Usage:  "Print the effective configuration with secrets redacted",
+				Flags:  serverFlags(),
+				Action: runConfigPrint,
+			},
+		},
+	}
+}
+
+func runConfigPrint(ctx *cli.Context) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	bz, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.App.Writer.Write(bz)
+	return err
+}
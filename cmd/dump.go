@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/cosmos/atlas/db"
+)
+
+const flagFile = "file"
+
+// ExportCommand returns a CLI command that dumps the registry database to
+// NDJSON.
+func ExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Export the registry database as NDJSON",
+		Description: `Write every module, version, user, keyword and download statistic to
+NDJSON, one record per line, from a consistent snapshot of the database.`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{Name: flagFile, Aliases: []string{"f"}, Usage: "the output file (defaults to stdout)"},
+		}, serverFlags()...),
+		Action: runExport,
+	}
+}
+
+// ImportCommand returns a CLI command that restores an NDJSON registry export.
+func ImportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "import",
+		Usage: "Import an NDJSON registry export",
+		Description: `Restore an export produced by 'atlas export' in a single transaction.
+Records that already exist are skipped, so an import may safely be re-run.`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{Name: flagFile, Aliases: []string{"f"}, Usage: "the input file (defaults to stdin)"},
+		}, serverFlags()...),
+		Action: runImport,
+	}
+}
+
+func runExport(ctx *cli.Context) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := db.Open(cfg.Database.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+
+	var w io.Writer = ctx.App.Writer
+	if file := ctx.String(flagFile); file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	n, err := db.Export(ctx.Context, resolver.Primary(), w)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d records\n", n)
+	return nil
+}
+
+func runImport(ctx *cli.Context) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := db.Open(cfg.Database.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+
+	var r io.Reader = os.Stdin
+	if file := ctx.String(flagFile); file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to open import file: %w", err)
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	n, err := db.Import(ctx.Context, resolver.Primary(), r)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "imported %d records\n", n)
+	return nil
+}
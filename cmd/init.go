@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	flagName        = "name"
+	flagDescription = "description"
+	flagVersion     = "version"
+	flagHomepage    = "homepage"
+	flagRepo        = "repo"
+	flagKeywords    = "keywords"
+	flagFormat      = "format"
+	flagForce       = "force"
+	flagYes         = "yes"
+)
+
+// scpLikeURLRegex matches scp-like git remote URLs (e.g. git@github.com:cosmos/cosmos-sdk.git).
+var scpLikeURLRegex = regexp.MustCompile(`^[\w.-]+@([\w.-]+):(.+)$`)
+
+// InitCommand returns a CLI command that scaffolds a module manifest in the
+// current directory.
+func InitCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "init",
+		Usage: "Scaffold a module manifest in the current directory",
+		Description: `Generate a module manifest, pre-filling the repository URL from the git
+origin remote and the author from git config. Any required field not
+provided via flags is prompted for unless --yes is given.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: flagName, Usage: "the module name"},
+			&cli.StringFlag{Name: flagDescription, Usage: "a short description of the module"},
+			&cli.StringFlag{Name: flagVersion, Usage: "the initial module version", Value: "v0.1.0"},
+			&cli.StringFlag{Name: flagHomepage, Usage: "the module homepage URL"},
+			&cli.StringFlag{Name: flagRepo, Usage: "the module repository URL (defaults to the git origin remote)"},
+			&cli.StringSliceFlag{Name: flagKeywords, Usage: "module keywords"},
+			&cli.StringFlag{Name: flagFormat, Usage: "the manifest format (toml, yaml or json)", Value: module.FormatTOML},
+			&cli.BoolFlag{Name: flagForce, Usage: "overwrite an existing manifest"},
+			&cli.BoolFlag{Name: flagYes, Aliases: []string{"y"}, Usage: "do not prompt for missing fields"},
+		},
+		Action: runInit,
+	}
+}
+
+func runInit(ctx *cli.Context) error {
+	format := ctx.String(flagFormat)
+	switch format {
+	case module.FormatTOML, module.FormatYAML, module.FormatJSON:
+	default:
+		return fmt.Errorf("unsupported manifest format: %s", format)
+	}
+
+	path := "atlas." + format
+	if _, err := os.Stat(path); err == nil && !ctx.Bool(flagForce) {
+		return fmt.Errorf("%s already exists; use --%s to overwrite it", path, flagForce)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	manifest := module.Manifest{
+		Name:        ctx.String(flagName),
+		Description: ctx.String(flagDescription),
+		Version:     ctx.String(flagVersion),
+		Homepage:    ctx.String(flagHomepage),
+		Repo:        ctx.String(flagRepo),
+		Keywords:    ctx.StringSlice(flagKeywords),
+	}
+
+	if manifest.Name == "" {
+		manifest.Name = filepath.Base(wd)
+	}
+
+	if manifest.Repo == "" {
+		manifest.Repo = normalizeRemoteURL(gitConfig("remote.origin.url"))
+	}
+
+	if name := gitConfig("user.name"); name != "" {
+		manifest.Authors = []module.ManifestAuthor{{Name: name, Email: gitConfig("user.email")}}
+	}
+
+	if !ctx.Bool(flagYes) {
+		p := &prompter{in: bufio.NewReader(os.Stdin), out: ctx.App.Writer}
+		manifest.Name = p.prompt("Module name", manifest.Name)
+		manifest.Description = p.prompt("Description", manifest.Description)
+		manifest.Version = p.prompt("Version", manifest.Version)
+		manifest.Repo = p.prompt("Repository URL", manifest.Repo)
+		manifest.Homepage = p.prompt("Homepage URL", manifest.Homepage)
+	}
+
+	if err := manifest.Validate(); err != nil {
+		return err
+	}
+
+	if err := manifest.ValidateSchema(module.ManifestSchemaVersion); err != nil {
+		return err
+	}
+
+	bz, err := module.EncodeManifest(manifest, format)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, bz, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	fmt.Fprintf(ctx.App.Writer, "wrote %s\n", path)
+	return nil
+}
+
+// prompter reads field values from an interactive input, falling back to a
+// default value when the input is empty.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *prompter) prompt(label, def string) string {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s (%s): ", label, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", label)
+	}
+
+	line, _ := p.in.ReadString('\n')
+	if line = strings.TrimSpace(line); line == "" {
+		return def
+	}
+
+	return line
+}
+
+// gitConfig returns the value of a git config key or an empty string if it is
+// not set or git is unavailable.
+func gitConfig(key string) string {
+	out, err := exec.Command("git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// normalizeRemoteURL converts a git remote URL into an https repository URL.
+func normalizeRemoteURL(remote string) string {
+	if m := scpLikeURLRegex.FindStringSubmatch(remote); m != nil {
+		remote = fmt.Sprintf("https://%s/%s", m[1], m[2])
+	}
+
+	remote = strings.Replace(remote, "ssh://git@", "https://", 1)
+	return strings.TrimSuffix(remote, ".git")
+}
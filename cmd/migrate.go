@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/urfave/cli/v2"
+
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/db"
+)
+
+const (
+	flagDatabaseURL   = "database-url"
+	flagMigrationsDir = "migrations-dir"
+	flagSteps         = "steps"
+)
+
+// MigrateCommand returns a CLI command that manages the registry's database
+// schema migrations.
+func MigrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Manage database schema migrations",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "up",
+				Usage:  "Apply all pending migrations",
+				Flags:  serverFlags(),
+				Action: runMigrateUp,
+			},
+			{
+				Name:  "down",
+				Usage: "Roll back applied migrations",
+				Flags: append([]cli.Flag{
+					&cli.IntFlag{Name: flagSteps, Usage: "the number of migrations to roll back", Value: 1},
+				}, serverFlags()...),
+				Action: runMigrateDown,
+			},
+			{
+				Name:   "status",
+				Usage:  "Show the current schema version and pending migrations",
+				Flags:  serverFlags(),
+				Action: runMigrateStatus,
+			},
+		},
+	}
+}
+
+// migrationTarget defines a database schema managed by the migrate command.
+type migrationTarget struct {
+	tenant      config.Tenant
+	databaseURL string
+}
+
+// migrationTargets returns the schemas managed by the migrate command: the
+// configured database or, in a multi-tenant deployment, every tenant's schema.
+func migrationTargets(cfg config.Config) ([]migrationTarget, error) {
+	if len(cfg.Tenants) == 0 {
+		return []migrationTarget{{databaseURL: cfg.Database.URL}}, nil
+	}
+
+	targets := make([]migrationTarget, len(cfg.Tenants))
+	for i, t := range cfg.Tenants {
+		u, err := config.TenantDatabaseURL(cfg.Database.URL, t)
+		if err != nil {
+			return nil, err
+		}
+
+		targets[i] = migrationTarget{tenant: t, databaseURL: u}
+	}
+
+	return targets, nil
+}
+
+// eachMigrator runs fn with the migrator of every migration target of the
+// deployment, then prints the target's migration status.
+func eachMigrator(ctx *cli.Context, cfg config.Config, fn func(m *migrate.Migrate) error) error {
+	targets, err := migrationTargets(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		if t.tenant.Name == "" {
+			return migrateTarget(ctx, cfg.Database.MigrationsDir, t.databaseURL, fn)
+		}
+
+		fmt.Fprintf(ctx.App.Writer, "tenant:  %s\n", t.tenant.Name)
+		if err := migrateTarget(ctx, cfg.Database.MigrationsDir, t.databaseURL, fn); err != nil {
+			return fmt.Errorf("tenant %s: %w", t.tenant.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func migrateTarget(ctx *cli.Context, dir, databaseURL string, fn func(m *migrate.Migrate) error) error {
+	m, err := db.NewMigrator(databaseURL, dir)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := fn(m); err != nil {
+		return err
+	}
+
+	return printMigrationStatus(ctx, m, dir)
+}
+
+func runMigrateUp(ctx *cli.Context) error {
+	cfg, err := loadDeploymentConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range cfg.Tenants {
+		if err := db.CreateSchema(ctx.Context, cfg.Database.URL, t.Schema()); err != nil {
+			return fmt.Errorf("tenant %s: %w", t.Name, err)
+		}
+	}
+
+	return eachMigrator(ctx, cfg, func(m *migrate.Migrate) error {
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func runMigrateDown(ctx *cli.Context) error {
+	steps := ctx.Int(flagSteps)
+	if steps < 1 {
+		return fmt.Errorf("--%s must be positive", flagSteps)
+	}
+
+	cfg, err := loadDeploymentConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	return eachMigrator(ctx, cfg, func(m *migrate.Migrate) error {
+		if err := m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to roll back migrations: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func runMigrateStatus(ctx *cli.Context) error {
+	cfg, err := loadDeploymentConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	return eachMigrator(ctx, cfg, func(*migrate.Migrate) error { return nil })
+}
+
+func printMigrationStatus(ctx *cli.Context, m *migrate.Migrate, dir string) error {
+	status, err := db.Status(m, dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.App.Writer, "version: %d (latest %d)\n", status.Version, status.Latest)
+	fmt.Fprintf(ctx.App.Writer, "pending: %d\n", status.Pending)
+	if status.Dirty {
+		fmt.Fprintln(ctx.App.Writer, "dirty:   true (fix the failed migration and force the version)")
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/mod/modfile"
+
+	"github.com/cosmos/atlas/client"
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	flagRegistry = "registry"
+	flagToken    = "token"
+	flagManifest = "manifest"
+	flagGoMod    = "go-mod"
+)
+
+// OutdatedCommand returns a CLI command listing the available upgrades of a
+// set of dependencies, as advised by a registry.
+func OutdatedCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "outdated",
+		Usage:     "List available upgrades of module dependencies",
+		ArgsUsage: "[name@version...]",
+		Description: `Query the registry for the patch, minor and major upgrades of each
+dependency, given as name@version arguments, read from the dependencies of a
+manifest pinned to exact versions or matched from the requirements of a
+go.mod against the repositories of registered modules, warning of yanked
+versions, archived or deprecated modules, open advisories and changed Cosmos
+SDK compatibility. With no arguments or --manifest, the go.mod of the current
+directory is checked. Only dependencies with an upgrade or warning are
+listed.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: flagRegistry, Usage: "the base URL of the registry", EnvVars: []string{"ATLAS_REGISTRY"}, Required: true},
+			&cli.StringFlag{Name: flagToken, Usage: "an API token, to include private modules", EnvVars: []string{"ATLAS_TOKEN"}},
+			&cli.StringFlag{Name: flagManifest, Usage: "path to a manifest whose dependencies to check"},
+			&cli.StringFlag{Name: flagGoMod, Usage: "path to a go.mod whose requirements to check"},
+		},
+		Action: runOutdated,
+	}
+}
+
+func runOutdated(ctx *cli.Context) error {
+	deps, err := outdatedDependencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(deps) == 0 {
+		return errors.New("no dependencies to check")
+	}
+
+	var opts []client.Option
+	if token := ctx.String(flagToken); token != "" {
+		opts = append(opts, client.WithToken(token))
+	}
+
+	c, err := client.New(ctx.String(flagRegistry), opts...)
+	if err != nil {
+		return err
+	}
+
+	results, err := c.Upgrades(ctx.Context, deps)
+	if err != nil {
+		return err
+	}
+
+	var (
+		tw    = tabwriter.NewWriter(ctx.App.Writer, 0, 4, 2, ' ', 0)
+		links []string
+		stale int
+	)
+
+	fmt.Fprintln(tw, "MODULE\tCURRENT\tPATCH\tMINOR\tMAJOR\tNOTES")
+
+	for _, res := range results {
+		if res.Error != nil {
+			// go.mod requirements are mostly not registry modules
+			if res.Path == "" || !client.HasCode(res.Error, "MODULE_NOT_FOUND") {
+				fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\t%s\n", dependencyName(res), res.Version, res.Error.Message)
+				stale++
+			}
+
+			continue
+		}
+
+		notes := upgradeNotes(res)
+		if len(res.Upgrades) == 0 && notes == "" {
+			continue
+		}
+
+		upgrades := map[string]string{module.UpgradePatch: "-", module.UpgradeMinor: "-", module.UpgradeMajor: "-"}
+		for _, u := range res.Upgrades {
+			upgrades[u.Kind] = u.Version
+
+			if u.ChangelogURL != "" {
+				links = append(links, fmt.Sprintf("%s %s changelog: %s", res.Name, u.Version, u.ChangelogURL))
+			}
+		}
+
+		for _, a := range res.Advisories {
+			links = append(links, fmt.Sprintf("%s %s: %s", res.Name, a.Identifier, a.URL()))
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", dependencyName(res), res.Version,
+			upgrades[module.UpgradePatch], upgrades[module.UpgradeMinor], upgrades[module.UpgradeMajor], notes)
+		stale++
+	}
+
+	if stale == 0 {
+		fmt.Fprintln(ctx.App.Writer, "all registry modules are up to date")
+		return nil
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(links) > 0 {
+		fmt.Fprintln(ctx.App.Writer)
+		for _, l := range links {
+			fmt.Fprintln(ctx.App.Writer, l)
+		}
+	}
+
+	return nil
+}
+
+// dependencyName returns the name under which a dependency is listed: its
+// module name, followed by the Go module path it was matched from, if any.
+func dependencyName(res client.UpgradeResult) string {
+	switch {
+	case res.Path == "":
+		return res.Name
+	case res.Name == "":
+		return res.Path
+	default:
+		return fmt.Sprintf("%s (%s)", res.Name, res.Path)
+	}
+}
+
+// outdatedDependencies returns the dependencies given as arguments, read from
+// --manifest and required by --go-mod, defaulting to ./go.mod. Manifest
+// dependencies constrained to a range rather than an exact version are
+// skipped.
+func outdatedDependencies(ctx *cli.Context) ([]client.Dependency, error) {
+	var deps []client.Dependency
+
+	for _, arg := range ctx.Args().Slice() {
+		i := strings.LastIndex(arg, "@")
+		if i <= 0 || i == len(arg)-1 {
+			return nil, fmt.Errorf("invalid dependency %q, must be name@version", arg)
+		}
+
+		deps = append(deps, client.Dependency{Name: arg[:i], Version: arg[i+1:]})
+	}
+
+	if path := ctx.String(flagManifest); path != "" {
+		bz, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := module.ParseManifest(bz, module.FormatFromFilename(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+		}
+
+		for _, md := range m.Dependencies {
+			if _, err := semver.StrictNewVersion(strings.TrimPrefix(md.VersionConstraint, "v")); err != nil {
+				fmt.Fprintf(ctx.App.ErrWriter, "skipping %s: %q is not an exact version\n", md.Name, md.VersionConstraint)
+				continue
+			}
+
+			deps = append(deps, client.Dependency{Name: md.Name, Version: md.VersionConstraint})
+		}
+	}
+
+	goMod := ctx.String(flagGoMod)
+	if goMod == "" && len(deps) == 0 && !ctx.IsSet(flagManifest) {
+		goMod = "go.mod"
+	}
+
+	if goMod != "" {
+		bz, err := ioutil.ReadFile(goMod)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := modfile.ParseLax(goMod, bz, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", goMod, err)
+		}
+
+		// replaced requirements are checked at their replacement version
+		replaced := make(map[string]string, len(f.Replace))
+		for _, r := range f.Replace {
+			if r.New.Version != "" {
+				replaced[r.Old.Path] = r.New.Version
+			}
+		}
+
+		for _, r := range f.Require {
+			version := r.Mod.Version
+			if v, ok := replaced[r.Mod.Path]; ok {
+				version = v
+			}
+
+			deps = append(deps, client.Dependency{Path: r.Mod.Path, Version: version})
+		}
+	}
+
+	return deps, nil
+}
+
+// upgradeNotes summarizes the warnings of a dependency's upgrade advice.
+func upgradeNotes(res client.UpgradeResult) string {
+	var notes []string
+
+	if res.Yanked {
+		notes = append(notes, "current version yanked")
+	}
+
+	if res.Archived {
+		notes = append(notes, "archived")
+	}
+
+	if d := res.Deprecation; d != nil {
+		if d.ReplacedBy != "" {
+			notes = append(notes, "deprecated, replaced by "+d.ReplacedBy)
+		} else {
+			notes = append(notes, "deprecated")
+		}
+	}
+
+	for _, a := range res.Advisories {
+		notes = append(notes, fmt.Sprintf("%s (%s)", a.Identifier, a.Severity))
+	}
+
+	for _, u := range res.Upgrades {
+		if u.SDKCompatChanged {
+			notes = append(notes, fmt.Sprintf("%s requires SDK %s", u.Version, orAny(u.SDKCompat)))
+		}
+
+		if len(u.Advisories) > 0 {
+			notes = append(notes, fmt.Sprintf("%s affected by %d advisories", u.Version, len(u.Advisories)))
+		}
+	}
+
+	return strings.Join(notes, "; ")
+}
+
+func orAny(constraint string) string {
+	if constraint == "" {
+		return "any"
+	}
+
+	return constraint
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/seed"
+)
+
+const flagDev = "dev"
+
+// SeedCommand returns a CLI command that seeds a fresh development registry
+// database with fixture data.
+func SeedCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "seed",
+		Usage: "Seed a fresh development registry database with fixture data",
+		Description: `Load fixture users, keywords, modules, versions and download statistics
+into a migrated database that has no users yet, e.g. for local development of
+frontends and integrations. One of the fixture users is an admin, so seeding
+requires --dev to confirm that the database is not a production one. The
+random API token of every fixture user is printed to stdout.`,
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{Name: flagDev, Usage: "confirm that the database is a development database"},
+		}, serverFlags()...),
+		Action: runSeed,
+	}
+}
+
+func runSeed(ctx *cli.Context) error {
+	if !ctx.Bool(flagDev) {
+		return errors.New("seeding creates an admin user; pass --dev to confirm that the database is a development database")
+	}
+
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, err := db.NewMigrator(cfg.Database.URL, cfg.Database.MigrationsDir)
+	if err != nil {
+		return err
+	}
+
+	err = db.CheckPending(m, cfg.Database.MigrationsDir)
+	m.Close()
+	if err != nil {
+		return fmt.Errorf("%w; run 'atlas migrate up' first", err)
+	}
+
+	resolver, err := db.Open(cfg.Database.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+
+	now := time.Now()
+	fixtures, err := seed.Default(now)
+	if err != nil {
+		return err
+	}
+
+	if err := seed.Insert(ctx.Context, resolver.Primary(), fixtures, now); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(ctx.App.Writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER\tADMIN\tAPI TOKEN")
+	for _, u := range fixtures.Users {
+		fmt.Fprintf(tw, "%s\t%t\t%s\n", u.Name, u.Admin, u.APIToken)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "seeded %d users and %d modules\n", len(fixtures.Users), len(fixtures.Modules))
+	return nil
+}
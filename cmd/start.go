@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/cosmos/atlas/server"
+)
+
+const shutdownTimeout = 30 * time.Second
+
+// registry defines the server run by the start command: a single registry or
+// the registries of a multi-tenant deployment.
+type registry interface {
+	Handler() http.Handler
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+// StartCommand returns a CLI command that runs the registry server.
+func StartCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "start",
+		Usage: "Start the registry server",
+		Description: `Serve the registry API and run its background jobs until interrupted, then
+shut down gracefully, waiting for in-flight requests and jobs to finish.`,
+		Flags:  serverFlags(),
+		Action: runStart,
+	}
+}
+
+func runStart(ctx *cli.Context) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	var srv registry
+	if len(cfg.Tenants) > 0 {
+		srv, err = server.NewTenants(cfg)
+	} else {
+		srv, err = server.New(cfg)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := srv.Start(ctx.Context); err != nil {
+		srv.Shutdown(context.Background()) // nolint: errcheck
+		return err
+	}
+
+	var (
+		tlsCfg     *tls.Config
+		challenges *http.Server
+	)
+
+	if cfg.TLS.Enabled() {
+		var challengeHandler http.Handler
+		if tlsCfg, challengeHandler, err = server.NewTLSConfig(cfg.TLS); err != nil {
+			srv.Shutdown(context.Background()) // nolint: errcheck
+			return err
+		}
+
+		if challengeHandler != nil {
+			challenges = &http.Server{Addr: ":80", Handler: challengeHandler}
+			go func() {
+				if err := challenges.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Printf("ACME challenge server stopped: %v", err)
+				}
+			}()
+		}
+	}
+
+	httpSrv, err := server.NewHTTPServer(cfg.ListenAddr, srv.Handler(), tlsCfg)
+	if err != nil {
+		if challenges != nil {
+			challenges.Close() // nolint: errcheck
+		}
+
+		srv.Shutdown(context.Background()) // nolint: errcheck
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("serving registry on %s", cfg.ListenAddr)
+
+		if httpSrv.TLSConfig != nil {
+			errCh <- httpSrv.ListenAndServeTLS("", "")
+		} else {
+			errCh <- httpSrv.ListenAndServe()
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	var serveErr error
+	select {
+	case serveErr = <-errCh:
+	case sig := <-sigCh:
+		log.Printf("received %s, shutting down", sig)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shut down HTTP server: %v", err)
+	}
+
+	if challenges != nil {
+		challenges.Shutdown(shutdownCtx) // nolint: errcheck
+	}
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+
+	return nil
+}
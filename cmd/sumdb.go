@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/mod/sumdb/note"
+
+	"github.com/cosmos/atlas/checksumdb"
+	"github.com/cosmos/atlas/db"
+)
+
+// SumDBCommand returns a CLI command for managing the checksum database.
+func SumDBCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "sumdb",
+		Usage: "Manage the checksum database",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "keygen",
+				Usage: "Generate a checksum database signer key",
+				Description: `Print a new signer key, to be set as sumdb_key or ATLAS_SUMDB_KEY, to
+stdout and its verifier key, to be distributed to clients, to stderr.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: flagName, Usage: "the name of the checksum database, e.g. its host", Required: true},
+				},
+				Action: runSumDBKeygen,
+			},
+			{
+				Name:   "sync",
+				Usage:  "Log the checksums of every published version not yet logged",
+				Flags:  serverFlags(),
+				Action: runSumDBSync,
+			},
+		},
+	}
+}
+
+func runSumDBKeygen(ctx *cli.Context) error {
+	skey, vkey, err := note.GenerateKey(rand.Reader, ctx.String(flagName))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(ctx.App.Writer, skey)
+	fmt.Fprintln(os.Stderr, vkey)
+	return nil
+}
+
+func runSumDBSync(ctx *cli.Context) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if cfg.SumDBKey == "" {
+		return errors.New("the checksum database is disabled: sumdb_key is not set")
+	}
+
+	resolver, err := db.Open(cfg.Database.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+
+	log, err := checksumdb.NewLog(resolver.Primary(), cfg.SumDBKey)
+	if err != nil {
+		return err
+	}
+
+	n, err := log.Sync(ctx.Context)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "logged %d records\n", n)
+	return nil
+}
@@ -0,0 +1,441 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"golang.org/x/mod/sumdb/note"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cosmos/atlas/anomaly"
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/issuetracker"
+	"github.com/cosmos/atlas/jobs"
+	"github.com/cosmos/atlas/policy"
+	"github.com/cosmos/atlas/scan"
+	"github.com/cosmos/atlas/storage"
+)
+
+// Storage backends.
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// Server operating modes. In read-only mode mutating requests are rejected
+// while reads continue to be served; in maintenance mode every request is
+// rejected.
+const (
+	ModeNormal      = "normal"
+	ModeReadOnly    = "read_only"
+	ModeMaintenance = "maintenance"
+)
+
+const redacted = "REDACTED"
+
+// Config defines the configuration of the registry server. It is resolved, in
+// increasing order of precedence, from defaults, a YAML file, ATLAS_*
+// environment variables and command-line flags.
+type Config struct {
+	ListenAddr      string              `yaml:"listen_addr"`
+	BaseURL         string              `yaml:"base_url"`
+	Mode            string              `yaml:"mode"`
+	Workers         int                 `yaml:"workers"`
+	KeylessAudience string              `yaml:"keyless_audience"`
+	TLS             TLSConfig           `yaml:"tls"`
+	CORS            CORSConfig          `yaml:"cors"`
+	Database        DatabaseConfig      `yaml:"database"`
+	Storage         StorageConfig       `yaml:"storage"`
+	Policy          policy.Config       `yaml:"policy"`
+	Schedules       []jobs.Schedule     `yaml:"schedules"`
+	Upstreams       []Upstream          `yaml:"upstreams"`
+	Anomalies       anomaly.Config      `yaml:"anomalies"`
+	Scanning        scan.Config         `yaml:"scanning"`
+	Issues          issuetracker.Config `yaml:"issues"`
+
+	// SumDBKey defines the note signer key of the checksum database, as
+	// generated by 'atlas sumdb keygen'. The checksum database is disabled
+	// when empty.
+	SumDBKey string `yaml:"sumdb_key"`
+
+	// Tenants defines the isolated registries of a multi-tenant deployment.
+	// When set, only the tenants' registries are served.
+	Tenants []Tenant `yaml:"tenants"`
+
+	// SDKReleases defines the Cosmos SDK releases listed in module
+	// compatibility matrices.
+	SDKReleases []string `yaml:"sdk_releases"`
+}
+
+// Upstream defines an upstream Atlas registry mirrored by this registry.
+type Upstream struct {
+	// URL defines the base URL of the upstream registry.
+	URL string `yaml:"url"`
+
+	// Fallthrough enables serving modules unknown to this registry by fetching
+	// them from the upstream on read.
+	Fallthrough bool `yaml:"fallthrough"`
+}
+
+// TLSConfig defines the HTTPS configuration of the server. At most one of a
+// certificate/key pair or autocert domains may be set; with neither the server
+// serves plain HTTP, e.g. behind a TLS-terminating proxy.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+
+	// AutocertDomains defines the domains for which certificates are
+	// provisioned and renewed from Let's Encrypt.
+	AutocertDomains  []string `yaml:"autocert_domains"`
+	AutocertEmail    string   `yaml:"autocert_email"`
+	AutocertCacheDir string   `yaml:"autocert_cache_dir"`
+}
+
+// Enabled returns true if the server should serve HTTPS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || len(c.AutocertDomains) > 0
+}
+
+// DatabaseConfig defines the database configuration of the server.
+type DatabaseConfig struct {
+	URL           string   `yaml:"url"`
+	ReplicaURLs   []string `yaml:"replica_urls"`
+	MigrationsDir string   `yaml:"migrations_dir"`
+}
+
+// StorageConfig defines the artifact storage configuration of the server.
+type StorageConfig struct {
+	Backend   string           `yaml:"backend"`
+	LocalRoot string           `yaml:"local_root"`
+	S3        storage.S3Config `yaml:"s3"`
+
+	// Prefix defines a key prefix under which every object is stored.
+	Prefix string `yaml:"prefix"`
+}
+
+// Default returns the default server configuration.
+func Default() Config {
+	return Config{
+		ListenAddr:      ":8080",
+		BaseURL:         "http://localhost:8080",
+		Mode:            ModeNormal,
+		Workers:         4,
+		KeylessAudience: "atlas",
+		TLS: TLSConfig{
+			AutocertCacheDir: "autocert",
+		},
+		CORS: CORSConfig{
+			MaxAge: 10 * time.Minute,
+		},
+		Database: DatabaseConfig{
+			MigrationsDir: db.DefaultMigrationsDir,
+		},
+		Storage: StorageConfig{
+			Backend:   StorageLocal,
+			LocalRoot: "artifacts",
+		},
+		Policy:    policy.DefaultConfig(),
+		Anomalies: anomaly.DefaultConfig(),
+		Scanning:  scan.DefaultConfig(),
+		Schedules: jobs.DefaultSchedules(),
+		SDKReleases: []string{
+			"v0.40.0",
+			"v0.41.0",
+			"v0.42.0",
+			"v0.43.0",
+			"v0.44.0",
+			"v0.45.0",
+			"v0.46.0",
+			"v0.47.0",
+			"v0.50.0",
+		},
+	}
+}
+
+// Load returns the default configuration overridden by the YAML file, if
+// given, and then by the environment.
+func Load(file string) (Config, error) {
+	cfg := Default()
+
+	if file != "" {
+		bz, err := ioutil.ReadFile(file)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read config: %w", err)
+		}
+
+		if err := yaml.UnmarshalStrict(bz, &cfg); err != nil {
+			return Config{}, fmt.Errorf("failed to decode config: %w", err)
+		}
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// applyEnv overrides the configuration with any ATLAS_* environment variables
+// that are set.
+func (cfg *Config) applyEnv(lookup func(string) (string, bool)) error {
+	strs := map[string]*string{
+		"ATLAS_LISTEN_ADDR":          &cfg.ListenAddr,
+		"ATLAS_BASE_URL":             &cfg.BaseURL,
+		"ATLAS_MODE":                 &cfg.Mode,
+		"ATLAS_KEYLESS_AUDIENCE":     &cfg.KeylessAudience,
+		"ATLAS_TLS_CERT_FILE":        &cfg.TLS.CertFile,
+		"ATLAS_TLS_KEY_FILE":         &cfg.TLS.KeyFile,
+		"ATLAS_AUTOCERT_EMAIL":       &cfg.TLS.AutocertEmail,
+		"ATLAS_AUTOCERT_CACHE_DIR":   &cfg.TLS.AutocertCacheDir,
+		"ATLAS_DATABASE_URL":         &cfg.Database.URL,
+		"ATLAS_MIGRATIONS_DIR":       &cfg.Database.MigrationsDir,
+		"ATLAS_STORAGE_BACKEND":      &cfg.Storage.Backend,
+		"ATLAS_STORAGE_LOCAL_ROOT":   &cfg.Storage.LocalRoot,
+		"ATLAS_STORAGE_PREFIX":       &cfg.Storage.Prefix,
+		"ATLAS_S3_ENDPOINT":          &cfg.Storage.S3.Endpoint,
+		"ATLAS_S3_BUCKET":            &cfg.Storage.S3.Bucket,
+		"ATLAS_S3_ACCESS_KEY_ID":     &cfg.Storage.S3.AccessKeyID,
+		"ATLAS_S3_SECRET_ACCESS_KEY": &cfg.Storage.S3.SecretAccessKey,
+		"ATLAS_S3_REGION":            &cfg.Storage.S3.Region,
+		"ATLAS_SUMDB_KEY":            &cfg.SumDBKey,
+		"ATLAS_GITHUB_TOKEN":         &cfg.Issues.GitHubToken,
+		"ATLAS_GITLAB_TOKEN":         &cfg.Issues.GitLabToken,
+	}
+	for key, dst := range strs {
+		if v, ok := lookup(key); ok {
+			*dst = v
+		}
+	}
+
+	if v, ok := lookup("ATLAS_AUTOCERT_DOMAINS"); ok {
+		cfg.TLS.AutocertDomains = splitList(v)
+	}
+
+	if v, ok := lookup("ATLAS_DATABASE_REPLICA_URLS"); ok {
+		cfg.Database.ReplicaURLs = splitList(v)
+	}
+
+	if v, ok := lookup("ATLAS_CORS_ALLOWED_ORIGINS"); ok {
+		cfg.CORS.AllowedOrigins = splitList(v)
+	}
+
+	if v, ok := lookup("ATLAS_CORS_ALLOW_CREDENTIALS"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid ATLAS_CORS_ALLOW_CREDENTIALS: %w", err)
+		}
+
+		cfg.CORS.AllowCredentials = b
+	}
+
+	if v, ok := lookup("ATLAS_WORKERS"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid ATLAS_WORKERS: %w", err)
+		}
+
+		cfg.Workers = n
+	}
+
+	if v, ok := lookup("ATLAS_S3_USE_SSL"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid ATLAS_S3_USE_SSL: %w", err)
+		}
+
+		cfg.Storage.S3.UseSSL = b
+	}
+
+	if v, ok := lookup("ATLAS_ISSUES_ENABLED"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid ATLAS_ISSUES_ENABLED: %w", err)
+		}
+
+		cfg.Issues.Enabled = b
+	}
+
+	return nil
+}
+
+// Validate returns an error describing every invalid setting of the
+// configuration. The server refuses to start if it fails.
+func (cfg Config) Validate() error {
+	return cfg.validate(true)
+}
+
+// ValidateEmbedded validates the configuration of a registry embedded in a
+// program that provides its database, for which database.url is not required.
+func (cfg Config) ValidateEmbedded() error {
+	return cfg.validate(false)
+}
+
+func (cfg Config) validate(requireDatabase bool) error {
+	var errs []string
+
+	if cfg.ListenAddr == "" {
+		errs = append(errs, "listen_addr must not be empty")
+	}
+
+	if u, err := url.Parse(cfg.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, "base_url must be a valid http(s) URL")
+	}
+
+	switch cfg.Mode {
+	case ModeNormal, ModeReadOnly, ModeMaintenance:
+	default:
+		errs = append(errs, fmt.Sprintf("mode must be one of: %s, %s, %s", ModeNormal, ModeReadOnly, ModeMaintenance))
+	}
+
+	if cfg.Workers < 1 {
+		errs = append(errs, "workers must be positive")
+	}
+
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs = append(errs, "tls.cert_file and tls.key_file must be set together")
+	}
+
+	if cfg.TLS.CertFile != "" && len(cfg.TLS.AutocertDomains) > 0 {
+		errs = append(errs, "tls.cert_file and tls.autocert_domains are mutually exclusive")
+	}
+
+	if len(cfg.TLS.AutocertDomains) > 0 && cfg.TLS.AutocertCacheDir == "" {
+		errs = append(errs, "tls.autocert_cache_dir must not be empty")
+	}
+
+	errs = append(errs, cfg.CORS.validate()...)
+
+	if requireDatabase && cfg.Database.URL == "" {
+		errs = append(errs, "database.url must not be empty")
+	}
+
+	for i, u := range append([]string{cfg.Database.URL}, cfg.Database.ReplicaURLs...) {
+		if u == "" {
+			continue
+		}
+
+		if _, err := url.Parse(u); err != nil {
+			errs = append(errs, fmt.Sprintf("database URL %d is invalid", i))
+		}
+	}
+
+	switch cfg.Storage.Backend {
+	case StorageLocal:
+		if cfg.Storage.LocalRoot == "" {
+			errs = append(errs, "storage.local_root must not be empty")
+		}
+
+	case StorageS3:
+		if cfg.Storage.S3.Endpoint == "" || cfg.Storage.S3.Bucket == "" {
+			errs = append(errs, "storage.s3.endpoint and storage.s3.bucket must not be empty")
+		}
+
+	default:
+		errs = append(errs, fmt.Sprintf("storage.backend must be one of: %s, %s", StorageLocal, StorageS3))
+	}
+
+	for i, up := range cfg.Upstreams {
+		if u, err := url.Parse(up.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("upstreams[%d].url must be a valid http(s) URL", i))
+		}
+	}
+
+	for i, wh := range cfg.Scanning.Webhooks {
+		if u, err := url.Parse(wh.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("scanning.webhooks[%d].url must be a valid http(s) URL", i))
+		}
+	}
+
+	if cfg.SumDBKey != "" {
+		if _, err := note.NewSigner(cfg.SumDBKey); err != nil {
+			errs = append(errs, "sumdb_key must be a valid note signer key")
+		}
+	}
+
+	errs = append(errs, cfg.validateTenants()...)
+
+	for i, v := range cfg.SDKReleases {
+		if _, err := semver.NewVersion(v); err != nil {
+			errs = append(errs, fmt.Sprintf("sdk_releases[%d] must be a valid semantic version", i))
+		}
+	}
+
+	if _, err := jobs.NewScheduler(nil, cfg.Schedules); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid config: " + strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Redacted returns a copy of the configuration with credentials replaced, safe
+// for printing or logging.
+func (cfg Config) Redacted() Config {
+	cfg.Database.URL = redactURL(cfg.Database.URL)
+
+	var replicas []string
+	for _, u := range cfg.Database.ReplicaURLs {
+		replicas = append(replicas, redactURL(u))
+	}
+	cfg.Database.ReplicaURLs = replicas
+
+	if cfg.Storage.S3.SecretAccessKey != "" {
+		cfg.Storage.S3.SecretAccessKey = redacted
+	}
+
+	if cfg.SumDBKey != "" {
+		cfg.SumDBKey = redacted
+	}
+
+	if cfg.Issues.GitHubToken != "" {
+		cfg.Issues.GitHubToken = redacted
+	}
+
+	if cfg.Issues.GitLabToken != "" {
+		cfg.Issues.GitLabToken = redacted
+	}
+
+	return cfg
+}
+
+// redactURL replaces the password of a connection URL. Unparsable URLs are
+// redacted entirely since they may still embed credentials.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+
+	q := u.Query()
+	if q.Get("password") != "" {
+		q.Set("password", redacted)
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String()
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
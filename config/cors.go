@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CORSConfig defines the cross-origin resource sharing policy of the API,
+// allowing browser frontends served from other origins to call it. Requests
+// from origins that are not allowed are served without CORS headers, so that
+// browsers deny their frontends access to the response.
+type CORSConfig struct {
+	// AllowedOrigins defines the origins, as scheme://host[:port], allowed to
+	// call the API. A leading "*." in the host allows its subdomains, e.g.
+	// https://*.example.com, and "*" allows any origin to send uncredentialed
+	// requests.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+
+	// AllowCredentials allows the allowed origins to send credentialed
+	// requests, authenticated by the session cookie. Mutating requests
+	// authenticated by the cookie must carry the session's CSRF token.
+	AllowCredentials bool `yaml:"allow_credentials"`
+
+	// MaxAge defines how long browsers may cache preflight responses.
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// Enabled returns true if any origin is allowed.
+func (c CORSConfig) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
+}
+
+// AllowsOrigin returns true if the value of a request's Origin header is an
+// allowed origin.
+func (c CORSConfig) AllowsOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+
+		a, err := url.Parse(allowed)
+		if err != nil || !strings.EqualFold(a.Scheme, u.Scheme) {
+			continue
+		}
+
+		if suffix := strings.TrimPrefix(a.Host, "*"); suffix != a.Host {
+			if host := strings.ToLower(u.Host); strings.HasSuffix(host, strings.ToLower(suffix)) && len(host) > len(suffix) {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.EqualFold(a.Host, u.Host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c CORSConfig) validate() []string {
+	var errs []string
+
+	for i, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				errs = append(errs, "cors.allowed_origins must list explicit origins when cors.allow_credentials is set")
+			}
+
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			(u.Path != "" && u.Path != "/") || u.RawQuery != "" ||
+			strings.Contains(strings.TrimPrefix(u.Host, "*."), "*") {
+			errs = append(errs, fmt.Sprintf("cors.allowed_origins[%d] must be an http(s) origin", i))
+		}
+	}
+
+	if c.MaxAge < 0 {
+		errs = append(errs, "cors.max_age must not be negative")
+	}
+
+	return errs
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var tenantNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]{0,47}$`)
+
+// Tenant defines an isolated registry hosted by a multi-tenant deployment,
+// e.g. per organization or environment. Every tenant has its own modules,
+// users and tokens, stored in its own Postgres schema of the configured
+// database, and its own artifacts, stored under its own storage prefix.
+// Requests are routed to a tenant by their host or path prefix.
+type Tenant struct {
+	Name       string   `yaml:"name"`
+	Hosts      []string `yaml:"hosts"`
+	PathPrefix string   `yaml:"path_prefix"`
+
+	// BaseURL defines the public URL of the tenant's registry. It defaults to
+	// the deployment's base URL joined with the tenant's path prefix.
+	BaseURL string `yaml:"base_url"`
+}
+
+// Schema returns the Postgres schema holding the tenant's tables.
+func (t Tenant) Schema() string {
+	return "tenant_" + t.Name
+}
+
+// ForTenant returns the configuration of a tenant's registry, derived from
+// the deployment's configuration.
+func (cfg Config) ForTenant(t Tenant) (Config, error) {
+	tcfg := cfg
+	tcfg.Tenants = nil
+
+	tcfg.BaseURL = t.BaseURL
+	if tcfg.BaseURL == "" {
+		tcfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/") + t.PathPrefix
+	}
+
+	primary, err := TenantDatabaseURL(cfg.Database.URL, t)
+	if err != nil {
+		return Config{}, err
+	}
+
+	tcfg.Database.URL = primary
+	tcfg.Database.ReplicaURLs = nil
+
+	for _, u := range cfg.Database.ReplicaURLs {
+		replica, err := TenantDatabaseURL(u, t)
+		if err != nil {
+			return Config{}, err
+		}
+
+		tcfg.Database.ReplicaURLs = append(tcfg.Database.ReplicaURLs, replica)
+	}
+
+	tcfg.Storage.Prefix = path.Join(cfg.Storage.Prefix, "tenants", t.Name)
+	return tcfg, nil
+}
+
+// TenantDatabaseURL returns the Postgres connection URL of a tenant, whose
+// search path resolves tables in the tenant's schema and shared extensions in
+// the public schema.
+func TenantDatabaseURL(databaseURL string, t Tenant) (string, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return "", fmt.Errorf("tenant %s: database URL must be a postgres:// URL", t.Name)
+	}
+
+	q := u.Query()
+	q.Set("search_path", t.Schema()+",public")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// validateTenants returns the errors of the configured tenants.
+func (cfg Config) validateTenants() []string {
+	var (
+		errs     []string
+		names    = make(map[string]bool)
+		hosts    = make(map[string]bool)
+		prefixes = make(map[string]bool)
+	)
+
+	for i, t := range cfg.Tenants {
+		if !tenantNameRegex.MatchString(t.Name) {
+			errs = append(errs, fmt.Sprintf("tenants[%d].name must be lowercase alphanumeric or underscores, starting with a letter", i))
+		} else if names[t.Name] {
+			errs = append(errs, fmt.Sprintf("tenants[%d].name %s is not unique", i, t.Name))
+		}
+		names[t.Name] = true
+
+		if len(t.Hosts) == 0 && t.PathPrefix == "" {
+			errs = append(errs, fmt.Sprintf("tenants[%d] must set hosts or path_prefix", i))
+		}
+
+		for _, h := range t.Hosts {
+			h = strings.ToLower(h)
+			if hosts[h] {
+				errs = append(errs, fmt.Sprintf("tenants[%d].hosts %s is not unique", i, h))
+			}
+			hosts[h] = true
+		}
+
+		if t.PathPrefix != "" {
+			if !strings.HasPrefix(t.PathPrefix, "/") || strings.HasSuffix(t.PathPrefix, "/") {
+				errs = append(errs, fmt.Sprintf("tenants[%d].path_prefix must start and must not end with a slash", i))
+			} else if prefixes[t.PathPrefix] {
+				errs = append(errs, fmt.Sprintf("tenants[%d].path_prefix %s is not unique", i, t.PathPrefix))
+			}
+			prefixes[t.PathPrefix] = true
+		}
+
+		if t.BaseURL != "" {
+			if u, err := url.Parse(t.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Sprintf("tenants[%d].base_url must be a valid http(s) URL", i))
+			}
+		}
+
+		if cfg.Database.URL != "" {
+			if _, err := TenantDatabaseURL(cfg.Database.URL, t); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	return errs
+}
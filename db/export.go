@@ -0,0 +1,181 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// dumpTable defines a table included in registry exports.
+type dumpTable struct {
+	name   string
+	serial bool // whether the table has a SERIAL id whose sequence must be reset on import
+}
+
+// dumpTables defines the tables included in registry exports, ordered so that
+// every table follows the tables it references. Sessions, jobs and derived
+// data (e.g. recommendations, notifications and materialized views) are
+// excluded as they are transient or recomputed.
+var dumpTables = []dumpTable{
+	{"users", true},
+	{"recovery_codes", true},
+	{"publisher_verifications", true},
+	{"keywords", true},
+	{"keyword_aliases", false},
+	{"categories", true},
+	{"bugs", true},
+	{"modules", true},
+	{"modules_users", false},
+	{"modules_keywords", false},
+	{"modules_categories", false},
+	{"module_grants", false},
+	{"quota_overrides", false},
+	{"module_aliases", true},
+	{"module_repo_history", true},
+	{"released_names", false},
+	{"module_dependencies", true},
+	{"public_keys", true},
+	{"module_versions", true},
+	{"module_version_docs", false},
+	{"module_version_interfaces", false},
+	{"advisories", true},
+	{"trust_policies", true},
+	{"client_downloads", false},
+	{"module_daily_downloads", false},
+	{"removal_requests", true},
+	{"reviews", true},
+	{"module_subscriptions", false},
+	{"questions", true},
+	{"answers", true},
+	{"reports", true},
+	{"anomalies", true},
+	{"report_comments", true},
+	{"report_events", true},
+	{"suggested_modules", true},
+	{"module_sets", true},
+	{"module_set_versions", false},
+	{"checksum_records", false},
+	{"checksum_hashes", false},
+	{"checksum_tree_heads", false},
+}
+
+// DumpRecord defines a single NDJSON line of a registry export.
+type DumpRecord struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// Export writes every row of the registry's tables to w as NDJSON records,
+// reading from a single consistent snapshot.
+func Export(ctx context.Context, sqlDB *sql.DB, w io.Writer) (int, error) {
+	tx, err := sqlDB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	var (
+		n   int
+		bw  = bufio.NewWriter(w)
+		enc = json.NewEncoder(bw)
+	)
+
+	for _, t := range dumpTables {
+		// table names are constants, never user input
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT row_to_json(t) FROM %s t`, t.name))
+		if err != nil {
+			return n, fmt.Errorf("failed to export %s: %w", t.name, err)
+		}
+
+		for rows.Next() {
+			rec := DumpRecord{Table: t.name}
+			if err := rows.Scan(&rec.Row); err != nil {
+				rows.Close()
+				return n, fmt.Errorf("failed to export %s: %w", t.name, err)
+			}
+
+			if err := enc.Encode(rec); err != nil {
+				rows.Close()
+				return n, err
+			}
+
+			n++
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return n, fmt.Errorf("failed to export %s: %w", t.name, err)
+		}
+	}
+
+	return n, bw.Flush()
+}
+
+// Import restores an NDJSON export produced by Export in a single transaction.
+// Rows whose primary key already exists are skipped, so importing the same
+// export twice is a no-op. ID sequences are advanced past the imported rows.
+// It returns the number of rows inserted.
+func Import(ctx context.Context, sqlDB *sql.DB, r io.Reader) (int, error) {
+	tables := make(map[string]dumpTable, len(dumpTables))
+	for _, t := range dumpTables {
+		tables[t.name] = t
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	var (
+		n    int
+		line int
+		dec  = json.NewDecoder(r)
+	)
+
+	for dec.More() {
+		line++
+
+		var rec DumpRecord
+		if err := dec.Decode(&rec); err != nil {
+			return n, fmt.Errorf("invalid record %d: %w", line, err)
+		}
+
+		t, ok := tables[rec.Table]
+		if !ok {
+			return n, fmt.Errorf("invalid record %d: unknown table %q", line, rec.Table)
+		}
+
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(
+			`INSERT INTO %[1]s SELECT * FROM json_populate_record(NULL::%[1]s, $1) ON CONFLICT DO NOTHING`, t.name,
+		), []byte(rec.Row))
+		if err != nil {
+			return n, fmt.Errorf("failed to import record %d into %s: %w", line, t.name, err)
+		}
+
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return n, err
+		}
+
+		n += int(inserted)
+	}
+
+	for _, t := range dumpTables {
+		if !t.serial {
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(
+			`SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM %[1]s`, t.name,
+		))
+		if err != nil {
+			return n, fmt.Errorf("failed to reset %s sequence: %w", t.name, err)
+		}
+	}
+
+	return n, tx.Commit()
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres" // register the postgres driver
+	"github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// DefaultMigrationsDir defines the default directory of the SQL migrations.
+const DefaultMigrationsDir = "db/migrations"
+
+// ErrPendingMigrations defines an error returned when the database schema is
+// behind the latest migration.
+var ErrPendingMigrations = errors.New("database has pending migrations")
+
+// MigrationStatus defines the state of the database schema relative to the
+// available migrations.
+type MigrationStatus struct {
+	Version uint `json:"version"`
+	Latest  uint `json:"latest"`
+	Pending int  `json:"pending"`
+	Dirty   bool `json:"dirty"`
+}
+
+// NewMigrator returns a migrator applying the SQL migrations in dir to the
+// Postgres database at databaseURL.
+func NewMigrator(databaseURL, dir string) (*migrate.Migrate, error) {
+	src, err := sourceURL(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.New(src, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize migrator: %w", err)
+	}
+
+	return m, nil
+}
+
+// Status returns the migration status of the database managed by m against the
+// migrations in dir.
+func Status(m *migrate.Migrate, dir string) (MigrationStatus, error) {
+	var status MigrationStatus
+
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, fmt.Errorf("failed to read database version: %w", err)
+	}
+
+	versions, err := migrationVersions(dir)
+	if err != nil {
+		return status, err
+	}
+
+	status.Version = version
+	status.Dirty = dirty
+	for _, v := range versions {
+		if v > version {
+			status.Pending++
+		}
+
+		status.Latest = v
+	}
+
+	return status, nil
+}
+
+// CheckPending returns ErrPendingMigrations if the database managed by m is
+// dirty or has not applied every migration in dir. The server calls it on
+// startup and refuses to serve when it fails.
+func CheckPending(m *migrate.Migrate, dir string) error {
+	status, err := Status(m, dir)
+	if err != nil {
+		return err
+	}
+
+	if status.Dirty {
+		return fmt.Errorf("%w: version %d is dirty", ErrPendingMigrations, status.Version)
+	}
+
+	if status.Pending > 0 {
+		return fmt.Errorf("%w: at version %d, latest is %d", ErrPendingMigrations, status.Version, status.Latest)
+	}
+
+	return nil
+}
+
+// migrationVersions returns the ascending versions of the migrations in dir.
+func migrationVersions(dir string) ([]uint, error) {
+	src, err := sourceURL(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := (&file.File{}).Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %w", err)
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	versions := []uint{version}
+	for {
+		version, err = driver.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return versions, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read migrations: %w", err)
+		}
+
+		versions = append(versions, version)
+	}
+}
+
+func sourceURL(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid migrations directory: %w", err)
+	}
+
+	return "file://" + filepath.ToSlash(abs), nil
+}
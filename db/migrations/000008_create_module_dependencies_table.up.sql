@@ -0,0 +1,16 @@
+BEGIN;
+-- create a directed relationship mapping modules to the registered modules
+-- they depend on along with the version constraint of each dependency
+CREATE TABLE IF NOT EXISTS module_dependencies (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  dependency_id int NOT NULL,
+  version_constraint VARCHAR NOT NULL,
+  UNIQUE (module_id, dependency_id),
+  CHECK (module_id <> dependency_id),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (dependency_id) REFERENCES modules(id) ON UPDATE CASCADE
+);
+-- create an index on the dependency FK so dependents can be queried
+CREATE INDEX IF NOT EXISTS dependency_id_idx ON module_dependencies(dependency_id);
+COMMIT;
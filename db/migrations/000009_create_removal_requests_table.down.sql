@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS removal_requests;
+ALTER TABLE modules DROP COLUMN disputed;
+COMMIT;
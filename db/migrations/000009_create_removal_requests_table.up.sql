@@ -0,0 +1,23 @@
+BEGIN;
+-- add disputed column to modules table which blocks downloads of a module
+-- while a removal request is pending
+ALTER TABLE modules
+ADD COLUMN disputed BOOLEAN NOT NULL DEFAULT FALSE;
+-- create removal_requests table tracking legal/DMCA takedown requests
+CREATE TABLE IF NOT EXISTS removal_requests (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  kind VARCHAR NOT NULL,
+  claimant VARCHAR NOT NULL,
+  contact VARCHAR NOT NULL,
+  reason TEXT NOT NULL,
+  counter_notice TEXT,
+  status VARCHAR NOT NULL,
+  resolution VARCHAR,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- create index on modules FK
+CREATE INDEX IF NOT EXISTS removal_requests_module_id_idx ON removal_requests(module_id);
+COMMIT;
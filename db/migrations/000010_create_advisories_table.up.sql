@@ -0,0 +1,17 @@
+BEGIN;
+-- create advisories table tracking security advisories published per module
+CREATE TABLE IF NOT EXISTS advisories (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  identifier VARCHAR NOT NULL,
+  affected_versions VARCHAR NOT NULL,
+  severity VARCHAR NOT NULL,
+  description TEXT NOT NULL,
+  resolved BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (module_id, identifier),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- create index on modules FK
+CREATE INDEX IF NOT EXISTS advisories_module_id_idx ON advisories(module_id);
+COMMIT;
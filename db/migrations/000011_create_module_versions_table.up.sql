@@ -0,0 +1,16 @@
+BEGIN;
+-- create module_versions table tracking every published version of a module
+-- along with its optional source tarball artifact
+CREATE TABLE IF NOT EXISTS module_versions (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  version VARCHAR NOT NULL,
+  checksum VARCHAR,
+  artifact_key VARCHAR,
+  artifact_size BIGINT NOT NULL DEFAULT 0,
+  downloads BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (module_id, version),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+-- create suggested_modules table acting as an admin curation queue of modules
+-- discovered from external sources
+CREATE TABLE IF NOT EXISTS suggested_modules (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR NOT NULL,
+  repo VARCHAR NOT NULL UNIQUE,
+  source VARCHAR NOT NULL,
+  status VARCHAR NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS suggested_modules_status_idx ON suggested_modules(status);
+COMMIT;
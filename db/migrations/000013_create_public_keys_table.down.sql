@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE module_versions DROP COLUMN signature_key_id;
+ALTER TABLE module_versions DROP COLUMN signature;
+ALTER TABLE module_versions DROP COLUMN signature_kind;
+DROP TABLE IF EXISTS public_keys;
+COMMIT;
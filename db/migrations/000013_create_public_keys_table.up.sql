@@ -0,0 +1,22 @@
+BEGIN;
+-- create public_keys table storing keys users register to sign module versions
+CREATE TABLE IF NOT EXISTS public_keys (
+  id SERIAL PRIMARY KEY,
+  user_id int NOT NULL,
+  kind VARCHAR NOT NULL,
+  key TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS public_keys_user_id_idx ON public_keys(user_id);
+-- add signature metadata columns to module_versions table
+ALTER TABLE module_versions
+ADD COLUMN signature_kind VARCHAR;
+ALTER TABLE module_versions
+ADD COLUMN signature TEXT;
+ALTER TABLE module_versions
+ADD COLUMN signature_key_id INT;
+ALTER TABLE module_versions
+ADD CONSTRAINT fk_signature_key FOREIGN KEY (signature_key_id) REFERENCES public_keys(id) ON DELETE
+SET NULL;
+COMMIT;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS trust_policies;
@@ -0,0 +1,14 @@
+BEGIN;
+-- create trust_policies table describing which CI workflows may publish a
+-- module using an OIDC ID token instead of an API token
+CREATE TABLE IF NOT EXISTS trust_policies (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  repository VARCHAR NOT NULL,
+  workflow VARCHAR,
+  ref VARCHAR,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS trust_policies_module_id_idx ON trust_policies(module_id);
+COMMIT;
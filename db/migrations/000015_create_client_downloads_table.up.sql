@@ -0,0 +1,11 @@
+BEGIN;
+-- create client_downloads table attributing module downloads to the client
+-- (CLI, go-proxy, web UI, mirror) that fetched them
+CREATE TABLE IF NOT EXISTS client_downloads (
+  module_id int NOT NULL,
+  client VARCHAR NOT NULL,
+  downloads BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (module_id, client),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE modules DROP COLUMN links_checked_at;
+ALTER TABLE modules DROP COLUMN link_status;
+COMMIT;
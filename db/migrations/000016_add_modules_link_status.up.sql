@@ -0,0 +1,7 @@
+BEGIN;
+-- add columns recording the result of the last repository health check
+ALTER TABLE modules
+ADD COLUMN link_status VARCHAR;
+ALTER TABLE modules
+ADD COLUMN links_checked_at TIMESTAMP;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE modules DROP COLUMN hidden;
+ALTER TABLE users DROP COLUMN banned;
+ALTER TABLE users DROP COLUMN admin;
+COMMIT;
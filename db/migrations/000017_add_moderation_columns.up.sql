@@ -0,0 +1,10 @@
+BEGIN;
+-- add admin and banned flags to users table
+ALTER TABLE users
+ADD COLUMN admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users
+ADD COLUMN banned BOOLEAN NOT NULL DEFAULT FALSE;
+-- add hidden flag to modules table
+ALTER TABLE modules
+ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT FALSE;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TABLE IF EXISTS report_events;
+DROP TABLE IF EXISTS report_comments;
+DROP TABLE IF EXISTS reports;
+COMMIT;
@@ -0,0 +1,40 @@
+BEGIN;
+-- create reports table acting as the moderation queue of abuse reports
+CREATE TABLE IF NOT EXISTS reports (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  reporter_id int NOT NULL,
+  reason VARCHAR NOT NULL,
+  details TEXT,
+  status VARCHAR NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (reporter_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS reports_module_id_idx ON reports(module_id);
+CREATE INDEX IF NOT EXISTS reports_status_idx ON reports(status);
+-- create report_comments table for admin comments on reports
+CREATE TABLE IF NOT EXISTS report_comments (
+  id SERIAL PRIMARY KEY,
+  report_id int NOT NULL,
+  user_id int NOT NULL,
+  body TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (report_id) REFERENCES reports(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS report_comments_report_id_idx ON report_comments(report_id);
+-- create report_events table auditing report status transitions
+CREATE TABLE IF NOT EXISTS report_events (
+  id SERIAL PRIMARY KEY,
+  report_id int NOT NULL,
+  actor_id int NOT NULL,
+  from_status VARCHAR NOT NULL,
+  to_status VARCHAR NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (report_id) REFERENCES reports(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (actor_id) REFERENCES users(id) ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS report_events_report_id_idx ON report_events(report_id);
+COMMIT;
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS released_names (
+  name VARCHAR PRIMARY KEY,
+  previous_owner INT REFERENCES users(id) ON DELETE SET NULL,
+  released_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
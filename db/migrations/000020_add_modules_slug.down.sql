@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX IF EXISTS modules_slug_idx;
+ALTER TABLE modules DROP COLUMN slug;
+COMMIT;
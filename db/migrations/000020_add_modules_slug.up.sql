@@ -0,0 +1,11 @@
+BEGIN;
+-- add slug column holding the normalized module name used for uniqueness
+ALTER TABLE modules
+ADD COLUMN slug VARCHAR;
+UPDATE modules
+SET slug = LOWER(name);
+ALTER TABLE modules
+ALTER COLUMN slug
+SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS modules_slug_idx ON modules(slug);
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+-- create module_aliases table mapping previous names of renamed modules to
+-- their current identity
+CREATE TABLE IF NOT EXISTS module_aliases (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  name VARCHAR NOT NULL,
+  slug VARCHAR NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS module_aliases_module_id_idx ON module_aliases(module_id);
+COMMIT;
@@ -0,0 +1 @@
+ALTER TABLE module_versions DROP COLUMN yanked;
@@ -0,0 +1,2 @@
+ALTER TABLE module_versions
+ADD COLUMN yanked BOOLEAN NOT NULL DEFAULT FALSE;
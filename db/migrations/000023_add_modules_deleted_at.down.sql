@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX IF EXISTS modules_deleted_at_idx;
+ALTER TABLE modules DROP COLUMN deleted_at;
+COMMIT;
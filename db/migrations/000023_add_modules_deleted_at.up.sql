@@ -0,0 +1,6 @@
+BEGIN;
+-- add deleted_at column to modules table supporting soft deletes
+ALTER TABLE modules
+ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS modules_deleted_at_idx ON modules(deleted_at);
+COMMIT;
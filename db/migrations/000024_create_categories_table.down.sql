@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS modules_categories;
+DROP TABLE IF EXISTS categories;
+COMMIT;
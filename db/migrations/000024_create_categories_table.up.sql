@@ -0,0 +1,60 @@
+BEGIN;
+-- create a curated categories table
+CREATE TABLE IF NOT EXISTS categories (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR NOT NULL UNIQUE,
+  slug VARCHAR NOT NULL UNIQUE,
+  description VARCHAR
+);
+-- create a many-to-many relationship mapping modules and categories
+CREATE TABLE modules_categories (
+  module_id int NOT NULL,
+  category_id int NOT NULL,
+  PRIMARY KEY (module_id, category_id),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (category_id) REFERENCES categories(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS category_id_idx ON modules_categories(category_id);
+-- seed the initial taxonomy
+INSERT INTO categories (name, slug, description)
+VALUES (
+    'Staking',
+    'staking',
+    'Validator, delegation and staking modules'
+  ),
+  (
+    'Governance',
+    'governance',
+    'On-chain governance and voting modules'
+  ),
+  (
+    'IBC',
+    'ibc',
+    'Inter-Blockchain Communication protocol modules'
+  ),
+  (
+    'Tokens',
+    'tokens',
+    'Fungible and non-fungible token modules'
+  ),
+  (
+    'Oracles',
+    'oracles',
+    'Off-chain data and price feed modules'
+  ),
+  (
+    'DeFi',
+    'defi',
+    'Exchange, lending and liquidity modules'
+  ),
+  (
+    'Identity',
+    'identity',
+    'Accounts, authentication and identity modules'
+  ),
+  (
+    'Utilities',
+    'utilities',
+    'General purpose and developer tooling modules'
+  ) ON CONFLICT DO NOTHING;
+COMMIT;
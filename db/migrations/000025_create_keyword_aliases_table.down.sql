@@ -0,0 +1,4 @@
+BEGIN;
+DROP FUNCTION IF EXISTS merge_keywords(int, int);
+DROP TABLE IF EXISTS keyword_aliases;
+COMMIT;
@@ -0,0 +1,34 @@
+BEGIN;
+-- create keyword_aliases table resolving keyword synonyms to a canonical keyword
+CREATE TABLE IF NOT EXISTS keyword_aliases (
+  alias VARCHAR PRIMARY KEY,
+  keyword_id int NOT NULL,
+  FOREIGN KEY (keyword_id) REFERENCES keywords(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS keyword_aliases_keyword_id_idx ON keyword_aliases(keyword_id);
+-- merge_keywords merges the keyword src into dst: module associations are
+-- rewritten to dst, src and its aliases become aliases of dst and src is
+-- removed
+CREATE OR REPLACE FUNCTION merge_keywords(src int, dst int) RETURNS void AS $$ BEGIN
+INSERT INTO modules_keywords (module_id, keyword_id)
+SELECT module_id,
+  dst
+FROM modules_keywords
+WHERE keyword_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_keywords
+WHERE keyword_id = src;
+UPDATE keyword_aliases
+SET keyword_id = dst
+WHERE keyword_id = src;
+INSERT INTO keyword_aliases (alias, keyword_id)
+SELECT name,
+  dst
+FROM keywords
+WHERE id = src ON CONFLICT (alias) DO
+UPDATE
+SET keyword_id = dst;
+DELETE FROM keywords
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
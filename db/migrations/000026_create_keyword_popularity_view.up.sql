@@ -0,0 +1,18 @@
+BEGIN;
+-- create a materialized view ranking keywords by the number of attached
+-- modules and the number of versions published under them in the last 30 days;
+-- it is refreshed periodically rather than computed per request
+CREATE MATERIALIZED VIEW IF NOT EXISTS keyword_popularity AS
+SELECT k.id AS keyword_id,
+  k.name,
+  COUNT(DISTINCT mk.module_id) AS module_count,
+  COUNT(mv.id) AS recent_publishes
+FROM keywords k
+  LEFT JOIN modules_keywords mk ON mk.keyword_id = k.id
+  LEFT JOIN module_versions mv ON mv.module_id = mk.module_id
+  AND mv.created_at > NOW() - INTERVAL '30 days'
+GROUP BY k.id,
+  k.name;
+-- a unique index is required to refresh the view concurrently
+CREATE UNIQUE INDEX IF NOT EXISTS keyword_popularity_keyword_id_idx ON keyword_popularity(keyword_id);
+COMMIT;
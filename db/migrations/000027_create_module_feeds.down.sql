@@ -0,0 +1,5 @@
+BEGIN;
+DROP MATERIALIZED VIEW IF EXISTS recent_modules;
+DROP MATERIALIZED VIEW IF EXISTS trending_modules;
+DROP TABLE IF EXISTS module_daily_downloads;
+COMMIT;
@@ -0,0 +1,47 @@
+BEGIN;
+-- create module_daily_downloads table rolling up module downloads per day
+CREATE TABLE IF NOT EXISTS module_daily_downloads (
+  module_id int NOT NULL,
+  day DATE NOT NULL,
+  downloads BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (module_id, day),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- create a materialized view ranking modules by download growth over the last
+-- 7 days compared to the 7 days before
+CREATE MATERIALIZED VIEW IF NOT EXISTS trending_modules AS
+SELECT m.id AS module_id,
+  COALESCE(
+    SUM(d.downloads) FILTER (
+      WHERE d.day > CURRENT_DATE - 7
+    ),
+    0
+  ) AS recent_downloads,
+  COALESCE(
+    SUM(d.downloads) FILTER (
+      WHERE d.day <= CURRENT_DATE - 7
+    ),
+    0
+  ) AS previous_downloads
+FROM modules m
+  JOIN module_daily_downloads d ON d.module_id = m.id
+  AND d.day > CURRENT_DATE - 14
+WHERE m.deleted_at IS NULL
+  AND NOT m.hidden
+GROUP BY m.id;
+CREATE UNIQUE INDEX IF NOT EXISTS trending_modules_module_id_idx ON trending_modules(module_id);
+-- create a materialized view of modules ordered by their latest version publish
+CREATE MATERIALIZED VIEW IF NOT EXISTS recent_modules AS
+SELECT DISTINCT ON (mv.module_id) mv.module_id,
+  mv.version,
+  mv.created_at AS published_at
+FROM module_versions mv
+  JOIN modules m ON m.id = mv.module_id
+WHERE m.deleted_at IS NULL
+  AND NOT m.hidden
+  AND NOT mv.yanked
+ORDER BY mv.module_id,
+  mv.created_at DESC;
+CREATE UNIQUE INDEX IF NOT EXISTS recent_modules_module_id_idx ON recent_modules(module_id);
+CREATE INDEX IF NOT EXISTS recent_modules_published_at_idx ON recent_modules(published_at DESC);
+COMMIT;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS module_recommendations;
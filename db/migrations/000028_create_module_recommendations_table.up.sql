@@ -0,0 +1,11 @@
+BEGIN;
+-- create module_recommendations table caching precomputed related modules
+CREATE TABLE IF NOT EXISTS module_recommendations (
+  module_id int NOT NULL,
+  related_id int NOT NULL,
+  score DOUBLE PRECISION NOT NULL,
+  PRIMARY KEY (module_id, related_id),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (related_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS modules_name_trgm_idx;
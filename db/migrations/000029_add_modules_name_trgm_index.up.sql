@@ -0,0 +1,5 @@
+BEGIN;
+-- enable trigram matching used by module name typeahead suggestions
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS modules_name_trgm_idx ON modules USING GIN (name gin_trgm_ops);
+COMMIT;
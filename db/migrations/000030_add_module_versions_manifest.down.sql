@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE module_versions DROP COLUMN changelog;
+ALTER TABLE module_versions DROP COLUMN manifest;
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+-- store the published manifest and release notes of every version so that
+-- versions can be compared
+ALTER TABLE module_versions
+ADD COLUMN manifest JSONB;
+ALTER TABLE module_versions
+ADD COLUMN changelog TEXT;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE module_versions DROP COLUMN rendered_readme;
+ALTER TABLE module_versions DROP COLUMN readme;
+COMMIT;
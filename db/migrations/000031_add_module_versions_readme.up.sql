@@ -0,0 +1,7 @@
+BEGIN;
+-- add raw Markdown readme and its rendered HTML to module_versions table
+ALTER TABLE module_versions
+ADD COLUMN readme TEXT;
+ALTER TABLE module_versions
+ADD COLUMN rendered_readme TEXT;
+COMMIT;
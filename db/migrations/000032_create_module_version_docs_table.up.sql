@@ -0,0 +1,12 @@
+BEGIN;
+-- create module_version_docs table storing the extracted package
+-- documentation of every published version
+CREATE TABLE IF NOT EXISTS module_version_docs (
+  module_version_id int NOT NULL,
+  import_path VARCHAR NOT NULL,
+  synopsis VARCHAR,
+  doc JSONB NOT NULL,
+  PRIMARY KEY (module_version_id, import_path),
+  FOREIGN KEY (module_version_id) REFERENCES module_versions(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX IF EXISTS modules_license_idx;
+ALTER TABLE module_versions DROP COLUMN license;
+ALTER TABLE modules DROP COLUMN license;
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+-- add SPDX license columns to modules and module_versions tables
+ALTER TABLE modules
+ADD COLUMN license VARCHAR;
+ALTER TABLE module_versions
+ADD COLUMN license VARCHAR;
+-- create index on modules license for search filters
+CREATE INDEX IF NOT EXISTS modules_license_idx ON modules(license);
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX IF EXISTS users_name_idx;
+ALTER TABLE users DROP COLUMN bio;
+ALTER TABLE users DROP COLUMN avatar_url;
+COMMIT;
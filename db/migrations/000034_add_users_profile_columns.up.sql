@@ -0,0 +1,8 @@
+BEGIN;
+ALTER TABLE users
+ADD COLUMN avatar_url VARCHAR;
+ALTER TABLE users
+ADD COLUMN bio TEXT;
+-- names identify public profiles and must be unique
+CREATE UNIQUE INDEX IF NOT EXISTS users_name_idx ON users(name);
+COMMIT;
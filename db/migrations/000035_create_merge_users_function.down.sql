@@ -0,0 +1 @@
+DROP FUNCTION IF EXISTS merge_users(int, int);
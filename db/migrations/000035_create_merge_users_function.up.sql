@@ -0,0 +1,38 @@
+BEGIN;
+-- merge_users merges the user src into the surviving user dst: authored and
+-- contributed modules, public keys, reports and name reservations are
+-- reassigned to dst and src is removed, atomically within the calling
+-- transaction
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TABLE IF EXISTS recovery_codes;
+ALTER TABLE users DROP COLUMN totp_enabled;
+ALTER TABLE users DROP COLUMN totp_secret;
+COMMIT;
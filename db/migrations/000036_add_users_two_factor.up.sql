@@ -0,0 +1,16 @@
+BEGIN;
+-- add TOTP two-factor authentication columns to users table
+ALTER TABLE users
+ADD COLUMN totp_secret VARCHAR;
+ALTER TABLE users
+ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+-- create recovery_codes table storing hashed single-use recovery codes
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id SERIAL PRIMARY KEY,
+  user_id int NOT NULL,
+  hash VARCHAR NOT NULL UNIQUE,
+  used_at TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS recovery_codes_user_id_idx ON recovery_codes(user_id);
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+-- create sessions table tracking authenticated user sessions per device
+CREATE TABLE IF NOT EXISTS sessions (
+  id SERIAL PRIMARY KEY,
+  user_id int NOT NULL,
+  token_hash VARCHAR NOT NULL UNIQUE,
+  user_agent VARCHAR,
+  ip VARCHAR,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  revoked_at TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions(user_id);
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+-- create jobs table backing the background job queue
+CREATE TABLE IF NOT EXISTS jobs (
+  id BIGSERIAL PRIMARY KEY,
+  kind VARCHAR NOT NULL,
+  payload JSONB NOT NULL,
+  status VARCHAR NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  max_attempts INT NOT NULL,
+  last_error TEXT,
+  run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  locked_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- create index used by workers to claim runnable jobs
+CREATE INDEX IF NOT EXISTS jobs_status_run_at_idx ON jobs(status, run_at);
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+-- create schedules table tracking the next run of each recurring job
+CREATE TABLE IF NOT EXISTS schedules (
+  name VARCHAR PRIMARY KEY,
+  spec VARCHAR NOT NULL,
+  kind VARCHAR NOT NULL,
+  next_run_at TIMESTAMP NOT NULL,
+  last_run_at TIMESTAMP
+);
+COMMIT;
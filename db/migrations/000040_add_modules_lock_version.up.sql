@@ -0,0 +1,4 @@
+BEGIN;
+-- add lock_version column for optimistic concurrency control of module updates
+ALTER TABLE modules ADD COLUMN IF NOT EXISTS lock_version BIGINT NOT NULL DEFAULT 0;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX IF EXISTS modules_origin_idx;
+ALTER TABLE modules DROP COLUMN origin;
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+-- add origin column recording the upstream registry of mirrored modules
+ALTER TABLE modules ADD COLUMN IF NOT EXISTS origin VARCHAR;
+-- create index used to list the modules mirrored from an upstream
+CREATE INDEX IF NOT EXISTS modules_origin_idx ON modules(origin) WHERE origin IS NOT NULL;
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+DROP TRIGGER IF EXISTS advisories_insert_change ON advisories;
+DROP TRIGGER IF EXISTS module_versions_yank_change ON module_versions;
+DROP TRIGGER IF EXISTS module_versions_insert_change ON module_versions;
+DROP TRIGGER IF EXISTS modules_update_change ON modules;
+DROP TRIGGER IF EXISTS modules_insert_change ON modules;
+DROP FUNCTION IF EXISTS record_advisory_change();
+DROP FUNCTION IF EXISTS record_version_change();
+DROP FUNCTION IF EXISTS record_module_change();
+DROP TABLE IF EXISTS changes;
+COMMIT;
@@ -0,0 +1,92 @@
+BEGIN;
+-- create changes table holding the ordered log of registry changes served by
+-- the changes feed; ids are the feed cursor, and every trigger below takes a
+-- transaction-scoped advisory lock before recording a change so that ids
+-- become visible in order and consumers never skip a change
+CREATE TABLE IF NOT EXISTS changes (
+  id BIGSERIAL PRIMARY KEY,
+  kind VARCHAR NOT NULL,
+  module_name VARCHAR NOT NULL,
+  version VARCHAR,
+  advisory VARCHAR,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- record_module_change records module creation and changes to the public
+-- metadata of a module
+CREATE OR REPLACE FUNCTION record_module_change() RETURNS trigger AS $$ BEGIN PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name)
+VALUES (
+    CASE
+      WHEN TG_OP = 'INSERT' THEN 'module_created'
+      ELSE 'module_updated'
+    END,
+    NEW.name
+  );
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER modules_insert_change
+AFTER
+INSERT ON modules FOR EACH ROW EXECUTE PROCEDURE record_module_change();
+CREATE TRIGGER modules_update_change
+AFTER
+UPDATE ON modules FOR EACH ROW
+  WHEN (
+    (
+      OLD.name,
+      OLD.description,
+      OLD.version,
+      OLD.homepage,
+      OLD.repo,
+      OLD.license,
+      OLD.hidden,
+      OLD.deleted_at
+    ) IS DISTINCT
+    FROM (
+        NEW.name,
+        NEW.description,
+        NEW.version,
+        NEW.homepage,
+        NEW.repo,
+        NEW.license,
+        NEW.hidden,
+        NEW.deleted_at
+      )
+  ) EXECUTE PROCEDURE record_module_change();
+-- record_version_change records version publishes and (un)yanks
+CREATE OR REPLACE FUNCTION record_version_change() RETURNS trigger AS $$ BEGIN PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name, version)
+SELECT CASE
+    WHEN TG_OP = 'INSERT' THEN 'version_published'
+    WHEN NEW.yanked THEN 'version_yanked'
+    ELSE 'version_unyanked'
+  END,
+  m.name,
+  NEW.version
+FROM modules m
+WHERE m.id = NEW.module_id;
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER module_versions_insert_change
+AFTER
+INSERT ON module_versions FOR EACH ROW EXECUTE PROCEDURE record_version_change();
+CREATE TRIGGER module_versions_yank_change
+AFTER
+UPDATE OF yanked ON module_versions FOR EACH ROW
+  WHEN (OLD.yanked IS DISTINCT FROM NEW.yanked) EXECUTE PROCEDURE record_version_change();
+-- record_advisory_change records published advisories
+CREATE OR REPLACE FUNCTION record_advisory_change() RETURNS trigger AS $$ BEGIN PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name, advisory)
+SELECT 'advisory_published',
+  m.name,
+  NEW.identifier
+FROM modules m
+WHERE m.id = NEW.module_id;
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER advisories_insert_change
+AFTER
+INSERT ON advisories FOR EACH ROW EXECUTE PROCEDURE record_advisory_change();
+COMMIT;
@@ -0,0 +1,107 @@
+BEGIN;
+-- record_module_change records module creation and changes to the public
+-- metadata of a module
+CREATE OR REPLACE FUNCTION record_module_change() RETURNS trigger AS $$ BEGIN PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name)
+VALUES (
+    CASE
+      WHEN TG_OP = 'INSERT' THEN 'module_created'
+      ELSE 'module_updated'
+    END,
+    NEW.name
+  );
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS modules_update_change ON modules;
+CREATE TRIGGER modules_update_change
+AFTER
+UPDATE ON modules FOR EACH ROW
+  WHEN (
+    (
+      OLD.name,
+      OLD.description,
+      OLD.version,
+      OLD.homepage,
+      OLD.repo,
+      OLD.license,
+      OLD.hidden,
+      OLD.deleted_at
+    ) IS DISTINCT
+    FROM (
+        NEW.name,
+        NEW.description,
+        NEW.version,
+        NEW.homepage,
+        NEW.repo,
+        NEW.license,
+        NEW.hidden,
+        NEW.deleted_at
+      )
+  ) EXECUTE PROCEDURE record_module_change();
+-- record_version_change records version publishes and (un)yanks
+CREATE OR REPLACE FUNCTION record_version_change() RETURNS trigger AS $$ BEGIN PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name, version)
+SELECT CASE
+    WHEN TG_OP = 'INSERT' THEN 'version_published'
+    WHEN NEW.yanked THEN 'version_yanked'
+    ELSE 'version_unyanked'
+  END,
+  m.name,
+  NEW.version
+FROM modules m
+WHERE m.id = NEW.module_id;
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- record_advisory_change records published advisories
+CREATE OR REPLACE FUNCTION record_advisory_change() RETURNS trigger AS $$ BEGIN PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name, advisory)
+SELECT 'advisory_published',
+  m.name,
+  NEW.identifier
+FROM modules m
+WHERE m.id = NEW.module_id;
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- merge_users merges the user src into the surviving user dst: authored and
+-- contributed modules, public keys, reports and name reservations are
+-- reassigned to dst and src is removed, atomically within the calling
+-- transaction
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+DROP FUNCTION IF EXISTS module_readable(int, int);
+DROP TABLE IF EXISTS module_grants;
+ALTER TABLE modules DROP COLUMN visibility;
+COMMIT;
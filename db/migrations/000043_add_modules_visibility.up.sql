@@ -0,0 +1,160 @@
+BEGIN;
+-- add visibility column to modules; private modules are only readable by
+-- their author, contributors, grantees and admins
+ALTER TABLE modules
+ADD COLUMN visibility VARCHAR NOT NULL DEFAULT 'public';
+-- create module_grants table granting users read access to private modules
+CREATE TABLE IF NOT EXISTS module_grants (
+  module_id int NOT NULL,
+  user_id int NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (module_id, user_id),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- create index on users FK
+CREATE INDEX IF NOT EXISTS module_grants_user_id_idx ON module_grants(user_id);
+-- module_readable reports whether the user uid (NULL for anonymous requests)
+-- may read the module mid; every query listing modules must filter on it
+CREATE OR REPLACE FUNCTION module_readable(mid int, uid int) RETURNS boolean AS $$
+SELECT m.visibility = 'public'
+  OR (
+    uid IS NOT NULL
+    AND (
+      m.author = uid
+      OR EXISTS (
+        SELECT 1
+        FROM users u
+        WHERE u.id = uid
+          AND u.admin
+          AND NOT u.banned
+      )
+      OR EXISTS (
+        SELECT 1
+        FROM modules_users mu
+        WHERE mu.module_id = mid
+          AND mu.user_id = uid
+      )
+      OR EXISTS (
+        SELECT 1
+        FROM module_grants g
+        WHERE g.module_id = mid
+          AND g.user_id = uid
+      )
+    )
+  )
+FROM modules m
+WHERE m.id = mid;
+$$ LANGUAGE sql STABLE;
+-- exclude private modules from the public changes feed; a module becoming
+-- public is recorded as an update
+CREATE OR REPLACE FUNCTION record_module_change() RETURNS trigger AS $$ BEGIN IF NEW.visibility <> 'public' THEN RETURN NEW;
+END IF;
+PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name)
+VALUES (
+    CASE
+      WHEN TG_OP = 'INSERT' THEN 'module_created'
+      ELSE 'module_updated'
+    END,
+    NEW.name
+  );
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS modules_update_change ON modules;
+CREATE TRIGGER modules_update_change
+AFTER
+UPDATE ON modules FOR EACH ROW
+  WHEN (
+    (
+      OLD.name,
+      OLD.description,
+      OLD.version,
+      OLD.homepage,
+      OLD.repo,
+      OLD.license,
+      OLD.visibility,
+      OLD.hidden,
+      OLD.deleted_at
+    ) IS DISTINCT
+    FROM (
+        NEW.name,
+        NEW.description,
+        NEW.version,
+        NEW.homepage,
+        NEW.repo,
+        NEW.license,
+        NEW.visibility,
+        NEW.hidden,
+        NEW.deleted_at
+      )
+  ) EXECUTE PROCEDURE record_module_change();
+CREATE OR REPLACE FUNCTION record_version_change() RETURNS trigger AS $$ BEGIN PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name, version)
+SELECT CASE
+    WHEN TG_OP = 'INSERT' THEN 'version_published'
+    WHEN NEW.yanked THEN 'version_yanked'
+    ELSE 'version_unyanked'
+  END,
+  m.name,
+  NEW.version
+FROM modules m
+WHERE m.id = NEW.module_id
+  AND m.visibility = 'public';
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE OR REPLACE FUNCTION record_advisory_change() RETURNS trigger AS $$ BEGIN PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name, advisory)
+SELECT 'advisory_published',
+  m.name,
+  NEW.identifier
+FROM modules m
+WHERE m.id = NEW.module_id
+  AND m.visibility = 'public';
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- merge_users additionally reassigns private module grants
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+INSERT INTO module_grants (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_grants
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_grants
+WHERE user_id = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
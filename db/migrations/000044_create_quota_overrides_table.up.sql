@@ -0,0 +1,11 @@
+BEGIN;
+-- create quota_overrides table holding admin overrides of per-user publish
+-- quotas; NULL limits fall back to the configured limits
+CREATE TABLE IF NOT EXISTS quota_overrides (
+  user_id int PRIMARY KEY,
+  modules_per_user int,
+  versions_per_day int,
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE modules DROP COLUMN logo;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+-- add logo column holding the checksum of the module's processed logo image
+ALTER TABLE modules ADD COLUMN IF NOT EXISTS logo VARCHAR;
+COMMIT;
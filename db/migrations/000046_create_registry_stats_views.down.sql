@@ -0,0 +1,4 @@
+BEGIN;
+DROP MATERIALIZED VIEW IF EXISTS registry_weekly_publishes;
+DROP MATERIALIZED VIEW IF EXISTS registry_stats;
+COMMIT;
@@ -0,0 +1,47 @@
+BEGIN;
+-- create a materialized view of registry-wide totals over public, listed
+-- modules, refreshed by the stats rollup job
+CREATE MATERIALIZED VIEW IF NOT EXISTS registry_stats AS
+SELECT 1 AS id,
+  (
+    SELECT COUNT(*)
+    FROM modules m
+    WHERE m.visibility = 'public'
+      AND m.deleted_at IS NULL
+      AND NOT m.hidden
+  ) AS modules,
+  (
+    SELECT COUNT(*)
+    FROM module_versions mv
+      JOIN modules m ON m.id = mv.module_id
+    WHERE m.visibility = 'public'
+      AND m.deleted_at IS NULL
+      AND NOT m.hidden
+  ) AS versions,
+  (
+    SELECT COUNT(DISTINCT m.author)
+    FROM modules m
+    WHERE m.visibility = 'public'
+      AND m.deleted_at IS NULL
+      AND NOT m.hidden
+  ) AS publishers,
+  (
+    SELECT COALESCE(SUM(d.downloads), 0)
+    FROM module_daily_downloads d
+      JOIN modules m ON m.id = d.module_id
+    WHERE m.visibility = 'public'
+  ) AS downloads;
+-- a unique index is required to refresh the view concurrently
+CREATE UNIQUE INDEX IF NOT EXISTS registry_stats_id_idx ON registry_stats(id);
+-- create a materialized view of version publishes per week over the last 12
+-- weeks
+CREATE MATERIALIZED VIEW IF NOT EXISTS registry_weekly_publishes AS
+SELECT DATE_TRUNC('week', mv.created_at)::DATE AS week,
+  COUNT(*) AS publishes
+FROM module_versions mv
+  JOIN modules m ON m.id = mv.module_id
+WHERE m.visibility = 'public'
+  AND mv.created_at > DATE_TRUNC('week', NOW()) - INTERVAL '11 weeks'
+GROUP BY 1;
+CREATE UNIQUE INDEX IF NOT EXISTS registry_weekly_publishes_week_idx ON registry_weekly_publishes(week);
+COMMIT;
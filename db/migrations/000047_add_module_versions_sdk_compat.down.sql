@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE module_versions DROP COLUMN sdk_compat;
+COMMIT;
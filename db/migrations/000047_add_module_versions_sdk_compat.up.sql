@@ -0,0 +1,5 @@
+BEGIN;
+-- add sdk_compat column holding the Cosmos SDK version constraint declared by
+-- a module version's manifest
+ALTER TABLE module_versions ADD COLUMN IF NOT EXISTS sdk_compat VARCHAR;
+COMMIT;
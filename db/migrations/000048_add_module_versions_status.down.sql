@@ -0,0 +1,19 @@
+BEGIN;
+DROP TRIGGER IF EXISTS module_versions_release_change ON module_versions;
+CREATE OR REPLACE FUNCTION record_version_change() RETURNS trigger AS $$ BEGIN PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name, version)
+SELECT CASE
+    WHEN TG_OP = 'INSERT' THEN 'version_published'
+    WHEN NEW.yanked THEN 'version_yanked'
+    ELSE 'version_unyanked'
+  END,
+  m.name,
+  NEW.version
+FROM modules m
+WHERE m.id = NEW.module_id
+  AND m.visibility = 'public';
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+ALTER TABLE module_versions DROP COLUMN status;
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+-- add status column to module_versions; staged versions are visible only to
+-- module owners until released
+ALTER TABLE module_versions
+ADD COLUMN status VARCHAR NOT NULL DEFAULT 'published';
+-- record releases of staged versions as publishes and keep staged versions
+-- out of the changes feed
+CREATE OR REPLACE FUNCTION record_version_change() RETURNS trigger AS $$
+DECLARE change_kind VARCHAR;
+BEGIN IF NEW.status <> 'published' THEN RETURN NEW;
+END IF;
+IF TG_OP = 'INSERT' THEN change_kind := 'version_published';
+ELSIF OLD.status <> 'published' THEN change_kind := 'version_published';
+ELSIF NEW.yanked THEN change_kind := 'version_yanked';
+ELSE change_kind := 'version_unyanked';
+END IF;
+PERFORM pg_advisory_xact_lock(hashtext('changes'));
+INSERT INTO changes (kind, module_name, version)
+SELECT change_kind,
+  m.name,
+  NEW.version
+FROM modules m
+WHERE m.id = NEW.module_id
+  AND m.visibility = 'public';
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER module_versions_release_change
+AFTER
+UPDATE OF status ON module_versions FOR EACH ROW
+  WHEN (OLD.status IS DISTINCT FROM NEW.status) EXECUTE PROCEDURE record_version_change();
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+-- add verified column recording whether a version's tag and manifest were
+-- verified against the module repository at publish time
+ALTER TABLE module_versions
+ADD COLUMN verified BOOLEAN NOT NULL DEFAULT FALSE;
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+-- create module_version_interfaces table recording the protobuf services, Msg
+-- types and IBC ports declared by each module version, for interface search
+CREATE TABLE IF NOT EXISTS module_version_interfaces (
+  module_version_id int NOT NULL,
+  kind VARCHAR NOT NULL,
+  name VARCHAR NOT NULL,
+  short_name VARCHAR NOT NULL,
+  PRIMARY KEY (module_version_id, kind, name),
+  FOREIGN KEY (module_version_id) REFERENCES module_versions(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- create indexes used by the msg_type and has_ibc search filters
+CREATE INDEX IF NOT EXISTS module_version_interfaces_short_name_idx ON module_version_interfaces(kind, short_name);
+CREATE INDEX IF NOT EXISTS module_version_interfaces_name_idx ON module_version_interfaces(kind, name);
+COMMIT;
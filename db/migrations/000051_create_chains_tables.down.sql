@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS chain_modules;
+DROP TABLE IF EXISTS chains;
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+-- create chains table holding live chains ingested from the Cosmos chain
+-- registry
+CREATE TABLE IF NOT EXISTS chains (
+  id serial PRIMARY KEY,
+  name VARCHAR NOT NULL UNIQUE,
+  network_id VARCHAR NOT NULL,
+  pretty_name VARCHAR NOT NULL DEFAULT '',
+  network_type VARCHAR NOT NULL DEFAULT '',
+  repo VARCHAR NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- create chain_modules table recording which modules each chain includes and
+-- at which version
+CREATE TABLE IF NOT EXISTS chain_modules (
+  chain_id int NOT NULL,
+  module_id int NOT NULL,
+  version VARCHAR NOT NULL,
+  source VARCHAR NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (chain_id, module_id),
+  FOREIGN KEY (chain_id) REFERENCES chains(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS chain_modules_module_id_idx ON chain_modules(module_id);
+COMMIT;
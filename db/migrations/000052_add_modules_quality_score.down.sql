@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE modules DROP COLUMN quality_score;
+ALTER TABLE modules DROP COLUMN quality_rules;
+ALTER TABLE modules DROP COLUMN quality_computed_at;
+COMMIT;
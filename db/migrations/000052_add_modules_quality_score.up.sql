@@ -0,0 +1,9 @@
+BEGIN;
+-- add quality score columns to modules; quality_rules holds the per-rule
+-- breakdown of the score
+ALTER TABLE modules ADD COLUMN IF NOT EXISTS quality_score DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE modules ADD COLUMN IF NOT EXISTS quality_rules JSONB;
+ALTER TABLE modules ADD COLUMN IF NOT EXISTS quality_computed_at TIMESTAMP;
+-- create index on modules quality_score for search ranking
+CREATE INDEX IF NOT EXISTS modules_quality_score_idx ON modules(quality_score DESC);
+COMMIT;
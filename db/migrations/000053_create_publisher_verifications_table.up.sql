@@ -0,0 +1,80 @@
+BEGIN;
+-- create publisher_verifications table tracking requests of users to be
+-- verified as the publisher of a GitHub organization or domain
+CREATE TABLE IF NOT EXISTS publisher_verifications (
+  id SERIAL PRIMARY KEY,
+  user_id int NOT NULL,
+  kind VARCHAR NOT NULL,
+  subject VARCHAR NOT NULL,
+  token VARCHAR NOT NULL,
+  status VARCHAR NOT NULL,
+  reviewer_id int,
+  proven_at TIMESTAMP,
+  reviewed_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (reviewer_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS publisher_verifications_user_id_idx ON publisher_verifications(user_id);
+CREATE INDEX IF NOT EXISTS publisher_verifications_status_idx ON publisher_verifications(status);
+-- a subject may only be approved for a single user
+CREATE UNIQUE INDEX IF NOT EXISTS publisher_verifications_approved_idx ON publisher_verifications(kind, subject)
+WHERE status = 'approved';
+-- verified_publisher returns true if the user holds an approved publisher
+-- verification, used to flag modules with the verified publisher badge
+CREATE OR REPLACE FUNCTION verified_publisher(uid int) RETURNS boolean AS $$
+SELECT EXISTS (
+    SELECT 1
+    FROM publisher_verifications pv
+    WHERE pv.user_id = uid
+      AND pv.status = 'approved'
+  );
+$$ LANGUAGE sql STABLE;
+-- merge_users additionally reassigns publisher verifications
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+INSERT INTO module_grants (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_grants
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_grants
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET reviewer_id = dst
+WHERE reviewer_id = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
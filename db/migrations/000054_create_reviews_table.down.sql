@@ -0,0 +1,54 @@
+BEGIN;
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+INSERT INTO module_grants (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_grants
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_grants
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET reviewer_id = dst
+WHERE reviewer_id = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS reviews_update_module_rating ON reviews;
+DROP FUNCTION IF EXISTS update_module_rating();
+ALTER TABLE modules DROP COLUMN rating_average;
+ALTER TABLE modules DROP COLUMN rating_count;
+ALTER TABLE reports DROP COLUMN review_id;
+DROP TABLE IF EXISTS reviews;
+COMMIT;
@@ -0,0 +1,120 @@
+BEGIN;
+-- create reviews table holding user ratings and reviews of modules; a user may
+-- leave a single review per module
+CREATE TABLE IF NOT EXISTS reviews (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  user_id int NOT NULL,
+  rating int NOT NULL CHECK (
+    rating BETWEEN 1 AND 5
+  ),
+  body TEXT NOT NULL DEFAULT '',
+  response TEXT,
+  responder_id int,
+  responded_at TIMESTAMP,
+  hidden BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (module_id, user_id),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (responder_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS reviews_user_id_idx ON reviews(user_id);
+-- allow abuse reports to target a review of the reported module
+ALTER TABLE reports
+ADD COLUMN review_id INT REFERENCES reviews(id) ON UPDATE CASCADE ON DELETE CASCADE;
+-- add aggregate rating columns to modules, maintained over visible reviews
+ALTER TABLE modules
+ADD COLUMN rating_average DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE modules
+ADD COLUMN rating_count INT NOT NULL DEFAULT 0;
+-- update_module_rating recomputes the aggregate rating of the reviewed module
+CREATE OR REPLACE FUNCTION update_module_rating() RETURNS trigger AS $$
+DECLARE mid int;
+BEGIN IF TG_OP = 'DELETE' THEN mid := OLD.module_id;
+ELSE mid := NEW.module_id;
+END IF;
+UPDATE modules
+SET rating_average = COALESCE(r.average, 0),
+  rating_count = r.count
+FROM (
+    SELECT AVG(rating) AS average,
+      COUNT(*) AS count
+    FROM reviews
+    WHERE module_id = mid
+      AND NOT hidden
+  ) r
+WHERE modules.id = mid;
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER reviews_update_module_rating
+AFTER
+INSERT
+  OR
+UPDATE OF rating,
+  hidden
+  OR DELETE ON reviews FOR EACH ROW EXECUTE PROCEDURE update_module_rating();
+-- merge_users additionally reassigns reviews, dropping reviews of the merged
+-- user on modules the target user already reviewed
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+INSERT INTO module_grants (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_grants
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_grants
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET reviewer_id = dst
+WHERE reviewer_id = src;
+DELETE FROM reviews
+WHERE user_id = src
+  AND module_id IN (
+    SELECT module_id
+    FROM reviews
+    WHERE user_id = dst
+  );
+UPDATE reviews
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reviews
+SET responder_id = dst
+WHERE responder_id = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
@@ -0,0 +1,219 @@
+BEGIN;
+-- create questions table holding Q&A style support discussions per module
+CREATE TABLE IF NOT EXISTS questions (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  user_id int NOT NULL,
+  title VARCHAR NOT NULL,
+  body TEXT NOT NULL,
+  accepted_answer_id int,
+  hidden BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS questions_module_id_idx ON questions(module_id);
+-- create answers table holding answers to questions
+CREATE TABLE IF NOT EXISTS answers (
+  id SERIAL PRIMARY KEY,
+  question_id int NOT NULL,
+  user_id int NOT NULL,
+  body TEXT NOT NULL,
+  maintainer BOOLEAN NOT NULL DEFAULT FALSE,
+  hidden BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (question_id) REFERENCES questions(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS answers_question_id_idx ON answers(question_id);
+-- deferred so that questions and their accepted answers may be imported in
+-- either order within a transaction
+ALTER TABLE questions
+ADD CONSTRAINT questions_accepted_answer_id_fkey FOREIGN KEY (accepted_answer_id) REFERENCES answers(id) ON UPDATE CASCADE ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED;
+-- create module_subscriptions table of users subscribed to the discussions of
+-- a module
+CREATE TABLE IF NOT EXISTS module_subscriptions (
+  module_id int NOT NULL,
+  user_id int NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (module_id, user_id),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS module_subscriptions_user_id_idx ON module_subscriptions(user_id);
+-- create notifications table holding discussion notifications of users
+CREATE TABLE IF NOT EXISTS notifications (
+  id BIGSERIAL PRIMARY KEY,
+  user_id int NOT NULL,
+  kind VARCHAR NOT NULL,
+  module_id int NOT NULL,
+  question_id int NOT NULL,
+  answer_id int,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  read_at TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (question_id) REFERENCES questions(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (answer_id) REFERENCES answers(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS notifications_user_id_idx ON notifications(user_id, created_at DESC);
+-- module_watchers returns the owners and subscribers of a module
+CREATE OR REPLACE FUNCTION module_watchers(mid int) RETURNS TABLE (user_id int) AS $$
+SELECT m.author
+FROM modules m
+WHERE m.id = mid
+  AND m.author IS NOT NULL
+UNION
+SELECT mu.user_id
+FROM modules_users mu
+WHERE mu.module_id = mid
+UNION
+SELECT s.user_id
+FROM module_subscriptions s
+WHERE s.module_id = mid;
+$$ LANGUAGE sql STABLE;
+-- notify_question fans out a notification of a new question to the watchers
+-- of its module
+CREATE OR REPLACE FUNCTION notify_question() RETURNS trigger AS $$ BEGIN
+INSERT INTO notifications (user_id, kind, module_id, question_id)
+SELECT w.user_id,
+  'question',
+  NEW.module_id,
+  NEW.id
+FROM module_watchers(NEW.module_id) w
+WHERE w.user_id <> NEW.user_id;
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER questions_notify
+AFTER
+INSERT ON questions FOR EACH ROW EXECUTE PROCEDURE notify_question();
+-- notify_answer fans out a notification of a new answer to the asker of the
+-- question and the watchers of its module
+CREATE OR REPLACE FUNCTION notify_answer() RETURNS trigger AS $$
+DECLARE q questions %ROWTYPE;
+BEGIN
+SELECT * INTO q
+FROM questions
+WHERE id = NEW.question_id;
+INSERT INTO notifications (user_id, kind, module_id, question_id, answer_id)
+SELECT r.user_id,
+  'answer',
+  q.module_id,
+  q.id,
+  NEW.id
+FROM (
+    SELECT q.user_id
+    UNION
+    SELECT w.user_id
+    FROM module_watchers(q.module_id) w
+  ) r
+WHERE r.user_id <> NEW.user_id;
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER answers_notify
+AFTER
+INSERT ON answers FOR EACH ROW EXECUTE PROCEDURE notify_answer();
+-- notify_accepted notifies the author of an answer that it was accepted
+CREATE OR REPLACE FUNCTION notify_accepted() RETURNS trigger AS $$ BEGIN
+INSERT INTO notifications (user_id, kind, module_id, question_id, answer_id)
+SELECT a.user_id,
+  'accepted',
+  NEW.module_id,
+  NEW.id,
+  a.id
+FROM answers a
+WHERE a.id = NEW.accepted_answer_id
+  AND a.user_id <> NEW.user_id;
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER questions_notify_accepted
+AFTER
+UPDATE OF accepted_answer_id ON questions FOR EACH ROW
+  WHEN (
+    NEW.accepted_answer_id IS NOT NULL
+    AND OLD.accepted_answer_id IS DISTINCT
+    FROM NEW.accepted_answer_id
+  ) EXECUTE PROCEDURE notify_accepted();
+-- merge_users additionally reassigns discussions, subscriptions and
+-- notifications
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+INSERT INTO module_grants (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_grants
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_grants
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET reviewer_id = dst
+WHERE reviewer_id = src;
+DELETE FROM reviews
+WHERE user_id = src
+  AND module_id IN (
+    SELECT module_id
+    FROM reviews
+    WHERE user_id = dst
+  );
+UPDATE reviews
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reviews
+SET responder_id = dst
+WHERE responder_id = src;
+UPDATE questions
+SET user_id = dst
+WHERE user_id = src;
+UPDATE answers
+SET user_id = dst
+WHERE user_id = src;
+INSERT INTO module_subscriptions (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_subscriptions
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_subscriptions
+WHERE user_id = src;
+UPDATE notifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
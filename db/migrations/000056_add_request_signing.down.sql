@@ -0,0 +1,6 @@
+BEGIN;
+DROP TABLE IF EXISTS request_signatures;
+DROP TRIGGER IF EXISTS users_set_api_key_id ON users;
+DROP FUNCTION IF EXISTS set_api_key_id();
+ALTER TABLE users DROP COLUMN api_key_id;
+COMMIT;
@@ -0,0 +1,34 @@
+BEGIN;
+-- add api_key_id column to users identifying the API token that signed a
+-- request; it is the first 16 hex characters of the SHA-256 of the token, or
+-- NULL for users without a token
+ALTER TABLE users
+ADD COLUMN api_key_id VARCHAR;
+UPDATE users
+SET api_key_id = LEFT(ENCODE(SHA256(CONVERT_TO(api_token, 'UTF8')), 'hex'), 16)
+WHERE api_token <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS users_api_key_id_idx ON users(api_key_id)
+WHERE api_token <> '';
+-- set_api_key_id keeps api_key_id in sync with api_token
+CREATE OR REPLACE FUNCTION set_api_key_id() RETURNS trigger AS $$ BEGIN NEW.api_key_id := CASE
+    WHEN NEW.api_token = '' THEN NULL
+    ELSE LEFT(
+      ENCODE(SHA256(CONVERT_TO(NEW.api_token, 'UTF8')), 'hex'),
+      16
+    )
+  END;
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER users_set_api_key_id BEFORE
+INSERT
+  OR
+UPDATE OF api_token ON users FOR EACH ROW EXECUTE PROCEDURE set_api_key_id();
+-- create request_signatures table recording accepted request signatures until
+-- they expire, rejecting replays
+CREATE TABLE IF NOT EXISTS request_signatures (
+  signature VARCHAR PRIMARY KEY,
+  expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS request_signatures_expires_at_idx ON request_signatures(expires_at);
+COMMIT;
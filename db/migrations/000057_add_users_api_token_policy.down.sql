@@ -0,0 +1,14 @@
+BEGIN;
+CREATE OR REPLACE FUNCTION set_api_key_id() RETURNS trigger AS $$ BEGIN NEW.api_key_id := CASE
+    WHEN NEW.api_token = '' THEN NULL
+    ELSE LEFT(
+      ENCODE(SHA256(CONVERT_TO(NEW.api_token, 'UTF8')), 'hex'),
+      16
+    )
+  END;
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+ALTER TABLE users DROP COLUMN api_token_cidrs;
+ALTER TABLE users DROP COLUMN api_token_created_at;
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+-- add API token IP allowlist and creation time columns to users, enforced by
+-- the registry token policy
+ALTER TABLE users
+ADD COLUMN api_token_cidrs TEXT [] NOT NULL DEFAULT '{}';
+ALTER TABLE users
+ADD COLUMN api_token_created_at TIMESTAMP NOT NULL DEFAULT NOW();
+-- set_api_key_id additionally resets api_token_created_at whenever the token
+-- is rotated
+CREATE OR REPLACE FUNCTION set_api_key_id() RETURNS trigger AS $$ BEGIN NEW.api_key_id := CASE
+    WHEN NEW.api_token = '' THEN NULL
+    ELSE LEFT(
+      ENCODE(SHA256(CONVERT_TO(NEW.api_token, 'UTF8')), 'hex'),
+      16
+    )
+  END;
+NEW.api_token_created_at := NOW();
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
@@ -0,0 +1,94 @@
+BEGIN;
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+INSERT INTO module_grants (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_grants
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_grants
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET reviewer_id = dst
+WHERE reviewer_id = src;
+DELETE FROM reviews
+WHERE user_id = src
+  AND module_id IN (
+    SELECT module_id
+    FROM reviews
+    WHERE user_id = dst
+  );
+UPDATE reviews
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reviews
+SET responder_id = dst
+WHERE responder_id = src;
+UPDATE questions
+SET user_id = dst
+WHERE user_id = src;
+UPDATE answers
+SET user_id = dst
+WHERE user_id = src;
+INSERT INTO module_subscriptions (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_subscriptions
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_subscriptions
+WHERE user_id = src;
+UPDATE notifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+DELETE FROM notifications
+WHERE question_id IS NULL;
+ALTER TABLE notifications DROP COLUMN anomaly_id;
+ALTER TABLE notifications
+ALTER COLUMN question_id
+SET NOT NULL;
+DROP TABLE IF EXISTS anomalies;
+DELETE FROM reports
+WHERE reporter_id IS NULL;
+ALTER TABLE reports
+ALTER COLUMN reporter_id
+SET NOT NULL;
+DROP TRIGGER IF EXISTS modules_record_repo_change ON modules;
+DROP FUNCTION IF EXISTS record_repo_change();
+DROP TABLE IF EXISTS module_repo_history;
+ALTER TABLE module_versions DROP COLUMN published_by;
+COMMIT;
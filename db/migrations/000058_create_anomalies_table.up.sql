@@ -0,0 +1,131 @@
+BEGIN;
+-- add published_by column to module_versions recording the publishing user
+ALTER TABLE module_versions
+ADD COLUMN published_by INT REFERENCES users(id) ON UPDATE CASCADE ON DELETE SET NULL;
+-- create module_repo_history table recording changes of module repositories
+CREATE TABLE IF NOT EXISTS module_repo_history (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  old_repo VARCHAR,
+  new_repo VARCHAR,
+  changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS module_repo_history_changed_at_idx ON module_repo_history(changed_at);
+-- record_repo_change records the previous and new repository of a module
+CREATE OR REPLACE FUNCTION record_repo_change() RETURNS trigger AS $$ BEGIN
+INSERT INTO module_repo_history (module_id, old_repo, new_repo)
+VALUES (NEW.id, OLD.repo, NEW.repo);
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER modules_record_repo_change
+AFTER
+UPDATE OF repo ON modules FOR EACH ROW
+  WHEN (
+    OLD.repo IS DISTINCT
+    FROM NEW.repo
+  ) EXECUTE PROCEDURE record_repo_change();
+-- reports filed by the registry itself have no reporter
+ALTER TABLE reports
+ALTER COLUMN reporter_id DROP NOT NULL;
+-- create anomalies table recording anomalous publish activity flagged by the
+-- analyzer; the fingerprint prevents flagging the same activity twice
+CREATE TABLE IF NOT EXISTS anomalies (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  kind VARCHAR NOT NULL,
+  fingerprint VARCHAR NOT NULL,
+  details TEXT NOT NULL,
+  report_id int,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (module_id, kind, fingerprint),
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE,
+  FOREIGN KEY (report_id) REFERENCES reports(id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+-- notify owners of anomalies on their modules
+ALTER TABLE notifications
+ALTER COLUMN question_id DROP NOT NULL;
+ALTER TABLE notifications
+ADD COLUMN anomaly_id INT REFERENCES anomalies(id) ON UPDATE CASCADE ON DELETE CASCADE;
+-- merge_users additionally reassigns published versions
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+INSERT INTO module_grants (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_grants
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_grants
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET reviewer_id = dst
+WHERE reviewer_id = src;
+DELETE FROM reviews
+WHERE user_id = src
+  AND module_id IN (
+    SELECT module_id
+    FROM reviews
+    WHERE user_id = dst
+  );
+UPDATE reviews
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reviews
+SET responder_id = dst
+WHERE responder_id = src;
+UPDATE questions
+SET user_id = dst
+WHERE user_id = src;
+UPDATE answers
+SET user_id = dst
+WHERE user_id = src;
+INSERT INTO module_subscriptions (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_subscriptions
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_subscriptions
+WHERE user_id = src;
+UPDATE notifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE module_versions
+SET published_by = dst
+WHERE published_by = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+UPDATE module_versions
+SET status = quarantined_from
+WHERE status = 'quarantined';
+ALTER TABLE module_versions DROP COLUMN quarantined_from;
+ALTER TABLE module_versions DROP COLUMN quarantine_reason;
+COMMIT;
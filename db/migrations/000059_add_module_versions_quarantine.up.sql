@@ -0,0 +1,11 @@
+BEGIN;
+-- add quarantine columns to module_versions; versions with blocking artifact
+-- scan findings have the quarantined status until cleared, after which the
+-- status they were quarantined from is restored
+ALTER TABLE module_versions
+ADD COLUMN quarantined_from VARCHAR;
+ALTER TABLE module_versions
+ADD COLUMN quarantine_reason TEXT;
+CREATE INDEX IF NOT EXISTS module_versions_quarantined_idx ON module_versions(status)
+WHERE status = 'quarantined';
+COMMIT;
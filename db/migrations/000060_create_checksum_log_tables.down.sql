@@ -0,0 +1,6 @@
+BEGIN;
+DROP TABLE IF EXISTS checksum_tree_heads;
+DROP TABLE IF EXISTS checksum_hashes;
+DROP TABLE IF EXISTS checksum_records;
+DROP FUNCTION IF EXISTS forbid_checksum_log_change;
+COMMIT;
@@ -0,0 +1,41 @@
+BEGIN;
+-- create checksum_records table holding the records of the checksum
+-- transparency log; ids are the 0-based record numbers of the log
+CREATE TABLE IF NOT EXISTS checksum_records (
+  id bigint PRIMARY KEY,
+  module VARCHAR NOT NULL,
+  version VARCHAR NOT NULL,
+  checksum VARCHAR NOT NULL,
+  data BYTEA NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (module, version)
+);
+-- create checksum_hashes table holding the stored hashes of the log's Merkle
+-- tree, indexed by their tlog stored hash index
+CREATE TABLE IF NOT EXISTS checksum_hashes (
+  idx bigint PRIMARY KEY,
+  hash BYTEA NOT NULL
+);
+-- create checksum_tree_heads table holding the signed tree head of every log
+-- size
+CREATE TABLE IF NOT EXISTS checksum_tree_heads (
+  size bigint PRIMARY KEY,
+  hash BYTEA NOT NULL,
+  signed BYTEA NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- forbid_checksum_log_change keeps the checksum log append-only
+CREATE OR REPLACE FUNCTION forbid_checksum_log_change() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'checksum log table % is append-only',
+  TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER checksum_records_append_only BEFORE
+UPDATE
+  OR DELETE ON checksum_records FOR EACH ROW EXECUTE PROCEDURE forbid_checksum_log_change();
+CREATE TRIGGER checksum_hashes_append_only BEFORE
+UPDATE
+  OR DELETE ON checksum_hashes FOR EACH ROW EXECUTE PROCEDURE forbid_checksum_log_change();
+CREATE TRIGGER checksum_tree_heads_append_only BEFORE
+UPDATE
+  OR DELETE ON checksum_tree_heads FOR EACH ROW EXECUTE PROCEDURE forbid_checksum_log_change();
+COMMIT;
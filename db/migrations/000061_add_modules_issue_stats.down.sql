@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE modules DROP COLUMN issues_checked_at;
+ALTER TABLE modules DROP COLUMN issues_active_at;
+ALTER TABLE modules DROP COLUMN open_issues;
+COMMIT;
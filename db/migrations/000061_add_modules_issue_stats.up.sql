@@ -0,0 +1,10 @@
+BEGIN;
+-- add columns recording the open issues and last issue activity of the
+-- module's repository, as last polled from its Git provider
+ALTER TABLE modules
+ADD COLUMN open_issues INT;
+ALTER TABLE modules
+ADD COLUMN issues_active_at TIMESTAMP;
+ALTER TABLE modules
+ADD COLUMN issues_checked_at TIMESTAMP;
+COMMIT;
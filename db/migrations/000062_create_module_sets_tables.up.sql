@@ -0,0 +1,138 @@
+BEGIN;
+-- create module_sets table of named collections of pinned module versions,
+-- shared by their random public ID
+CREATE TABLE IF NOT EXISTS module_sets (
+  id SERIAL PRIMARY KEY,
+  public_id VARCHAR NOT NULL UNIQUE,
+  owner_id int NOT NULL,
+  name VARCHAR NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  visibility VARCHAR NOT NULL DEFAULT 'public',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (owner_id, name),
+  FOREIGN KEY (owner_id) REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- create module_set_versions table of the versions pinned by each set, at
+-- most one per module
+CREATE TABLE IF NOT EXISTS module_set_versions (
+  set_id int NOT NULL,
+  module_id int NOT NULL,
+  module_version_id int NOT NULL,
+  position int NOT NULL,
+  PRIMARY KEY (set_id, module_id),
+  FOREIGN KEY (set_id) REFERENCES module_sets(id) ON DELETE CASCADE,
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON DELETE CASCADE,
+  FOREIGN KEY (module_version_id) REFERENCES module_versions(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS module_set_versions_module_version_id_idx ON module_set_versions(module_version_id);
+-- merge_users additionally reassigns module sets, renaming those whose name
+-- the surviving user already uses, along with sessions, recovery codes and
+-- quota overrides, which would otherwise be deleted with the merged user
+CREATE OR REPLACE FUNCTION merge_users(src int, dst int) RETURNS void AS $$ BEGIN IF src = dst THEN RAISE EXCEPTION 'cannot merge user % into itself',
+  src;
+END IF;
+UPDATE modules
+SET author = dst
+WHERE author = src;
+INSERT INTO modules_users (module_id, user_id)
+SELECT module_id,
+  dst
+FROM modules_users
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM modules_users
+WHERE user_id = src;
+INSERT INTO module_grants (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_grants
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_grants
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE publisher_verifications
+SET reviewer_id = dst
+WHERE reviewer_id = src;
+DELETE FROM reviews
+WHERE user_id = src
+  AND module_id IN (
+    SELECT module_id
+    FROM reviews
+    WHERE user_id = dst
+  );
+UPDATE reviews
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reviews
+SET responder_id = dst
+WHERE responder_id = src;
+UPDATE questions
+SET user_id = dst
+WHERE user_id = src;
+UPDATE answers
+SET user_id = dst
+WHERE user_id = src;
+INSERT INTO module_subscriptions (module_id, user_id, created_at)
+SELECT module_id,
+  dst,
+  created_at
+FROM module_subscriptions
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM module_subscriptions
+WHERE user_id = src;
+UPDATE notifications
+SET user_id = dst
+WHERE user_id = src;
+UPDATE module_versions
+SET published_by = dst
+WHERE published_by = src;
+UPDATE public_keys
+SET user_id = dst
+WHERE user_id = src;
+UPDATE reports
+SET reporter_id = dst
+WHERE reporter_id = src;
+UPDATE report_comments
+SET user_id = dst
+WHERE user_id = src;
+UPDATE report_events
+SET actor_id = dst
+WHERE actor_id = src;
+UPDATE released_names
+SET previous_owner = dst
+WHERE previous_owner = src;
+UPDATE module_sets s
+SET owner_id = dst,
+  name = CASE
+    WHEN EXISTS (
+      SELECT 1
+      FROM module_sets d
+      WHERE d.owner_id = dst
+        AND d.name = s.name
+    ) THEN s.name || ' (' || s.public_id || ')'
+    ELSE s.name
+  END
+WHERE owner_id = src;
+UPDATE sessions
+SET user_id = dst
+WHERE user_id = src;
+UPDATE recovery_codes
+SET user_id = dst
+WHERE user_id = src;
+INSERT INTO quota_overrides (user_id, modules_per_user, versions_per_day, updated_at)
+SELECT dst,
+  modules_per_user,
+  versions_per_day,
+  updated_at
+FROM quota_overrides
+WHERE user_id = src ON CONFLICT DO NOTHING;
+DELETE FROM quota_overrides
+WHERE user_id = src;
+DELETE FROM users
+WHERE id = src;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
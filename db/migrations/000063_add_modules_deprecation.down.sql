@@ -0,0 +1,33 @@
+BEGIN;
+DROP TRIGGER IF EXISTS modules_update_change ON modules;
+CREATE TRIGGER modules_update_change
+AFTER
+UPDATE ON modules FOR EACH ROW
+  WHEN (
+    (
+      OLD.name,
+      OLD.description,
+      OLD.version,
+      OLD.homepage,
+      OLD.repo,
+      OLD.license,
+      OLD.visibility,
+      OLD.hidden,
+      OLD.deleted_at
+    ) IS DISTINCT
+    FROM (
+        NEW.name,
+        NEW.description,
+        NEW.version,
+        NEW.homepage,
+        NEW.repo,
+        NEW.license,
+        NEW.visibility,
+        NEW.hidden,
+        NEW.deleted_at
+      )
+  ) EXECUTE PROCEDURE record_module_change();
+ALTER TABLE modules DROP COLUMN replaced_by;
+ALTER TABLE modules DROP COLUMN deprecation_message;
+ALTER TABLE modules DROP COLUMN deprecated_at;
+COMMIT;
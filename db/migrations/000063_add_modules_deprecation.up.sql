@@ -0,0 +1,44 @@
+BEGIN;
+-- add columns recording the deprecation of a module by its owners and its
+-- optional replacement, which is unset if the replacement is purged; the
+-- reference is deferred so that imports may restore modules in any order
+ALTER TABLE modules
+ADD COLUMN deprecated_at TIMESTAMP;
+ALTER TABLE modules
+ADD COLUMN deprecation_message TEXT;
+ALTER TABLE modules
+ADD COLUMN replaced_by INT REFERENCES modules(id) ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED;
+-- record deprecations in the changes feed
+DROP TRIGGER IF EXISTS modules_update_change ON modules;
+CREATE TRIGGER modules_update_change
+AFTER
+UPDATE ON modules FOR EACH ROW
+  WHEN (
+    (
+      OLD.name,
+      OLD.description,
+      OLD.version,
+      OLD.homepage,
+      OLD.repo,
+      OLD.license,
+      OLD.visibility,
+      OLD.hidden,
+      OLD.deleted_at,
+      OLD.deprecated_at,
+      OLD.replaced_by
+    ) IS DISTINCT
+    FROM (
+        NEW.name,
+        NEW.description,
+        NEW.version,
+        NEW.homepage,
+        NEW.repo,
+        NEW.license,
+        NEW.visibility,
+        NEW.hidden,
+        NEW.deleted_at,
+        NEW.deprecated_at,
+        NEW.replaced_by
+      )
+  ) EXECUTE PROCEDURE record_module_change();
+COMMIT;
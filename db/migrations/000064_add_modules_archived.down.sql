@@ -0,0 +1,57 @@
+BEGIN;
+DROP TRIGGER IF EXISTS modules_update_change ON modules;
+CREATE TRIGGER modules_update_change
+AFTER
+UPDATE ON modules FOR EACH ROW
+  WHEN (
+    (
+      OLD.name,
+      OLD.description,
+      OLD.version,
+      OLD.homepage,
+      OLD.repo,
+      OLD.license,
+      OLD.visibility,
+      OLD.hidden,
+      OLD.deleted_at,
+      OLD.deprecated_at,
+      OLD.replaced_by
+    ) IS DISTINCT
+    FROM (
+        NEW.name,
+        NEW.description,
+        NEW.version,
+        NEW.homepage,
+        NEW.repo,
+        NEW.license,
+        NEW.visibility,
+        NEW.hidden,
+        NEW.deleted_at,
+        NEW.deprecated_at,
+        NEW.replaced_by
+      )
+  ) EXECUTE PROCEDURE record_module_change();
+DROP MATERIALIZED VIEW IF EXISTS trending_modules;
+CREATE MATERIALIZED VIEW IF NOT EXISTS trending_modules AS
+SELECT m.id AS module_id,
+  COALESCE(
+    SUM(d.downloads) FILTER (
+      WHERE d.day > CURRENT_DATE - 7
+    ),
+    0
+  ) AS recent_downloads,
+  COALESCE(
+    SUM(d.downloads) FILTER (
+      WHERE d.day <= CURRENT_DATE - 7
+    ),
+    0
+  ) AS previous_downloads
+FROM modules m
+  JOIN module_daily_downloads d ON d.module_id = m.id
+  AND d.day > CURRENT_DATE - 14
+WHERE m.deleted_at IS NULL
+  AND NOT m.hidden
+GROUP BY m.id;
+CREATE UNIQUE INDEX IF NOT EXISTS trending_modules_module_id_idx ON trending_modules(module_id);
+ALTER TABLE modules DROP COLUMN archived;
+COMMIT;
@@ -0,0 +1,65 @@
+BEGIN;
+-- add column recording whether the module's owners archived it, making it
+-- read-only while its versions remain resolvable
+ALTER TABLE modules
+ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
+-- exclude archived modules from trending modules
+DROP MATERIALIZED VIEW IF EXISTS trending_modules;
+CREATE MATERIALIZED VIEW IF NOT EXISTS trending_modules AS
+SELECT m.id AS module_id,
+  COALESCE(
+    SUM(d.downloads) FILTER (
+      WHERE d.day > CURRENT_DATE - 7
+    ),
+    0
+  ) AS recent_downloads,
+  COALESCE(
+    SUM(d.downloads) FILTER (
+      WHERE d.day <= CURRENT_DATE - 7
+    ),
+    0
+  ) AS previous_downloads
+FROM modules m
+  JOIN module_daily_downloads d ON d.module_id = m.id
+  AND d.day > CURRENT_DATE - 14
+WHERE m.deleted_at IS NULL
+  AND NOT m.hidden
+  AND NOT m.archived
+GROUP BY m.id;
+CREATE UNIQUE INDEX IF NOT EXISTS trending_modules_module_id_idx ON trending_modules(module_id);
+-- record archivals in the changes feed
+DROP TRIGGER IF EXISTS modules_update_change ON modules;
+CREATE TRIGGER modules_update_change
+AFTER
+UPDATE ON modules FOR EACH ROW
+  WHEN (
+    (
+      OLD.name,
+      OLD.description,
+      OLD.version,
+      OLD.homepage,
+      OLD.repo,
+      OLD.license,
+      OLD.visibility,
+      OLD.hidden,
+      OLD.deleted_at,
+      OLD.deprecated_at,
+      OLD.replaced_by,
+      OLD.archived
+    ) IS DISTINCT
+    FROM (
+        NEW.name,
+        NEW.description,
+        NEW.version,
+        NEW.homepage,
+        NEW.repo,
+        NEW.license,
+        NEW.visibility,
+        NEW.hidden,
+        NEW.deleted_at,
+        NEW.deprecated_at,
+        NEW.replaced_by,
+        NEW.archived
+      )
+  ) EXECUTE PROCEDURE record_module_change();
+COMMIT;
@@ -0,0 +1,45 @@
+BEGIN;
+CREATE OR REPLACE FUNCTION module_readable(mid int, uid int) RETURNS boolean AS $$
+SELECT m.visibility = 'public'
+  OR (
+    uid IS NOT NULL
+    AND (
+      m.author = uid
+      OR EXISTS (
+        SELECT 1
+        FROM users u
+        WHERE u.id = uid
+          AND u.admin
+          AND NOT u.banned
+      )
+      OR EXISTS (
+        SELECT 1
+        FROM modules_users mu
+        WHERE mu.module_id = mid
+          AND mu.user_id = uid
+      )
+      OR EXISTS (
+        SELECT 1
+        FROM module_grants g
+        WHERE g.module_id = mid
+          AND g.user_id = uid
+      )
+    )
+  )
+FROM modules m
+WHERE m.id = mid;
+$$ LANGUAGE sql STABLE;
+DROP MATERIALIZED VIEW IF EXISTS keyword_popularity;
+CREATE MATERIALIZED VIEW keyword_popularity AS
+SELECT k.id AS keyword_id,
+  k.name,
+  COUNT(DISTINCT mk.module_id) AS module_count,
+  COUNT(mv.id) AS recent_publishes
+FROM keywords k
+  LEFT JOIN modules_keywords mk ON mk.keyword_id = k.id
+  LEFT JOIN module_versions mv ON mv.module_id = mk.module_id
+  AND mv.created_at > NOW() - INTERVAL '30 days'
+GROUP BY k.id,
+  k.name;
+CREATE UNIQUE INDEX IF NOT EXISTS keyword_popularity_keyword_id_idx ON keyword_popularity(keyword_id);
+COMMIT;
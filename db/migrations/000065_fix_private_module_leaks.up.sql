@@ -0,0 +1,53 @@
+BEGIN;
+-- module_readable no longer grants banned users access to private modules
+-- they author, contribute to or were granted, matching Module.ReadableBy
+CREATE OR REPLACE FUNCTION module_readable(mid int, uid int) RETURNS boolean AS $$
+SELECT m.visibility = 'public'
+  OR (
+    uid IS NOT NULL
+    AND EXISTS (
+      SELECT 1
+      FROM users u
+      WHERE u.id = uid
+        AND NOT u.banned
+        AND (
+          u.admin
+          OR m.author = uid
+          OR EXISTS (
+            SELECT 1
+            FROM modules_users mu
+            WHERE mu.module_id = mid
+              AND mu.user_id = uid
+          )
+          OR EXISTS (
+            SELECT 1
+            FROM module_grants g
+            WHERE g.module_id = mid
+              AND g.user_id = uid
+          )
+        )
+    )
+  )
+FROM modules m
+WHERE m.id = mid;
+$$ LANGUAGE sql STABLE;
+-- recreate keyword_popularity over public, listed modules only, so that the
+-- keywords of private modules are not disclosed by registry statistics
+DROP MATERIALIZED VIEW IF EXISTS keyword_popularity;
+CREATE MATERIALIZED VIEW keyword_popularity AS
+SELECT k.id AS keyword_id,
+  k.name,
+  COUNT(DISTINCT m.id) AS module_count,
+  COUNT(mv.id) AS recent_publishes
+FROM keywords k
+  LEFT JOIN modules_keywords mk ON mk.keyword_id = k.id
+  LEFT JOIN modules m ON m.id = mk.module_id
+  AND m.visibility = 'public'
+  AND NOT m.hidden
+  AND m.deleted_at IS NULL
+  LEFT JOIN module_versions mv ON mv.module_id = m.id
+  AND mv.created_at > NOW() - INTERVAL '30 days'
+GROUP BY k.id,
+  k.name;
+CREATE UNIQUE INDEX IF NOT EXISTS keyword_popularity_keyword_id_idx ON keyword_popularity(keyword_id);
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE notifications DROP COLUMN removal_request_id;
+ALTER TABLE reports DROP COLUMN removal_request_id;
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+-- file removal requests in the moderation queue
+ALTER TABLE reports
+ADD COLUMN removal_request_id INT REFERENCES removal_requests(id) ON UPDATE CASCADE ON DELETE CASCADE;
+-- notify owners of removal requests filed against their modules and of their
+-- resolution
+ALTER TABLE notifications
+ADD COLUMN removal_request_id INT REFERENCES removal_requests(id) ON UPDATE CASCADE ON DELETE CASCADE;
+COMMIT;
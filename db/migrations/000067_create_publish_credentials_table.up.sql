@@ -0,0 +1,15 @@
+BEGIN;
+-- create publish_credentials table holding the short-lived credentials minted
+-- for CI workflows in exchange for an OIDC ID token permitted by a trust
+-- policy; each credential only publishes versions of its module
+CREATE TABLE IF NOT EXISTS publish_credentials (
+  id SERIAL PRIMARY KEY,
+  module_id int NOT NULL,
+  token_hash VARCHAR NOT NULL UNIQUE,
+  repository VARCHAR NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (module_id) REFERENCES modules(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS publish_credentials_module_id_idx ON publish_credentials(module_id);
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE users DROP COLUMN totp_last_step;
+COMMIT;
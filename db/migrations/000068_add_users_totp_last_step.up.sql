@@ -0,0 +1,6 @@
+BEGIN;
+-- add totp_last_step column to users table recording the time step of the
+-- last accepted TOTP code, so that a code is never accepted twice
+ALTER TABLE users
+ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+DROP TRIGGER IF EXISTS module_versions_touch_module_updated_at ON module_versions;
+DROP FUNCTION IF EXISTS touch_module_updated_at();
+DROP TRIGGER IF EXISTS module_versions_touch_updated_at ON module_versions;
+DROP TRIGGER IF EXISTS modules_touch_updated_at ON modules;
+DROP FUNCTION IF EXISTS touch_updated_at();
+ALTER TABLE module_versions DROP COLUMN updated_at;
+ALTER TABLE modules DROP COLUMN updated_at;
+COMMIT;
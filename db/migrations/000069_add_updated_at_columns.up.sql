@@ -0,0 +1,28 @@
+BEGIN;
+-- add updated_at columns to modules and module_versions tables, from which
+-- the validators of conditional requests are derived
+ALTER TABLE modules
+ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE module_versions
+ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+-- touch_updated_at sets the updated_at column of every updated row
+CREATE OR REPLACE FUNCTION touch_updated_at() RETURNS trigger AS $$ BEGIN NEW.updated_at := NOW();
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER modules_touch_updated_at BEFORE
+UPDATE ON modules FOR EACH ROW EXECUTE PROCEDURE touch_updated_at();
+CREATE TRIGGER module_versions_touch_updated_at BEFORE
+UPDATE ON module_versions FOR EACH ROW EXECUTE PROCEDURE touch_updated_at();
+-- touch_module_updated_at sets the updated_at column of the module of every
+-- deleted version, as deletions leave no updated_at of a version behind
+CREATE OR REPLACE FUNCTION touch_module_updated_at() RETURNS trigger AS $$ BEGIN
+UPDATE modules
+SET updated_at = NOW()
+WHERE id = OLD.module_id;
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER module_versions_touch_module_updated_at
+AFTER DELETE ON module_versions FOR EACH ROW EXECUTE PROCEDURE touch_module_updated_at();
+COMMIT;
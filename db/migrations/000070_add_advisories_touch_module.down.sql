@@ -0,0 +1,4 @@
+BEGIN;
+DROP TRIGGER IF EXISTS advisories_touch_module ON advisories;
+DROP FUNCTION IF EXISTS touch_advisory_module();
+COMMIT;
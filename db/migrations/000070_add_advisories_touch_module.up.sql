@@ -0,0 +1,22 @@
+BEGIN;
+-- touch_advisory_module sets the updated_at column of the module of every
+-- published, updated or removed advisory, as the module detail and version
+-- list carry the module's open advisories
+CREATE OR REPLACE FUNCTION touch_advisory_module() RETURNS trigger AS $$
+DECLARE mid int;
+BEGIN IF TG_OP = 'DELETE' THEN mid := OLD.module_id;
+ELSE mid := NEW.module_id;
+END IF;
+UPDATE modules
+SET updated_at = NOW()
+WHERE id = mid;
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER advisories_touch_module
+AFTER
+INSERT
+  OR
+UPDATE
+  OR DELETE ON advisories FOR EACH ROW EXECUTE PROCEDURE touch_advisory_module();
+COMMIT;
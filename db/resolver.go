@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/lib/pq" // register the postgres driver
+)
+
+// Resolver routes database access between a primary, which receives all
+// writes, and any number of read replicas. Replicas failing a health check
+// are taken out of rotation until they recover; with no healthy replica,
+// reads fall back to the primary.
+type Resolver struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     uint32
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy int32
+}
+
+// Open opens the primary database and read replicas at the given Postgres
+// connection URLs.
+func Open(primaryURL string, replicaURLs []string) (*Resolver, error) {
+	primary, err := sql.Open("postgres", primaryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open primary database: %w", err)
+	}
+
+	r := &Resolver{primary: primary}
+	for i, u := range replicaURLs {
+		db, err := sql.Open("postgres", u)
+		if err != nil {
+			r.Close() // nolint: errcheck
+			return nil, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+
+		r.replicas = append(r.replicas, &replica{db: db, healthy: 1})
+	}
+
+	return r, nil
+}
+
+// Primary returns the primary database. It must be used for all writes and
+// for reads that must observe the caller's own writes.
+func (r *Resolver) Primary() *sql.DB {
+	return r.primary
+}
+
+// Reader returns a database for read-only queries, selecting healthy replicas
+// round-robin and falling back to the primary.
+func (r *Resolver) Reader() *sql.DB {
+	n := uint32(len(r.replicas))
+	start := atomic.AddUint32(&r.next, 1)
+
+	for i := uint32(0); i < n; i++ {
+		rep := r.replicas[(start+i)%n]
+		if atomic.LoadInt32(&rep.healthy) == 1 {
+			return rep.db
+		}
+	}
+
+	return r.primary
+}
+
+// CheckHealth pings every replica, removing unreachable replicas from rotation
+// and restoring those that have recovered.
+func (r *Resolver) CheckHealth(ctx context.Context, timeout time.Duration) {
+	for i, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := rep.db.PingContext(pingCtx)
+		cancel()
+
+		healthy := int32(1)
+		if err != nil {
+			healthy = 0
+		}
+
+		if prev := atomic.SwapInt32(&rep.healthy, healthy); prev != healthy {
+			if err != nil {
+				log.Printf("replica %d is unhealthy, routing reads elsewhere: %v", i, err)
+			} else {
+				log.Printf("replica %d recovered", i)
+			}
+		}
+	}
+}
+
+// Monitor runs CheckHealth every interval until the context is cancelled.
+func (r *Resolver) Monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.CheckHealth(ctx, interval/2)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the primary and replica databases.
+func (r *Resolver) Close() error {
+	err := r.primary.Close()
+	for _, rep := range r.replicas {
+		if cerr := rep.db.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
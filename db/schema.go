@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// schemaExtensions defines the extensions required by the migrations. They are
+// installed in the public schema, which every tenant's search path includes,
+// since an extension can only be installed once per database.
+var schemaExtensions = []string{"pg_trgm"}
+
+// CreateSchema creates the Postgres schema of a tenant, if it does not exist,
+// in the database at databaseURL, along with the extensions its migrations
+// require.
+func CreateSchema(ctx context.Context, databaseURL, schema string) error {
+	sqlDB, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer sqlDB.Close()
+
+	for _, ext := range schemaExtensions {
+		if _, err := sqlDB.ExecContext(ctx, fmt.Sprintf(
+			`CREATE EXTENSION IF NOT EXISTS %s SCHEMA public`, pq.QuoteIdentifier(ext),
+		)); err != nil {
+			return fmt.Errorf("failed to create extension %s: %w", ext, err)
+		}
+	}
+
+	if _, err := sqlDB.ExecContext(ctx, fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s`, pq.QuoteIdentifier(schema))); err != nil {
+		return fmt.Errorf("failed to create schema %s: %w", schema, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier defines the query methods shared by *sql.DB and *sql.Tx, so that
+// queries run in the request's transaction when there is one.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// WithTx returns a copy of ctx carrying tx, which Conn and InTx then use for
+// every query made with the context.
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx, if any.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// Conn returns the transaction carried by ctx or, if there is none, sqlDB.
+func Conn(ctx context.Context, sqlDB *sql.DB) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+
+	return sqlDB
+}
+
+// InTx runs fn in the transaction carried by ctx or, if there is none, in a
+// new transaction of sqlDB, committed if fn succeeds and rolled back
+// otherwise. A transaction carried by ctx is left to its owner to commit, so
+// that operations spanning several InTx calls remain atomic.
+func InTx(ctx context.Context, sqlDB *sql.DB, fn func(tx *sql.Tx) error) error {
+	if tx, ok := TxFromContext(ctx); ok {
+		return fn(tx)
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
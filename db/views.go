@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// materializedViews defines the materialized views refreshed by the stats
+// rollup job. Each has a unique index so it can be refreshed concurrently
+// without blocking readers.
+var materializedViews = []string{
+	"keyword_popularity",
+	"trending_modules",
+	"recent_modules",
+	"registry_stats",
+	"registry_weekly_publishes",
+}
+
+// RefreshMaterializedViews refreshes every materialized view backing feeds,
+// popularity rankings and registry statistics.
+func RefreshMaterializedViews(ctx context.Context, sqlDB *sql.DB) error {
+	for _, view := range materializedViews {
+		if _, err := sqlDB.ExecContext(ctx, fmt.Sprintf(`REFRESH MATERIALIZED VIEW CONCURRENTLY %s`, view)); err != nil {
+			return fmt.Errorf("failed to refresh %s: %w", view, err)
+		}
+	}
+
+	return nil
+}
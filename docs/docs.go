@@ -0,0 +1,172 @@
+package docs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type (
+	// Symbol defines the documentation of a single exported declaration.
+	Symbol struct {
+		Name string `json:"name" yaml:"name"`
+		Decl string `json:"decl" yaml:"decl"`
+		Doc  string `json:"doc" yaml:"doc"`
+	}
+
+	// Type defines the documentation of an exported type along with its
+	// associated functions and methods.
+	Type struct {
+		Symbol
+		Funcs   []Symbol `json:"funcs,omitempty" yaml:"funcs,omitempty"`
+		Methods []Symbol `json:"methods,omitempty" yaml:"methods,omitempty"`
+	}
+
+	// Package defines the structured documentation of a Go package.
+	Package struct {
+		ImportPath string   `json:"import_path" yaml:"import_path"`
+		Name       string   `json:"name" yaml:"name"`
+		Synopsis   string   `json:"synopsis" yaml:"synopsis"`
+		Doc        string   `json:"doc" yaml:"doc"`
+		Consts     []Symbol `json:"consts,omitempty" yaml:"consts,omitempty"`
+		Vars       []Symbol `json:"vars,omitempty" yaml:"vars,omitempty"`
+		Funcs      []Symbol `json:"funcs,omitempty" yaml:"funcs,omitempty"`
+		Types      []Type   `json:"types,omitempty" yaml:"types,omitempty"`
+	}
+)
+
+// Clone performs a shallow clone of the given tag of a git repository into dir.
+func Clone(ctx context.Context, repo, tag, dir string) error {
+	cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", "--depth", "1", "--branch", tag, repo, dir)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to clone %s@%s: %s: %w", repo, tag, bytes.TrimSpace(out), err)
+	}
+
+	return nil
+}
+
+// Extract walks the module rooted at dir and extracts the documentation of
+// every non-internal package, where modulePath is the module's import path.
+// Test files, testdata and vendor directories are skipped.
+func Extract(dir, modulePath string) ([]Package, error) {
+	var pkgs []Package
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		name := info.Name()
+		if p != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+			name == "testdata" || name == "vendor" || name == "internal") {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		pkg, ok, err := extractPackage(p, path.Join(modulePath, filepath.ToSlash(rel)))
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			pkgs = append(pkgs, pkg)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].ImportPath < pkgs[j].ImportPath })
+	return pkgs, nil
+}
+
+func extractPackage(dir, importPath string) (Package, bool, error) {
+	fset := token.NewFileSet()
+
+	parsed, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return Package{}, false, fmt.Errorf("failed to parse %s: %w", dir, err)
+	}
+
+	for name, astPkg := range parsed {
+		if name == "main" {
+			continue
+		}
+
+		dp := doc.New(astPkg, importPath, 0)
+		pkg := Package{
+			ImportPath: importPath,
+			Name:       dp.Name,
+			Synopsis:   doc.Synopsis(dp.Doc),
+			Doc:        dp.Doc,
+			Consts:     values(fset, dp.Consts),
+			Vars:       values(fset, dp.Vars),
+			Funcs:      funcs(fset, dp.Funcs),
+		}
+
+		for _, t := range dp.Types {
+			pkg.Types = append(pkg.Types, Type{
+				Symbol:  Symbol{Name: t.Name, Decl: decl(fset, t.Decl), Doc: t.Doc},
+				Funcs:   funcs(fset, t.Funcs),
+				Methods: funcs(fset, t.Methods),
+			})
+		}
+
+		return pkg, true, nil
+	}
+
+	return Package{}, false, nil
+}
+
+func values(fset *token.FileSet, vals []*doc.Value) []Symbol {
+	out := make([]Symbol, 0, len(vals))
+	for _, v := range vals {
+		out = append(out, Symbol{Name: strings.Join(v.Names, ", "), Decl: decl(fset, v.Decl), Doc: v.Doc})
+	}
+
+	return out
+}
+
+func funcs(fset *token.FileSet, fns []*doc.Func) []Symbol {
+	out := make([]Symbol, 0, len(fns))
+	for _, f := range fns {
+		f.Decl.Body = nil
+		out = append(out, Symbol{Name: f.Name, Decl: decl(fset, f.Decl), Doc: f.Doc})
+	}
+
+	return out
+}
+
+func decl(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+
+	return buf.String()
+}
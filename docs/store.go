@@ -0,0 +1,186 @@
+package docs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// Generate clones the tagged release of a published module version, extracts
+// the documentation of its packages and stores it in the module_version_docs
+// table, replacing any previously extracted documentation of the version. The
+// release is expected to be tagged v<version>. Versions whose manifest omits a
+// license are assigned the license detected from the release's LICENSE file,
+// as is their module if it has none.
+func Generate(ctx context.Context, db *sql.DB, moduleVersionID int) error {
+	var repo, version, license string
+	if err := db.QueryRowContext(ctx, `
+		SELECT m.repo, mv.version, COALESCE(mv.license, '')
+		FROM module_versions mv
+		JOIN modules m ON m.id = mv.module_id
+		WHERE mv.id = $1`,
+		moduleVersionID,
+	).Scan(&repo, &version, &license); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempDir("", "atlas-docs")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "src")
+	if err := Clone(ctx, repo, "v"+version, dir); err != nil {
+		return err
+	}
+
+	if license == "" {
+		if err := storeLicense(ctx, db, moduleVersionID, dir); err != nil {
+			return err
+		}
+	}
+
+	pkgs, err := Extract(dir, modulePath(dir, repo))
+	if err != nil {
+		return err
+	}
+
+	return Store(ctx, db, moduleVersionID, pkgs)
+}
+
+// Store replaces the documentation of a module version with the given
+// packages.
+func Store(ctx context.Context, db *sql.DB, moduleVersionID int, pkgs []Package) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM module_version_docs WHERE module_version_id = $1`, moduleVersionID); err != nil {
+		return err
+	}
+
+	for _, pkg := range pkgs {
+		bz, err := json.Marshal(pkg)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO module_version_docs (module_version_id, import_path, synopsis, doc)
+			VALUES ($1, $2, NULLIF($3, ''), $4)`,
+			moduleVersionID, pkg.ImportPath, pkg.Synopsis, bz,
+		); err != nil {
+			return fmt.Errorf("failed to store documentation of %s: %w", pkg.ImportPath, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Load returns the stored documentation of a module version, ordered by
+// import path.
+func Load(ctx context.Context, db *sql.DB, moduleVersionID int) ([]Package, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT doc
+		FROM module_version_docs
+		WHERE module_version_id = $1
+		ORDER BY import_path`,
+		moduleVersionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pkgs := []Package{}
+	for rows.Next() {
+		var bz []byte
+		if err := rows.Scan(&bz); err != nil {
+			return nil, err
+		}
+
+		var pkg Package
+		if err := json.Unmarshal(bz, &pkg); err != nil {
+			return nil, err
+		}
+
+		pkgs = append(pkgs, pkg)
+	}
+
+	return pkgs, rows.Err()
+}
+
+// licenseFiles defines the names of the license file of a repository, by
+// precedence.
+var licenseFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "COPYING"}
+
+// storeLicense detects the license of the repository cloned into dir and
+// assigns it to the module version along with its module, unless the module
+// already declares one.
+func storeLicense(ctx context.Context, db *sql.DB, moduleVersionID int, dir string) error {
+	var license string
+	for _, name := range licenseFiles {
+		bz, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+
+		license = module.DetectLicense(string(bz))
+		break
+	}
+
+	if license == "" {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	if _, err := tx.ExecContext(ctx, `UPDATE module_versions SET license = $2 WHERE id = $1`, moduleVersionID, license); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE modules
+		SET license = $2
+		WHERE id = (SELECT module_id FROM module_versions WHERE id = $1)
+			AND COALESCE(license, '') = ''`,
+		moduleVersionID, license,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// modulePath returns the module path declared by the go.mod file at the root
+// of dir, falling back to the repository URL without its scheme.
+func modulePath(dir, repo string) string {
+	if bz, err := ioutil.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		if p := modfile.ModulePath(bz); p != "" {
+			return p
+		}
+	}
+
+	p := repo
+	if i := strings.Index(p, "://"); i >= 0 {
+		p = p[i+3:]
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(p, "/"), ".git")
+}
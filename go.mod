@@ -2,4 +2,28 @@ module github.com/cosmos/atlas
 
 go 1.15
 
-require github.com/urfave/cli/v2 v2.2.0
+require (
+	github.com/BurntSushi/toml v0.3.1
+	github.com/Masterminds/semver/v3 v3.1.1
+	github.com/andybalholm/brotli v1.0.4
+	github.com/coreos/go-oidc v2.2.1+incompatible
+	github.com/golang-migrate/migrate/v4 v4.14.1
+	github.com/golang/protobuf v1.4.3
+	github.com/lib/pq v1.8.0
+	github.com/microcosm-cc/bluemonday v1.0.16
+	github.com/minio/minio-go/v7 v7.0.5
+	github.com/pquerna/cachecontrol v0.0.0-20180517163645-1555304b9b35 // indirect
+	github.com/robfig/cron/v3 v3.0.1
+	github.com/russross/blackfriday/v2 v2.0.1
+	github.com/urfave/cli/v2 v2.2.0
+	github.com/xeipuuv/gojsonschema v1.2.0
+	golang.org/x/crypto v0.0.0-20200709230013-948cd5f35899
+	golang.org/x/image v0.0.0-20200618115811-c13761719519
+	golang.org/x/mod v0.3.0
+	golang.org/x/net v0.0.0-20210614182718-04defd469f4e
+	golang.org/x/text v0.3.6
+	google.golang.org/grpc v1.33.1
+	google.golang.org/protobuf v1.25.0
+	gopkg.in/square/go-jose.v2 v2.5.1
+	gopkg.in/yaml.v2 v2.3.0
+)
@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"github.com/cosmos/atlas/db"
+)
+
+// Check statuses.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check defines a named readiness check of a dependency of the server.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result defines the outcome of a single Check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report defines the readiness response body.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker serves the liveness and readiness endpoints of the server.
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker returns a Checker running the given readiness checks, each bound
+// by timeout.
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Liveness handles /healthz. It reports that the process is serving and never
+// inspects dependencies, so that a dependency outage does not cause
+// Kubernetes to restart healthy instances.
+func (c *Checker) Liveness(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// Readiness handles /readyz. It runs every check concurrently and responds
+// with 503 Service Unavailable if any of them fail.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, report)
+}
+
+// Run runs every check concurrently and returns their results.
+func (c *Checker) Run(ctx context.Context) Report {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		report = Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+	)
+
+	for _, check := range c.checks {
+		wg.Add(1)
+
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(checkCtx)
+			res := Result{Status: StatusOK, Duration: time.Since(start).String()}
+			if err != nil {
+				res.Status = StatusFail
+				res.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[check.Name] = res
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}(check)
+	}
+
+	wg.Wait()
+	return report
+}
+
+// Database returns a Check pinging the given database.
+func Database(name string, sqlDB *sql.DB) Check {
+	return Check{Name: name, Run: sqlDB.PingContext}
+}
+
+// Migrations returns a Check failing while the database has pending or dirty
+// migrations.
+func Migrations(m *migrate.Migrate, dir string) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(context.Context) error {
+			return db.CheckPending(m, dir)
+		},
+	}
+}
+
+// JobQueue returns a Check verifying that the jobs table backing the job queue
+// is reachable.
+func JobQueue(sqlDB *sql.DB) Check {
+	return Check{
+		Name: "job_queue",
+		Run: func(ctx context.Context) error {
+			_, err := sqlDB.ExecContext(ctx, `SELECT 1 FROM jobs LIMIT 1`)
+			return err
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v) // nolint: errcheck
+}
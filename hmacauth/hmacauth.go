@@ -0,0 +1,317 @@
+package hmacauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+)
+
+// Request signing headers. The key ID identifies the signing user's API
+// token, the timestamp is the signing time in Unix seconds and the signature
+// is the hex-encoded HMAC-SHA256 of the canonical request keyed by the token.
+const (
+	HeaderKeyID     = "X-Atlas-Key-Id"
+	HeaderTimestamp = "X-Atlas-Timestamp"
+	HeaderSignature = "X-Atlas-Signature"
+)
+
+// Session credentials of browser clients. The session cookie carries the raw
+// session token and mutating requests authenticated by it must carry the
+// session's CSRF token, as returned by module.CSRFToken, in HeaderCSRFToken.
+const (
+	SessionCookie   = "atlas_session"
+	HeaderCSRFToken = "X-CSRF-Token"
+)
+
+const (
+	// DefaultMaxSkew defines the default maximum difference between the
+	// signing time of a request and the time it is verified.
+	DefaultMaxSkew = 5 * time.Minute
+
+	// DefaultMaxBodySize bounds the size of a signed request body.
+	DefaultMaxBodySize = 64 << 20
+
+	// DefaultSessionMaxAge defines the default age after which sessions
+	// expire, whether or not they are in use.
+	DefaultSessionMaxAge = 30 * 24 * time.Hour
+
+	// DefaultSessionIdleTimeout defines the default time after its last use
+	// that a session expires.
+	DefaultSessionIdleTimeout = 7 * 24 * time.Hour
+
+	keyIDLength = 16
+)
+
+// ErrUnauthenticated is returned when a signed request is missing, malformed,
+// expired, replayed or carries an invalid signature.
+var ErrUnauthenticated = errors.New("request signature rejected")
+
+// KeyID returns the public identifier of an API token sent in HeaderKeyID. It
+// is maintained on users.api_key_id by the database whenever the token
+// changes. An empty token has no identifier.
+func KeyID(apiToken string) string {
+	if apiToken == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(apiToken))
+	return hex.EncodeToString(sum[:])[:keyIDLength]
+}
+
+// Sign returns the signature of a request keyed by the API token. The signed
+// canonical request consists of the method, path, canonical query string,
+// timestamp and hex-encoded SHA-256 of the body, separated by newlines.
+func Sign(apiToken, method, path, rawQuery string, timestamp int64, body []byte) string {
+	sum := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		strings.ToUpper(method), path, canonicalQuery(rawQuery), strconv.FormatInt(timestamp, 10), hex.EncodeToString(sum[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(apiToken))
+	mac.Write([]byte(canonical)) // nolint: errcheck
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// canonicalQuery returns the query string with its parameters sorted by key,
+// so that equivalent queries share a signature. A query that cannot be parsed
+// is signed as is.
+func canonicalQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+
+	return values.Encode()
+}
+
+// SignRequest sets the signing headers of req for the given body, signed now
+// with the API token. The caller remains responsible for setting req.Body.
+func SignRequest(req *http.Request, apiToken string, body []byte, now time.Time) {
+	ts := now.Unix()
+
+	req.Header.Set(HeaderKeyID, KeyID(apiToken))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(apiToken, req.Method, req.URL.EscapedPath(), req.URL.RawQuery, ts, body))
+}
+
+// Signed returns true if the request carries a signature, as opposed to
+// session or bearer token credentials.
+func Signed(r *http.Request) bool {
+	return r.Header.Get(HeaderSignature) != ""
+}
+
+// Verifier verifies signed requests against the API tokens of registry users.
+// Sessions expire SessionMaxAge after they were created or SessionIdleTimeout
+// after they were last used, whichever comes first; zero disables either.
+type Verifier struct {
+	db                 *sql.DB
+	tokens             policy.TokenPolicy
+	MaxSkew            time.Duration
+	MaxBodySize        int64
+	SessionMaxAge      time.Duration
+	SessionIdleTimeout time.Duration
+}
+
+// NewVerifier returns a Verifier enforcing the given API token policy, with
+// the default maximum skew, body size and session lifetimes.
+func NewVerifier(db *sql.DB, tokens policy.TokenPolicy) *Verifier {
+	return &Verifier{
+		db:                 db,
+		tokens:             tokens,
+		MaxSkew:            DefaultMaxSkew,
+		MaxBodySize:        DefaultMaxBodySize,
+		SessionMaxAge:      DefaultSessionMaxAge,
+		SessionIdleTimeout: DefaultSessionIdleTimeout,
+	}
+}
+
+// Verify authenticates a signed request, returning the signing User. The
+// request body is consumed to verify the signature and replaced so that it
+// may be read again by the handler. Each signature is accepted once; replays
+// within the skew window are rejected. The token policy is enforced against
+// the remote address of the request, returning policy.ErrTokenExpired or
+// policy.ErrTokenIPDenied.
+func (v *Verifier) Verify(r *http.Request) (module.User, error) {
+	keyID := r.Header.Get(HeaderKeyID)
+	sig := r.Header.Get(HeaderSignature)
+	if keyID == "" || sig == "" {
+		return module.User{}, fmt.Errorf("%w: missing %s or %s header", ErrUnauthenticated, HeaderKeyID, HeaderSignature)
+	}
+
+	ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return module.User{}, fmt.Errorf("%w: invalid %s header", ErrUnauthenticated, HeaderTimestamp)
+	}
+
+	now := time.Now()
+	if signedAt := time.Unix(ts, 0); signedAt.Before(now.Add(-v.MaxSkew)) || signedAt.After(now.Add(v.MaxSkew)) {
+		return module.User{}, fmt.Errorf("%w: timestamp outside of the allowed %s skew", ErrUnauthenticated, v.MaxSkew)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, v.MaxBodySize+1))
+	if err != nil {
+		return module.User{}, err
+	}
+	if int64(len(body)) > v.MaxBodySize {
+		return module.User{}, fmt.Errorf("%w: body exceeds %d bytes", ErrUnauthenticated, v.MaxBodySize)
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	u, err := v.lookup(r.Context(), "api_key_id", keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return module.User{}, fmt.Errorf("%w: unknown key ID", ErrUnauthenticated)
+	}
+
+	if err != nil {
+		return module.User{}, err
+	}
+
+	if u.APIToken == "" {
+		return module.User{}, fmt.Errorf("%w: user has no API token", ErrUnauthenticated)
+	}
+
+	expected := Sign(u.APIToken, r.Method, r.URL.EscapedPath(), r.URL.RawQuery, ts, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(sig))) {
+		return module.User{}, fmt.Errorf("%w: invalid signature", ErrUnauthenticated)
+	}
+
+	if err := v.tokens.Check(u, remoteIP(r), now); err != nil {
+		return module.User{}, err
+	}
+
+	if err := v.recordSignature(r.Context(), expected, now.Add(v.MaxSkew)); err != nil {
+		return module.User{}, err
+	}
+
+	return u, nil
+}
+
+// VerifyToken authenticates an API token sent as a bearer token from the
+// given IP address, returning its User. The token policy is enforced as by
+// Verify.
+func (v *Verifier) VerifyToken(ctx context.Context, token string, ip net.IP) (module.User, error) {
+	if token == "" {
+		return module.User{}, fmt.Errorf("%w: missing API token", ErrUnauthenticated)
+	}
+
+	u, err := v.lookup(ctx, "api_token", token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return module.User{}, fmt.Errorf("%w: unknown API token", ErrUnauthenticated)
+	}
+
+	if err != nil {
+		return module.User{}, err
+	}
+
+	if err := v.tokens.Check(u, ip, time.Now()); err != nil {
+		return module.User{}, err
+	}
+
+	return u, nil
+}
+
+// VerifySession authenticates a session token sent in SessionCookie,
+// returning the User of the session if it has neither been revoked nor
+// expired. Each use of the session extends its idle timeout.
+func (v *Verifier) VerifySession(ctx context.Context, token string) (module.User, error) {
+	var userID int
+
+	err := v.db.QueryRowContext(ctx, `
+		UPDATE sessions SET last_seen_at = NOW()
+		WHERE token_hash = $1
+			AND revoked_at IS NULL
+			AND ($2 = 0 OR created_at > NOW() - $2 * INTERVAL '1 second')
+			AND ($3 = 0 OR last_seen_at > NOW() - $3 * INTERVAL '1 second')
+		RETURNING user_id`,
+		module.HashSessionToken(token), v.SessionMaxAge.Seconds(), v.SessionIdleTimeout.Seconds(),
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return module.User{}, fmt.Errorf("%w: unknown, revoked or expired session", ErrUnauthenticated)
+	}
+
+	if err != nil {
+		return module.User{}, err
+	}
+
+	return v.lookup(ctx, "id", strconv.Itoa(userID))
+}
+
+// lookup returns the User whose token matches value in the given users
+// column, which must be a constant.
+func (v *Verifier) lookup(ctx context.Context, column, value string) (module.User, error) {
+	var u module.User
+
+	err := v.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT id, COALESCE(name, ''), email, api_token, api_token_cidrs, api_token_created_at, admin, banned
+		FROM users
+		WHERE %s = $1`, column),
+		value,
+	).Scan(&u.ID, &u.Name, &u.Email, &u.APIToken, pq.Array(&u.APITokenCIDRs), &u.APITokenCreatedAt, &u.Admin, &u.Banned)
+
+	return u, err
+}
+
+// remoteIP returns the IP address of the request's remote address, or nil if
+// it cannot be parsed.
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return net.ParseIP(host)
+}
+
+// recordSignature records an accepted signature until it expires, returning
+// ErrUnauthenticated if it was already recorded.
+func (v *Verifier) recordSignature(ctx context.Context, sig string, expiresAt time.Time) error {
+	res, err := v.db.ExecContext(ctx, `
+		INSERT INTO request_signatures (signature, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (signature) DO NOTHING`,
+		sig, expiresAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: replayed request", ErrUnauthenticated)
+	}
+
+	return nil
+}
+
+// PurgeSignatures removes expired signatures recorded for replay protection.
+func PurgeSignatures(ctx context.Context, db *sql.DB) (int64, error) {
+	res, err := db.ExecContext(ctx, `DELETE FROM request_signatures WHERE expires_at < $1`, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,140 @@
+package hmacauth
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cosmos/atlas/policy"
+)
+
+func TestKeyID(t *testing.T) {
+	if id := KeyID(""); id != "" {
+		t.Errorf("expected no key ID for an empty token, got %q", id)
+	}
+
+	id := KeyID("secret-token")
+	if len(id) != keyIDLength {
+		t.Errorf("expected a %d character key ID, got %q", keyIDLength, id)
+	}
+
+	if id != KeyID("secret-token") || id == KeyID("other-token") {
+		t.Errorf("expected key IDs to identify tokens, got %q", id)
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"name":"x/liquidity"}`)
+	sig := Sign("secret-token", "post", "/api/v1/modules", "b=2&a=1", 1600000000, body)
+
+	testCases := []struct {
+		name  string
+		sig   string
+		equal bool
+	}{
+		{"method casing", Sign("secret-token", "POST", "/api/v1/modules", "b=2&a=1", 1600000000, body), true},
+		{"query order", Sign("secret-token", "POST", "/api/v1/modules", "a=1&b=2", 1600000000, body), true},
+		{"token", Sign("other-token", "POST", "/api/v1/modules", "a=1&b=2", 1600000000, body), false},
+		{"method", Sign("secret-token", "PUT", "/api/v1/modules", "a=1&b=2", 1600000000, body), false},
+		{"path", Sign("secret-token", "POST", "/api/v1/modules/x%2Fliquidity", "a=1&b=2", 1600000000, body), false},
+		{"query", Sign("secret-token", "POST", "/api/v1/modules", "a=1&b=3", 1600000000, body), false},
+		{"timestamp", Sign("secret-token", "POST", "/api/v1/modules", "a=1&b=2", 1600000001, body), false},
+		{"body", Sign("secret-token", "POST", "/api/v1/modules", "a=1&b=2", 1600000000, []byte(`{}`)), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if (tc.sig == sig) != tc.equal {
+				t.Errorf("expected equal signatures: %v, got %s and %s", tc.equal, sig, tc.sig)
+			}
+		})
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	body := []byte(`{"name":"x/liquidity"}`)
+	now := time.Unix(1600000000, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/modules?b=2&a=1", bytes.NewReader(body))
+	if Signed(req) {
+		t.Fatal("expected an unsigned request")
+	}
+
+	SignRequest(req, "secret-token", body, now)
+
+	if !Signed(req) {
+		t.Fatal("expected a signed request")
+	}
+
+	if got := req.Header.Get(HeaderKeyID); got != KeyID("secret-token") {
+		t.Errorf("expected key ID %s, got %s", KeyID("secret-token"), got)
+	}
+
+	if got := req.Header.Get(HeaderTimestamp); got != "1600000000" {
+		t.Errorf("expected timestamp 1600000000, got %s", got)
+	}
+
+	if got, want := req.Header.Get(HeaderSignature), Sign("secret-token", http.MethodPost, "/api/v1/modules", "a=1&b=2", now.Unix(), body); got != want {
+		t.Errorf("expected signature %s, got %s", want, got)
+	}
+}
+
+func TestVerifyRejectsMalformed(t *testing.T) {
+	v := NewVerifier(nil, policy.TokenPolicy{})
+	v.MaxBodySize = 8
+
+	signed := func(ts time.Time, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/modules", bytes.NewReader([]byte(body)))
+		SignRequest(req, "secret-token", []byte(body), ts)
+		return req
+	}
+
+	missing := signed(time.Now(), "{}")
+	missing.Header.Del(HeaderKeyID)
+
+	malformed := signed(time.Now(), "{}")
+	malformed.Header.Set(HeaderTimestamp, "yesterday")
+
+	testCases := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"missing key ID", missing},
+		{"malformed timestamp", malformed},
+		{"expired", signed(time.Now().Add(-DefaultMaxSkew-time.Minute), "{}")},
+		{"future", signed(time.Now().Add(DefaultMaxSkew+time.Minute), "{}")},
+		{"body too large", signed(time.Now(), `{"name":"x/liquidity"}`)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := v.Verify(tc.req); !errors.Is(err, ErrUnauthenticated) {
+				t.Errorf("expected %v, got %v", ErrUnauthenticated, err)
+			}
+		})
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	testCases := []struct {
+		addr string
+		ip   string
+	}{
+		{"203.0.113.7:51234", "203.0.113.7"},
+		{"[2001:db8::1]:443", "2001:db8::1"},
+		{"203.0.113.7", "203.0.113.7"},
+		{"invalid", "<nil>"},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tc.addr
+
+		if got := remoteIP(r).String(); got != tc.ip {
+			t.Errorf("%s: expected %s, got %s", tc.addr, tc.ip, got)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package issuetracker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// DefaultTimeout defines the default timeout of a single provider request.
+const DefaultTimeout = 30 * time.Second
+
+// maxResponseSize bounds the size of a provider API response.
+const maxResponseSize = 1 << 20
+
+// ErrRepoNotFound is returned when the repository does not exist on its
+// provider or is not accessible.
+var ErrRepoNotFound = errors.New("repository not found")
+
+// Config defines the configuration of issue tracker polling.
+type Config struct {
+	// Enabled enables polling the issue trackers of modules hosted on GitHub
+	// and GitLab for their open issues and last issue activity.
+	Enabled bool `yaml:"enabled"`
+
+	// GitHubToken and GitLabToken authenticate provider API requests, raising
+	// rate limits.
+	GitHubToken string `yaml:"github_token"`
+	GitLabToken string `yaml:"gitlab_token"`
+}
+
+// Client polls the issue trackers of repositories hosted on GitHub and
+// GitLab.
+type Client struct {
+	client      *http.Client
+	githubToken string
+	gitlabToken string
+	githubURL   string
+	gitlabURL   string
+}
+
+// NewClient returns a Client using the given HTTP client. The tokens, if
+// non-empty, authenticate GitHub and GitLab API requests respectively to raise
+// rate limits and allow access to private repositories.
+func NewClient(client *http.Client, githubToken, gitlabToken string) *Client {
+	return &Client{
+		client:      client,
+		githubToken: githubToken,
+		gitlabToken: gitlabToken,
+		githubURL:   "https://api.github.com",
+		gitlabURL:   "https://gitlab.com/api/v4",
+	}
+}
+
+// Stats returns the number of open issues and the time of the last issue
+// activity of a hosted repository. The CheckedAt time is left to the caller.
+func (c *Client) Stats(ctx context.Context, r module.HostedRepo) (module.IssueStats, error) {
+	switch r.Provider {
+	case module.ProviderGitHub:
+		return c.githubStats(ctx, r)
+
+	case module.ProviderGitLab:
+		return c.gitlabStats(ctx, r)
+
+	default:
+		return module.IssueStats{}, fmt.Errorf("unsupported provider: %s", r.Provider)
+	}
+}
+
+// githubStats counts open issues with the search API, as the repository's
+// open_issues_count includes pull requests, and reads the last activity from
+// the most recently updated issue.
+func (c *Client) githubStats(ctx context.Context, r module.HostedRepo) (module.IssueStats, error) {
+	var stats module.IssueStats
+
+	var search struct {
+		TotalCount int `json:"total_count"`
+	}
+
+	q := url.QueryEscape(fmt.Sprintf("repo:%s is:issue is:open", r.Path))
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/search/issues?q=%s&per_page=1", c.githubURL, q), c.githubAuth(), &search); err != nil {
+		return stats, err
+	}
+
+	var issues []struct {
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/repos/%s/issues?state=all&sort=updated&direction=desc&per_page=1", c.githubURL, r.Path), c.githubAuth(), &issues); err != nil {
+		return stats, err
+	}
+
+	stats.OpenIssues = search.TotalCount
+	if len(issues) > 0 {
+		stats.LastActivityAt = issues[0].UpdatedAt
+	}
+
+	return stats, nil
+}
+
+// gitlabStats reads the open issues and last activity of the project.
+func (c *Client) gitlabStats(ctx context.Context, r module.HostedRepo) (module.IssueStats, error) {
+	var project struct {
+		OpenIssuesCount int       `json:"open_issues_count"`
+		LastActivityAt  time.Time `json:"last_activity_at"`
+	}
+
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/projects/%s", c.gitlabURL, url.PathEscape(r.Path)), c.gitlabAuth(), &project); err != nil {
+		return module.IssueStats{}, err
+	}
+
+	return module.IssueStats{OpenIssues: project.OpenIssuesCount, LastActivityAt: project.LastActivityAt}, nil
+}
+
+func (c *Client) githubAuth() func(*http.Request) {
+	return func(req *http.Request) {
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+		if c.githubToken != "" {
+			req.Header.Set("Authorization", "token "+c.githubToken)
+		}
+	}
+}
+
+func (c *Client) gitlabAuth() func(*http.Request) {
+	return func(req *http.Request) {
+		if c.gitlabToken != "" {
+			req.Header.Set("PRIVATE-TOKEN", c.gitlabToken)
+		}
+	}
+}
+
+func (c *Client) getJSON(ctx context.Context, u string, auth func(*http.Request), v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	auth(req)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bz, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrRepoNotFound
+
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s responded %d", req.URL.Host, resp.StatusCode)
+	}
+
+	return json.Unmarshal(bz, v)
+}
+
+// Sync polls the issue tracker of every module whose repository is hosted on
+// a supported provider, recording its issue statistics. Modules whose
+// repository cannot be polled are skipped and keep their previous statistics,
+// except for repositories that no longer exist, whose statistics are cleared.
+func Sync(ctx context.Context, db *sql.DB, c *Client) error {
+	repos, err := moduleRepos(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for id, r := range repos {
+		stats, err := c.Stats(ctx, r)
+		if errors.Is(err, ErrRepoNotFound) {
+			if _, err := db.ExecContext(ctx, `
+				UPDATE modules
+				SET open_issues = NULL, issues_active_at = NULL, issues_checked_at = NOW()
+				WHERE id = $1`,
+				id,
+			); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			log.Printf("skipping issue stats of %s/%s: %v", r.Host, r.Path, err)
+			continue
+		}
+
+		var activeAt interface{}
+		if !stats.LastActivityAt.IsZero() {
+			activeAt = stats.LastActivityAt.UTC()
+		}
+
+		if _, err := db.ExecContext(ctx, `
+			UPDATE modules
+			SET open_issues = $2, issues_active_at = $3, issues_checked_at = NOW()
+			WHERE id = $1`,
+			id, stats.OpenIssues, activeAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moduleRepos returns the hosted repositories of live modules keyed by module
+// ID.
+func moduleRepos(ctx context.Context, db *sql.DB) (map[int]module.HostedRepo, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, repo
+		FROM modules
+		WHERE deleted_at IS NULL
+			AND origin IS NULL`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	repos := make(map[int]module.HostedRepo)
+	for rows.Next() {
+		var (
+			id   int
+			repo string
+		)
+
+		if err := rows.Scan(&id, &repo); err != nil {
+			return nil, err
+		}
+
+		if r, ok := module.ParseHostedRepo(strings.TrimSpace(repo)); ok {
+			repos[id] = r
+		}
+	}
+
+	return repos, rows.Err()
+}
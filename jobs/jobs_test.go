@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{5, 32 * time.Second},
+		{11, 2048 * time.Second},
+		{12, time.Hour},
+		{13, time.Hour},
+		{64, time.Hour},
+	}
+
+	for _, tc := range testCases {
+		if got := Backoff(tc.attempts); got != tc.backoff {
+			t.Errorf("%d attempts: expected %s, got %s", tc.attempts, tc.backoff, got)
+		}
+	}
+}
+
+func TestNewScheduler(t *testing.T) {
+	if _, err := NewScheduler(nil, DefaultSchedules()); err != nil {
+		t.Errorf("expected the default schedules to be valid, got %v", err)
+	}
+
+	if _, err := NewScheduler(nil, []Schedule{{Name: "broken", Spec: "every hour", Kind: KindAggregateStats}}); err == nil {
+		t.Error("expected an invalid cron expression to be rejected")
+	}
+
+	if _, err := NewScheduler(nil, []Schedule{{Name: "seconds", Spec: "0 */5 * * * *", Kind: KindAggregateStats}}); err == nil {
+		t.Error("expected a six-field cron expression to be rejected")
+	}
+}
+
+func TestDefaultSchedules(t *testing.T) {
+	names := map[string]bool{}
+	kinds := map[string]bool{}
+
+	for _, s := range DefaultSchedules() {
+		if names[s.Name] {
+			t.Errorf("duplicate schedule name %s", s.Name)
+		}
+
+		if kinds[s.Kind] {
+			t.Errorf("duplicate schedule kind %s", s.Kind)
+		}
+
+		names[s.Name] = true
+		kinds[s.Kind] = true
+	}
+}
+
+func TestPoolRun(t *testing.T) {
+	p := NewPool(nil, 0)
+	if p.concurrency != 1 {
+		t.Errorf("expected a concurrency of at least 1, got %d", p.concurrency)
+	}
+
+	errFailed := errors.New("failed")
+
+	p.Register("succeeds", func(context.Context, Job) error { return nil })
+	p.Register("fails", func(context.Context, Job) error { return errFailed })
+	p.Register("panics", func(context.Context, Job) error { panic("boom") })
+
+	ctx := context.Background()
+
+	if err := p.run(ctx, Job{Kind: "succeeds"}); err != nil {
+		t.Errorf("expected the job to succeed, got %v", err)
+	}
+
+	if err := p.run(ctx, Job{Kind: "fails"}); !errors.Is(err, errFailed) {
+		t.Errorf("expected %v, got %v", errFailed, err)
+	}
+
+	if err := p.run(ctx, Job{Kind: "panics"}); err == nil {
+		t.Error("expected a panicking job to fail")
+	}
+
+	if err := p.run(ctx, Job{Kind: "unknown"}); !errors.Is(err, errNoHandler) {
+		t.Errorf("expected %v, got %v", errNoHandler, err)
+	}
+}
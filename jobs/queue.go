@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+)
+
+// Job statuses.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// ErrNoDeadJob is returned when retrying a job that does not exist or is not
+// in the dead-letter state.
+var ErrNoDeadJob = errors.New("no dead job")
+
+// DefaultMaxAttempts defines the default number of attempts of a job before it
+// is moved to the dead-letter state.
+const DefaultMaxAttempts = 5
+
+// Job defines a unit of asynchronous work persisted in the jobs table.
+type Job struct {
+	ID          int64           `json:"id" db:"id"`
+	Kind        string          `json:"kind" db:"kind"`
+	Payload     json.RawMessage `json:"payload" db:"payload"`
+	Status      string          `json:"status" db:"status"`
+	Attempts    int             `json:"attempts" db:"attempts"`
+	MaxAttempts int             `json:"max_attempts" db:"max_attempts"`
+	LastError   string          `json:"last_error" db:"last_error"`
+	RunAt       time.Time       `json:"run_at" db:"run_at"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Queue defines a Postgres-backed job queue. Jobs are claimed with
+// SELECT ... FOR UPDATE SKIP LOCKED so that any number of workers across
+// server replicas may consume the queue concurrently.
+type Queue struct {
+	db *sql.DB
+
+	// LockTimeout defines how long a job may remain running before it is
+	// considered abandoned (e.g. its worker crashed) and may be reclaimed.
+	LockTimeout time.Duration
+}
+
+// NewQueue returns a Queue backed by the given database.
+func NewQueue(db *sql.DB) *Queue {
+	return &Queue{db: db, LockTimeout: 15 * time.Minute}
+}
+
+// Enqueue adds a job of the given kind with a JSON-encoded payload to the
+// queue, to be run no earlier than runAt. The job is enqueued in the
+// transaction carried by ctx, if any, so that it is only run if the
+// transaction commits.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}, runAt time.Time) (int64, error) {
+	bz, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	var id int64
+	err = db.Conn(ctx, q.db).QueryRowContext(ctx, `
+		INSERT INTO jobs (kind, payload, status, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		kind, bz, StatusPending, DefaultMaxAttempts, runAt.UTC(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue %s job: %w", kind, err)
+	}
+
+	return id, nil
+}
+
+// claim atomically claims the next runnable job, returning sql.ErrNoRows when
+// the queue is empty.
+func (q *Queue) claim(ctx context.Context) (Job, error) {
+	var (
+		j         Job
+		lastError sql.NullString
+	)
+
+	err := q.db.QueryRowContext(ctx, `
+		UPDATE jobs SET status = $1, attempts = attempts + 1, locked_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = $2 AND run_at <= NOW())
+				OR (status = $1 AND locked_at < NOW() - $3 * INTERVAL '1 second')
+			ORDER BY run_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, kind, payload, status, attempts, max_attempts, last_error, run_at, created_at`,
+		StatusRunning, StatusPending, q.LockTimeout.Seconds(),
+	).Scan(&j.ID, &j.Kind, &j.Payload, &j.Status, &j.Attempts, &j.MaxAttempts, &lastError, &j.RunAt, &j.CreatedAt)
+
+	j.LastError = lastError.String
+	return j, err
+}
+
+// complete marks a job as succeeded.
+func (q *Queue) complete(ctx context.Context, j Job) error {
+	_, err := q.db.ExecContext(ctx, `UPDATE jobs SET status = $1, locked_at = NULL WHERE id = $2`, StatusSucceeded, j.ID)
+	return err
+}
+
+// fail records a failed attempt of a job, rescheduling it with exponential
+// backoff or moving it to the dead-letter state once its attempts are
+// exhausted.
+func (q *Queue) fail(ctx context.Context, j Job, jobErr error) error {
+	status, runAt := StatusPending, time.Now().UTC().Add(Backoff(j.Attempts))
+	if j.Attempts >= j.MaxAttempts {
+		status = StatusDead
+	}
+
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE jobs SET status = $1, run_at = $2, last_error = $3, locked_at = NULL
+		WHERE id = $4`,
+		status, runAt, jobErr.Error(), j.ID,
+	)
+	return err
+}
+
+// Dead returns up to limit jobs in the dead-letter state, most recent first.
+func (q *Queue) Dead(ctx context.Context, limit int) ([]Job, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, kind, payload, status, attempts, max_attempts, last_error, run_at, created_at
+		FROM jobs WHERE status = $1
+		ORDER BY run_at DESC
+		LIMIT $2`,
+		StatusDead, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var (
+			j         Job
+			lastError sql.NullString
+		)
+
+		if err := rows.Scan(&j.ID, &j.Kind, &j.Payload, &j.Status, &j.Attempts, &j.MaxAttempts, &lastError, &j.RunAt, &j.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		j.LastError = lastError.String
+		jobs = append(jobs, j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Retry moves a dead job back to the queue with a fresh set of attempts.
+// The job is retried in the transaction carried by ctx, if any.
+func (q *Queue) Retry(ctx context.Context, id int64) error {
+	res, err := db.Conn(ctx, q.db).ExecContext(ctx, `
+		UPDATE jobs SET status = $1, attempts = 0, run_at = NOW()
+		WHERE id = $2 AND status = $3`,
+		StatusPending, id, StatusDead,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("%w with ID %d", ErrNoDeadJob, id)
+	}
+
+	return nil
+}
+
+// Backoff returns the delay before retrying a job after the given number of
+// attempts: 2^attempts seconds, capped at one hour.
+func Backoff(attempts int) time.Duration {
+	if attempts > 12 {
+		return time.Hour
+	}
+
+	d := time.Duration(1<<uint(attempts)) * time.Second
+	if d > time.Hour {
+		return time.Hour
+	}
+
+	return d
+}
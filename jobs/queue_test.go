@@ -0,0 +1,134 @@
+package jobs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/jobs"
+	"github.com/cosmos/atlas/testutil"
+)
+
+func TestQueue(t *testing.T) {
+	sqlDB, _ := testutil.NewDB(t)
+	ctx := context.Background()
+	q := jobs.NewQueue(sqlDB)
+
+	status := func(id int64) string {
+		t.Helper()
+
+		var s string
+		if err := sqlDB.QueryRowContext(ctx, `SELECT status FROM jobs WHERE id = $1`, id).Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+
+		return s
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.Enqueue(db.WithTx(ctx, tx), "succeeds", nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := sqlDB.QueryRowContext(ctx, `SELECT COUNT(*) FROM jobs`).Scan(&n); err != nil || n != 0 {
+		t.Fatalf("expected a job enqueued in a rolled back transaction to be discarded, got %d, %v", n, err)
+	}
+
+	succeeds, err := q.Enqueue(ctx, "succeeds", jobs.DocsPayload{ModuleVersionID: 7}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fails, err := q.Enqueue(ctx, "fails", struct{}{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	later, err := q.Enqueue(ctx, "succeeds", struct{}{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sqlDB.ExecContext(ctx, `UPDATE jobs SET max_attempts = 1 WHERE id = $1`, fails); err != nil {
+		t.Fatal(err)
+	}
+
+	payloads := make(chan jobs.DocsPayload, 1)
+	p := jobs.NewPool(q, 2)
+	p.Register("succeeds", func(_ context.Context, j jobs.Job) error {
+		if j.ID != succeeds {
+			return nil
+		}
+
+		var payload jobs.DocsPayload
+		if err := json.Unmarshal(j.Payload, &payload); err != nil {
+			return err
+		}
+
+		payloads <- payload
+		return nil
+	})
+	p.Register("fails", func(context.Context, jobs.Job) error { return errors.New("upstream unavailable") })
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		p.Run(runCtx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for status(succeeds) != jobs.StatusSucceeded || status(fails) != jobs.StatusDead {
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatalf("jobs not run: %s, %s", status(succeeds), status(fails))
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	if got := <-payloads; got.ModuleVersionID != 7 {
+		t.Errorf("expected the job payload, got %+v", got)
+	}
+
+	if s := status(later); s != jobs.StatusPending {
+		t.Errorf("expected a job scheduled later not to run, got %s", s)
+	}
+
+	dead, err := q.Dead(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dead) != 1 || dead[0].ID != fails || dead[0].Attempts != 1 || dead[0].LastError != "upstream unavailable" {
+		t.Fatalf("expected the failed job in the dead-letter state, got %+v", dead)
+	}
+
+	if err := q.Retry(ctx, fails); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := status(fails); s != jobs.StatusPending {
+		t.Errorf("expected a retried job to be pending, got %s", s)
+	}
+
+	if err := q.Retry(ctx, fails); !errors.Is(err, jobs.ErrNoDeadJob) {
+		t.Errorf("expected %v retrying a pending job, got %v", jobs.ErrNoDeadJob, err)
+	}
+}
@@ -0,0 +1,186 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Kinds of recurring jobs run by the scheduler.
+const (
+	KindAggregateStats         = "aggregate_stats"
+	KindSyncAdvisories         = "sync_advisories"
+	KindCheckRepoHealth        = "check_repo_health"
+	KindPurgeDeleted           = "purge_deleted"
+	KindSyncUpstreams          = "sync_upstreams"
+	KindGenerateSitemap        = "generate_sitemap"
+	KindSyncChains             = "sync_chains"
+	KindComputeScores          = "compute_scores"
+	KindDetectAnomalies        = "detect_anomalies"
+	KindLogChecksums           = "log_checksums"
+	KindPollIssues             = "poll_issues"
+	KindComputeRecommendations = "compute_recommendations"
+)
+
+// Kinds of one-off jobs enqueued by the registry.
+const (
+	// KindExtractDocs extracts the documentation of a published version,
+	// with a DocsPayload.
+	KindExtractDocs = "extract_docs"
+
+	// KindVerifyTag verifies a published version against the tag of its
+	// repository, with a VerifyTagPayload.
+	KindVerifyTag = "verify_tag"
+)
+
+// DocsPayload defines the payload of a KindExtractDocs job.
+type DocsPayload struct {
+	ModuleVersionID int `json:"module_version_id"`
+}
+
+// VerifyTagPayload defines the payload of a KindVerifyTag job. Promoted marks
+// versions promoted from a pre-release, whose manifest was submitted for the
+// pre-release's tag.
+type VerifyTagPayload struct {
+	ModuleVersionID int  `json:"module_version_id"`
+	Promoted        bool `json:"promoted,omitempty"`
+}
+
+const schedulerPollInterval = 30 * time.Second
+
+// Schedule defines a recurring job, enqueued on the Queue whenever its
+// standard five-field cron expression fires.
+type Schedule struct {
+	Name string `yaml:"name"`
+	Spec string `yaml:"spec"`
+	Kind string `yaml:"kind"`
+}
+
+// DefaultSchedules returns the default recurring jobs of a registry.
+func DefaultSchedules() []Schedule {
+	return []Schedule{
+		{Name: "stats", Spec: "*/15 * * * *", Kind: KindAggregateStats},
+		{Name: "advisories", Spec: "0 * * * *", Kind: KindSyncAdvisories},
+		{Name: "repo-health", Spec: "0 3 * * *", Kind: KindCheckRepoHealth},
+		{Name: "purge", Spec: "30 4 * * *", Kind: KindPurgeDeleted},
+		{Name: "sitemap", Spec: "45 * * * *", Kind: KindGenerateSitemap},
+		{Name: "chains", Spec: "15 2 * * *", Kind: KindSyncChains},
+		{Name: "scores", Spec: "0 5 * * *", Kind: KindComputeScores},
+		{Name: "anomalies", Spec: "10 * * * *", Kind: KindDetectAnomalies},
+		{Name: "checksums", Spec: "*/5 * * * *", Kind: KindLogChecksums},
+		{Name: "issues", Spec: "20 */6 * * *", Kind: KindPollIssues},
+		{Name: "recommendations", Spec: "30 5 * * *", Kind: KindComputeRecommendations},
+	}
+}
+
+// Scheduler defines a component that enqueues recurring jobs. Every server
+// replica may run a Scheduler; each firing of a schedule is claimed by
+// atomically advancing its row in the schedules table, so exactly one replica
+// enqueues the corresponding job.
+type Scheduler struct {
+	queue     *Queue
+	schedules []Schedule
+	specs     map[string]cron.Schedule
+}
+
+// NewScheduler returns a Scheduler enqueueing the given schedules on the
+// Queue. It returns an error if any cron expression is invalid.
+func NewScheduler(queue *Queue, schedules []Schedule) (*Scheduler, error) {
+	specs := make(map[string]cron.Schedule, len(schedules))
+
+	for _, s := range schedules {
+		spec, err := cron.ParseStandard(s.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression for schedule %s: %w", s.Name, err)
+		}
+
+		specs[s.Name] = spec
+	}
+
+	return &Scheduler{queue: queue, schedules: schedules, specs: specs}, nil
+}
+
+// Run registers the schedules and enqueues due jobs until the context is
+// cancelled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.register(ctx, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(schedulerPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, sched := range s.schedules {
+			if err := s.fire(ctx, sched, time.Now().UTC()); err != nil && ctx.Err() == nil {
+				log.Printf("failed to run schedule %s: %v", sched.Name, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// register inserts any schedule not yet known, updating the next run time of
+// schedules whose cron expression changed.
+func (s *Scheduler) register(ctx context.Context, now time.Time) error {
+	for _, sched := range s.schedules {
+		_, err := s.queue.db.ExecContext(ctx, `
+			INSERT INTO schedules (name, spec, kind, next_run_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (name) DO UPDATE
+			SET spec = EXCLUDED.spec, kind = EXCLUDED.kind,
+				next_run_at = CASE WHEN schedules.spec = EXCLUDED.spec THEN schedules.next_run_at ELSE EXCLUDED.next_run_at END`,
+			sched.Name, sched.Spec, sched.Kind, s.specs[sched.Name].Next(now),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to register schedule %s: %w", sched.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// fire enqueues the job of a schedule if it is due. Advancing the schedule and
+// enqueueing its job happen in a single transaction, and only the replica
+// whose UPDATE matches the due row proceeds.
+func (s *Scheduler) fire(ctx context.Context, sched Schedule, now time.Time) error {
+	tx, err := s.queue.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	var name string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE schedules SET next_run_at = $1, last_run_at = $2
+		WHERE name = $3 AND next_run_at <= $2
+		RETURNING name`,
+		s.specs[sched.Name].Next(now), now, sched.Name,
+	).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO jobs (kind, payload, status, max_attempts, run_at)
+		VALUES ($1, '{}', $2, $3, $4)`,
+		sched.Kind, StatusPending, DefaultMaxAttempts, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
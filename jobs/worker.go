@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var errNoHandler = errors.New("no handler registered for job kind")
+
+// Handler defines a function that performs a job. A returned error causes the
+// job to be retried with backoff.
+type Handler func(ctx context.Context, j Job) error
+
+// Pool defines a pool of workers consuming a Queue.
+type Pool struct {
+	queue        *Queue
+	handlers     map[string]Handler
+	concurrency  int
+	pollInterval time.Duration
+}
+
+// NewPool returns a worker Pool of the given concurrency consuming the Queue.
+func NewPool(queue *Queue, concurrency int) *Pool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Pool{
+		queue:        queue,
+		handlers:     make(map[string]Handler),
+		concurrency:  concurrency,
+		pollInterval: time.Second,
+	}
+}
+
+// Register registers the Handler of a job kind. It must be called before Run.
+func (p *Pool) Register(kind string, h Handler) {
+	p.handlers[kind] = h
+}
+
+// Run starts the workers and blocks until the context is cancelled and every
+// in-flight job has finished.
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (p *Pool) work(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		j, err := p.queue.claim(ctx)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.pollInterval):
+			}
+
+			continue
+
+		case err != nil:
+			if ctx.Err() == nil {
+				log.Printf("failed to claim job: %v", err)
+			}
+
+			continue
+		}
+
+		p.process(j)
+	}
+}
+
+// process runs a claimed job to completion. The job's outcome is recorded with
+// a background context so that shutdown does not leave jobs marked running.
+func (p *Pool) process(j Job) {
+	ctx := context.Background()
+
+	err := p.run(ctx, j)
+	if err == nil {
+		err = p.queue.complete(ctx, j)
+	} else {
+		log.Printf("job %d (%s) attempt %d failed: %v", j.ID, j.Kind, j.Attempts, err)
+		err = p.queue.fail(ctx, j, err)
+	}
+
+	if err != nil {
+		log.Printf("failed to record outcome of job %d: %v", j.ID, err)
+	}
+}
+
+func (p *Pool) run(ctx context.Context, j Job) (err error) {
+	h, ok := p.handlers[j.Kind]
+	if !ok {
+		return fmt.Errorf("%w: %s", errNoHandler, j.Kind)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return h(ctx, j)
+}
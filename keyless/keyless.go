@@ -0,0 +1,66 @@
+package keyless
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/go-oidc"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// GitHubActionsIssuer defines the OIDC issuer of GitHub Actions ID tokens.
+const GitHubActionsIssuer = "https://token.actions.githubusercontent.com"
+
+// Claims defines the subset of GitHub Actions ID token claims used to
+// authorize keyless publishing.
+type Claims struct {
+	Repository string `json:"repository"`
+	Workflow   string `json:"workflow"`
+	Ref        string `json:"ref"`
+	Actor      string `json:"actor"`
+}
+
+// Verifier verifies GitHub Actions OIDC ID tokens.
+type Verifier struct {
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewVerifier returns a Verifier that checks ID tokens issued by GitHub
+// Actions for the given audience.
+func NewVerifier(ctx context.Context, audience string) (*Verifier, error) {
+	provider, err := oidc.NewProvider(ctx, GitHubActionsIssuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+
+	return &Verifier{verifier: provider.Verifier(&oidc.Config{ClientID: audience})}, nil
+}
+
+// Verify verifies the signature, issuer, audience and expiry of a raw ID token
+// and returns its claims.
+func (v *Verifier) Verify(ctx context.Context, rawToken string) (Claims, error) {
+	token, err := v.verifier.Verify(ctx, rawToken)
+	if err != nil {
+		return Claims{}, fmt.Errorf("failed to verify ID token: %w", err)
+	}
+
+	var claims Claims
+	if err := token.Claims(&claims); err != nil {
+		return Claims{}, fmt.Errorf("failed to decode ID token claims: %w", err)
+	}
+
+	return claims, nil
+}
+
+// Authorize returns an error if the claims are not permitted to publish the
+// given module under any of the module's trust policies.
+func Authorize(m module.Module, policies []module.TrustPolicy, claims Claims) error {
+	for _, tp := range policies {
+		if tp.Allows(m, claims.Repository, claims.Workflow, claims.Ref) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no trust policy of module %s permits publishing from %s", m.Name, claims.Repository)
+}
@@ -0,0 +1,143 @@
+package keyless
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// staticKeySet verifies ID tokens against a single RSA key instead of the
+// keys published by the issuer.
+type staticKeySet struct {
+	key *rsa.PublicKey
+}
+
+func (ks staticKeySet) VerifySignature(_ context.Context, jwt string) ([]byte, error) {
+	jws, err := jose.ParseSigned(jwt)
+	if err != nil {
+		return nil, err
+	}
+
+	return jws.Verify(ks.key)
+}
+
+func TestVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &Verifier{verifier: oidc.NewVerifier(GitHubActionsIssuer, staticKeySet{key: &key.PublicKey}, &oidc.Config{ClientID: "atlas"})}
+
+	sign := func(k *rsa.PrivateKey, issuer, audience string, expiry time.Time) string {
+		t.Helper()
+
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: k}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		payload, err := json.Marshal(map[string]interface{}{
+			"iss":        issuer,
+			"aud":        audience,
+			"sub":        "repo:example/liquidity:ref:refs/tags/v1.2.0",
+			"exp":        expiry.Unix(),
+			"iat":        time.Now().Add(-time.Minute).Unix(),
+			"repository": "example/liquidity",
+			"workflow":   "release",
+			"ref":        "refs/tags/v1.2.0",
+			"actor":      "alice",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		jws, err := signer.Sign(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err := jws.CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return raw
+	}
+
+	valid := time.Now().Add(time.Hour)
+
+	claims, err := v.Verify(context.Background(), sign(key, GitHubActionsIssuer, "atlas", valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Claims{Repository: "example/liquidity", Workflow: "release", Ref: "refs/tags/v1.2.0", Actor: "alice"}
+	if claims != expected {
+		t.Errorf("expected claims %+v, got %+v", expected, claims)
+	}
+
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-token"},
+		{"other key", sign(other, GitHubActionsIssuer, "atlas", valid)},
+		{"other issuer", sign(key, "https://accounts.example.com", "atlas", valid)},
+		{"other audience", sign(key, GitHubActionsIssuer, "sigstore", valid)},
+		{"expired", sign(key, GitHubActionsIssuer, "atlas", time.Now().Add(-time.Hour))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := v.Verify(context.Background(), tc.token); err == nil {
+				t.Error("expected the ID token to be rejected")
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	m := module.Module{Name: "liquidity", Repo: "https://github.com/example/liquidity"}
+
+	policies := []module.TrustPolicy{
+		{Repository: "example/liquidity", Workflow: "release", Ref: "refs/tags/v*"},
+		{Repository: "example/liquidity", Workflow: "nightly", Ref: "refs/heads/main"},
+	}
+
+	testCases := []struct {
+		name     string
+		policies []module.TrustPolicy
+		claims   Claims
+		allowed  bool
+	}{
+		{"release tag", policies, Claims{Repository: "example/liquidity", Workflow: "release", Ref: "refs/tags/v1.2.0"}, true},
+		{"nightly main", policies, Claims{Repository: "Example/Liquidity", Workflow: "nightly", Ref: "refs/heads/main"}, true},
+		{"release branch", policies, Claims{Repository: "example/liquidity", Workflow: "release", Ref: "refs/heads/main"}, false},
+		{"other workflow", policies, Claims{Repository: "example/liquidity", Workflow: "test", Ref: "refs/tags/v1.2.0"}, false},
+		{"fork", policies, Claims{Repository: "mallory/liquidity", Workflow: "release", Ref: "refs/tags/v1.2.0"}, false},
+		{"no policies", nil, Claims{Repository: "example/liquidity", Workflow: "release", Ref: "refs/tags/v1.2.0"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Authorize(m, tc.policies, tc.claims)
+			if tc.allowed != (err == nil) {
+				t.Errorf("expected allowed: %v, got %v", tc.allowed, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// DefaultTimeout defines the default timeout of a single link check.
+const DefaultTimeout = 10 * time.Second
+
+// Check verifies that the given URL is reachable. It issues a HEAD request,
+// falling back to GET for servers that do not support HEAD, and returns the
+// resulting link status.
+func Check(ctx context.Context, client *http.Client, url string) string {
+	if url == "" {
+		return module.LinkStatusOK
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	code, err := do(ctx, client, http.MethodHead, url)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented) {
+		code, err = do(ctx, client, http.MethodGet, url)
+	}
+
+	switch {
+	case err != nil:
+		return module.LinkStatusUnreachable
+
+	case code == http.StatusNotFound || code == http.StatusGone:
+		return module.LinkStatusNotFound
+
+	case code >= 400:
+		return module.LinkStatusUnreachable
+
+	default:
+		return module.LinkStatusOK
+	}
+}
+
+// CheckModule checks the Repo and Homepage URLs of a Module and records the
+// worst resulting status on the module.
+func CheckModule(ctx context.Context, client *http.Client, m *module.Module) {
+	status := module.LinkStatusOK
+
+	for _, url := range []string{m.Repo, m.Homepage} {
+		switch s := Check(ctx, client, url); s {
+		case module.LinkStatusNotFound:
+			status = s
+
+		case module.LinkStatusUnreachable:
+			if status == module.LinkStatusOK {
+				status = s
+			}
+		}
+	}
+
+	m.LinkStatus = status
+	m.LinksCheckedAt = time.Now().UTC()
+}
+
+func do(ctx context.Context, client *http.Client, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,98 @@
+package module
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Advisory severities.
+const (
+	SeverityLow      = "low"
+	SeverityModerate = "moderate"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+var (
+	cveRegex  = regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)
+	ghsaRegex = regexp.MustCompile(`^GHSA(-[23456789cfghjmpqrvwx]{4}){3}$`)
+)
+
+// Advisory defines a security advisory published by a Module owner against a
+// range of the module's versions.
+type Advisory struct {
+	ID               int       `json:"-" yaml:"-" db:"id"`
+	ModuleID         int       `json:"-" yaml:"-" db:"module_id"`
+	Identifier       string    `json:"identifier" yaml:"identifier" db:"identifier"`
+	AffectedVersions string    `json:"affected_versions" yaml:"affected_versions" db:"affected_versions"`
+	Severity         string    `json:"severity" yaml:"severity" db:"severity"`
+	Description      string    `json:"description" yaml:"description" db:"description"`
+	Resolved         bool      `json:"resolved" yaml:"resolved" db:"resolved"`
+	CreatedAt        time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+}
+
+// Validate performs basic validation of an Advisory. The identifier must be
+// a CVE or GHSA ID and the affected versions must be a valid semantic
+// version constraint.
+func (a Advisory) Validate() error {
+	v := &validator{}
+
+	if !cveRegex.MatchString(a.Identifier) && !ghsaRegex.MatchString(a.Identifier) {
+		v.fail("identifier", ErrCodeInvalidValue, "must be a CVE or GHSA identifier")
+	}
+
+	v.oneOf("severity", a.Severity, SeverityLow, SeverityModerate, SeverityHigh, SeverityCritical)
+	v.constraint("affected_versions", a.AffectedVersions)
+	v.required("description", a.Description)
+
+	return v.err()
+}
+
+// URL returns the URL of the Advisory's public CVE or GHSA record.
+func (a Advisory) URL() string {
+	if ghsaRegex.MatchString(a.Identifier) {
+		return "https://github.com/advisories/" + a.Identifier
+	}
+
+	return "https://nvd.nist.gov/vuln/detail/" + a.Identifier
+}
+
+// Affects returns true if the Advisory is open and the given version falls
+// within its affected version range.
+func (a Advisory) Affects(version string) (bool, error) {
+	if a.Resolved {
+		return false, nil
+	}
+
+	c, err := semver.NewConstraint(a.AffectedVersions)
+	if err != nil {
+		return false, fmt.Errorf("invalid affected versions %q: %w", a.AffectedVersions, err)
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	return c.Check(v), nil
+}
+
+// Affecting returns the open advisories affecting the given version.
+func Affecting(version string, advisories []Advisory) ([]Advisory, error) {
+	var affected []Advisory
+	for _, a := range advisories {
+		ok, err := a.Affects(version)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			affected = append(affected, a)
+		}
+	}
+
+	return affected, nil
+}
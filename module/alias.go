@@ -0,0 +1,49 @@
+package module
+
+import (
+	"fmt"
+	"time"
+)
+
+// ModuleAlias defines a previous name of a renamed Module. Requests for an
+// alias are permanently redirected to the Module's current identity.
+type ModuleAlias struct {
+	ID        int       `json:"-" yaml:"-" db:"id"`
+	ModuleID  int       `json:"-" yaml:"-" db:"module_id"`
+	Name      string    `json:"name" yaml:"name" db:"name"`
+	Slug      string    `json:"-" yaml:"-" db:"slug"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+}
+
+// Rename changes the name of the Module. It returns the ModuleAlias to record
+// for the old name along with a ReleasedName that holds the old name for the
+// module's owner, blocking its re-registration by others during the policy
+// cooldown.
+func (m *Module) Rename(name string, now time.Time) (ModuleAlias, ReleasedName, error) {
+	if Slug(name) == m.Slug {
+		return ModuleAlias{}, ReleasedName{}, fmt.Errorf("module %s already has name %s", m.Name, name)
+	}
+
+	renamed := *m
+	renamed.Name = name
+	renamed.Slug = Slug(name)
+
+	if err := renamed.Validate(); err != nil {
+		return ModuleAlias{}, ReleasedName{}, err
+	}
+
+	alias := ModuleAlias{
+		ModuleID:  m.ID,
+		Name:      m.Name,
+		Slug:      m.Slug,
+		CreatedAt: now,
+	}
+	released := ReleasedName{
+		Name:          m.Name,
+		PreviousOwner: m.Author,
+		ReleasedAt:    now,
+	}
+
+	*m = renamed
+	return alias, released, nil
+}
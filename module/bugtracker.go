@@ -0,0 +1,124 @@
+package module
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Git providers whose repositories have a derivable bug tracker.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+type (
+	// HostedRepo defines a Module repository hosted on a supported Git
+	// provider.
+	HostedRepo struct {
+		Provider string
+		Host     string
+
+		// Path is the repository path on the host, e.g. owner/repo on GitHub or
+		// group/subgroup/project on GitLab.
+		Path string
+	}
+
+	// IssueStats defines the open issues and last issue activity of a Module's
+	// repository, as last polled from its Git provider. They are surfaced as a
+	// maintenance signal.
+	IssueStats struct {
+		OpenIssues     int       `json:"open_issues" yaml:"-" db:"open_issues"`
+		LastActivityAt time.Time `json:"last_activity_at" yaml:"-" db:"issues_active_at"`
+		CheckedAt      time.Time `json:"checked_at" yaml:"-" db:"issues_checked_at"`
+	}
+)
+
+// ParseHostedRepo parses a repository URL hosted on GitHub or GitLab,
+// returning false for any other host or a URL without a repository path.
+func ParseHostedRepo(repo string) (HostedRepo, bool) {
+	u, err := url.Parse(repo)
+	if err != nil || u.Host == "" {
+		return HostedRepo{}, false
+	}
+
+	host := strings.ToLower(u.Host)
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+
+	switch host {
+	case "github.com":
+		if strings.Count(path, "/") != 1 {
+			return HostedRepo{}, false
+		}
+
+		return HostedRepo{Provider: ProviderGitHub, Host: host, Path: path}, true
+
+	case "gitlab.com":
+		// GitLab subpages (e.g. group/project/-/tree/main) follow a /-/ segment
+		if i := strings.Index(path, "/-/"); i >= 0 {
+			path = path[:i]
+		}
+
+		if !strings.Contains(path, "/") {
+			return HostedRepo{}, false
+		}
+
+		return HostedRepo{Provider: ProviderGitLab, Host: host, Path: path}, true
+
+	default:
+		return HostedRepo{}, false
+	}
+}
+
+// IssuesURL returns the URL of the repository's issue tracker.
+func (r HostedRepo) IssuesURL() string {
+	if r.Provider == ProviderGitLab {
+		return "https://" + r.Host + "/" + r.Path + "/-/issues"
+	}
+
+	return "https://" + r.Host + "/" + r.Path + "/issues"
+}
+
+// NewIssueURL returns the URL at which issues of the repository are filed.
+func (r HostedRepo) NewIssueURL() string {
+	return r.IssuesURL() + "/new"
+}
+
+// ReleaseURL returns the URL of the repository's release of the given
+// version, whose notes are the version's changelog.
+func (r HostedRepo) ReleaseURL(version string) string {
+	if r.Provider == ProviderGitLab {
+		return "https://" + r.Host + "/" + r.Path + "/-/releases/" + url.PathEscape(version)
+	}
+
+	return "https://" + r.Host + "/" + r.Path + "/releases/tag/" + url.PathEscape(version)
+}
+
+// BugTracker returns the bug tracker of the Manifest. Unless the Manifest
+// defines both, the bug tracker URL and contact are derived from its
+// repository when hosted on GitHub or GitLab. It returns nil if the Manifest
+// defines no bug tracker and none can be derived.
+func (m Manifest) BugTracker() *Bug {
+	var b Bug
+	if m.Bugs != nil {
+		b = *m.Bugs
+	}
+
+	if b.URL == "" || b.Contact == "" {
+		if r, ok := ParseHostedRepo(m.Repo); ok {
+			if b.URL == "" {
+				b.URL = r.IssuesURL()
+			}
+
+			if b.Contact == "" {
+				b.Contact = r.NewIssueURL()
+			}
+		}
+	}
+
+	if b.URL == "" && b.Contact == "" {
+		return nil
+	}
+
+	return &b
+}
@@ -0,0 +1,62 @@
+package module
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Sources of chain usage records.
+const (
+	ChainSourceRegistry   = "registry"
+	ChainSourceSelfReport = "self_report"
+)
+
+type (
+	// Chain defines a live chain, as listed in the Cosmos chain registry.
+	Chain struct {
+		ID          int       `json:"-" yaml:"-" db:"id"`
+		Name        string    `json:"name" yaml:"name" db:"name"`
+		NetworkID   string    `json:"network_id" yaml:"network_id" db:"network_id"`
+		PrettyName  string    `json:"pretty_name,omitempty" yaml:"pretty_name,omitempty" db:"pretty_name"`
+		NetworkType string    `json:"network_type,omitempty" yaml:"network_type,omitempty" db:"network_type"`
+		Repo        string    `json:"repo,omitempty" yaml:"repo,omitempty" db:"repo"`
+		CreatedAt   time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at" yaml:"updated_at" db:"updated_at"`
+	}
+
+	// ChainModule records that a Chain includes a Module at a given version.
+	ChainModule struct {
+		ChainID   int       `json:"-" yaml:"-" db:"chain_id"`
+		ModuleID  int       `json:"-" yaml:"-" db:"module_id"`
+		Version   string    `json:"version" yaml:"version" db:"version"`
+		Source    string    `json:"source" yaml:"source" db:"source"`
+		UpdatedAt time.Time `json:"updated_at" yaml:"updated_at" db:"updated_at"`
+	}
+
+	// ChainUsage defines a Chain including a Module, as returned by the module
+	// chains endpoint.
+	ChainUsage struct {
+		Chain
+		Version string `json:"version" yaml:"version"`
+		Source  string `json:"source" yaml:"source"`
+	}
+)
+
+// GoModulePath returns the Go module path of a Module repository URL (e.g.
+// https://github.com/cosmos/cosmos-sdk.git yields github.com/cosmos/cosmos-sdk),
+// lower-cased so that requirements of a chain's go.mod can be matched against
+// registered modules. It returns an empty string for invalid URLs.
+func GoModulePath(repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if path == "" {
+		return ""
+	}
+
+	return strings.ToLower(u.Host + "/" + path)
+}
@@ -0,0 +1,26 @@
+package module
+
+import "time"
+
+// Kinds of registry changes recorded in the changes feed.
+const (
+	ChangeModuleCreated     = "module_created"
+	ChangeModuleUpdated     = "module_updated"
+	ChangeVersionPublished  = "version_published"
+	ChangeVersionYanked     = "version_yanked"
+	ChangeVersionUnyanked   = "version_unyanked"
+	ChangeAdvisoryPublished = "advisory_published"
+)
+
+// Change defines an entry of the ordered registry changes feed consumed by
+// mirrors, search indexers and analytics pipelines. Changes are recorded by
+// database triggers and their IDs increase monotonically, serving as the feed
+// cursor.
+type Change struct {
+	ID        int64     `json:"id" db:"id"`
+	Kind      string    `json:"kind" db:"kind"`
+	Module    string    `json:"module" db:"module_name"`
+	Version   string    `json:"version,omitempty" db:"version"`
+	Advisory  string    `json:"advisory,omitempty" db:"advisory"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,135 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Release channels, from most to least stable.
+const (
+	ChannelStable = "stable"
+	ChannelRC     = "rc"
+	ChannelBeta   = "beta"
+	ChannelAlpha  = "alpha"
+)
+
+// channelRanks orders the release channels by stability.
+var channelRanks = map[string]int{
+	ChannelStable: 3,
+	ChannelRC:     2,
+	ChannelBeta:   1,
+	ChannelAlpha:  0,
+}
+
+// ValidChannel returns true if channel is a known release channel.
+func ValidChannel(channel string) bool {
+	_, ok := channelRanks[channel]
+	return ok
+}
+
+// Channel classifies a version into a release channel from its semver
+// pre-release tag: versions without one are stable, and pre-releases whose tag
+// starts with "rc" or "beta" are in the respective channel. Any other
+// pre-release (e.g. -alpha.1, -dev) is in the alpha channel.
+func Channel(version string) (string, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	pre := strings.ToLower(v.Prerelease())
+	switch {
+	case pre == "":
+		return ChannelStable, nil
+	case strings.HasPrefix(pre, ChannelRC):
+		return ChannelRC, nil
+	case strings.HasPrefix(pre, ChannelBeta):
+		return ChannelBeta, nil
+	default:
+		return ChannelAlpha, nil
+	}
+}
+
+// Latest returns the greatest resolvable version in the given channel or any
+// more stable channel, so that e.g. the latest beta is superseded by a newer
+// rc or stable release. It returns ErrNoMatchingVersion if there is none.
+func Latest(channel string, versions []ModuleVersion) (ModuleVersion, error) {
+	minRank, ok := channelRanks[channel]
+	if !ok {
+		return ModuleVersion{}, fmt.Errorf("invalid channel %q", channel)
+	}
+
+	var (
+		best    ModuleVersion
+		bestVer *semver.Version
+	)
+
+	for _, mv := range versions {
+		if !mv.Resolvable() {
+			continue
+		}
+
+		c, err := Channel(mv.Version)
+		if err != nil || channelRanks[c] < minRank {
+			continue
+		}
+
+		v := semver.MustParse(mv.Version)
+		if bestVer == nil || v.GreaterThan(bestVer) {
+			best, bestVer = mv, v
+		}
+	}
+
+	if bestVer == nil {
+		return ModuleVersion{}, fmt.Errorf("%w: channel %s", ErrNoMatchingVersion, channel)
+	}
+
+	return best, nil
+}
+
+// Promote returns the stable version promoted from a pre-release ModuleVersion,
+// e.g. v1.2.0 from v1.2.0-rc.1, sharing its artifact, checksum and manifest.
+// The stable version is neither verified nor signed, as its tag was never
+// checked and the pre-release's signature does not cover it; it must be
+// verified anew, e.g. with tagverify.Verifier.VerifyPromoted. It returns
+// ErrVersionConflict if the stable version already exists among the module's
+// existing versions.
+func (mv ModuleVersion) Promote(existing []ModuleVersion) (ModuleVersion, error) {
+	v, err := semver.NewVersion(mv.Version)
+	if err != nil {
+		return ModuleVersion{}, fmt.Errorf("invalid version %q: %w", mv.Version, err)
+	}
+
+	if v.Prerelease() == "" {
+		return ModuleVersion{}, fmt.Errorf("version %s is not a pre-release", mv.Version)
+	}
+
+	if !mv.Resolvable() {
+		return ModuleVersion{}, fmt.Errorf("cannot promote unreleased or yanked version %s", mv.Version)
+	}
+
+	version := fmt.Sprintf("%d.%d.%d", v.Major(), v.Minor(), v.Patch())
+	if strings.HasPrefix(mv.Version, "v") {
+		version = "v" + version
+	}
+
+	for _, e := range existing {
+		if e.Version == version {
+			return ModuleVersion{}, fmt.Errorf("%w: %s", ErrVersionConflict, version)
+		}
+	}
+
+	promoted := mv
+	promoted.ID = 0
+	promoted.Version = version
+	promoted.Manifest.Version = version
+	promoted.Downloads = 0
+	promoted.Verified = false
+	promoted.Signature = Signature{}
+	promoted.CreatedAt = time.Time{}
+
+	return promoted, nil
+}
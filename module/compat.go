@@ -0,0 +1,96 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// SDKCompatibility defines the Cosmos SDK releases a ModuleVersion declares
+// compatibility with.
+type SDKCompatibility struct {
+	Version     string   `json:"version" yaml:"version"`
+	Constraint  string   `json:"sdk_compat" yaml:"sdk_compat"`
+	SDKVersions []string `json:"sdk_versions" yaml:"sdk_versions"`
+}
+
+// CompatibleWithSDK returns true if the ModuleVersion declares compatibility
+// with the given Cosmos SDK version. Versions without a declared constraint
+// are never considered compatible.
+func (mv ModuleVersion) CompatibleWithSDK(sdkVersion string) (bool, error) {
+	if mv.SDKCompat == "" {
+		return false, nil
+	}
+
+	c, err := semver.NewConstraint(mv.SDKCompat)
+	if err != nil {
+		return false, fmt.Errorf("invalid SDK constraint %q: %w", mv.SDKCompat, err)
+	}
+
+	v, err := semver.NewVersion(sdkVersion)
+	if err != nil {
+		return false, fmt.Errorf("invalid SDK version %q: %w", sdkVersion, err)
+	}
+
+	return c.Check(v), nil
+}
+
+// LatestForSDK returns the greatest resolvable version compatible with the
+// given Cosmos SDK version, returning ErrNoMatchingVersion if there is none.
+// Versions with an invalid version or constraint are skipped.
+func LatestForSDK(sdkVersion string, versions []ModuleVersion) (ModuleVersion, error) {
+	if _, err := semver.NewVersion(sdkVersion); err != nil {
+		return ModuleVersion{}, fmt.Errorf("invalid SDK version %q: %w", sdkVersion, err)
+	}
+
+	var (
+		best    ModuleVersion
+		bestVer *semver.Version
+	)
+
+	for _, mv := range versions {
+		if !mv.Resolvable() {
+			continue
+		}
+
+		v, err := semver.NewVersion(mv.Version)
+		if err != nil {
+			continue
+		}
+
+		if ok, err := mv.CompatibleWithSDK(sdkVersion); err != nil || !ok {
+			continue
+		}
+
+		if bestVer == nil || v.GreaterThan(bestVer) {
+			best, bestVer = mv, v
+		}
+	}
+
+	if bestVer == nil {
+		return ModuleVersion{}, fmt.Errorf("%w: Cosmos SDK %s", ErrNoMatchingVersion, sdkVersion)
+	}
+
+	return best, nil
+}
+
+// CompatibilityMatrix returns, for every version of a module, the given Cosmos
+// SDK releases it declares compatibility with. Versions with an invalid
+// constraint are reported with no compatible releases.
+func CompatibilityMatrix(versions []ModuleVersion, sdkReleases []string) []SDKCompatibility {
+	matrix := make([]SDKCompatibility, 0, len(versions))
+
+	for _, mv := range versions {
+		entry := SDKCompatibility{Version: mv.Version, Constraint: mv.SDKCompat, SDKVersions: []string{}}
+
+		for _, sdk := range sdkReleases {
+			if ok, err := mv.CompatibleWithSDK(sdk); err == nil && ok {
+				entry.SDKVersions = append(entry.SDKVersions, sdk)
+			}
+		}
+
+		matrix = append(matrix, entry)
+	}
+
+	return matrix
+}
@@ -0,0 +1,73 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// ModuleDependency defines a directed edge in the module dependency graph,
+// where a Module depends on another registered Module under a given semantic
+// version constraint (e.g. "^0.40", ">= 1.2.0, < 2.0.0").
+type ModuleDependency struct {
+	ID                int    `json:"-" yaml:"-" toml:"-" db:"id"`
+	ModuleID          int    `json:"-" yaml:"-" toml:"-" db:"module_id"`
+	DependencyID      int    `json:"-" yaml:"-" toml:"-" db:"dependency_id"`
+	Name              string `json:"name" yaml:"name" toml:"name" db:"-"`
+	VersionConstraint string `json:"version" yaml:"version" toml:"version" db:"version_constraint"`
+}
+
+// Validate performs basic validation of a ModuleDependency. It returns an
+// error if the dependency does not reference a module or if the version
+// constraint cannot be parsed.
+func (md ModuleDependency) Validate() error {
+	v := &validator{}
+
+	if md.DependencyID == 0 {
+		v.required("name", md.Name)
+	}
+
+	if md.ModuleID != 0 && md.ModuleID == md.DependencyID {
+		v.fail("name", ErrCodeInvalidValue, "module cannot depend on itself")
+	}
+
+	v.constraint("version", md.VersionConstraint)
+
+	return v.err()
+}
+
+// Admits returns true if the given version satisfies the ModuleDependency's
+// version constraint.
+func (md ModuleDependency) Admits(version string) (bool, error) {
+	c, err := semver.NewConstraint(md.VersionConstraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid version constraint %q: %w", md.VersionConstraint, err)
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	return c.Check(v), nil
+}
+
+// ImpactedDependencies returns the subset of dependencies whose version
+// constraints resolve to the given version of a module. It allows evaluating
+// the blast radius of a bad release prior to yanking it.
+func ImpactedDependencies(version string, deps []ModuleDependency) ([]ModuleDependency, error) {
+	var impacted []ModuleDependency
+
+	for _, md := range deps {
+		ok, err := md.Admits(version)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			impacted = append(impacted, md)
+		}
+	}
+
+	return impacted, nil
+}
@@ -0,0 +1,69 @@
+package module
+
+import "time"
+
+// MaxDeprecationMessageLength bounds the message of a Deprecation.
+const MaxDeprecationMessageLength = 512
+
+// Deprecation defines the deprecation of a Module by its owners. ReplacedBy
+// optionally names a successor module that consumers should migrate to.
+type Deprecation struct {
+	ReplacedBy   string    `json:"replaced_by,omitempty" yaml:"replaced_by,omitempty"`
+	Message      string    `json:"message,omitempty" yaml:"message,omitempty"`
+	DeprecatedAt time.Time `json:"deprecated_at" yaml:"-"`
+}
+
+// Validate performs validation of a Deprecation of the named Module, which
+// may not be replaced by itself.
+func (d Deprecation) Validate(name string) error {
+	v := &validator{}
+
+	if d.ReplacedBy != "" && Slug(d.ReplacedBy) == Slug(name) {
+		v.fail("replaced_by", ErrCodeInvalidValue, "module cannot replace itself")
+	}
+
+	v.maxLength("message", d.Message, MaxDeprecationMessageLength)
+
+	return v.err()
+}
+
+// Deprecated returns true if the Module has been deprecated by its owners.
+func (m Module) Deprecated() bool {
+	return m.Deprecation != nil
+}
+
+// Deprecate deprecates the Module as of now. The successor is the Module
+// named by the Deprecation's ReplacedBy, if any; it may not be deprecated
+// itself, and a public Module may only be replaced by a public one so that
+// every consumer can follow the replacement.
+func (m *Module) Deprecate(d Deprecation, successor *Module, now time.Time) error {
+	if err := d.Validate(m.Name); err != nil {
+		return err
+	}
+
+	v := &validator{}
+	if successor != nil {
+		switch {
+		case successor.Deprecated():
+			v.fail("replaced_by", ErrCodeInvalidValue, "replacement module %s is deprecated", successor.Name)
+
+		case !m.Private() && successor.Private():
+			v.fail("replaced_by", ErrCodeInvalidValue, "public modules may only be replaced by public modules")
+		}
+
+		d.ReplacedBy = successor.Name
+	}
+
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	d.DeprecatedAt = now
+	m.Deprecation = &d
+	return nil
+}
+
+// Undeprecate lifts the deprecation of the Module.
+func (m *Module) Undeprecate() {
+	m.Deprecation = nil
+}
@@ -0,0 +1,147 @@
+package module
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+type (
+	// DependencyChange defines a dependency whose version constraint changed
+	// between two versions.
+	DependencyChange struct {
+		Name string `json:"name" yaml:"name"`
+		From string `json:"from" yaml:"from"`
+		To   string `json:"to" yaml:"to"`
+	}
+
+	// ChangelogEntry defines the release notes of a single version.
+	ChangelogEntry struct {
+		Version   string `json:"version" yaml:"version"`
+		Changelog string `json:"changelog" yaml:"changelog"`
+	}
+
+	// VersionDiff defines the changes between two published versions of a
+	// Module.
+	VersionDiff struct {
+		From                string             `json:"from" yaml:"from"`
+		To                  string             `json:"to" yaml:"to"`
+		AddedDependencies   []ModuleDependency `json:"added_dependencies" yaml:"added_dependencies"`
+		RemovedDependencies []ModuleDependency `json:"removed_dependencies" yaml:"removed_dependencies"`
+		ChangedDependencies []DependencyChange `json:"changed_dependencies" yaml:"changed_dependencies"`
+		AddedAuthors        []ManifestAuthor   `json:"added_authors" yaml:"added_authors"`
+		RemovedAuthors      []ManifestAuthor   `json:"removed_authors" yaml:"removed_authors"`
+		Changelog           []ChangelogEntry   `json:"changelog" yaml:"changelog"`
+	}
+)
+
+// Compare returns the changes between versions a and b of a module given all
+// of its published versions. The changelog contains the release notes of every
+// version after a up to and including b, newest first. ErrNoMatchingVersion is
+// returned if either version was not published.
+func Compare(versions []ModuleVersion, a, b string) (VersionDiff, error) {
+	from, fromVer, err := findVersion(versions, a)
+	if err != nil {
+		return VersionDiff{}, err
+	}
+
+	to, toVer, err := findVersion(versions, b)
+	if err != nil {
+		return VersionDiff{}, err
+	}
+
+	diff := VersionDiff{From: from.Version, To: to.Version}
+
+	fromDeps := make(map[string]ModuleDependency)
+	for _, md := range from.Manifest.Dependencies {
+		fromDeps[md.Name] = md
+	}
+
+	for _, md := range to.Manifest.Dependencies {
+		prev, ok := fromDeps[md.Name]
+		switch {
+		case !ok:
+			diff.AddedDependencies = append(diff.AddedDependencies, md)
+
+		case prev.VersionConstraint != md.VersionConstraint:
+			diff.ChangedDependencies = append(diff.ChangedDependencies, DependencyChange{
+				Name: md.Name,
+				From: prev.VersionConstraint,
+				To:   md.VersionConstraint,
+			})
+		}
+
+		delete(fromDeps, md.Name)
+	}
+
+	for _, md := range from.Manifest.Dependencies {
+		if _, ok := fromDeps[md.Name]; ok {
+			diff.RemovedDependencies = append(diff.RemovedDependencies, md)
+		}
+	}
+
+	diff.AddedAuthors = subtractAuthors(to.Manifest.Authors, from.Manifest.Authors)
+	diff.RemovedAuthors = subtractAuthors(from.Manifest.Authors, to.Manifest.Authors)
+
+	lo, hi := fromVer, toVer
+	if hi.LessThan(lo) {
+		lo, hi = hi, lo
+	}
+
+	var between []ModuleVersion
+	for _, mv := range versions {
+		v, err := semver.NewVersion(mv.Version)
+		if err != nil || !v.GreaterThan(lo) || v.GreaterThan(hi) {
+			continue
+		}
+
+		between = append(between, mv)
+	}
+
+	sort.Slice(between, func(i, j int) bool {
+		vi, _ := semver.NewVersion(between[i].Version)
+		vj, _ := semver.NewVersion(between[j].Version)
+		return vi.GreaterThan(vj)
+	})
+
+	for _, mv := range between {
+		if mv.Changelog != "" {
+			diff.Changelog = append(diff.Changelog, ChangelogEntry{Version: mv.Version, Changelog: mv.Changelog})
+		}
+	}
+
+	return diff, nil
+}
+
+func findVersion(versions []ModuleVersion, version string) (ModuleVersion, *semver.Version, error) {
+	target, err := semver.NewVersion(version)
+	if err != nil {
+		return ModuleVersion{}, nil, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	for _, mv := range versions {
+		if v, err := semver.NewVersion(mv.Version); err == nil && v.Equal(target) {
+			return mv, v, nil
+		}
+	}
+
+	return ModuleVersion{}, nil, fmt.Errorf("%w: version %s not found", ErrNoMatchingVersion, version)
+}
+
+// subtractAuthors returns the authors in a that are not in b, matched by name.
+func subtractAuthors(a, b []ManifestAuthor) []ManifestAuthor {
+	names := make(map[string]struct{}, len(b))
+	for _, author := range b {
+		names[author.Name] = struct{}{}
+	}
+
+	var out []ManifestAuthor
+	for _, author := range a {
+		if _, ok := names[author.Name]; !ok {
+			out = append(out, author)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,142 @@
+package module
+
+import (
+	"fmt"
+	"time"
+)
+
+// Discussion limits.
+const (
+	MaxQuestionTitleLength = 256
+	MaxDiscussionLength    = 16384
+)
+
+// Notification kinds.
+const (
+	NotificationQuestion = "question"
+	NotificationAnswer   = "answer"
+	NotificationAccepted = "accepted"
+	NotificationAnomaly  = "anomaly"
+
+	NotificationRemovalFiled    = "removal_filed"
+	NotificationRemovalResolved = "removal_resolved"
+)
+
+type (
+	// Question defines a support question asked by a User about a Module. The
+	// asker or an owner of the module may accept one of its answers.
+	Question struct {
+		ID               int       `json:"id" yaml:"id" db:"id"`
+		ModuleID         int       `json:"-" yaml:"-" db:"module_id"`
+		UserID           int       `json:"-" yaml:"-" db:"user_id"`
+		Title            string    `json:"title" yaml:"title" db:"title"`
+		Body             string    `json:"body" yaml:"body" db:"body"`
+		AcceptedAnswerID int       `json:"accepted_answer_id,omitempty" yaml:"accepted_answer_id,omitempty" db:"accepted_answer_id"`
+		Hidden           bool      `json:"-" yaml:"-" db:"hidden"`
+		CreatedAt        time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+		UpdatedAt        time.Time `json:"updated_at" yaml:"updated_at" db:"updated_at"`
+	}
+
+	// Answer defines an answer to a Question. Maintainer is set when the
+	// answer was given by an owner of the module.
+	Answer struct {
+		ID         int       `json:"id" yaml:"id" db:"id"`
+		QuestionID int       `json:"-" yaml:"-" db:"question_id"`
+		UserID     int       `json:"-" yaml:"-" db:"user_id"`
+		Body       string    `json:"body" yaml:"body" db:"body"`
+		Maintainer bool      `json:"maintainer" yaml:"maintainer" db:"maintainer"`
+		Hidden     bool      `json:"-" yaml:"-" db:"hidden"`
+		CreatedAt  time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+	}
+
+	// ModuleSubscription defines a User subscribed to the discussions of a
+	// Module.
+	ModuleSubscription struct {
+		ModuleID  int       `json:"-" yaml:"-" db:"module_id"`
+		UserID    int       `json:"-" yaml:"-" db:"user_id"`
+		CreatedAt time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+	}
+
+	// Notification defines a notification of discussion activity delivered to
+	// a User. Notifications are fanned out by database triggers to the owners
+	// and subscribers of the module and to the asker of the question. Owners
+	// are also notified of anomalous publish activity on their modules and of
+	// removal requests filed against them.
+	Notification struct {
+		ID         int        `json:"id" yaml:"id" db:"id"`
+		UserID     int        `json:"-" yaml:"-" db:"user_id"`
+		Kind       string     `json:"kind" yaml:"kind" db:"kind"`
+		ModuleID   int        `json:"-" yaml:"-" db:"module_id"`
+		QuestionID int        `json:"question_id,omitempty" yaml:"question_id,omitempty" db:"question_id"`
+		AnswerID   int        `json:"answer_id,omitempty" yaml:"answer_id,omitempty" db:"answer_id"`
+		AnomalyID  int        `json:"anomaly_id,omitempty" yaml:"anomaly_id,omitempty" db:"anomaly_id"`
+		RemovalID  int        `json:"removal_request_id,omitempty" yaml:"removal_request_id,omitempty" db:"removal_request_id"`
+		CreatedAt  time.Time  `json:"created_at" yaml:"created_at" db:"created_at"`
+		ReadAt     *time.Time `json:"read_at,omitempty" yaml:"read_at,omitempty" db:"read_at"`
+	}
+)
+
+// Validate performs validation of a new or edited Question.
+func (q Question) Validate() error {
+	v := &validator{}
+
+	if v.required("title", q.Title) {
+		v.maxLength("title", q.Title, MaxQuestionTitleLength)
+	}
+
+	if v.required("body", q.Body) {
+		v.maxLength("body", q.Body, MaxDiscussionLength)
+	}
+
+	return v.err()
+}
+
+// NewAnswer returns an Answer by the given User to the Question, flagged as a
+// maintainer answer if the user is an owner of the module.
+func NewAnswer(u User, q Question, m Module, contributors Contributors, body string, now time.Time) (Answer, error) {
+	if u.Banned {
+		return Answer{}, fmt.Errorf("user %d is banned", u.ID)
+	}
+
+	a := Answer{
+		QuestionID: q.ID,
+		UserID:     u.ID,
+		Body:       body,
+		Maintainer: isOwner(u, m, contributors),
+		CreatedAt:  now,
+	}
+
+	return a, a.Validate()
+}
+
+// Validate performs validation of a new or edited Answer.
+func (a Answer) Validate() error {
+	v := &validator{}
+
+	if v.required("body", a.Body) {
+		v.maxLength("body", a.Body, MaxDiscussionLength)
+	}
+
+	return v.err()
+}
+
+// Accept marks the Answer as the accepted answer of the Question on behalf of
+// the given User, who must be the asker or an owner of the module.
+func (q *Question) Accept(u User, m Module, contributors Contributors, a Answer, now time.Time) error {
+	if u.ID != q.UserID && !isOwner(u, m, contributors) {
+		return fmt.Errorf("user %d may not accept answers to question %d", u.ID, q.ID)
+	}
+
+	if a.QuestionID != q.ID {
+		return fmt.Errorf("answer %d does not belong to question %d", a.ID, q.ID)
+	}
+
+	q.AcceptedAnswerID = a.ID
+	q.UpdatedAt = now
+	return nil
+}
+
+// Answered returns true if the Question has an accepted answer.
+func (q Question) Answered() bool {
+	return q.AcceptedAnswerID != 0
+}
@@ -0,0 +1,43 @@
+package module
+
+import "strings"
+
+// Download clients used to attribute module fetches.
+const (
+	ClientCLI     = "cli"
+	ClientGoProxy = "go-proxy"
+	ClientWebUI   = "web"
+	ClientMirror  = "mirror"
+	ClientOther   = "other"
+)
+
+// ClientDownloads defines the number of downloads of a Module attributed to a
+// given client.
+type ClientDownloads struct {
+	ModuleID  int    `json:"-" yaml:"-" db:"module_id"`
+	Client    string `json:"client" yaml:"client" db:"client"`
+	Downloads int64  `json:"downloads" yaml:"downloads" db:"downloads"`
+}
+
+// ClientFromUserAgent attributes a download to a client based on the request's
+// User-Agent header.
+func ClientFromUserAgent(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case strings.HasPrefix(ua, "atlas-mirror"):
+		return ClientMirror
+
+	case strings.HasPrefix(ua, "atlas"):
+		return ClientCLI
+
+	case strings.HasPrefix(ua, "go/"), strings.Contains(ua, "goproxy"), strings.Contains(ua, "athens"):
+		return ClientGoProxy
+
+	case strings.HasPrefix(ua, "mozilla/"):
+		return ClientWebUI
+
+	default:
+		return ClientOther
+	}
+}
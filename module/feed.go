@@ -0,0 +1,38 @@
+package module
+
+import "time"
+
+type (
+	// DailyDownloads defines the number of downloads of a Module on a given day.
+	DailyDownloads struct {
+		ModuleID  int       `json:"-" yaml:"-" db:"module_id"`
+		Day       time.Time `json:"day" yaml:"day" db:"day"`
+		Downloads int64     `json:"downloads" yaml:"downloads" db:"downloads"`
+	}
+
+	// TrendingModule defines a Module's downloads over the last 7 days compared
+	// to the 7 days before.
+	TrendingModule struct {
+		ModuleID          int   `json:"-" yaml:"-" db:"module_id"`
+		RecentDownloads   int64 `json:"recent_downloads" yaml:"recent_downloads" db:"recent_downloads"`
+		PreviousDownloads int64 `json:"previous_downloads" yaml:"previous_downloads" db:"previous_downloads"`
+	}
+
+	// RecentModule defines the latest version publish of a Module.
+	RecentModule struct {
+		ModuleID    int       `json:"-" yaml:"-" db:"module_id"`
+		Version     string    `json:"version" yaml:"version" db:"version"`
+		PublishedAt time.Time `json:"published_at" yaml:"published_at" db:"published_at"`
+	}
+)
+
+// Growth returns the relative download growth of a TrendingModule, treating a
+// module with no previous downloads as growing from a single download.
+func (tm TrendingModule) Growth() float64 {
+	prev := tm.PreviousDownloads
+	if prev == 0 {
+		prev = 1
+	}
+
+	return float64(tm.RecentDownloads-tm.PreviousDownloads) / float64(prev)
+}
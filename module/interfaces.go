@@ -0,0 +1,163 @@
+package module
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of on-chain interfaces recorded per module version.
+const (
+	InterfaceService = "service"
+	InterfaceMsg     = "msg"
+	InterfaceIBCPort = "ibc_port"
+)
+
+var (
+	// protoNameRegex matches fully-qualified protobuf names (e.g.
+	// cosmos.staking.v1beta1.MsgDelegate).
+	protoNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)+$`)
+
+	// ibcPortRegex matches IBC port identifiers as defined by ICS-24.
+	ibcPortRegex = regexp.MustCompile(`^[a-zA-Z0-9._+\-#\[\]<>]{2,128}$`)
+)
+
+type (
+	// Interfaces defines the on-chain interfaces a module version exposes, as
+	// declared by its manifest.
+	Interfaces struct {
+		// Services defines the fully-qualified protobuf services of the module
+		// (e.g. cosmos.bank.v1beta1.Msg).
+		Services []string `json:"services,omitempty" yaml:"services,omitempty" toml:"services,omitempty"`
+
+		// Msgs defines the fully-qualified protobuf Msg types the module
+		// handles (e.g. cosmos.staking.v1beta1.MsgDelegate).
+		Msgs []string `json:"msgs,omitempty" yaml:"msgs,omitempty" toml:"msgs,omitempty"`
+
+		// IBC defines the IBC capabilities of the module, if any.
+		IBC *IBCCapabilities `json:"ibc,omitempty" yaml:"ibc,omitempty" toml:"ibc,omitempty"`
+	}
+
+	// IBCCapabilities defines the IBC capabilities of a module.
+	IBCCapabilities struct {
+		Ports      []string `json:"ports" yaml:"ports" toml:"ports"`
+		Middleware bool     `json:"middleware,omitempty" yaml:"middleware,omitempty" toml:"middleware,omitempty"`
+	}
+
+	// ModuleVersionInterface defines a single interface of a module version as
+	// stored for search, keyed by its kind and fully-qualified name.
+	ModuleVersionInterface struct {
+		ModuleVersionID int    `json:"-" yaml:"-" db:"module_version_id"`
+		Kind            string `json:"kind" yaml:"kind" db:"kind"`
+		Name            string `json:"name" yaml:"name" db:"name"`
+		ShortName       string `json:"short_name" yaml:"short_name" db:"short_name"`
+	}
+
+	// InterfaceFilter defines search filters on module interfaces.
+	InterfaceFilter struct {
+		HasIBC  *bool
+		MsgType string
+	}
+)
+
+// Validate performs basic validation of the declared Interfaces.
+func (i Interfaces) Validate() error {
+	v := &validator{}
+
+	for n, s := range i.Services {
+		if !protoNameRegex.MatchString(s) {
+			v.fail(fmt.Sprintf("services[%d]", n), ErrCodeInvalidValue, "must be a fully-qualified protobuf service name")
+		}
+	}
+
+	for n, m := range i.Msgs {
+		if !protoNameRegex.MatchString(m) {
+			v.fail(fmt.Sprintf("msgs[%d]", n), ErrCodeInvalidValue, "must be a fully-qualified protobuf message name")
+		}
+	}
+
+	if i.IBC != nil {
+		if len(i.IBC.Ports) == 0 {
+			v.fail("ibc.ports", ErrCodeRequired, "must declare at least one port")
+		}
+
+		for n, p := range i.IBC.Ports {
+			if !ibcPortRegex.MatchString(p) {
+				v.fail(fmt.Sprintf("ibc.ports[%d]", n), ErrCodeInvalidValue, "must be a valid IBC port identifier")
+			}
+		}
+	}
+
+	return v.err()
+}
+
+// Records returns the declared Interfaces flattened into the records stored
+// for a module version.
+func (i Interfaces) Records(moduleVersionID int) []ModuleVersionInterface {
+	var records []ModuleVersionInterface
+
+	add := func(kind, name string) {
+		records = append(records, ModuleVersionInterface{
+			ModuleVersionID: moduleVersionID,
+			Kind:            kind,
+			Name:            name,
+			ShortName:       name[strings.LastIndex(name, ".")+1:],
+		})
+	}
+
+	for _, s := range i.Services {
+		add(InterfaceService, s)
+	}
+
+	for _, m := range i.Msgs {
+		add(InterfaceMsg, m)
+	}
+
+	if i.IBC != nil {
+		for _, p := range i.IBC.Ports {
+			add(InterfaceIBCPort, p)
+		}
+	}
+
+	return records
+}
+
+// ParseInterfaceFilter parses the has_ibc and msg_type search query
+// parameters. msg_type matches either a fully-qualified Msg type or its short
+// name (e.g. MsgDelegate).
+func ParseInterfaceFilter(q url.Values) (InterfaceFilter, error) {
+	var f InterfaceFilter
+
+	if v := q.Get("has_ibc"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid has_ibc %q: %w", v, err)
+		}
+
+		f.HasIBC = &b
+	}
+
+	f.MsgType = q.Get("msg_type")
+	return f, nil
+}
+
+// Matches returns true if the declared Interfaces satisfy the filter.
+func (f InterfaceFilter) Matches(i Interfaces) bool {
+	if f.HasIBC != nil && *f.HasIBC != (i.IBC != nil && len(i.IBC.Ports) > 0) {
+		return false
+	}
+
+	if f.MsgType == "" {
+		return true
+	}
+
+	for _, m := range i.Msgs {
+		if m == f.MsgType || m[strings.LastIndex(m, ".")+1:] == f.MsgType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,13 @@
+package module
+
+import "time"
+
+// PublicKey defines a public key registered by a User which is used to verify
+// signatures attached to published module versions.
+type PublicKey struct {
+	ID        int       `json:"id" yaml:"id" db:"id"`
+	UserID    int       `json:"-" yaml:"-" db:"user_id"`
+	Kind      string    `json:"kind" yaml:"kind" db:"kind"`
+	Key       string    `json:"key" yaml:"key" db:"key"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+}
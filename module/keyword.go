@@ -0,0 +1,47 @@
+package module
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var keywordSeparatorRegex = regexp.MustCompile(`[\s_.]+|-{2,}`)
+
+// KeywordAlias defines a synonym of a canonical Keyword, e.g. "ibc-transfer"
+// resolving to "ibc".
+type KeywordAlias struct {
+	Alias     string `json:"alias" yaml:"alias" db:"alias"`
+	KeywordID int    `json:"-" yaml:"-" db:"keyword_id"`
+}
+
+// NormalizeKeyword returns the normalized form of a keyword that is stored on
+// write: NFKC-normalized, lowercased, with whitespace, underscores and dots
+// collapsed into single hyphens.
+func NormalizeKeyword(name string) string {
+	s := strings.ToLower(norm.NFKC.String(strings.TrimSpace(name)))
+	s = keywordSeparatorRegex.ReplaceAllString(s, "-")
+
+	return strings.Trim(s, "-")
+}
+
+// CanonicalKeyword normalizes a keyword and resolves it through the given
+// alias mapping (normalized alias to canonical keyword name).
+func CanonicalKeyword(name string, aliases map[string]string) string {
+	n := NormalizeKeyword(name)
+	if canonical, ok := aliases[n]; ok {
+		return canonical
+	}
+
+	return n
+}
+
+// KeywordPopularity defines the aggregated popularity of a Keyword, ranked by
+// the number of attached modules and recent publish velocity.
+type KeywordPopularity struct {
+	KeywordID       int    `json:"-" yaml:"-" db:"keyword_id"`
+	Name            string `json:"name" yaml:"name" db:"name"`
+	ModuleCount     int64  `json:"module_count" yaml:"module_count" db:"module_count"`
+	RecentPublishes int64  `json:"recent_publishes" yaml:"recent_publishes" db:"recent_publishes"`
+}
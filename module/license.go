@@ -0,0 +1,88 @@
+package module
+
+import (
+	"regexp"
+	"strings"
+)
+
+// spdxLicenses defines the SPDX license identifiers accepted by the registry.
+// It covers the OSI-approved and otherwise commonly used licenses of Go
+// modules.
+var spdxLicenses = map[string]struct{}{
+	"0BSD": {}, "AFL-3.0": {}, "AGPL-3.0-only": {}, "AGPL-3.0-or-later": {},
+	"Apache-1.1": {}, "Apache-2.0": {}, "Artistic-2.0": {}, "BlueOak-1.0.0": {},
+	"BSD-1-Clause": {}, "BSD-2-Clause": {}, "BSD-2-Clause-Patent": {}, "BSD-3-Clause": {},
+	"BSD-3-Clause-Clear": {}, "BSD-4-Clause": {}, "BSL-1.0": {}, "CC-BY-4.0": {},
+	"CC-BY-SA-4.0": {}, "CC0-1.0": {}, "CDDL-1.0": {}, "CDDL-1.1": {},
+	"ECL-2.0": {}, "EPL-1.0": {}, "EPL-2.0": {}, "EUPL-1.1": {}, "EUPL-1.2": {},
+	"GPL-2.0-only": {}, "GPL-2.0-or-later": {}, "GPL-3.0-only": {}, "GPL-3.0-or-later": {},
+	"ISC": {}, "LGPL-2.1-only": {}, "LGPL-2.1-or-later": {}, "LGPL-3.0-only": {},
+	"LGPL-3.0-or-later": {}, "MIT": {}, "MIT-0": {}, "MPL-1.1": {}, "MPL-2.0": {},
+	"MS-PL": {}, "MS-RL": {}, "NCSA": {}, "OFL-1.1": {}, "OSL-3.0": {},
+	"PostgreSQL": {}, "Unlicense": {}, "UPL-1.0": {}, "Zlib": {},
+}
+
+var (
+	licenseOperatorRegex = regexp.MustCompile(`\s+(?:AND|OR)\s+`)
+
+	// licenseSignatures maps distinctive phrases of license texts to their SPDX
+	// identifier, ordered from most to least specific.
+	licenseSignatures = []struct {
+		id      string
+		phrases []string
+	}{
+		{"AGPL-3.0-only", []string{"gnu affero general public license", "version 3"}},
+		{"LGPL-3.0-only", []string{"gnu lesser general public license", "version 3"}},
+		{"LGPL-2.1-only", []string{"gnu lesser general public license", "version 2.1"}},
+		{"GPL-3.0-only", []string{"gnu general public license", "version 3"}},
+		{"GPL-2.0-only", []string{"gnu general public license", "version 2"}},
+		{"Apache-2.0", []string{"apache license", "version 2.0"}},
+		{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+		{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+		{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+		{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+		{"MIT", []string{"permission is hereby granted, free of charge", "the above copyright notice and this permission notice shall be included"}},
+		{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+		{"CC0-1.0", []string{"cc0 1.0 universal"}},
+	}
+)
+
+// ValidLicense returns true if the given SPDX license expression is composed
+// of known license identifiers joined by AND or OR, e.g. "MIT OR Apache-2.0".
+func ValidLicense(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return false
+	}
+
+	for _, id := range licenseOperatorRegex.Split(expr, -1) {
+		id = strings.Trim(id, "() ")
+		if _, ok := spdxLicenses[id]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DetectLicense returns the SPDX identifier of a LICENSE file's contents or an
+// empty string if it cannot be identified.
+func DetectLicense(text string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+
+	for _, sig := range licenseSignatures {
+		matched := true
+		for _, phrase := range sig.phrases {
+			if !strings.Contains(normalized, phrase) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return sig.id
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,108 @@
+package module
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register the GIF decoder
+	_ "image/jpeg" // register the JPEG decoder
+	"image/png"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Logo limits.
+const (
+	MaxLogoBytes     = 1 << 20
+	MaxLogoDimension = 4096
+	LogoSize         = 256
+)
+
+// ErrInvalidLogo is returned when an uploaded logo is not an acceptable image.
+var ErrInvalidLogo = errors.New("invalid logo")
+
+// ProcessLogo validates an uploaded logo image (PNG, JPEG or GIF) and
+// re-encodes it as a PNG scaled to fit within LogoSize x LogoSize. Image
+// dimensions are checked before decoding so that small files declaring huge
+// dimensions are rejected cheaply. It returns the PNG and its hex-encoded
+// SHA-256 checksum.
+func ProcessLogo(r io.Reader) ([]byte, string, error) {
+	bz, err := ioutil.ReadAll(io.LimitReader(r, MaxLogoBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(bz) > MaxLogoBytes {
+		return nil, "", fmt.Errorf("%w: must not exceed %d bytes", ErrInvalidLogo, MaxLogoBytes)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(bz))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: must be a PNG, JPEG or GIF image", ErrInvalidLogo)
+	}
+
+	if cfg.Width > MaxLogoDimension || cfg.Height > MaxLogoDimension {
+		return nil, "", fmt.Errorf("%w: must not exceed %dx%d pixels", ErrInvalidLogo, MaxLogoDimension, MaxLogoDimension)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(bz))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: failed to decode %s image", ErrInvalidLogo, format)
+	}
+
+	dst := image.NewNRGBA(fitWithin(src.Bounds(), LogoSize))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, "", err
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), hex.EncodeToString(sum[:]), nil
+}
+
+// LogoKey returns the storage key of a module logo. Keys are content
+// addressed, so a logo URL never changes contents and may be cached forever.
+func LogoKey(name, checksum string) string {
+	return fmt.Sprintf("logos/%s/%s.png", name, checksum)
+}
+
+// LogoURL returns the URL of the Module's logo, or an empty string if it has
+// none.
+func (m Module) LogoURL(baseURL string) string {
+	if m.Logo == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(baseURL, "/") + "/" + LogoKey(m.Name, m.Logo)
+}
+
+// fitWithin returns the bounds of r scaled down, preserving its aspect ratio,
+// to fit within a size x size square. Smaller images are not scaled up.
+func fitWithin(r image.Rectangle, size int) image.Rectangle {
+	w, h := r.Dx(), r.Dy()
+	if w <= size && h <= size {
+		return image.Rect(0, 0, w, h)
+	}
+
+	if w >= h {
+		return image.Rect(0, 0, size, max(1, h*size/w))
+	}
+
+	return image.Rect(0, 0, max(1, w*size/h), size)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,202 @@
+package module
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported manifest formats.
+const (
+	FormatTOML = "toml"
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+type (
+	// ManifestAuthor defines an author listed in a module manifest.
+	ManifestAuthor struct {
+		Name  string `json:"name" yaml:"name" toml:"name"`
+		Email string `json:"email,omitempty" yaml:"email,omitempty" toml:"email,omitempty"`
+		URL   string `json:"url,omitempty" yaml:"url,omitempty" toml:"url,omitempty"`
+	}
+
+	// Manifest defines the canonical representation of a module manifest that
+	// authors publish, independent of the format it was encoded in.
+	Manifest struct {
+		Name         string             `json:"name" yaml:"name" toml:"name"`
+		Description  string             `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+		Version      string             `json:"version" yaml:"version" toml:"version"`
+		Homepage     string             `json:"homepage,omitempty" yaml:"homepage,omitempty" toml:"homepage,omitempty"`
+		Repo         string             `json:"repo" yaml:"repo" toml:"repo"`
+		License      string             `json:"license,omitempty" yaml:"license,omitempty" toml:"license,omitempty"`
+		Visibility   string             `json:"visibility,omitempty" yaml:"visibility,omitempty" toml:"visibility,omitempty"`
+		Keywords     []string           `json:"keywords,omitempty" yaml:"keywords,omitempty" toml:"keywords,omitempty"`
+		Authors      []ManifestAuthor   `json:"authors,omitempty" yaml:"authors,omitempty" toml:"authors,omitempty"`
+		Bugs         *Bug               `json:"bugs,omitempty" yaml:"bugs,omitempty" toml:"bugs,omitempty"`
+		Dependencies []ModuleDependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty" toml:"dependencies,omitempty"`
+		SDKCompat    string             `json:"sdk_compat,omitempty" yaml:"sdk_compat,omitempty" toml:"sdk_compat,omitempty"`
+		Interfaces   *Interfaces        `json:"interfaces,omitempty" yaml:"interfaces,omitempty" toml:"interfaces,omitempty"`
+		Readme       string             `json:"readme,omitempty" yaml:"readme,omitempty" toml:"readme,omitempty"`
+	}
+)
+
+// Module returns the Module described by the Manifest.
+func (m Manifest) Module() Module {
+	return Module{
+		Name:        m.Name,
+		Description: m.Description,
+		Version:     m.Version,
+		Homepage:    m.Homepage,
+		Repo:        m.Repo,
+		License:     m.License,
+		Visibility:  m.Visibility,
+	}
+}
+
+// Value implements driver.Valuer, storing the Manifest as JSON so the exact
+// manifest of every published version is retained.
+func (m Manifest) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// Scan implements sql.Scanner, decoding a Manifest stored as JSON.
+func (m *Manifest) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Manifest{}
+		return nil
+
+	case []byte:
+		return json.Unmarshal(v, m)
+
+	case string:
+		return json.Unmarshal([]byte(v), m)
+
+	default:
+		return fmt.Errorf("cannot scan %T into Manifest", src)
+	}
+}
+
+// FormatFromFilename returns the manifest format implied by a file extension
+// or an empty string if it is unknown.
+func FormatFromFilename(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".toml":
+		return FormatTOML
+
+	case ".yaml", ".yml":
+		return FormatYAML
+
+	case ".json":
+		return FormatJSON
+
+	default:
+		return ""
+	}
+}
+
+// FormatFromContentType returns the manifest format implied by a Content-Type
+// header value or an empty string if it is unknown.
+func FormatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	switch mediaType {
+	case "application/toml":
+		return FormatTOML
+
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return FormatYAML
+
+	case "application/json":
+		return FormatJSON
+
+	default:
+		return ""
+	}
+}
+
+// ParseManifest decodes a Manifest encoded in the given format. If format is
+// empty, the format is detected from the contents, trying JSON, TOML and YAML
+// in that order.
+func ParseManifest(bz []byte, format string) (Manifest, error) {
+	if format != "" {
+		return decodeManifest(bz, format)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(bz), []byte("{")) {
+		return decodeManifest(bz, FormatJSON)
+	}
+
+	if m, err := decodeManifest(bz, FormatTOML); err == nil {
+		return m, nil
+	}
+
+	m, err := decodeManifest(bz, FormatYAML)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to detect manifest format: %w", err)
+	}
+
+	return m, nil
+}
+
+// EncodeManifest encodes a Manifest in the given format.
+func EncodeManifest(m Manifest, format string) ([]byte, error) {
+	switch format {
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(m); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+
+	case FormatYAML:
+		return yaml.Marshal(m)
+
+	case FormatJSON:
+		return json.MarshalIndent(m, "", "  ")
+
+	default:
+		return nil, fmt.Errorf("unsupported manifest format: %s", format)
+	}
+}
+
+func decodeManifest(bz []byte, format string) (Manifest, error) {
+	var (
+		m   Manifest
+		err error
+	)
+
+	switch format {
+	case FormatTOML:
+		_, err = toml.Decode(string(bz), &m)
+
+	case FormatYAML:
+		err = yaml.UnmarshalStrict(bz, &m)
+
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(bz))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&m)
+
+	default:
+		return Manifest{}, fmt.Errorf("unsupported manifest format: %s", format)
+	}
+
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to decode %s manifest: %w", format, err)
+	}
+
+	return m, nil
+}
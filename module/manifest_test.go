@@ -0,0 +1,127 @@
+package module
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testManifest() Manifest {
+	return Manifest{
+		Name:        "x/liquidity",
+		Description: "Constant product automated market maker pools.",
+		Version:     "v1.2.0",
+		Homepage:    "https://liquidity.example.com",
+		Repo:        "https://github.com/example/liquidity",
+		License:     "Apache-2.0",
+		Visibility:  VisibilityPublic,
+		Keywords:    []string{"amm", "dex"},
+		Authors: []ManifestAuthor{
+			{Name: "Alice", Email: "alice@example.com"},
+			{Name: "Bob", URL: "https://github.com/bob"},
+		},
+		Bugs: &Bug{URL: "https://github.com/example/liquidity/issues", Contact: "bugs@example.com"},
+		Dependencies: []ModuleDependency{
+			{Name: "x/bank", VersionConstraint: "^0.44"},
+		},
+		SDKCompat: ">= 0.43.0",
+		Readme:    "# Liquidity\n\nPools for token swaps.\n",
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	want := testManifest()
+
+	// encode in each format in turn, decoding the previous encoding
+	m := want
+	for _, format := range []string{FormatTOML, FormatYAML, FormatJSON, FormatTOML} {
+		bz, err := EncodeManifest(m, format)
+		if err != nil {
+			t.Fatalf("failed to encode %s: %v", format, err)
+		}
+
+		if m, err = ParseManifest(bz, format); err != nil {
+			t.Fatalf("failed to parse %s: %v", format, err)
+		}
+
+		if !reflect.DeepEqual(m, want) {
+			t.Fatalf("%s round-trip mismatch:\ngot  %+v\nwant %+v", format, m, want)
+		}
+	}
+}
+
+func TestParseManifestDetectsFormat(t *testing.T) {
+	want := testManifest()
+
+	for _, format := range []string{FormatTOML, FormatYAML, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			bz, err := EncodeManifest(want, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ParseManifest(bz, "")
+			if err != nil {
+				t.Fatalf("failed to detect %s: %v", format, err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("detected %s mismatch:\ngot  %+v\nwant %+v", format, got, want)
+			}
+		})
+	}
+}
+
+func TestParseManifestFallbackOrder(t *testing.T) {
+	testCases := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{
+			// valid TOML is decoded as TOML, whereas YAML would decode the
+			// line as a single scalar
+			name:     "toml before yaml",
+			manifest: "name = \"x/oracle\"\nversion = \"v0.3.0\"\n",
+			want:     "x/oracle",
+		},
+		{
+			// invalid TOML falls back to YAML
+			name:     "yaml after toml",
+			manifest: "name: x/oracle\nversion: v0.3.0\n",
+			want:     "x/oracle",
+		},
+		{
+			// a leading brace is always decoded as JSON
+			name:     "json",
+			manifest: "  {\"name\": \"x/oracle\", \"version\": \"v0.3.0\"}",
+			want:     "x/oracle",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := ParseManifest([]byte(tc.manifest), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m.Name != tc.want || m.Version != "v0.3.0" {
+				t.Errorf("expected %s at v0.3.0, got %s at %s", tc.want, m.Name, m.Version)
+			}
+		})
+	}
+}
+
+func TestParseManifestUndetectable(t *testing.T) {
+	_, err := ParseManifest([]byte("name = \n\t- [\n"), "")
+	if err == nil || !strings.Contains(err.Error(), "failed to detect manifest format") {
+		t.Errorf("expected a detection error, got %v", err)
+	}
+}
+
+func TestEncodeManifestUnsupportedFormat(t *testing.T) {
+	if _, err := EncodeManifest(testManifest(), "xml"); err == nil {
+		t.Error("expected an error encoding an unsupported format")
+	}
+}
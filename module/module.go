@@ -1,11 +1,41 @@
 package module
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStaleModule is returned when updating a Module that was modified
+// concurrently since it was read. Callers should re-read the Module and retry.
+var ErrStaleModule = errors.New("module was modified concurrently")
+
+// ErrModuleArchived is returned when publishing to or editing a Module that
+// its owners archived.
+var ErrModuleArchived = errors.New("module is archived")
+
+// Link statuses recorded by repository health checks.
+const (
+	LinkStatusOK          = "ok"
+	LinkStatusUnreachable = "unreachable"
+	LinkStatusNotFound    = "not_found"
+)
+
 // Keyword defines a module keyword, where a module can have one or more keywords.
 type Keyword struct {
 	ID   int    `json:"-" yaml:"-" db:"id"`
 	Name string `json:"name" yaml:"name" db:"name"`
 }
 
+// Category defines a curated module category. Unlike keywords, categories are
+// managed by registry admins and form a controlled taxonomy.
+type Category struct {
+	ID          int    `json:"-" yaml:"-" db:"id"`
+	Name        string `json:"name" yaml:"name" db:"name"`
+	Slug        string `json:"slug" yaml:"slug" db:"slug"`
+	Description string `json:"description" yaml:"description" db:"description"`
+}
+
 type (
 	// Author defines a type alias for a User
 	Author User
@@ -15,31 +45,210 @@ type (
 
 	// User defines an entity that contributes to a Module type.
 	User struct {
-		ID                int    `json:"-" yaml:"-" db:"id"`
-		Name              string `json:"name" yaml:"name" db:"name"`
-		URL               string `json:"url" yaml:"url" db:"url"`
-		Email             string `json:"email" yaml:"email" db:"email"`
-		GithubAccessToken string `json:"github_access_token" yaml:"github_access_token" db:"github_access_token"`
-		APIToken          string `json:"api_token" yaml:"api_token" db:"api_token"`
+		ID                int       `json:"-" yaml:"-" db:"id"`
+		Name              string    `json:"name" yaml:"name" db:"name"`
+		URL               string    `json:"url" yaml:"url" db:"url"`
+		Email             string    `json:"email" yaml:"email" db:"email"`
+		GithubAccessToken string    `json:"github_access_token" yaml:"github_access_token" db:"github_access_token"`
+		APIToken          string    `json:"api_token" yaml:"api_token" db:"api_token"`
+		APITokenCIDRs     []string  `json:"api_token_cidrs" yaml:"-" db:"api_token_cidrs"`
+		APITokenCreatedAt time.Time `json:"api_token_created_at" yaml:"-" db:"api_token_created_at"`
+		AvatarURL         string    `json:"avatar_url" yaml:"avatar_url" db:"avatar_url"`
+		Bio               string    `json:"bio" yaml:"bio" db:"bio"`
+		TOTPSecret        string    `json:"-" yaml:"-" db:"totp_secret"`
+		TOTPEnabled       bool      `json:"totp_enabled" yaml:"-" db:"totp_enabled"`
+		Admin             bool      `json:"admin" yaml:"-" db:"admin"`
+		Banned            bool      `json:"banned" yaml:"-" db:"banned"`
 	}
 )
 
+// Profile defines the public profile of a User, excluding credentials and
+// other private fields.
+type Profile struct {
+	Name        string   `json:"name" yaml:"name"`
+	URL         string   `json:"url" yaml:"url"`
+	AvatarURL   string   `json:"avatar_url" yaml:"avatar_url"`
+	Bio         string   `json:"bio" yaml:"bio"`
+	Authored    []Module `json:"authored,omitempty" yaml:"authored,omitempty"`
+	Contributed []Module `json:"contributed,omitempty" yaml:"contributed,omitempty"`
+}
+
+// Profile returns the public Profile of the User given the modules it authored
+// and those it contributes to.
+func (u User) Profile(authored, contributed []Module) Profile {
+	return Profile{
+		Name:        u.Name,
+		URL:         u.URL,
+		AvatarURL:   u.AvatarURL,
+		Bio:         u.Bio,
+		Authored:    authored,
+		Contributed: contributed,
+	}
+}
+
+// CanPublish returns true if the User is permitted to publish modules.
+func (u User) CanPublish() bool {
+	return !u.Banned
+}
+
+// CanModerate returns true if the User is permitted to perform registry
+// moderation actions such as hiding modules and banning users.
+func (u User) CanModerate() bool {
+	return u.Admin && !u.Banned
+}
+
+// RecoveryCode defines a hashed single-use two-factor recovery code of a User.
+type RecoveryCode struct {
+	ID     int       `json:"-" yaml:"-" db:"id"`
+	UserID int       `json:"-" yaml:"-" db:"user_id"`
+	Hash   string    `json:"-" yaml:"-" db:"hash"`
+	UsedAt time.Time `json:"-" yaml:"-" db:"used_at"`
+}
+
 // Bug defines the metadata information for reporting bug reports on a given
 // Module type.
 type Bug struct {
-	ID      int    `json:"-" yaml:"-" db:"id"`
-	URL     string `json:"url" yaml:"url" db:"url"`
-	Contact string `json:"contact" yaml:"contact" db:"contact"`
+	ID      int    `json:"-" yaml:"-" toml:"-" db:"id"`
+	URL     string `json:"url" yaml:"url" toml:"url" db:"url"`
+	Contact string `json:"contact" yaml:"contact" toml:"contact" db:"contact"`
 }
 
 // Module defines a Cosmos SDK module.
 type Module struct {
-	ID          int    `json:"-" yaml:"-" db:"id"`
-	Name        string `json:"name" yaml:"name" db:"name"`
-	Description string `json:"description" yaml:"description" db:"description"`
-	Version     string `json:"version" yaml:"version" db:"version"`
-	Homepage    string `json:"homepage" yaml:"homepage" db:"homepage"`
-	Repo        string `json:"repo" yaml:"repo" db:"repo"`
-	BugID       int    `json:"-" yaml:"-" db:"bug_id"`
-	Author      int    `json:"-" yaml:"-" db:"author"`
+	ID             int       `json:"-" yaml:"-" db:"id"`
+	Name           string    `json:"name" yaml:"name" db:"name"`
+	Slug           string    `json:"-" yaml:"-" db:"slug"`
+	Description    string    `json:"description" yaml:"description" db:"description"`
+	Version        string    `json:"version" yaml:"version" db:"version"`
+	Homepage       string    `json:"homepage" yaml:"homepage" db:"homepage"`
+	Repo           string    `json:"repo" yaml:"repo" db:"repo"`
+	License        string    `json:"license" yaml:"license" db:"license"`
+	Visibility     string    `json:"visibility" yaml:"visibility" db:"visibility"`
+	Logo           string    `json:"-" yaml:"-" db:"logo"`
+	BugID          int       `json:"-" yaml:"-" db:"bug_id"`
+	Author         int       `json:"-" yaml:"-" db:"author"`
+	Disputed       bool      `json:"disputed" yaml:"disputed" db:"disputed"`
+	Hidden         bool      `json:"hidden" yaml:"-" db:"hidden"`
+	Archived       bool      `json:"archived" yaml:"-" db:"archived"`
+	LinkStatus     string    `json:"link_status" yaml:"-" db:"link_status"`
+	LinksCheckedAt time.Time `json:"links_checked_at" yaml:"-" db:"links_checked_at"`
+	DeletedAt      time.Time `json:"-" yaml:"-" db:"deleted_at"`
+	QualityScore   float64   `json:"quality_score" yaml:"-" db:"quality_score"`
+	Rating         Rating    `json:"rating" yaml:"-"`
+
+	// Bugs defines the Module's bug tracker, if any.
+	Bugs *Bug `json:"bugs,omitempty" yaml:"-"`
+
+	// Issues defines the issue statistics of the Module's repository, if its
+	// provider has been polled.
+	Issues *IssueStats `json:"issues,omitempty" yaml:"-"`
+
+	// Deprecation defines the Module's deprecation by its owners, if any.
+	Deprecation *Deprecation `json:"deprecation,omitempty" yaml:"-"`
+
+	// Advisories defines the open security advisories published against the
+	// Module's versions, oldest first.
+	Advisories []Advisory `json:"advisories,omitempty" yaml:"-"`
+
+	// VerifiedPublisher reports whether the Module's author holds an approved
+	// PublisherVerification, as computed by the verified_publisher SQL
+	// function.
+	VerifiedPublisher bool `json:"verified_publisher" yaml:"-" db:"verified_publisher"`
+
+	// Origin is the base URL of the upstream registry a mirrored Module was
+	// synced from, or empty for modules published to this registry.
+	Origin string `json:"origin,omitempty" yaml:"-" db:"origin"`
+
+	// LockVersion is incremented on every update. Updates are conditioned on
+	// the LockVersion that was read (WHERE lock_version = $n) so that
+	// concurrent publishes cannot clobber each other's changes.
+	LockVersion int64 `json:"lock_version" yaml:"-" db:"lock_version"`
+}
+
+// Mirrored returns true if the Module was synced from an upstream registry.
+// Mirrored modules are read-only; they may only be updated by the sync job.
+func (m Module) Mirrored() bool {
+	return m.Origin != ""
+}
+
+// CheckLock returns ErrStaleModule if the Module's LockVersion differs from
+// the version the caller read, e.g. as sent back by an API client.
+func (m Module) CheckLock(expected int64) error {
+	if m.LockVersion != expected {
+		return fmt.Errorf("%w: have lock version %d, got %d", ErrStaleModule, m.LockVersion, expected)
+	}
+
+	return nil
+}
+
+// Delete soft-deletes the Module, excluding it from search and lists while
+// retaining it so that it may be restored.
+func (m *Module) Delete(now time.Time) {
+	m.DeletedAt = now
+}
+
+// Restore restores a soft-deleted Module.
+func (m *Module) Restore() {
+	m.DeletedAt = time.Time{}
+}
+
+// Archive archives the Module, making it read-only: no versions may be
+// published and its metadata may not be edited, while its existing versions
+// remain resolvable.
+func (m *Module) Archive() {
+	m.Archived = true
+}
+
+// Unarchive lifts the archival of the Module.
+func (m *Module) Unarchive() {
+	m.Archived = false
+}
+
+// CheckWritable returns ErrModuleArchived if the Module is archived. Publish
+// pipelines and metadata edits must check it before modifying the Module.
+func (m Module) CheckWritable() error {
+	if m.Archived {
+		return fmt.Errorf("%w: %s is read-only", ErrModuleArchived, m.Name)
+	}
+
+	return nil
+}
+
+// Deleted returns true if the Module has been soft-deleted.
+func (m Module) Deleted() bool {
+	return !m.DeletedAt.IsZero()
+}
+
+// Purgeable returns true if the Module was soft-deleted longer than the given
+// retention window ago and may be hard-deleted.
+func (m Module) Purgeable(now time.Time, retention time.Duration) bool {
+	return m.Deleted() && now.Sub(m.DeletedAt) >= retention
+}
+
+// TransferOwnership reassigns the Module's author to the given User.
+func (m *Module) TransferOwnership(to User) error {
+	if to.ID == 0 {
+		return fmt.Errorf("cannot transfer module %s to an unregistered user", m.Name)
+	}
+
+	if to.Banned {
+		return fmt.Errorf("cannot transfer module %s to a banned user", m.Name)
+	}
+
+	m.Author = to.ID
+	return nil
+}
+
+// Stale returns true if the last repository health check found the Module's
+// links to be broken.
+func (m Module) Stale() bool {
+	return m.LinkStatus != "" && m.LinkStatus != LinkStatusOK
+}
+
+// ReleasedName defines the record of a deleted Module's name, which is held
+// for its previous owner for a cooldown period before it can be re-claimed.
+type ReleasedName struct {
+	Name          string    `json:"name" yaml:"name" db:"name"`
+	PreviousOwner int       `json:"-" yaml:"-" db:"previous_owner"`
+	ReleasedAt    time.Time `json:"released_at" yaml:"released_at" db:"released_at"`
 }
@@ -0,0 +1,61 @@
+package module
+
+import "fmt"
+
+// ModulePatch defines a sparse update of a Module's metadata. Nil fields are
+// left unchanged. Versions are never modified by a patch.
+type ModulePatch struct {
+	Description *string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Homepage    *string   `json:"homepage,omitempty" yaml:"homepage,omitempty"`
+	Keywords    *[]string `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+
+	// LockVersion, if set, is the Module's LockVersion the patch was made
+	// against. The patch is rejected with ErrStaleModule if the Module was
+	// updated since.
+	LockVersion *int64 `json:"lock_version,omitempty" yaml:"lock_version,omitempty"`
+}
+
+// Apply applies the patch to the given Module, returning the names of the
+// fields that changed so they may be recorded in an audit log. The Module is
+// left untouched if the patched result fails validation.
+func (mp ModulePatch) Apply(m *Module) ([]string, error) {
+	var (
+		patched = *m
+		changed []string
+	)
+
+	if mp.Description != nil && *mp.Description != m.Description {
+		patched.Description = *mp.Description
+		changed = append(changed, "description")
+	}
+
+	if mp.Homepage != nil && *mp.Homepage != m.Homepage {
+		patched.Homepage = *mp.Homepage
+		changed = append(changed, "homepage")
+	}
+
+	if err := patched.Validate(); err != nil {
+		return nil, err
+	}
+
+	if mp.Keywords != nil {
+		if len(*mp.Keywords) > MaxKeywords {
+			return nil, ValidationErrors{{
+				Field:   "keywords",
+				Code:    ErrCodeTooLong,
+				Message: fmt.Sprintf("must not contain more than %d keywords", MaxKeywords),
+			}}
+		}
+
+		for _, k := range *mp.Keywords {
+			if err := (Keyword{Name: k}).Validate(); err != nil {
+				return nil, err
+			}
+		}
+
+		changed = append(changed, "keywords")
+	}
+
+	*m = patched
+	return changed, nil
+}
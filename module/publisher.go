@@ -0,0 +1,140 @@
+package module
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Kinds of publisher verifications.
+const (
+	VerificationGitHubOrg = "github_org"
+	VerificationDomain    = "domain"
+)
+
+// Publisher verification statuses.
+const (
+	VerificationPending  = "pending"
+	VerificationProven   = "proven"
+	VerificationApproved = "approved"
+	VerificationRejected = "rejected"
+)
+
+// VerificationTXTPrefix defines the prefix of the DNS TXT record proving
+// control of a domain.
+const VerificationTXTPrefix = "atlas-verification="
+
+var (
+	githubOrgRegex = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+	domainRegex    = regexp.MustCompile(`^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+)
+
+// PublisherVerification defines a request by a User to be verified as the
+// publisher of a GitHub organization or domain. The user first proves control
+// of the subject (status proven), after which an admin approves it, granting
+// the verified publisher badge on the user's modules.
+type PublisherVerification struct {
+	ID         int       `json:"id" yaml:"id" db:"id"`
+	UserID     int       `json:"-" yaml:"-" db:"user_id"`
+	Kind       string    `json:"kind" yaml:"kind" db:"kind"`
+	Subject    string    `json:"subject" yaml:"subject" db:"subject"`
+	Token      string    `json:"token,omitempty" yaml:"-" db:"token"`
+	Status     string    `json:"status" yaml:"status" db:"status"`
+	ReviewerID int       `json:"-" yaml:"-" db:"reviewer_id"`
+	ProvenAt   time.Time `json:"proven_at" yaml:"proven_at" db:"proven_at"`
+	ReviewedAt time.Time `json:"reviewed_at" yaml:"reviewed_at" db:"reviewed_at"`
+	CreatedAt  time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+}
+
+// NewPublisherVerification returns a pending PublisherVerification of the
+// given subject with a random challenge token. Domain subjects are
+// lower-cased.
+func NewPublisherVerification(userID int, kind, subject string, now time.Time) (PublisherVerification, error) {
+	if kind == VerificationDomain {
+		subject = strings.ToLower(strings.TrimSuffix(subject, "."))
+	}
+
+	pv := PublisherVerification{
+		UserID:    userID,
+		Kind:      kind,
+		Subject:   subject,
+		Status:    VerificationPending,
+		CreatedAt: now,
+	}
+
+	if err := pv.Validate(); err != nil {
+		return PublisherVerification{}, err
+	}
+
+	bz := make([]byte, 16)
+	if _, err := rand.Read(bz); err != nil {
+		return PublisherVerification{}, err
+	}
+
+	pv.Token = hex.EncodeToString(bz)
+	return pv, nil
+}
+
+// Validate performs validation of a newly requested PublisherVerification.
+func (pv PublisherVerification) Validate() error {
+	v := &validator{}
+
+	v.oneOf("kind", pv.Kind, VerificationGitHubOrg, VerificationDomain)
+
+	if v.required("subject", pv.Subject) {
+		switch {
+		case pv.Kind == VerificationGitHubOrg && !githubOrgRegex.MatchString(pv.Subject):
+			v.fail("subject", ErrCodeInvalidValue, "must be a valid GitHub organization name")
+
+		case pv.Kind == VerificationDomain && !domainRegex.MatchString(pv.Subject):
+			v.fail("subject", ErrCodeInvalidValue, "must be a valid domain name")
+		}
+	}
+
+	return v.err()
+}
+
+// TXTRecord returns the DNS TXT record value proving control of a domain.
+func (pv PublisherVerification) TXTRecord() string {
+	return VerificationTXTPrefix + pv.Token
+}
+
+// Prove records that the user proved control of the subject.
+func (pv *PublisherVerification) Prove(now time.Time) error {
+	if pv.Status != VerificationPending {
+		return fmt.Errorf("publisher verification %d is not pending", pv.ID)
+	}
+
+	pv.Status = VerificationProven
+	pv.ProvenAt = now
+	return nil
+}
+
+// Review approves or rejects a proven PublisherVerification on behalf of the
+// given admin.
+func (pv *PublisherVerification) Review(admin User, approve bool, now time.Time) error {
+	if !admin.CanModerate() {
+		return fmt.Errorf("user %d is not permitted to review publisher verifications", admin.ID)
+	}
+
+	if pv.Status != VerificationProven {
+		return fmt.Errorf("publisher verification %d has not been proven", pv.ID)
+	}
+
+	pv.Status = VerificationRejected
+	if approve {
+		pv.Status = VerificationApproved
+	}
+
+	pv.ReviewerID = admin.ID
+	pv.ReviewedAt = now
+	return nil
+}
+
+// Verified returns true if the PublisherVerification was approved.
+func (pv PublisherVerification) Verified() bool {
+	return pv.Status == VerificationApproved
+}
@@ -0,0 +1,30 @@
+package module
+
+import (
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday/v2"
+)
+
+// readmePolicy defines the HTML sanitization policy applied to rendered
+// readmes, permitting user-generated content markup while stripping scripts,
+// styles and event handlers.
+var readmePolicy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(bluemonday.SpaceSeparatedTokens).OnElements("code", "pre")
+	p.RequireNoFollowOnLinks(true)
+	return p
+}()
+
+// RenderReadme renders a Markdown readme to sanitized HTML.
+func RenderReadme(markdown string) string {
+	unsafe := blackfriday.Run([]byte(markdown), blackfriday.WithExtensions(blackfriday.CommonExtensions|blackfriday.AutoHeadingIDs))
+	return string(readmePolicy.SanitizeBytes(unsafe))
+}
+
+// SetReadme stores the raw Markdown readme of the version along with its
+// rendered HTML, so rendering happens once at publish time rather than on
+// every read.
+func (mv *ModuleVersion) SetReadme(markdown string) {
+	mv.Readme = markdown
+	mv.RenderedReadme = RenderReadme(markdown)
+}
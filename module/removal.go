@@ -0,0 +1,114 @@
+package module
+
+import (
+	"fmt"
+	"time"
+)
+
+// Removal request kinds.
+const (
+	RemovalKindDMCA  = "dmca"
+	RemovalKindLegal = "legal"
+)
+
+// Removal request statuses. A request is filed, after which the module is
+// placed in a disputed state. The module owner may file a counter-notice
+// before the request is finally resolved.
+const (
+	RemovalStatusFiled     = "filed"
+	RemovalStatusDisputed  = "disputed"
+	RemovalStatusCountered = "countered"
+	RemovalStatusResolved  = "resolved"
+)
+
+// Removal request resolutions.
+const (
+	RemovalResolutionRemoved    = "removed"
+	RemovalResolutionReinstated = "reinstated"
+)
+
+// removalTransitions defines the valid status transitions of a RemovalRequest.
+var removalTransitions = map[string][]string{
+	RemovalStatusFiled:     {RemovalStatusDisputed, RemovalStatusResolved},
+	RemovalStatusDisputed:  {RemovalStatusCountered, RemovalStatusResolved},
+	RemovalStatusCountered: {RemovalStatusResolved},
+}
+
+// RemovalRequest defines a legal or DMCA takedown request filed against a
+// Module. While a request is open, the module's metadata is retained but its
+// downloads are blocked.
+type RemovalRequest struct {
+	ID            int       `json:"id" yaml:"id" db:"id"`
+	ModuleID      int       `json:"-" yaml:"-" db:"module_id"`
+	Kind          string    `json:"kind" yaml:"kind" db:"kind"`
+	Claimant      string    `json:"claimant" yaml:"claimant" db:"claimant"`
+	Contact       string    `json:"contact" yaml:"contact" db:"contact"`
+	Reason        string    `json:"reason" yaml:"reason" db:"reason"`
+	CounterNotice string    `json:"counter_notice" yaml:"counter_notice" db:"counter_notice"`
+	Status        string    `json:"status" yaml:"status" db:"status"`
+	Resolution    string    `json:"resolution" yaml:"resolution" db:"resolution"`
+	CreatedAt     time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" yaml:"updated_at" db:"updated_at"`
+}
+
+// Validate performs basic validation of a newly filed RemovalRequest.
+func (rr RemovalRequest) Validate() error {
+	v := &validator{}
+
+	v.oneOf("kind", rr.Kind, RemovalKindDMCA, RemovalKindLegal)
+	v.required("claimant", rr.Claimant)
+	v.required("contact", rr.Contact)
+	v.required("reason", rr.Reason)
+
+	return v.err()
+}
+
+// Transition moves the RemovalRequest to the given status. It returns an
+// error if the transition is not permitted from the current status.
+func (rr *RemovalRequest) Transition(status string) error {
+	for _, s := range removalTransitions[rr.Status] {
+		if s == status {
+			rr.Status = status
+			rr.UpdatedAt = time.Now().UTC()
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid removal request transition: %s -> %s", rr.Status, status)
+}
+
+// Counter records a counter-notice filed by the module owner.
+func (rr *RemovalRequest) Counter(notice string) error {
+	if notice == "" {
+		return fmt.Errorf("counter-notice must not be empty")
+	}
+
+	if err := rr.Transition(RemovalStatusCountered); err != nil {
+		return err
+	}
+
+	rr.CounterNotice = notice
+	return nil
+}
+
+// Resolve records the final resolution of the RemovalRequest.
+func (rr *RemovalRequest) Resolve(resolution string) error {
+	switch resolution {
+	case RemovalResolutionRemoved, RemovalResolutionReinstated:
+	default:
+		return fmt.Errorf("invalid removal request resolution: %s", resolution)
+	}
+
+	if err := rr.Transition(RemovalStatusResolved); err != nil {
+		return err
+	}
+
+	rr.Resolution = resolution
+	return nil
+}
+
+// Open returns true if the RemovalRequest has not yet been resolved. A module
+// with an open removal request should have its downloads blocked.
+func (rr RemovalRequest) Open() bool {
+	return rr.Status != RemovalStatusResolved
+}
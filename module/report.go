@@ -0,0 +1,113 @@
+package module
+
+import (
+	"fmt"
+	"time"
+)
+
+// Abuse report reasons.
+const (
+	ReportReasonSpam          = "spam"
+	ReportReasonMalware       = "malware"
+	ReportReasonNameSquatting = "name_squatting"
+	ReportReasonOther         = "other"
+
+	// ReportReasonSuspicious is used by reports filed by the registry itself
+	// when anomalous publish activity is detected. Such reports have no
+	// reporter and cannot be filed by users.
+	ReportReasonSuspicious = "suspicious_activity"
+
+	// ReportReasonRemoval is used by reports filing a RemovalRequest in the
+	// moderation queue. Such reports cannot be filed directly.
+	ReportReasonRemoval = "removal_request"
+)
+
+// Abuse report statuses.
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+type (
+	// Report defines an abuse report filed by a User against a Module, or
+	// against one of its reviews when ReviewID is set, or the filing of a
+	// RemovalRequest when RemovalID is set. Reports form the moderation queue
+	// that admins work through.
+	Report struct {
+		ID         int       `json:"id" yaml:"id" db:"id"`
+		ModuleID   int       `json:"-" yaml:"-" db:"module_id"`
+		ReviewID   int       `json:"review_id,omitempty" yaml:"review_id,omitempty" db:"review_id"`
+		RemovalID  int       `json:"removal_request_id,omitempty" yaml:"removal_request_id,omitempty" db:"removal_request_id"`
+		ReporterID int       `json:"-" yaml:"-" db:"reporter_id"`
+		Reason     string    `json:"reason" yaml:"reason" db:"reason"`
+		Details    string    `json:"details" yaml:"details" db:"details"`
+		Status     string    `json:"status" yaml:"status" db:"status"`
+		CreatedAt  time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at" yaml:"updated_at" db:"updated_at"`
+	}
+
+	// ReportComment defines a comment left by an admin on a Report.
+	ReportComment struct {
+		ID        int       `json:"id" yaml:"id" db:"id"`
+		ReportID  int       `json:"-" yaml:"-" db:"report_id"`
+		UserID    int       `json:"-" yaml:"-" db:"user_id"`
+		Body      string    `json:"body" yaml:"body" db:"body"`
+		CreatedAt time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+	}
+
+	// ReportEvent defines an audit log entry recording a status transition of a
+	// Report and the User that performed it.
+	ReportEvent struct {
+		ID         int       `json:"id" yaml:"id" db:"id"`
+		ReportID   int       `json:"-" yaml:"-" db:"report_id"`
+		ActorID    int       `json:"-" yaml:"-" db:"actor_id"`
+		FromStatus string    `json:"from_status" yaml:"from_status" db:"from_status"`
+		ToStatus   string    `json:"to_status" yaml:"to_status" db:"to_status"`
+		CreatedAt  time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+	}
+)
+
+// Validate performs validation of a newly filed Report.
+func (r Report) Validate() error {
+	v := &validator{}
+
+	v.oneOf("reason", r.Reason, ReportReasonSpam, ReportReasonMalware, ReportReasonNameSquatting, ReportReasonOther)
+	v.maxLength("details", r.Details, MaxDescriptionLength)
+
+	if r.Reason == ReportReasonOther {
+		v.required("details", r.Details)
+	}
+
+	return v.err()
+}
+
+// Resolve transitions an open Report to the resolved or dismissed status on
+// behalf of the given admin, returning the audit log entry to record.
+func (r *Report) Resolve(admin User, status string) (ReportEvent, error) {
+	if !admin.CanModerate() {
+		return ReportEvent{}, fmt.Errorf("user %d is not permitted to moderate reports", admin.ID)
+	}
+
+	if r.Status != ReportStatusOpen {
+		return ReportEvent{}, fmt.Errorf("report %d is not open", r.ID)
+	}
+
+	if status != ReportStatusResolved && status != ReportStatusDismissed {
+		return ReportEvent{}, fmt.Errorf("invalid report status: %s", status)
+	}
+
+	now := time.Now().UTC()
+	event := ReportEvent{
+		ReportID:   r.ID,
+		ActorID:    admin.ID,
+		FromStatus: r.Status,
+		ToStatus:   status,
+		CreatedAt:  now,
+	}
+
+	r.Status = status
+	r.UpdatedAt = now
+
+	return event, nil
+}
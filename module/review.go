@@ -0,0 +1,103 @@
+package module
+
+import (
+	"fmt"
+	"time"
+)
+
+// Review rating bounds and limits.
+const (
+	MinRating       = 1
+	MaxRating       = 5
+	MaxReviewLength = 4096
+)
+
+type (
+	// Review defines a rating and review left by a User on a Module. A user may
+	// leave a single review per module, to which the module's author or a
+	// contributor may respond. Abuse reports may be filed against a review,
+	// referencing it from the Report.
+	Review struct {
+		ID          int        `json:"id" yaml:"id" db:"id"`
+		ModuleID    int        `json:"-" yaml:"-" db:"module_id"`
+		UserID      int        `json:"-" yaml:"-" db:"user_id"`
+		Rating      int        `json:"rating" yaml:"rating" db:"rating"`
+		Body        string     `json:"body" yaml:"body" db:"body"`
+		Response    string     `json:"response,omitempty" yaml:"response,omitempty" db:"response"`
+		ResponderID int        `json:"-" yaml:"-" db:"responder_id"`
+		RespondedAt *time.Time `json:"responded_at,omitempty" yaml:"responded_at,omitempty" db:"responded_at"`
+		Hidden      bool       `json:"-" yaml:"-" db:"hidden"`
+		CreatedAt   time.Time  `json:"created_at" yaml:"created_at" db:"created_at"`
+		UpdatedAt   time.Time  `json:"updated_at" yaml:"updated_at" db:"updated_at"`
+	}
+
+	// Rating defines the aggregate rating of a Module over its visible
+	// reviews.
+	Rating struct {
+		Average float64 `json:"average" yaml:"average" db:"rating_average"`
+		Count   int     `json:"count" yaml:"count" db:"rating_count"`
+	}
+)
+
+// Validate performs validation of a new or edited Review.
+func (r Review) Validate() error {
+	v := &validator{}
+
+	if r.Rating < MinRating || r.Rating > MaxRating {
+		v.fail("rating", ErrCodeInvalidValue, "must be between %d and %d", MinRating, MaxRating)
+	}
+
+	v.maxLength("body", r.Body, MaxReviewLength)
+
+	return v.err()
+}
+
+// CanReview returns an error if the User may not review the Module. Banned
+// users may not review and authors may not review their own modules.
+func CanReview(u User, m Module) error {
+	if u.Banned {
+		return fmt.Errorf("user %d is banned", u.ID)
+	}
+
+	if u.ID == m.Author {
+		return fmt.Errorf("user %d may not review its own module %s", u.ID, m.Name)
+	}
+
+	return nil
+}
+
+// Respond records the response of the Module's owner to the Review. The owner
+// is the module's author or one of its contributors.
+func (r *Review) Respond(owner User, m Module, contributors Contributors, response string, now time.Time) error {
+	if !isOwner(owner, m, contributors) {
+		return fmt.Errorf("user %d is not an owner of module %s", owner.ID, m.Name)
+	}
+
+	v := &validator{}
+	if v.required("response", response) {
+		v.maxLength("response", response, MaxReviewLength)
+	}
+
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	r.Response = response
+	r.ResponderID = owner.ID
+	r.RespondedAt = &now
+	return nil
+}
+
+func isOwner(u User, m Module, contributors Contributors) bool {
+	if u.ID == m.Author {
+		return true
+	}
+
+	for _, c := range contributors {
+		if c.ID == u.ID {
+			return true
+		}
+	}
+
+	return false
+}
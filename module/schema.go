@@ -0,0 +1,166 @@
+package module
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ManifestSchemaVersion defines the current version of the manifest JSON Schema.
+const ManifestSchemaVersion = "v1"
+
+// manifestSchemas defines every supported version of the manifest JSON Schema.
+// A published schema version may only gain optional properties; any breaking
+// change requires a new version.
+var manifestSchemas = map[string]string{
+	"v1": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://atlas.cosmos.network/api/v1/schema/manifest/v1",
+  "title": "Atlas module manifest",
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["name", "version", "repo"],
+  "properties": {
+    "name": {"type": "string", "minLength": 1, "maxLength": 64},
+    "description": {"type": "string", "maxLength": 2048},
+    "version": {"type": "string", "pattern": "^v?(0|[1-9]\\d*)\\.(0|[1-9]\\d*)\\.(0|[1-9]\\d*)(-[0-9A-Za-z.-]+)?(\\+[0-9A-Za-z.-]+)?$"},
+    "homepage": {"type": "string", "format": "uri", "maxLength": 512},
+    "repo": {"type": "string", "format": "uri", "maxLength": 512},
+    "license": {"type": "string", "maxLength": 128},
+    "visibility": {"type": "string", "enum": ["public", "private"]},
+    "sdk_compat": {"type": "string", "minLength": 1, "maxLength": 128},
+    "keywords": {
+      "type": "array",
+      "maxItems": 10,
+      "items": {"type": "string", "minLength": 1, "maxLength": 32}
+    },
+    "authors": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string", "minLength": 1, "maxLength": 64},
+          "email": {"type": "string", "format": "email"},
+          "url": {"type": "string", "format": "uri"}
+        }
+      }
+    },
+    "bugs": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "url": {"type": "string", "format": "uri"},
+        "contact": {"type": "string"}
+      }
+    },
+    "readme": {"type": "string", "maxLength": 524288},
+    "dependencies": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name", "version"],
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "version": {"type": "string", "minLength": 1}
+        }
+      }
+    },
+    "interfaces": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "services": {"type": "array", "items": {"type": "string", "minLength": 1}},
+        "msgs": {"type": "array", "items": {"type": "string", "minLength": 1}},
+        "ibc": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["ports"],
+          "properties": {
+            "ports": {"type": "array", "minItems": 1, "items": {"type": "string", "minLength": 2, "maxLength": 128}},
+            "middleware": {"type": "boolean"}
+          }
+        }
+      }
+    }
+  }
+}`,
+}
+
+// ManifestSchema returns the manifest JSON Schema of the given version. An
+// empty version returns the current schema.
+func ManifestSchema(version string) ([]byte, error) {
+	if version == "" {
+		version = ManifestSchemaVersion
+	}
+
+	schema, ok := manifestSchemas[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown manifest schema version: %s", version)
+	}
+
+	return []byte(schema), nil
+}
+
+// ValidateSchema validates a Manifest against the manifest JSON Schema of the
+// given version, regardless of the format the manifest was encoded in. Schema
+// violations are returned as ValidationErrors.
+func (m Manifest) ValidateSchema(version string) error {
+	schema, err := ManifestSchema(version)
+	if err != nil {
+		return err
+	}
+
+	bz, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewBytesLoader(bz))
+	if err != nil {
+		return fmt.Errorf("failed to validate manifest schema: %w", err)
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	errs := make(ValidationErrors, len(result.Errors()))
+	for i, re := range result.Errors() {
+		errs[i] = FieldError{
+			Field:   strings.TrimPrefix(re.Field(), "(root)."),
+			Code:    schemaErrCode(re),
+			Message: re.Description(),
+		}
+	}
+
+	return errs
+}
+
+// schemaErrCode maps a JSON Schema violation to a validation error code.
+func schemaErrCode(re gojsonschema.ResultError) string {
+	switch re.Type() {
+	case "required", "string_gte", "array_min_items":
+		return ErrCodeRequired
+
+	case "string_lte", "array_max_items":
+		return ErrCodeTooLong
+
+	case "format":
+		if re.Details()["format"] == "email" {
+			return ErrCodeInvalidEmail
+		}
+
+		return ErrCodeInvalidURL
+
+	case "pattern":
+		return ErrCodeInvalidSemver
+
+	default:
+		return ErrCodeInvalidValue
+	}
+}
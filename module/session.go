@@ -0,0 +1,87 @@
+package module
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Session defines an authenticated session of a User on a device. Only a hash
+// of the session token is stored so that a leaked database cannot be used to
+// hijack sessions.
+type Session struct {
+	ID         int       `json:"id" yaml:"id" db:"id"`
+	UserID     int       `json:"-" yaml:"-" db:"user_id"`
+	TokenHash  string    `json:"-" yaml:"-" db:"token_hash"`
+	UserAgent  string    `json:"user_agent" yaml:"user_agent" db:"user_agent"`
+	IP         string    `json:"ip" yaml:"ip" db:"ip"`
+	CreatedAt  time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" yaml:"last_seen_at" db:"last_seen_at"`
+	RevokedAt  time.Time `json:"-" yaml:"-" db:"revoked_at"`
+}
+
+// NewSession returns a new Session for the given User along with the raw
+// session token to hand to the client.
+func NewSession(userID int, userAgent, ip string, now time.Time) (Session, string, error) {
+	bz := make([]byte, 32)
+	if _, err := rand.Read(bz); err != nil {
+		return Session{}, "", err
+	}
+
+	token := hex.EncodeToString(bz)
+	s := Session{
+		UserID:     userID,
+		TokenHash:  HashSessionToken(token),
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	return s, token, nil
+}
+
+// HashSessionToken returns the hash under which a session token is stored.
+func HashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CSRFToken returns the CSRF token of a session, which browser clients must
+// send along with mutating requests authenticated by the session cookie. It is
+// derived from the session token, so it is only known to frontends the session
+// was handed to and it is invalidated along with the session.
+func CSRFToken(token string) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte("atlas-csrf")) // nolint: errcheck
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Active returns true if the Session has not been revoked.
+func (s Session) Active() bool {
+	return s.RevokedAt.IsZero()
+}
+
+// Revoke revokes the Session, invalidating its token.
+func (s *Session) Revoke(now time.Time) {
+	if s.Active() {
+		s.RevokedAt = now
+	}
+}
+
+// RevokeOthers revokes every active session except the current one, returning
+// the sessions that were revoked.
+func RevokeOthers(sessions []Session, currentID int, now time.Time) []Session {
+	var revoked []Session
+
+	for i := range sessions {
+		if sessions[i].ID != currentID && sessions[i].Active() {
+			sessions[i].Revoke(now)
+			revoked = append(revoked, sessions[i])
+		}
+	}
+
+	return revoked
+}
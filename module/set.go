@@ -0,0 +1,102 @@
+package module
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// MaxModuleSetPins bounds the pinned versions of a ModuleSet.
+const MaxModuleSetPins = 200
+
+type (
+	// ModuleSet defines a named collection of pinned module versions, e.g. the
+	// baseline of a chain release, shared by its public ID so that teams can
+	// standardize on the same versions across chains. Private sets are only
+	// readable by their owner.
+	ModuleSet struct {
+		ID          int            `json:"-" yaml:"-" db:"id"`
+		PublicID    string         `json:"id" yaml:"id" db:"public_id"`
+		OwnerID     int            `json:"-" yaml:"-" db:"owner_id"`
+		Owner       string         `json:"owner" yaml:"owner"`
+		Name        string         `json:"name" yaml:"name" db:"name"`
+		Description string         `json:"description" yaml:"description" db:"description"`
+		Visibility  string         `json:"visibility" yaml:"visibility" db:"visibility"`
+		Pins        []ModuleSetPin `json:"pins" yaml:"pins"`
+		CreatedAt   time.Time      `json:"created_at" yaml:"created_at" db:"created_at"`
+		UpdatedAt   time.Time      `json:"updated_at" yaml:"updated_at" db:"updated_at"`
+	}
+
+	// ModuleSetPin defines a module version pinned by a ModuleSet. When a set
+	// is resolved, Resolved holds the pinned version.
+	ModuleSetPin struct {
+		Module   string         `json:"module" yaml:"module"`
+		Version  string         `json:"version" yaml:"version"`
+		Resolved *ModuleVersion `json:"resolved,omitempty" yaml:"-"`
+	}
+)
+
+// NewModuleSetID returns a random public ID of a ModuleSet.
+func NewModuleSetID() (string, error) {
+	bz := make([]byte, 12)
+	if _, err := rand.Read(bz); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bz), nil
+}
+
+// Validate performs validation of a ModuleSet. It must pin at least one
+// version, and each module at most once.
+func (s ModuleSet) Validate() error {
+	v := &validator{}
+
+	if v.required("name", s.Name) {
+		v.maxLength("name", s.Name, MaxNameLength)
+	}
+
+	v.maxLength("description", s.Description, MaxDescriptionLength)
+
+	if s.Visibility != "" {
+		v.oneOf("visibility", s.Visibility, VisibilityPublic, VisibilityPrivate)
+	}
+
+	switch {
+	case len(s.Pins) == 0:
+		v.fail("pins", ErrCodeRequired, "must pin at least one version")
+
+	case len(s.Pins) > MaxModuleSetPins:
+		v.fail("pins", ErrCodeTooLong, "must pin at most %d versions", MaxModuleSetPins)
+	}
+
+	seen := make(map[string]bool, len(s.Pins))
+	for i, p := range s.Pins {
+		field := fmt.Sprintf("pins[%d]", i)
+
+		if v.required(field+".module", p.Module) {
+			if slug := Slug(p.Module); seen[slug] {
+				v.fail(field+".module", ErrCodeInvalidValue, "module is pinned more than once")
+			} else {
+				seen[slug] = true
+			}
+		}
+
+		if v.required(field+".version", p.Version) {
+			v.semver(field+".version", p.Version)
+		}
+	}
+
+	return v.err()
+}
+
+// Private returns true if the ModuleSet is only readable by its owner.
+func (s ModuleSet) Private() bool {
+	return s.Visibility == VisibilityPrivate
+}
+
+// ReadableBy returns true if the user, nil for anonymous requests, may read
+// the ModuleSet.
+func (s ModuleSet) ReadableBy(u *User) bool {
+	return !s.Private() || (u != nil && (u.ID == s.OwnerID || u.Admin))
+}
@@ -0,0 +1,37 @@
+package module
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables maps characters that are visually confusable with ASCII letters
+// and digits to their ASCII skeleton, so that e.g. a Cyrillic "а" cannot be
+// used to register a lookalike of an existing module name.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'с': 'c', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j', 'к': 'k',
+	'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'ѕ': 's', 'т': 't', 'у': 'y', 'х': 'x',
+	'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ӏ': 'l',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x',
+	// Latin lookalikes
+	'ı': 'i', 'ℓ': 'l',
+}
+
+// Slug returns the canonical form of a name used to enforce uniqueness. The
+// name is NFKC-normalized, lowercased and confusable characters are mapped to
+// their ASCII skeleton, while the original casing is preserved for display.
+func Slug(name string) string {
+	s := strings.ToLower(norm.NFKC.String(strings.TrimSpace(name)))
+
+	return strings.Map(func(r rune) rune {
+		if c, ok := confusables[r]; ok {
+			return c
+		}
+
+		return r
+	}, s)
+}
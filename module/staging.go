@@ -0,0 +1,76 @@
+package module
+
+import "fmt"
+
+// Version statuses. Staged versions are visible only to the module's owners
+// and are never resolvable until released. Quarantined versions failed an
+// artifact scan and are not resolvable until cleared by an admin. An empty
+// status is published.
+const (
+	VersionStatusStaged      = "staged"
+	VersionStatusPublished   = "published"
+	VersionStatusQuarantined = "quarantined"
+)
+
+// Staged returns true if the ModuleVersion is pending release.
+func (mv ModuleVersion) Staged() bool {
+	return mv.Status == VersionStatusStaged
+}
+
+// Quarantined returns true if the ModuleVersion is blocked pending review of
+// its artifact scan findings.
+func (mv ModuleVersion) Quarantined() bool {
+	return mv.Status == VersionStatusQuarantined
+}
+
+// Resolvable returns true if the ModuleVersion may be resolved by clients,
+// i.e. it is published and not yanked.
+func (mv ModuleVersion) Resolvable() bool {
+	return !mv.Staged() && !mv.Quarantined() && !mv.Yanked
+}
+
+// Release publishes a staged ModuleVersion. Releasing is a single status
+// update, so the version becomes resolvable atomically. Discarding a staged
+// version instead deletes it along with its artifact.
+func (mv *ModuleVersion) Release() error {
+	if !mv.Staged() {
+		return fmt.Errorf("version %s is not staged", mv.Version)
+	}
+
+	mv.Status = VersionStatusPublished
+	return nil
+}
+
+// Quarantine blocks the ModuleVersion from resolution for the given reason,
+// remembering its status so that it can be restored once cleared.
+func (mv *ModuleVersion) Quarantine(reason string) error {
+	if mv.Quarantined() {
+		return fmt.Errorf("version %s is already quarantined", mv.Version)
+	}
+
+	mv.QuarantinedFrom = mv.Status
+	if mv.QuarantinedFrom == "" {
+		mv.QuarantinedFrom = VersionStatusPublished
+	}
+
+	mv.Status = VersionStatusQuarantined
+	mv.QuarantineReason = reason
+	return nil
+}
+
+// Clear lifts the quarantine of the ModuleVersion on behalf of the given
+// admin, restoring the status it had when quarantined.
+func (mv *ModuleVersion) Clear(admin User) error {
+	if !admin.CanModerate() {
+		return fmt.Errorf("user %d is not permitted to clear quarantined versions", admin.ID)
+	}
+
+	if !mv.Quarantined() {
+		return fmt.Errorf("version %s is not quarantined", mv.Version)
+	}
+
+	mv.Status = mv.QuarantinedFrom
+	mv.QuarantinedFrom = ""
+	mv.QuarantineReason = ""
+	return nil
+}
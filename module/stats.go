@@ -0,0 +1,30 @@
+package module
+
+import "time"
+
+type (
+	// WeeklyPublishes defines the number of versions published in the week
+	// starting on Week.
+	WeeklyPublishes struct {
+		Week      time.Time `json:"week" yaml:"week" db:"week"`
+		Publishes int64     `json:"publishes" yaml:"publishes" db:"publishes"`
+	}
+
+	// PublisherStats defines the number of public modules authored by a User.
+	PublisherStats struct {
+		Name    string `json:"name" yaml:"name" db:"name"`
+		Modules int64  `json:"modules" yaml:"modules" db:"modules"`
+	}
+
+	// RegistryStats defines registry-wide aggregate metrics over public,
+	// listed modules, as computed by the stats rollup job.
+	RegistryStats struct {
+		Modules         int64               `json:"modules" yaml:"modules" db:"modules"`
+		Versions        int64               `json:"versions" yaml:"versions" db:"versions"`
+		Publishers      int64               `json:"publishers" yaml:"publishers" db:"publishers"`
+		Downloads       int64               `json:"downloads" yaml:"downloads" db:"downloads"`
+		WeeklyPublishes []WeeklyPublishes   `json:"weekly_publishes" yaml:"weekly_publishes"`
+		TopKeywords     []KeywordPopularity `json:"top_keywords" yaml:"top_keywords"`
+		TopPublishers   []PublisherStats    `json:"top_publishers" yaml:"top_publishers"`
+	}
+)
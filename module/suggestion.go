@@ -0,0 +1,46 @@
+package module
+
+import (
+	"fmt"
+	"time"
+)
+
+// Suggested module sources.
+const (
+	SuggestionSourceGitHubTopic  = "github_topic"
+	SuggestionSourceAwesomeList  = "awesome_list"
+	SuggestionSourceManualReport = "manual"
+)
+
+// Suggested module statuses.
+const (
+	SuggestionStatusPending  = "pending"
+	SuggestionStatusApproved = "approved"
+	SuggestionStatusRejected = "rejected"
+)
+
+// SuggestedModule defines an entry in the admin curation queue for a module
+// discovered from an external source that has not yet been registered.
+type SuggestedModule struct {
+	ID        int       `json:"id" yaml:"id" db:"id"`
+	Name      string    `json:"name" yaml:"name" db:"name"`
+	Repo      string    `json:"repo" yaml:"repo" db:"repo"`
+	Source    string    `json:"source" yaml:"source" db:"source"`
+	Status    string    `json:"status" yaml:"status" db:"status"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+}
+
+// Review records an admin decision on a pending SuggestedModule.
+func (sm *SuggestedModule) Review(approved bool) error {
+	if sm.Status != SuggestionStatusPending {
+		return fmt.Errorf("suggested module %s has already been reviewed", sm.Repo)
+	}
+
+	if approved {
+		sm.Status = SuggestionStatusApproved
+	} else {
+		sm.Status = SuggestionStatusRejected
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package module
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// MaxTokenCIDRs defines the maximum number of CIDR ranges in the IP allowlist
+// of an API token.
+const MaxTokenCIDRs = 32
+
+// ValidateCIDRs performs validation of the IP allowlist of an API token.
+func ValidateCIDRs(cidrs []string) error {
+	v := &validator{}
+
+	if len(cidrs) > MaxTokenCIDRs {
+		v.fail("cidrs", ErrCodeTooLong, "must not contain more than %d ranges", MaxTokenCIDRs)
+	}
+
+	for i, c := range cidrs {
+		if _, _, err := net.ParseCIDR(c); err != nil {
+			v.fail(fmt.Sprintf("cidrs[%d]", i), ErrCodeInvalidValue, "must be a valid CIDR range")
+		}
+	}
+
+	return v.err()
+}
+
+// TokenAllowsIP returns true if the User's API token may be used from the
+// given IP address. A token without an allowlist may be used from anywhere.
+func (u User) TokenAllowsIP(ip net.IP) bool {
+	if len(u.APITokenCIDRs) == 0 {
+		return true
+	}
+
+	if ip == nil {
+		return false
+	}
+
+	for _, c := range u.APITokenCIDRs {
+		if _, n, err := net.ParseCIDR(c); err == nil && n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TokenExpired returns true if the User's API token is older than maxAge. A
+// zero maxAge never expires tokens.
+func (u User) TokenExpired(maxAge time.Duration, now time.Time) bool {
+	return maxAge > 0 && now.Sub(u.APITokenCreatedAt) > maxAge
+}
@@ -0,0 +1,78 @@
+package module
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// TrustPolicy defines a policy that permits a CI workflow to publish a Module
+// without a long-lived token. The policy is matched against the claims of a
+// verified OIDC ID token, where the repository must additionally match the
+// Module's registered Repo.
+type TrustPolicy struct {
+	ID         int       `json:"id" yaml:"id" db:"id"`
+	ModuleID   int       `json:"-" yaml:"-" db:"module_id"`
+	Repository string    `json:"repository" yaml:"repository" db:"repository"`
+	Workflow   string    `json:"workflow" yaml:"workflow" db:"workflow"`
+	Ref        string    `json:"ref" yaml:"ref" db:"ref"`
+	CreatedAt  time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+}
+
+// Allows returns true if the given repository (owner/name), workflow and git
+// ref are permitted to publish the Module. An empty Workflow or Ref matches
+// any value, otherwise Ref may be a glob pattern (e.g. "refs/tags/v*").
+func (tp TrustPolicy) Allows(m Module, repository, workflow, ref string) bool {
+	if !strings.EqualFold(tp.Repository, repository) || !strings.EqualFold(repoSlug(m.Repo), repository) {
+		return false
+	}
+
+	if tp.Workflow != "" && tp.Workflow != workflow {
+		return false
+	}
+
+	if tp.Ref != "" {
+		if ok, err := path.Match(tp.Ref, ref); err != nil || !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Validate returns ValidationErrors unless the TrustPolicy's repository is the
+// owner/name slug of the Module's registered Repo and its Ref, if any, is a
+// valid glob pattern.
+func (tp TrustPolicy) Validate(m Module) error {
+	var errs ValidationErrors
+
+	switch {
+	case tp.Repository == "":
+		errs = append(errs, FieldError{Field: "repository", Code: ErrCodeRequired, Message: "is required"})
+
+	case !strings.EqualFold(tp.Repository, repoSlug(m.Repo)):
+		errs = append(errs, FieldError{Field: "repository", Code: ErrCodeInvalidValue, Message: "must be the repository of the module"})
+	}
+
+	if _, err := path.Match(tp.Ref, ""); err != nil {
+		errs = append(errs, FieldError{Field: "ref", Code: ErrCodeInvalidValue, Message: "must be a valid glob pattern"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// repoSlug returns the owner/name slug of a repository URL such as
+// "https://github.com/cosmos/cosmos-sdk.git".
+func repoSlug(repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+}
@@ -0,0 +1,134 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Kinds of version upgrades.
+const (
+	UpgradePatch = "patch"
+	UpgradeMinor = "minor"
+	UpgradeMajor = "major"
+)
+
+type (
+	// Upgrade defines a version a dependency may be upgraded to.
+	Upgrade struct {
+		Version   string `json:"version" yaml:"version"`
+		Kind      string `json:"kind" yaml:"kind"`
+		SDKCompat string `json:"sdk_compat,omitempty" yaml:"sdk_compat,omitempty"`
+
+		// SDKCompatChanged reports whether the version's Cosmos SDK constraint
+		// differs from the current version's, e.g. because it requires a newer
+		// SDK release.
+		SDKCompatChanged bool `json:"sdk_compat_changed" yaml:"sdk_compat_changed"`
+
+		// Advisories defines the open advisories affecting the version.
+		Advisories []Advisory `json:"advisories,omitempty" yaml:"advisories,omitempty"`
+
+		// ChangelogURL defines the URL of the version's release notes on the
+		// module's Git provider, if hosted on a supported one.
+		ChangelogURL string `json:"changelog_url,omitempty" yaml:"changelog_url,omitempty"`
+	}
+
+	// UpgradeAdvice defines the available upgrades of a dependency pinned to a
+	// version, along with warnings about the current version.
+	UpgradeAdvice struct {
+		Yanked     bool       `json:"yanked" yaml:"yanked"`
+		Advisories []Advisory `json:"advisories,omitempty" yaml:"advisories,omitempty"`
+
+		// Archived reports whether the module's owners archived it, so that
+		// no further versions will be published.
+		Archived bool `json:"archived" yaml:"archived"`
+
+		// Deprecation defines the module's deprecation, if any, naming the
+		// module to migrate to.
+		Deprecation *Deprecation `json:"deprecation,omitempty" yaml:"deprecation,omitempty"`
+
+		// Upgrades defines the greatest available patch, minor and major
+		// upgrade, in that order, omitting kinds without any.
+		Upgrades []Upgrade `json:"upgrades" yaml:"upgrades"`
+	}
+)
+
+// UpgradeKind classifies the upgrade between two versions as a patch, minor
+// or major upgrade.
+func UpgradeKind(from, to *semver.Version) string {
+	switch {
+	case to.Major() != from.Major():
+		return UpgradeMajor
+	case to.Minor() != from.Minor():
+		return UpgradeMinor
+	default:
+		return UpgradePatch
+	}
+}
+
+// AdviseUpgrade returns the upgrades available from the current version of a
+// module given all of its versions and advisories. Only resolvable versions
+// greater than the current one are considered, and pre-releases only when the
+// current version is itself a pre-release.
+func AdviseUpgrade(current string, versions []ModuleVersion, advisories []Advisory) (UpgradeAdvice, error) {
+	cur, err := semver.NewVersion(current)
+	if err != nil {
+		return UpgradeAdvice{}, fmt.Errorf("invalid version %q: %w", current, err)
+	}
+
+	advice := UpgradeAdvice{Upgrades: []Upgrade{}}
+
+	var curCompat string
+	for _, mv := range versions {
+		if v, err := semver.NewVersion(mv.Version); err == nil && v.Equal(cur) {
+			advice.Yanked = mv.Yanked
+			curCompat = mv.SDKCompat
+		}
+	}
+
+	if advice.Advisories, err = Affecting(cur.Original(), advisories); err != nil {
+		return UpgradeAdvice{}, err
+	}
+
+	best := make(map[string]*semver.Version)
+	bestVersions := make(map[string]ModuleVersion)
+
+	for _, mv := range versions {
+		if !mv.Resolvable() {
+			continue
+		}
+
+		v, err := semver.NewVersion(mv.Version)
+		if err != nil || !v.GreaterThan(cur) || (v.Prerelease() != "" && cur.Prerelease() == "") {
+			continue
+		}
+
+		kind := UpgradeKind(cur, v)
+		if b, ok := best[kind]; !ok || v.GreaterThan(b) {
+			best[kind] = v
+			bestVersions[kind] = mv
+		}
+	}
+
+	for _, kind := range []string{UpgradePatch, UpgradeMinor, UpgradeMajor} {
+		mv, ok := bestVersions[kind]
+		if !ok {
+			continue
+		}
+
+		u := Upgrade{
+			Version:          mv.Version,
+			Kind:             kind,
+			SDKCompat:        mv.SDKCompat,
+			SDKCompatChanged: mv.SDKCompat != curCompat,
+		}
+
+		if u.Advisories, err = Affecting(mv.Version, advisories); err != nil {
+			return UpgradeAdvice{}, err
+		}
+
+		advice.Upgrades = append(advice.Upgrades, u)
+	}
+
+	return advice, nil
+}
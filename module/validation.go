@@ -0,0 +1,267 @@
+package module
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Validation limits.
+const (
+	MaxNameLength        = 64
+	MaxDescriptionLength = 2048
+	MaxKeywordLength     = 32
+	MaxURLLength         = 512
+	MaxKeywords          = 10
+	MaxReadmeLength      = 512 * 1024
+)
+
+// Machine-readable validation error codes.
+const (
+	ErrCodeRequired      = "required"
+	ErrCodeTooLong       = "too_long"
+	ErrCodeInvalidURL    = "invalid_url"
+	ErrCodeInvalidEmail  = "invalid_email"
+	ErrCodeInvalidSemver = "invalid_semver"
+	ErrCodeInvalidValue  = "invalid_value"
+	ErrCodeInvalidSPDX   = "invalid_spdx"
+)
+
+// FieldError defines a validation failure of a single field.
+type FieldError struct {
+	Field   string `json:"field" yaml:"field"`
+	Code    string `json:"code" yaml:"code"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (fe FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+}
+
+// ValidationErrors defines a list of field validation failures. It implements
+// the error interface so it may be returned directly from Validate methods.
+type ValidationErrors []FieldError
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, fe := range ve {
+		msgs[i] = fe.Error()
+	}
+
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// validator accumulates field errors.
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) fail(field, code, format string, args ...interface{}) {
+	v.errs = append(v.errs, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// nest records the field errors of a nested value under the given prefix.
+func (v *validator) nest(prefix string, err error) {
+	for _, fe := range err.(ValidationErrors) {
+		fe.Field = prefix + "." + fe.Field
+		v.errs = append(v.errs, fe)
+	}
+}
+
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.fail(field, ErrCodeRequired, "must not be empty")
+		return false
+	}
+
+	return true
+}
+
+func (v *validator) maxLength(field, value string, max int) {
+	if len(value) > max {
+		v.fail(field, ErrCodeTooLong, "must not exceed %d characters", max)
+	}
+}
+
+func (v *validator) url(field, value string) {
+	if value == "" {
+		return
+	}
+
+	v.maxLength(field, value, MaxURLLength)
+
+	u, err := url.ParseRequestURI(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.fail(field, ErrCodeInvalidURL, "must be a valid http(s) URL")
+	}
+}
+
+func (v *validator) email(field, value string) {
+	if value == "" {
+		return
+	}
+
+	if _, err := mail.ParseAddress(value); err != nil {
+		v.fail(field, ErrCodeInvalidEmail, "must be a valid email address")
+	}
+}
+
+func (v *validator) semver(field, value string) {
+	if _, err := semver.StrictNewVersion(strings.TrimPrefix(value, "v")); err != nil {
+		v.fail(field, ErrCodeInvalidSemver, "must be a valid semantic version")
+	}
+}
+
+func (v *validator) constraint(field, value string) {
+	if _, err := semver.NewConstraint(value); err != nil {
+		v.fail(field, ErrCodeInvalidSemver, "must be a valid semantic version constraint")
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+
+	v.fail(field, ErrCodeInvalidValue, "must be one of: %s", strings.Join(allowed, ", "))
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+
+	return v.errs
+}
+
+// Validate performs validation of a Module prior to persisting it. It returns
+// ValidationErrors describing every invalid field.
+func (m Module) Validate() error {
+	v := &validator{}
+
+	if v.required("name", m.Name) {
+		v.maxLength("name", m.Name, MaxNameLength)
+	}
+
+	v.maxLength("description", m.Description, MaxDescriptionLength)
+
+	if v.required("version", m.Version) {
+		v.semver("version", m.Version)
+	}
+
+	if v.required("repo", m.Repo) {
+		v.url("repo", m.Repo)
+	}
+
+	v.url("homepage", m.Homepage)
+
+	if m.License != "" && !ValidLicense(m.License) {
+		v.fail("license", ErrCodeInvalidSPDX, "must be a valid SPDX license expression")
+	}
+
+	if m.Visibility != "" {
+		v.oneOf("visibility", m.Visibility, VisibilityPublic, VisibilityPrivate)
+	}
+
+	return v.err()
+}
+
+// Validate performs validation of a User.
+func (u User) Validate() error {
+	v := &validator{}
+
+	if v.required("email", u.Email) {
+		v.email("email", u.Email)
+	}
+
+	v.maxLength("name", u.Name, MaxNameLength)
+	v.url("url", u.URL)
+	v.url("avatar_url", u.AvatarURL)
+	v.maxLength("bio", u.Bio, MaxDescriptionLength)
+
+	return v.err()
+}
+
+// Validate performs validation of a Keyword.
+func (k Keyword) Validate() error {
+	v := &validator{}
+
+	if v.required("name", k.Name) {
+		v.maxLength("name", k.Name, MaxKeywordLength)
+	}
+
+	return v.err()
+}
+
+// Validate performs validation of a Bug.
+func (b Bug) Validate() error {
+	v := &validator{}
+
+	v.url("url", b.URL)
+
+	if strings.Contains(b.Contact, "@") {
+		v.email("contact", b.Contact)
+	} else {
+		v.url("contact", b.Contact)
+	}
+
+	return v.err()
+}
+
+// Validate performs validation of a Manifest, including the Module it
+// describes, its keywords, authors and dependencies.
+func (m Manifest) Validate() error {
+	v := &validator{}
+
+	if err := m.Module().Validate(); err != nil {
+		v.errs = append(v.errs, err.(ValidationErrors)...)
+	}
+
+	if len(m.Keywords) > MaxKeywords {
+		v.fail("keywords", ErrCodeTooLong, "must not contain more than %d keywords", MaxKeywords)
+	}
+
+	for i, k := range m.Keywords {
+		field := fmt.Sprintf("keywords[%d]", i)
+		if v.required(field, k) {
+			v.maxLength(field, k, MaxKeywordLength)
+		}
+	}
+
+	for i, a := range m.Authors {
+		v.required(fmt.Sprintf("authors[%d].name", i), a.Name)
+		v.email(fmt.Sprintf("authors[%d].email", i), a.Email)
+		v.url(fmt.Sprintf("authors[%d].url", i), a.URL)
+	}
+
+	v.maxLength("readme", m.Readme, MaxReadmeLength)
+
+	if m.SDKCompat != "" {
+		v.constraint("sdk_compat", m.SDKCompat)
+	}
+
+	if m.Interfaces != nil {
+		if err := m.Interfaces.Validate(); err != nil {
+			v.nest("interfaces", err)
+		}
+	}
+
+	if m.Bugs != nil {
+		if err := m.Bugs.Validate(); err != nil {
+			v.nest("bugs", err)
+		}
+	}
+
+	for i, md := range m.Dependencies {
+		if err := md.Validate(); err != nil {
+			v.nest(fmt.Sprintf("dependencies[%d]", i), err)
+		}
+	}
+
+	return v.err()
+}
@@ -0,0 +1,194 @@
+package module
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/cosmos/atlas/signature"
+)
+
+var (
+	// ErrVersionConflict is returned when publishing an existing version with
+	// different contents.
+	ErrVersionConflict = errors.New("version already published with different contents")
+
+	// ErrVersionInUse is returned when deleting a version that registered
+	// modules still depend on.
+	ErrVersionInUse = errors.New("version is depended on by registered modules")
+
+	// ErrNoMatchingVersion is returned when no published version satisfies a
+	// version constraint.
+	ErrNoMatchingVersion = errors.New("no version satisfies the constraint")
+)
+
+// ModuleVersion defines a published version of a Module. Each version may
+// optionally carry a source tarball artifact identified by its SHA-256
+// checksum.
+type ModuleVersion struct {
+	ID               int      `json:"-" yaml:"-" db:"id"`
+	ModuleID         int      `json:"-" yaml:"-" db:"module_id"`
+	Version          string   `json:"version" yaml:"version" db:"version"`
+	Checksum         string   `json:"checksum" yaml:"checksum" db:"checksum"`
+	ArtifactKey      string   `json:"-" yaml:"-" db:"artifact_key"`
+	ArtifactSize     int64    `json:"artifact_size" yaml:"artifact_size" db:"artifact_size"`
+	Downloads        int64    `json:"downloads" yaml:"downloads" db:"downloads"`
+	Yanked           bool     `json:"yanked" yaml:"yanked" db:"yanked"`
+	Status           string   `json:"status" yaml:"-" db:"status"`
+	Verified         bool     `json:"verified" yaml:"-" db:"verified"`
+	PublishedBy      int      `json:"-" yaml:"-" db:"published_by"`
+	QuarantinedFrom  string   `json:"-" yaml:"-" db:"quarantined_from"`
+	QuarantineReason string   `json:"quarantine_reason,omitempty" yaml:"-" db:"quarantine_reason"`
+	Changelog        string   `json:"changelog" yaml:"changelog" db:"changelog"`
+	Readme           string   `json:"readme" yaml:"readme" db:"readme"`
+	RenderedReadme   string   `json:"rendered_readme" yaml:"-" db:"rendered_readme"`
+	Manifest         Manifest `json:"-" yaml:"-" db:"manifest"`
+	SDKCompat        string   `json:"sdk_compat,omitempty" yaml:"sdk_compat,omitempty" db:"sdk_compat"`
+	Signature        `json:"signature" yaml:"signature"`
+	CreatedAt        time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+
+	// Advisories defines the open security advisories affecting the version,
+	// if listed along with them.
+	Advisories []Advisory `json:"advisories,omitempty" yaml:"-"`
+}
+
+// Signature defines the signature metadata attached to a ModuleVersion, where
+// KeyID references the PublicKey that verified the signature at publish time.
+type Signature struct {
+	Kind  string `json:"kind,omitempty" yaml:"kind,omitempty" db:"signature_kind"`
+	Value string `json:"value,omitempty" yaml:"value,omitempty" db:"signature"`
+	KeyID int    `json:"key_id,omitempty" yaml:"key_id,omitempty" db:"signature_key_id"`
+}
+
+// Signed returns true if a signature is attached.
+func (s Signature) Signed() bool {
+	return s.Value != ""
+}
+
+// Verify verifies the signature over payload against the given PublicKey and
+// records the key as the signer.
+func (s *Signature) Verify(key PublicKey, payload []byte) error {
+	if s.Kind != key.Kind {
+		return fmt.Errorf("signature kind %s does not match key kind %s", s.Kind, key.Kind)
+	}
+
+	if err := signature.Verify(s.Kind, key.Key, s.Value, payload); err != nil {
+		return err
+	}
+
+	s.KeyID = key.ID
+	return nil
+}
+
+// HasArtifact returns true if a source tarball was uploaded for the version.
+func (mv ModuleVersion) HasArtifact() bool {
+	return mv.ArtifactKey != ""
+}
+
+// Republish checks whether next may be published over the existing version.
+// Republishing identical contents, i.e. the same artifact checksum, signature,
+// manifest, changelog, readme and SDK constraint, is a no-op, whereas any
+// difference is rejected with ErrVersionConflict as published versions are
+// immutable. Only a yanked version may be replaced with different contents,
+// and only with force set.
+func (mv ModuleVersion) Republish(next ModuleVersion, force bool) error {
+	same, err := mv.sameContents(next)
+	if err != nil {
+		return err
+	}
+
+	if same {
+		return nil
+	}
+
+	if mv.Yanked && force {
+		return nil
+	}
+
+	if mv.Yanked {
+		return fmt.Errorf("%w: %s is yanked; replace it with force", ErrVersionConflict, mv.Version)
+	}
+
+	return fmt.Errorf("%w: %s", ErrVersionConflict, mv.Version)
+}
+
+// sameContents returns true if next has the same contents and metadata as the
+// version, comparing manifests by their canonical JSON encoding.
+func (mv ModuleVersion) sameContents(next ModuleVersion) (bool, error) {
+	if next.Checksum != mv.Checksum || next.Signature.Value != mv.Signature.Value ||
+		next.Changelog != mv.Changelog || next.Readme != mv.Readme || next.SDKCompat != mv.SDKCompat {
+		return false, nil
+	}
+
+	a, err := mv.Manifest.Value()
+	if err != nil {
+		return false, err
+	}
+
+	b, err := next.Manifest.Value()
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(a.([]byte), b.([]byte)), nil
+}
+
+// CanDelete checks whether the version may be deleted given the dependency
+// edges pointing at its module. Deletion is refused with ErrVersionInUse while
+// any dependent's constraint resolves to the version, unless it was yanked
+// first.
+func (mv ModuleVersion) CanDelete(dependents []ModuleDependency) error {
+	if mv.Yanked {
+		return nil
+	}
+
+	impacted, err := ImpactedDependencies(mv.Version, dependents)
+	if err != nil {
+		return err
+	}
+
+	if len(impacted) > 0 {
+		return fmt.Errorf("%w: %s has %d dependent(s); yank it first", ErrVersionInUse, mv.Version, len(impacted))
+	}
+
+	return nil
+}
+
+// Resolve returns the highest resolvable version satisfying the given semantic
+// version constraint (e.g. "^0.44"). Versions that are not valid semantic
+// versions are ignored. ErrNoMatchingVersion is returned if none match.
+func Resolve(constraint string, versions []ModuleVersion) (ModuleVersion, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return ModuleVersion{}, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+
+	var (
+		best    ModuleVersion
+		bestVer *semver.Version
+	)
+
+	for _, mv := range versions {
+		if !mv.Resolvable() {
+			continue
+		}
+
+		v, err := semver.NewVersion(mv.Version)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+
+		if bestVer == nil || v.GreaterThan(bestVer) {
+			best, bestVer = mv, v
+		}
+	}
+
+	if bestVer == nil {
+		return ModuleVersion{}, fmt.Errorf("%w: %s", ErrNoMatchingVersion, constraint)
+	}
+
+	return best, nil
+}
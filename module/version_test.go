@@ -0,0 +1,49 @@
+package module
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRepublish(t *testing.T) {
+	published := ModuleVersion{
+		Version:  "v1.2.0",
+		Checksum: "8e2f2c0a",
+		Manifest: testManifest(),
+	}
+
+	changed := published
+	changed.Checksum = "5b1d7e93"
+
+	testCases := []struct {
+		name     string
+		yanked   bool
+		next     ModuleVersion
+		force    bool
+		conflict bool
+	}{
+		{"identical", false, published, false, false},
+		{"identical forced", false, published, true, false},
+		{"identical yanked", true, published, false, false},
+		{"different", false, changed, false, true},
+		{"different forced", false, changed, true, true},
+		{"different yanked", true, changed, false, true},
+		{"different yanked forced", true, changed, true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			existing := published
+			existing.Yanked = tc.yanked
+
+			err := existing.Republish(tc.next, tc.force)
+			switch {
+			case tc.conflict && !errors.Is(err, ErrVersionConflict):
+				t.Fatalf("expected version conflict, got %v", err)
+
+			case !tc.conflict && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package module
+
+import "time"
+
+// Module visibilities. Modules without a visibility are public.
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// ModuleGrant defines read access to a private Module granted to a user, and
+// thereby to the user's API tokens.
+type ModuleGrant struct {
+	ModuleID  int       `json:"-" yaml:"-" db:"module_id"`
+	UserID    int       `json:"-" yaml:"-" db:"user_id"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at" db:"created_at"`
+}
+
+// Private returns true if the Module is only readable by users it was granted
+// to.
+func (m Module) Private() bool {
+	return m.Visibility == VisibilityPrivate
+}
+
+// ReadableBy returns true if the user may read and resolve the Module. The
+// user is nil for anonymous requests; granted reports whether the user is a
+// contributor of the Module or holds a ModuleGrant for it. Queries listing
+// modules (search, lists, feeds and stats) must apply the equivalent filter
+// implemented by the module_readable SQL function.
+func (m Module) ReadableBy(u *User, granted bool) bool {
+	if !m.Private() {
+		return true
+	}
+
+	if u == nil || u.Banned {
+		return false
+	}
+
+	return u.ID == m.Author || u.Admin || granted
+}
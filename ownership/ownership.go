@@ -0,0 +1,127 @@
+package ownership
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// DefaultTimeout defines the default timeout of a single ownership check.
+const DefaultTimeout = 10 * time.Second
+
+// ErrNotProven is returned when the user has not proven control of the
+// subject of a PublisherVerification.
+var ErrNotProven = errors.New("control of publisher subject not proven")
+
+// Checker checks that users control the GitHub organizations and domains they
+// request publisher verification for.
+type Checker struct {
+	client   *http.Client
+	resolver *net.Resolver
+	apiURL   string
+}
+
+// NewChecker returns a Checker using the given HTTP client and DNS resolver. A
+// nil resolver uses the default resolver.
+func NewChecker(client *http.Client, resolver *net.Resolver) *Checker {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &Checker{
+		client:   client,
+		resolver: resolver,
+		apiURL:   "https://api.github.com",
+	}
+}
+
+// Check returns nil if the user proved control of the subject of the
+// PublisherVerification. GitHub organizations are proven by the user being an
+// active admin of the organization, as seen through the user's GitHub access
+// token; domains are proven by a DNS TXT record holding the verification's
+// TXTRecord. Otherwise ErrNotProven is returned.
+func (c *Checker) Check(ctx context.Context, pv module.PublisherVerification, u module.User) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	switch pv.Kind {
+	case module.VerificationGitHubOrg:
+		return c.checkGitHubOrg(ctx, pv.Subject, u.GithubAccessToken)
+
+	case module.VerificationDomain:
+		return c.checkDomain(ctx, pv.Subject, pv.TXTRecord())
+
+	default:
+		return fmt.Errorf("unknown publisher verification kind: %s", pv.Kind)
+	}
+}
+
+func (c *Checker) checkGitHubOrg(ctx context.Context, org, token string) error {
+	if token == "" {
+		return fmt.Errorf("%w: no GitHub access token", ErrNotProven)
+	}
+
+	u := fmt.Sprintf("%s/user/memberships/orgs/%s", c.apiURL, url.PathEscape(org))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "token "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: not a member of GitHub organization %s", ErrNotProven, org)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("failed to look up membership of GitHub organization %s: GitHub responded %d", org, resp.StatusCode)
+	}
+
+	var membership struct {
+		State string `json:"state"`
+		Role  string `json:"role"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&membership); err != nil {
+		return fmt.Errorf("failed to decode GitHub organization membership: %w", err)
+	}
+
+	if membership.State != "active" || membership.Role != "admin" {
+		return fmt.Errorf("%w: not an admin of GitHub organization %s", ErrNotProven, org)
+	}
+
+	return nil
+}
+
+func (c *Checker) checkDomain(ctx context.Context, domain, record string) error {
+	records, err := c.resolver.LookupTXT(ctx, domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return fmt.Errorf("%w: no TXT records for %s", ErrNotProven, domain)
+		}
+
+		return fmt.Errorf("failed to look up TXT records of %s: %w", domain, err)
+	}
+
+	for _, r := range records {
+		if r == record {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: TXT record %q not found on %s", ErrNotProven, record, domain)
+}
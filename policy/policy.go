@@ -0,0 +1,386 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// Policy violation error codes.
+const (
+	ErrCodeNameReserved        = "name_reserved"
+	ErrCodeDescriptionTooShort = "description_too_short"
+	ErrCodeNameCooldown        = "name_cooldown"
+	ErrCodeTwoFactorRequired   = "two_factor_required"
+	ErrCodeModuleQuota         = "module_quota_exceeded"
+	ErrCodeVersionQuota        = "version_quota_exceeded"
+	ErrCodeManifestTooLarge    = "manifest_too_large"
+	ErrCodeReadmeTooLarge      = "readme_too_large"
+	ErrCodeTooManyKeywords     = "too_many_keywords"
+)
+
+var (
+	// ErrTokenExpired is returned when an API token is older than the maximum
+	// token age of the registry.
+	ErrTokenExpired = errors.New("API token expired")
+
+	// ErrTokenIPDenied is returned when an API token is used from an IP address
+	// outside its allowlist, or has no allowlist when one is required.
+	ErrTokenIPDenied = errors.New("API token not permitted from this address")
+)
+
+// Operations that may require two-factor authentication.
+const (
+	OpPublish     = "publish"
+	OpYank        = "yank"
+	OpTokenCreate = "token_create"
+)
+
+// Config defines the publish-time policy configuration of a registry.
+type Config struct {
+	// ReservedNames maps a module name or glob pattern (e.g. "x/*") to the
+	// names of the users permitted to publish under it.
+	ReservedNames map[string][]string `yaml:"reserved_names"`
+
+	// MinDescriptionLength defines the minimum length of a module description.
+	MinDescriptionLength int `yaml:"min_description_length"`
+
+	// ReclaimCooldown defines how long the name of a deleted module is held
+	// before a different user may claim it.
+	ReclaimCooldown time.Duration `yaml:"reclaim_cooldown"`
+
+	// DeletedRetention defines how long a soft-deleted module is retained, and
+	// may be restored, before it is purged.
+	DeletedRetention time.Duration `yaml:"deleted_retention"`
+
+	// RequireTwoFactor requires users to have enrolled in and verified
+	// two-factor authentication before publishing, yanking or creating tokens.
+	RequireTwoFactor bool `yaml:"require_two_factor"`
+
+	// Limits defines the publish quotas and size limits. A zero limit is
+	// unlimited.
+	Limits Limits `yaml:"limits"`
+
+	// Tokens defines the expiry and IP allowlist policy of API tokens.
+	Tokens TokenPolicy `yaml:"tokens"`
+}
+
+// TokenPolicy defines the expiry and IP allowlist policy of API tokens,
+// enforced whenever a request authenticates with a token.
+type TokenPolicy struct {
+	// MaxAge defines the age after which API tokens must be rotated. Zero
+	// never expires tokens.
+	MaxAge time.Duration `yaml:"max_age"`
+
+	// RequireAllowlist requires API tokens to carry a CIDR allowlist, e.g.
+	// restricting publish tokens to CI egress ranges.
+	RequireAllowlist bool `yaml:"require_allowlist"`
+}
+
+// Limits defines publish quotas and size limits protecting the registry from
+// accidental or malicious flooding.
+type Limits struct {
+	ModulesPerUser  int `yaml:"modules_per_user"`
+	VersionsPerDay  int `yaml:"versions_per_day"`
+	MaxManifestSize int `yaml:"max_manifest_size"`
+	MaxReadmeSize   int `yaml:"max_readme_size"`
+	MaxKeywords     int `yaml:"max_keywords"`
+}
+
+// QuotaOverride defines an admin-granted override of the publish quotas of a
+// user. A nil limit falls back to the configured limit; zero is unlimited.
+type QuotaOverride struct {
+	UserID         int  `json:"-" yaml:"-" db:"user_id"`
+	ModulesPerUser *int `json:"modules_per_user" yaml:"modules_per_user" db:"modules_per_user"`
+	VersionsPerDay *int `json:"versions_per_day" yaml:"versions_per_day" db:"versions_per_day"`
+}
+
+// Validate returns module.ValidationErrors if a limit of the QuotaOverride is
+// negative.
+func (o QuotaOverride) Validate() error {
+	var errs module.ValidationErrors
+
+	if o.ModulesPerUser != nil && *o.ModulesPerUser < 0 {
+		errs = append(errs, module.FieldError{Field: "modules_per_user", Code: module.ErrCodeInvalidValue, Message: "must not be negative"})
+	}
+
+	if o.VersionsPerDay != nil && *o.VersionsPerDay < 0 {
+		errs = append(errs, module.FieldError{Field: "versions_per_day", Code: module.ErrCodeInvalidValue, Message: "must not be negative"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Usage defines the publishing activity of a user counted against its quotas.
+type Usage struct {
+	// Modules defines the number of modules the user authored.
+	Modules int
+
+	// VersionsToday defines the number of versions the user published over
+	// the past 24 hours.
+	VersionsToday int
+}
+
+// DefaultConfig returns the default policy configuration, reserving the core
+// Cosmos SDK module names for the cosmos user.
+func DefaultConfig() Config {
+	cosmos := []string{"cosmos"}
+
+	return Config{
+		ReservedNames: map[string][]string{
+			"x/auth":         cosmos,
+			"x/bank":         cosmos,
+			"x/capability":   cosmos,
+			"x/crisis":       cosmos,
+			"x/distribution": cosmos,
+			"x/evidence":     cosmos,
+			"x/gov":          cosmos,
+			"x/ibc":          cosmos,
+			"x/mint":         cosmos,
+			"x/params":       cosmos,
+			"x/slashing":     cosmos,
+			"x/staking":      cosmos,
+			"x/upgrade":      cosmos,
+		},
+		MinDescriptionLength: 16,
+		ReclaimCooldown:      30 * 24 * time.Hour,
+		DeletedRetention:     30 * 24 * time.Hour,
+		Limits: Limits{
+			ModulesPerUser:  100,
+			VersionsPerDay:  50,
+			MaxManifestSize: 64 << 10,
+			MaxReadmeSize:   module.MaxReadmeLength,
+			MaxKeywords:     module.MaxKeywords,
+		},
+	}
+}
+
+// LoadConfig reads a YAML policy configuration file.
+func LoadConfig(file string) (Config, error) {
+	bz, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read policy config: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(bz, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to decode policy config: %w", err)
+	}
+
+	cfg.ReservedNames = slugReservedNames(cfg.ReservedNames)
+	return cfg, nil
+}
+
+// Check enforces the publish-time policy on a Module being published by the
+// given User. The released argument, if non-nil, is the record of a previously
+// deleted module with the same name. Violations are returned as
+// module.ValidationErrors.
+func (cfg Config) Check(m module.Module, publisher module.User, released *module.ReleasedName, now time.Time) error {
+	var errs module.ValidationErrors
+
+	if allowed, ok := cfg.reservedFor(m.Name); ok && !contains(allowed, publisher.Name) {
+		errs = append(errs, module.FieldError{
+			Field:   "name",
+			Code:    ErrCodeNameReserved,
+			Message: fmt.Sprintf("module name %s is reserved", m.Name),
+		})
+	}
+
+	if len(m.Description) < cfg.MinDescriptionLength {
+		errs = append(errs, module.FieldError{
+			Field:   "description",
+			Code:    ErrCodeDescriptionTooShort,
+			Message: fmt.Sprintf("must be at least %d characters", cfg.MinDescriptionLength),
+		})
+	}
+
+	if released != nil && released.PreviousOwner != publisher.ID && now.Before(released.ReleasedAt.Add(cfg.ReclaimCooldown)) {
+		errs = append(errs, module.FieldError{
+			Field:   "name",
+			Code:    ErrCodeNameCooldown,
+			Message: fmt.Sprintf("module name %s cannot be claimed until %s", m.Name, released.ReleasedAt.Add(cfg.ReclaimCooldown).Format(time.RFC3339)),
+		})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// CheckTwoFactor enforces the two-factor policy for a sensitive operation,
+// where verified reports whether the user supplied a valid TOTP or recovery
+// code for the current request or session.
+func (cfg Config) CheckTwoFactor(u module.User, op string, verified bool) error {
+	if !cfg.RequireTwoFactor {
+		return nil
+	}
+
+	if !u.TOTPEnabled || !verified {
+		return module.ValidationErrors{{
+			Field:   "otp",
+			Code:    ErrCodeTwoFactorRequired,
+			Message: fmt.Sprintf("two-factor authentication is required to %s", strings.ReplaceAll(op, "_", " ")),
+		}}
+	}
+
+	return nil
+}
+
+// Check enforces the TokenPolicy on a request authenticated with the API token
+// of the given User from the given IP address, returning ErrTokenExpired or
+// ErrTokenIPDenied.
+func (tp TokenPolicy) Check(u module.User, ip net.IP, now time.Time) error {
+	if u.TokenExpired(tp.MaxAge, now) {
+		return fmt.Errorf("%w: created at %s, tokens must be rotated every %s", ErrTokenExpired, u.APITokenCreatedAt.Format(time.RFC3339), tp.MaxAge)
+	}
+
+	if tp.RequireAllowlist && len(u.APITokenCIDRs) == 0 {
+		return fmt.Errorf("%w: token has no IP allowlist", ErrTokenIPDenied)
+	}
+
+	if !u.TokenAllowsIP(ip) {
+		return fmt.Errorf("%w: %s", ErrTokenIPDenied, ip)
+	}
+
+	return nil
+}
+
+// CheckQuota enforces the publish quotas and size limits on a manifest of
+// manifestSize bytes being published by a user with the given usage. newModule
+// reports whether the publish registers a new module rather than a version of
+// an existing one. Violations are returned as module.ValidationErrors.
+func (cfg Config) CheckQuota(mf module.Manifest, manifestSize int, newModule bool, usage Usage, override *QuotaOverride) error {
+	var (
+		errs   module.ValidationErrors
+		limits = cfg.Limits
+	)
+
+	if override != nil {
+		if override.ModulesPerUser != nil {
+			limits.ModulesPerUser = *override.ModulesPerUser
+		}
+
+		if override.VersionsPerDay != nil {
+			limits.VersionsPerDay = *override.VersionsPerDay
+		}
+	}
+
+	if newModule && exceeds(usage.Modules+1, limits.ModulesPerUser) {
+		errs = append(errs, module.FieldError{
+			Field:   "name",
+			Code:    ErrCodeModuleQuota,
+			Message: fmt.Sprintf("cannot publish more than %d modules", limits.ModulesPerUser),
+		})
+	}
+
+	if exceeds(usage.VersionsToday+1, limits.VersionsPerDay) {
+		errs = append(errs, module.FieldError{
+			Field:   "version",
+			Code:    ErrCodeVersionQuota,
+			Message: fmt.Sprintf("cannot publish more than %d versions per day", limits.VersionsPerDay),
+		})
+	}
+
+	if exceeds(manifestSize, limits.MaxManifestSize) {
+		errs = append(errs, module.FieldError{
+			Field:   "manifest",
+			Code:    ErrCodeManifestTooLarge,
+			Message: fmt.Sprintf("must not exceed %d bytes", limits.MaxManifestSize),
+		})
+	}
+
+	if exceeds(len(mf.Readme), limits.MaxReadmeSize) {
+		errs = append(errs, module.FieldError{
+			Field:   "readme",
+			Code:    ErrCodeReadmeTooLarge,
+			Message: fmt.Sprintf("must not exceed %d bytes", limits.MaxReadmeSize),
+		})
+	}
+
+	if exceeds(len(mf.Keywords), limits.MaxKeywords) {
+		errs = append(errs, module.FieldError{
+			Field:   "keywords",
+			Code:    ErrCodeTooManyKeywords,
+			Message: fmt.Sprintf("must not contain more than %d keywords", limits.MaxKeywords),
+		})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// exceeds returns true if n exceeds a limit, where a zero limit is unlimited.
+func exceeds(n, limit int) bool {
+	return limit > 0 && n > limit
+}
+
+// reservedFor returns the users permitted to publish a reserved module name
+// and whether the name is reserved at all. Names and patterns are compared by
+// their module.Slug, i.e. as uniqueness is enforced, so that casing and
+// confusable variants of a reserved name are reserved as well.
+func (cfg Config) reservedFor(name string) ([]string, bool) {
+	slug := module.Slug(name)
+
+	if allowed, ok := cfg.ReservedNames[slug]; ok {
+		return allowed, true
+	}
+
+	for pattern, allowed := range cfg.ReservedNames {
+		if ok, err := path.Match(module.Slug(pattern), slug); err == nil && ok {
+			return allowed, true
+		}
+	}
+
+	return nil, false
+}
+
+// slugReservedNames returns the reserved names keyed by their module.Slug,
+// merging the users permitted to publish names sharing a slug.
+func slugReservedNames(names map[string][]string) map[string][]string {
+	if names == nil {
+		return nil
+	}
+
+	slugged := make(map[string][]string, len(names))
+	for name, allowed := range names {
+		slug := module.Slug(name)
+		for _, u := range allowed {
+			if !contains(slugged[slug], u) {
+				slugged[slug] = append(slugged[slug], u)
+			}
+		}
+
+		if _, ok := slugged[slug]; !ok {
+			slugged[slug] = []string{}
+		}
+	}
+
+	return slugged
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
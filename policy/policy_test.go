@@ -0,0 +1,248 @@
+package policy
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// violations returns the codes of the policy violations in err by field.
+func violations(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+
+	var verrs module.ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+
+	codes := map[string]string{}
+	for _, fe := range verrs {
+		codes[fe.Field] = fe.Code
+	}
+
+	return codes
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	cfg := DefaultConfig()
+	cfg.ReservedNames["x/ibc-*"] = []string{"cosmos"}
+
+	cosmos := module.User{ID: 1, Name: "cosmos"}
+	alice := module.User{ID: 2, Name: "alice"}
+	description := "Constant product automated market maker pools."
+
+	testCases := []struct {
+		name      string
+		module    module.Module
+		publisher module.User
+		released  *module.ReleasedName
+		field     string
+		code      string
+	}{
+		{"unreserved", module.Module{Name: "x/liquidity", Description: description}, alice, nil, "", ""},
+		{"reserved", module.Module{Name: "x/bank", Description: description}, alice, nil, "name", ErrCodeNameReserved},
+		{"reserved for publisher", module.Module{Name: "x/bank", Description: description}, cosmos, nil, "", ""},
+		{"reserved confusable", module.Module{Name: "X/Bank", Description: description}, alice, nil, "name", ErrCodeNameReserved},
+		{"reserved pattern", module.Module{Name: "x/ibc-transfer", Description: description}, alice, nil, "name", ErrCodeNameReserved},
+		{"short description", module.Module{Name: "x/liquidity", Description: "AMM"}, alice, nil, "description", ErrCodeDescriptionTooShort},
+		{
+			"cooldown", module.Module{Name: "x/liquidity", Description: description}, alice,
+			&module.ReleasedName{PreviousOwner: cosmos.ID, ReleasedAt: now.Add(-24 * time.Hour)}, "name", ErrCodeNameCooldown,
+		},
+		{
+			"cooldown previous owner", module.Module{Name: "x/liquidity", Description: description}, cosmos,
+			&module.ReleasedName{PreviousOwner: cosmos.ID, ReleasedAt: now.Add(-24 * time.Hour)}, "", "",
+		},
+		{
+			"cooldown elapsed", module.Module{Name: "x/liquidity", Description: description}, alice,
+			&module.ReleasedName{PreviousOwner: cosmos.ID, ReleasedAt: now.Add(-cfg.ReclaimCooldown - time.Hour)}, "", "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			codes := violations(t, cfg.Check(tc.module, tc.publisher, tc.released, now))
+			switch {
+			case tc.field == "" && len(codes) > 0:
+				t.Errorf("expected no violations, got %v", codes)
+
+			case tc.field != "" && codes[tc.field] != tc.code:
+				t.Errorf("expected %s on %s, got %v", tc.code, tc.field, codes)
+			}
+		})
+	}
+}
+
+func TestCheckTwoFactor(t *testing.T) {
+	enrolled := module.User{TOTPEnabled: true}
+
+	if err := (Config{}).CheckTwoFactor(module.User{}, OpPublish, false); err != nil {
+		t.Errorf("expected no two-factor requirement by default, got %v", err)
+	}
+
+	cfg := Config{RequireTwoFactor: true}
+	if err := cfg.CheckTwoFactor(enrolled, OpPublish, true); err != nil {
+		t.Errorf("expected a verified user to pass, got %v", err)
+	}
+
+	if codes := violations(t, cfg.CheckTwoFactor(enrolled, OpYank, false)); codes["otp"] != ErrCodeTwoFactorRequired {
+		t.Errorf("expected %s without a code, got %v", ErrCodeTwoFactorRequired, codes)
+	}
+
+	if codes := violations(t, cfg.CheckTwoFactor(module.User{}, OpTokenCreate, true)); codes["otp"] != ErrCodeTwoFactorRequired {
+		t.Errorf("expected %s without enrollment, got %v", ErrCodeTwoFactorRequired, codes)
+	}
+}
+
+func TestTokenPolicyCheck(t *testing.T) {
+	now := time.Now()
+	fresh := now.Add(-time.Hour)
+
+	testCases := []struct {
+		name   string
+		policy TokenPolicy
+		cidrs  []string
+		ip     string
+		err    error
+	}{
+		{"no allowlist", TokenPolicy{}, nil, "198.51.100.4", nil},
+		{"no allowlist unknown address", TokenPolicy{}, nil, "", nil},
+		{"allowlisted", TokenPolicy{}, []string{"203.0.113.0/24"}, "203.0.113.7", nil},
+		{"second range", TokenPolicy{}, []string{"203.0.113.0/24", "192.0.2.0/28"}, "192.0.2.9", nil},
+		{"outside range", TokenPolicy{}, []string{"203.0.113.0/24"}, "203.0.114.7", ErrTokenIPDenied},
+		{"outside narrow range", TokenPolicy{}, []string{"192.0.2.0/28"}, "192.0.2.16", ErrTokenIPDenied},
+		{"single address", TokenPolicy{}, []string{"203.0.113.7/32"}, "203.0.113.7", nil},
+		{"IPv6", TokenPolicy{}, []string{"2001:db8::/32"}, "2001:db8::1", nil},
+		{"IPv6 outside range", TokenPolicy{}, []string{"2001:db8::/32"}, "2001:db9::1", ErrTokenIPDenied},
+		{"IPv4-mapped IPv6", TokenPolicy{}, []string{"203.0.113.0/24"}, "::ffff:203.0.113.7", nil},
+		{"invalid range ignored", TokenPolicy{}, []string{"not-a-cidr", "203.0.113.0/24"}, "203.0.113.7", nil},
+		{"unknown address", TokenPolicy{}, []string{"203.0.113.0/24"}, "", ErrTokenIPDenied},
+		{"allowlist required", TokenPolicy{RequireAllowlist: true}, nil, "203.0.113.7", ErrTokenIPDenied},
+		{"allowlist required and set", TokenPolicy{RequireAllowlist: true}, []string{"203.0.113.0/24"}, "203.0.113.7", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u := module.User{APITokenCIDRs: tc.cidrs, APITokenCreatedAt: fresh}
+
+			err := tc.policy.Check(u, net.ParseIP(tc.ip), now)
+			if tc.err == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+
+	expiring := TokenPolicy{MaxAge: 90 * 24 * time.Hour}
+	stale := module.User{APITokenCreatedAt: now.Add(-91 * 24 * time.Hour)}
+
+	if err := expiring.Check(stale, net.ParseIP("203.0.113.7"), now); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected %v, got %v", ErrTokenExpired, err)
+	}
+
+	if err := expiring.Check(module.User{APITokenCreatedAt: fresh}, net.ParseIP("203.0.113.7"), now); err != nil {
+		t.Errorf("expected a fresh token to pass, got %v", err)
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	cfg := Config{Limits: Limits{ModulesPerUser: 2, VersionsPerDay: 3, MaxManifestSize: 1024, MaxReadmeSize: 8, MaxKeywords: 2}}
+	mf := module.Manifest{Readme: "# readme", Keywords: []string{"amm", "pools"}}
+	unlimited := 0
+	more := 5
+
+	testCases := []struct {
+		name      string
+		manifest  module.Manifest
+		size      int
+		newModule bool
+		usage     Usage
+		override  *QuotaOverride
+		field     string
+		code      string
+	}{
+		{"within limits", mf, 512, true, Usage{Modules: 1, VersionsToday: 2}, nil, "", ""},
+		{"module quota", mf, 512, true, Usage{Modules: 2}, nil, "name", ErrCodeModuleQuota},
+		{"module quota existing module", mf, 512, false, Usage{Modules: 2}, nil, "", ""},
+		{"module quota lifted", mf, 512, true, Usage{Modules: 2}, &QuotaOverride{ModulesPerUser: &unlimited}, "", ""},
+		{"version quota", mf, 512, false, Usage{VersionsToday: 3}, nil, "version", ErrCodeVersionQuota},
+		{"version quota raised", mf, 512, false, Usage{VersionsToday: 3}, &QuotaOverride{VersionsPerDay: &more}, "", ""},
+		{"manifest size", mf, 2048, false, Usage{}, nil, "manifest", ErrCodeManifestTooLarge},
+		{"readme size", module.Manifest{Readme: "# long readme"}, 512, false, Usage{}, nil, "readme", ErrCodeReadmeTooLarge},
+		{"keywords", module.Manifest{Keywords: []string{"amm", "pools", "dex"}}, 512, false, Usage{}, nil, "keywords", ErrCodeTooManyKeywords},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			codes := violations(t, cfg.CheckQuota(tc.manifest, tc.size, tc.newModule, tc.usage, tc.override))
+			switch {
+			case tc.field == "" && len(codes) > 0:
+				t.Errorf("expected no violations, got %v", codes)
+
+			case tc.field != "" && codes[tc.field] != tc.code:
+				t.Errorf("expected %s on %s, got %v", tc.code, tc.field, codes)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := ioutil.WriteFile(file, []byte(`
+reserved_names:
+  X/Bank: [cosmos]
+  x/bank: [regen]
+min_description_length: 8
+tokens:
+  require_allowlist: true
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if allowed := cfg.ReservedNames[module.Slug("x/bank")]; len(allowed) != 2 {
+		t.Errorf("expected the users of names sharing a slug to be merged, got %v", cfg.ReservedNames)
+	}
+
+	if cfg.MinDescriptionLength != 8 || !cfg.Tokens.RequireAllowlist {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("unknown_option: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadConfig(file); err == nil {
+		t.Error("expected unknown options to be rejected")
+	}
+}
+
+func TestQuotaOverrideValidate(t *testing.T) {
+	negative := -1
+
+	if err := (QuotaOverride{}).Validate(); err != nil {
+		t.Errorf("expected an empty override to be valid, got %v", err)
+	}
+
+	codes := violations(t, QuotaOverride{ModulesPerUser: &negative, VersionsPerDay: &negative}.Validate())
+	if codes["modules_per_user"] == "" || codes["versions_per_day"] == "" {
+		t.Errorf("expected negative limits to be rejected, got %v", codes)
+	}
+}
@@ -0,0 +1,252 @@
+package quality
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// MaxScore defines the score of a module satisfying every rule.
+const MaxScore = 100.0
+
+// RecentReleaseWindow defines how recently a module must have released a
+// version to fully satisfy the recent release rule. The rule decays linearly
+// to zero over a further window of the same length.
+const RecentReleaseWindow = 180 * 24 * time.Hour
+
+type (
+	// Signals defines the signals of a module evaluated by scoring rules.
+	Signals struct {
+		HasDocs        bool
+		License        string
+		LastReleaseAt  time.Time
+		Releases       int
+		SignedReleases int
+		OpenAdvisories int
+		LinkStatus     string
+	}
+
+	// Rule defines a pluggable scoring rule. Eval returns how well the signals
+	// satisfy the rule, between 0 and 1, and contributes Weight to the total
+	// weight of an Engine.
+	Rule struct {
+		Name   string
+		Weight float64
+		Eval   func(s Signals, now time.Time) float64
+	}
+
+	// RuleScore defines the result of a single rule in a Score breakdown.
+	RuleScore struct {
+		Rule   string  `json:"rule" yaml:"rule"`
+		Weight float64 `json:"weight" yaml:"weight"`
+		Value  float64 `json:"value" yaml:"value"`
+	}
+
+	// Score defines the quality score of a module, between 0 and MaxScore,
+	// and its per-rule breakdown.
+	Score struct {
+		Score      float64     `json:"score" yaml:"score"`
+		Rules      []RuleScore `json:"rules" yaml:"rules"`
+		ComputedAt time.Time   `json:"computed_at" yaml:"computed_at"`
+	}
+)
+
+// DefaultRules returns the default scoring rules of a registry.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "has_docs", Weight: 2, Eval: func(s Signals, _ time.Time) float64 {
+			return boolValue(s.HasDocs)
+		}},
+		{Name: "has_license", Weight: 2, Eval: func(s Signals, _ time.Time) float64 {
+			return boolValue(s.License != "" && module.ValidLicense(s.License))
+		}},
+		{Name: "recent_release", Weight: 2, Eval: func(s Signals, now time.Time) float64 {
+			if s.LastReleaseAt.IsZero() {
+				return 0
+			}
+
+			age := now.Sub(s.LastReleaseAt)
+			switch {
+			case age <= RecentReleaseWindow:
+				return 1
+			case age >= 2*RecentReleaseWindow:
+				return 0
+			default:
+				return 1 - float64(age-RecentReleaseWindow)/float64(RecentReleaseWindow)
+			}
+		}},
+		{Name: "advisory_free", Weight: 3, Eval: func(s Signals, _ time.Time) float64 {
+			return boolValue(s.OpenAdvisories == 0)
+		}},
+		{Name: "signed_releases", Weight: 1, Eval: func(s Signals, _ time.Time) float64 {
+			if s.Releases == 0 {
+				return 0
+			}
+
+			return float64(s.SignedReleases) / float64(s.Releases)
+		}},
+		{Name: "repo_health", Weight: 1, Eval: func(s Signals, _ time.Time) float64 {
+			return boolValue(s.LinkStatus == "" || s.LinkStatus == module.LinkStatusOK)
+		}},
+	}
+}
+
+// Engine computes quality scores from a set of rules.
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine returns an Engine evaluating the given rules, or DefaultRules if
+// none are given.
+func NewEngine(rules ...Rule) *Engine {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+
+	return &Engine{rules: rules}
+}
+
+// Score evaluates every rule against the signals, returning the weighted score
+// scaled to MaxScore and the per-rule breakdown. Rule values are clamped to
+// [0, 1].
+func (e *Engine) Score(s Signals, now time.Time) Score {
+	var total, weights float64
+
+	breakdown := make([]RuleScore, len(e.rules))
+	for i, r := range e.rules {
+		v := clamp(r.Eval(s, now))
+
+		breakdown[i] = RuleScore{Rule: r.Name, Weight: r.Weight, Value: v}
+		total += r.Weight * v
+		weights += r.Weight
+	}
+
+	score := 0.0
+	if weights > 0 {
+		score = MaxScore * total / weights
+	}
+
+	return Score{Score: score, Rules: breakdown, ComputedAt: now}
+}
+
+// Recompute loads the signals of a module, scores them and stores the result
+// on the module. It should be called on publish and by the scheduled
+// compute_scores job.
+func Recompute(ctx context.Context, db *sql.DB, e *Engine, moduleID int) (Score, error) {
+	s, err := LoadSignals(ctx, db, moduleID)
+	if err != nil {
+		return Score{}, err
+	}
+
+	score := e.Score(s, time.Now().UTC())
+
+	bz, err := json.Marshal(score.Rules)
+	if err != nil {
+		return Score{}, err
+	}
+
+	if _, err := db.ExecContext(ctx, `
+		UPDATE modules
+		SET quality_score = $1, quality_rules = $2, quality_computed_at = $3
+		WHERE id = $4`,
+		score.Score, bz, score.ComputedAt, moduleID,
+	); err != nil {
+		return Score{}, fmt.Errorf("failed to store quality score of module %d: %w", moduleID, err)
+	}
+
+	return score, nil
+}
+
+// RecomputeAll recomputes the quality score of every module that is not
+// deleted.
+func RecomputeAll(ctx context.Context, db *sql.DB, e *Engine) error {
+	rows, err := db.QueryContext(ctx, `SELECT id FROM modules WHERE deleted_at IS NULL`)
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := Recompute(ctx, db, e, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadSignals loads the scoring signals of a module. Staged and yanked
+// versions are not counted as releases.
+func LoadSignals(ctx context.Context, db *sql.DB, moduleID int) (Signals, error) {
+	var (
+		s          Signals
+		license    sql.NullString
+		linkStatus sql.NullString
+		lastAt     sql.NullTime
+	)
+
+	err := db.QueryRowContext(ctx, `
+		SELECT m.license, m.link_status,
+			(SELECT MAX(mv.created_at) FROM module_versions mv
+				WHERE mv.module_id = m.id AND mv.status = 'published' AND NOT mv.yanked),
+			(SELECT COUNT(*) FROM module_versions mv
+				WHERE mv.module_id = m.id AND mv.status = 'published' AND NOT mv.yanked),
+			(SELECT COUNT(*) FROM module_versions mv
+				WHERE mv.module_id = m.id AND mv.status = 'published' AND NOT mv.yanked AND mv.signature IS NOT NULL),
+			(SELECT COUNT(*) FROM advisories a
+				WHERE a.module_id = m.id AND NOT a.resolved),
+			EXISTS (SELECT 1 FROM module_version_docs d
+				JOIN module_versions mv ON mv.id = d.module_version_id
+				WHERE mv.module_id = m.id)
+		FROM modules m
+		WHERE m.id = $1`,
+		moduleID,
+	).Scan(&license, &linkStatus, &lastAt, &s.Releases, &s.SignedReleases, &s.OpenAdvisories, &s.HasDocs)
+	if err != nil {
+		return Signals{}, fmt.Errorf("failed to load quality signals of module %d: %w", moduleID, err)
+	}
+
+	s.License = license.String
+	s.LinkStatus = linkStatus.String
+	s.LastReleaseAt = lastAt.Time
+
+	return s, nil
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func clamp(v float64) float64 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	default:
+		return v
+	}
+}
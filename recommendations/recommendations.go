@@ -0,0 +1,116 @@
+package recommendations
+
+import "sort"
+
+// Signal weights used when scoring the relatedness of two modules. Explicit
+// dependency edges are the strongest signal, followed by shared authors and
+// shared keywords.
+const (
+	KeywordWeight    = 1.0
+	AuthorWeight     = 2.0
+	DependencyWeight = 3.0
+)
+
+type (
+	// Signals defines the signals of a module used to compute relatedness.
+	Signals struct {
+		ModuleID     int
+		Keywords     []string
+		Authors      []int
+		Dependencies []int
+	}
+
+	// Recommendation defines a related module and its relatedness score.
+	Recommendation struct {
+		ModuleID int     `json:"-" yaml:"-" db:"related_id"`
+		Score    float64 `json:"score" yaml:"score" db:"score"`
+	}
+)
+
+// Score returns the relatedness score of two modules. Shared keywords and
+// authors contribute their weight per shared entry, and a dependency edge in
+// either direction contributes its weight once.
+func Score(a, b Signals) float64 {
+	score := KeywordWeight*float64(intersectStrings(a.Keywords, b.Keywords)) +
+		AuthorWeight*float64(intersectInts(a.Authors, b.Authors))
+
+	if containsInt(a.Dependencies, b.ModuleID) || containsInt(b.Dependencies, a.ModuleID) {
+		score += DependencyWeight
+	}
+
+	return score
+}
+
+// Related returns up to limit candidates related to the target module, ordered
+// by descending score. Candidates with no shared signals are omitted.
+func Related(target Signals, candidates []Signals, limit int) []Recommendation {
+	var recs []Recommendation
+
+	for _, c := range candidates {
+		if c.ModuleID == target.ModuleID {
+			continue
+		}
+
+		if s := Score(target, c); s > 0 {
+			recs = append(recs, Recommendation{ModuleID: c.ModuleID, Score: s})
+		}
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+
+		return recs[i].ModuleID < recs[j].ModuleID
+	})
+
+	if limit > 0 && len(recs) > limit {
+		recs = recs[:limit]
+	}
+
+	return recs
+}
+
+func intersectStrings(a, b []string) int {
+	set := make(map[string]struct{}, len(a))
+	for _, s := range a {
+		set[s] = struct{}{}
+	}
+
+	n := 0
+	for _, s := range b {
+		if _, ok := set[s]; ok {
+			delete(set, s)
+			n++
+		}
+	}
+
+	return n
+}
+
+func intersectInts(a, b []int) int {
+	set := make(map[int]struct{}, len(a))
+	for _, i := range a {
+		set[i] = struct{}{}
+	}
+
+	n := 0
+	for _, i := range b {
+		if _, ok := set[i]; ok {
+			delete(set, i)
+			n++
+		}
+	}
+
+	return n
+}
+
+func containsInt(list []int, i int) bool {
+	for _, e := range list {
+		if e == i {
+			return true
+		}
+	}
+
+	return false
+}
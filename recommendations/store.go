@@ -0,0 +1,95 @@
+package recommendations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// DefaultLimit defines the number of related modules precomputed per module.
+const DefaultLimit = 10
+
+// PrecomputeAll recomputes the related modules of every module that is not
+// deleted, replacing the cached results in the module_recommendations table.
+// Visibility is not considered; readers must filter the cached results.
+func PrecomputeAll(ctx context.Context, db *sql.DB, limit int) error {
+	signals, err := LoadSignals(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM module_recommendations`); err != nil {
+		return err
+	}
+
+	for _, target := range signals {
+		for _, rec := range Related(target, signals, limit) {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO module_recommendations (module_id, related_id, score)
+				VALUES ($1, $2, $3)`,
+				target.ModuleID, rec.ModuleID, rec.Score,
+			); err != nil {
+				return fmt.Errorf("failed to store recommendations of module %d: %w", target.ModuleID, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// LoadSignals loads the relatedness signals of every module that is not
+// deleted: its keywords, its author and contributors, and the modules it
+// depends on.
+func LoadSignals(ctx context.Context, db *sql.DB) ([]Signals, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT m.id,
+			ARRAY(SELECT k.name FROM modules_keywords mk
+				JOIN keywords k ON k.id = mk.keyword_id
+				WHERE mk.module_id = m.id),
+			ARRAY(SELECT m.author WHERE m.author IS NOT NULL
+				UNION SELECT mu.user_id FROM modules_users mu WHERE mu.module_id = m.id),
+			ARRAY(SELECT md.dependency_id FROM module_dependencies md WHERE md.module_id = m.id)
+		FROM modules m
+		WHERE m.deleted_at IS NULL
+		ORDER BY m.id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var signals []Signals
+	for rows.Next() {
+		var (
+			s                     Signals
+			authors, dependencies pq.Int64Array
+		)
+
+		if err := rows.Scan(&s.ModuleID, pq.Array(&s.Keywords), &authors, &dependencies); err != nil {
+			return nil, err
+		}
+
+		s.Authors = ints(authors)
+		s.Dependencies = ints(dependencies)
+		signals = append(signals, s)
+	}
+
+	return signals, rows.Err()
+}
+
+func ints(a pq.Int64Array) []int {
+	out := make([]int, len(a))
+	for i, v := range a {
+		out[i] = int(v)
+	}
+
+	return out
+}
@@ -0,0 +1,392 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	registryv1 "github.com/cosmos/atlas/api/registry/v1"
+	"github.com/cosmos/atlas/hmacauth"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Publisher defines the registry's publish pipeline, validating, storing and
+// recording a module version on behalf of an authenticated user. Publishers
+// must reject versions of archived modules (see module.Module.CheckWritable).
+type Publisher interface {
+	Publish(ctx context.Context, u module.User, m module.Manifest, artifact []byte, checksum string) (module.ModuleVersion, error)
+}
+
+// Service implements the gRPC RegistryService over the registry database,
+// delegating publishes to the registry's Publisher. Requests authenticate with
+// an API token sent as "authorization: Bearer <token>" metadata, subject to
+// the token policy; anonymous requests only read public modules.
+type Service struct {
+	registryv1.UnimplementedRegistryServiceServer
+
+	db        *sql.DB
+	verifier  *hmacauth.Verifier
+	publisher Publisher
+}
+
+// NewService returns a Service. It is registered on a gRPC server with
+// registryv1.RegisterRegistryServiceServer.
+func NewService(db *sql.DB, tokens policy.TokenPolicy, publisher Publisher) *Service {
+	return &Service{db: db, verifier: hmacauth.NewVerifier(db, tokens), publisher: publisher}
+}
+
+// SearchModules searches the modules readable by the caller whose name,
+// description or keywords match the query, ranked by quality score with
+// deprecated modules last.
+func (s *Service) SearchModules(ctx context.Context, req *registryv1.SearchModulesRequest) (*registryv1.SearchModulesResponse, error) {
+	u, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	size := int(req.PageSize)
+	switch {
+	case size == 0:
+		size = defaultPageSize
+
+	case size < 0 || size > maxPageSize:
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", maxPageSize)
+	}
+
+	var offset int
+	if req.PageToken != "" {
+		if offset, err = strconv.Atoi(req.PageToken); err != nil || offset < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+
+	pattern := "%" + escapeLike(req.Query) + "%"
+	modules, err := s.queryModules(ctx, `
+		WHERE module_readable(m.id, $1)
+			AND NOT m.hidden
+			AND m.deleted_at IS NULL
+			AND (
+				m.name ILIKE $2
+				OR m.description ILIKE $2
+				OR EXISTS (
+					SELECT 1 FROM modules_keywords mk
+					JOIN keywords k ON k.id = mk.keyword_id
+					WHERE mk.module_id = m.id AND k.name ILIKE $2
+				)
+			)
+		ORDER BY m.deprecated_at IS NOT NULL, m.quality_score DESC, m.name
+		LIMIT $3 OFFSET $4`,
+		userID(u), pattern, size+1, offset,
+	)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &registryv1.SearchModulesResponse{Modules: modules}
+	if len(modules) > size {
+		resp.Modules = modules[:size]
+		resp.NextPageToken = strconv.Itoa(offset + size)
+	}
+
+	return resp, nil
+}
+
+// GetModule returns a module readable by the caller by name.
+func (s *Service) GetModule(ctx context.Context, req *registryv1.GetModuleRequest) (*registryv1.GetModuleResponse, error) {
+	u, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	m, err := s.getModule(ctx, u, req.Name)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &registryv1.GetModuleResponse{Module: m}, nil
+}
+
+// ListModuleVersions lists the published versions of a module readable by
+// the caller in publish order, excluding yanked versions unless requested.
+func (s *Service) ListModuleVersions(ctx context.Context, req *registryv1.ListModuleVersionsRequest) (*registryv1.ListModuleVersionsResponse, error) {
+	u, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if _, err := s.getModule(ctx, u, req.Name); err != nil {
+		return nil, toStatus(err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT mv.version, mv.checksum, mv.artifact_size, mv.downloads, mv.yanked, mv.verified, COALESCE(mv.sdk_compat, ''), mv.created_at
+		FROM module_versions mv
+		JOIN modules m ON m.id = mv.module_id
+		WHERE m.name = $1
+			AND mv.status = 'published'
+			AND ($2 OR NOT mv.yanked)
+		ORDER BY mv.created_at, mv.id`,
+		req.Name, req.IncludeYanked,
+	)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	defer rows.Close()
+
+	resp := &registryv1.ListModuleVersionsResponse{Versions: []*registryv1.ModuleVersion{}}
+	for rows.Next() {
+		var mv module.ModuleVersion
+		if err := rows.Scan(&mv.Version, &mv.Checksum, &mv.ArtifactSize, &mv.Downloads, &mv.Yanked, &mv.Verified, &mv.SDKCompat, &mv.CreatedAt); err != nil {
+			return nil, toStatus(err)
+		}
+
+		pb, err := versionProto(mv)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+
+		resp.Versions = append(resp.Versions, pb)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return resp, nil
+}
+
+// PublishModule publishes a module version through the Publisher. It requires
+// an authenticated caller and rejects versions of archived modules.
+func (s *Service) PublishModule(ctx context.Context, req *registryv1.PublishModuleRequest) (*registryv1.PublishModuleResponse, error) {
+	u, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if u == nil {
+		return nil, status.Error(codes.Unauthenticated, "publishing requires an API token")
+	}
+
+	switch req.ManifestFormat {
+	case "", module.FormatTOML, module.FormatYAML, module.FormatJSON:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "manifest_format must be one of: toml, yaml, json")
+	}
+
+	manifest, err := module.ParseManifest(req.Manifest, req.ManifestFormat)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid manifest: %v", err)
+	}
+
+	m := module.Module{Name: manifest.Name}
+	err = s.db.QueryRowContext(ctx, `
+		SELECT archived
+		FROM modules
+		WHERE slug = $1
+			AND deleted_at IS NULL`,
+		module.Slug(manifest.Name),
+	).Scan(&m.Archived)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, toStatus(err)
+	}
+
+	if err := m.CheckWritable(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	mv, err := s.publisher.Publish(ctx, *u, manifest, req.Artifact, req.Checksum)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	pb, err := versionProto(mv)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &registryv1.PublishModuleResponse{Version: pb}, nil
+}
+
+// authenticate returns the user of the API token sent in the request
+// metadata, or nil for anonymous requests.
+func (s *Service) authenticate(ctx context.Context) (*module.User, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	token := strings.TrimPrefix(values[0], "Bearer ")
+	if token == values[0] || token == "" {
+		return nil, fmt.Errorf("%w: authorization must be a bearer API token", hmacauth.ErrUnauthenticated)
+	}
+
+	u, err := s.verifier.VerifyToken(ctx, token, peerIP(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// getModule returns a module by name if it is readable by the user.
+func (s *Service) getModule(ctx context.Context, u *module.User, name string) (*registryv1.Module, error) {
+	modules, err := s.queryModules(ctx, `
+		WHERE m.name = $2
+			AND module_readable(m.id, $1)
+			AND NOT m.hidden
+			AND m.deleted_at IS NULL`,
+		userID(u), name,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(modules) == 0 {
+		return nil, status.Errorf(codes.NotFound, "module %s not found", name)
+	}
+
+	return modules[0], nil
+}
+
+// queryModules selects the modules matching the given WHERE clause along with
+// their keywords and replacement, if deprecated.
+func (s *Service) queryModules(ctx context.Context, where string, args ...interface{}) ([]*registryv1.Module, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.name, COALESCE(m.description, ''), COALESCE(m.version, ''), COALESCE(m.homepage, ''), COALESCE(m.repo, ''),
+			COALESCE(m.license, ''), m.quality_score, verified_publisher(m.author),
+			m.deprecated_at IS NOT NULL, COALESCE(r.name, ''), m.archived,
+			ARRAY(
+				SELECT k.name FROM modules_keywords mk
+				JOIN keywords k ON k.id = mk.keyword_id
+				WHERE mk.module_id = m.id
+				ORDER BY k.name
+			)
+		FROM modules m
+		LEFT JOIN modules r ON r.id = m.replaced_by AND r.deleted_at IS NULL
+		`+where,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var modules []*registryv1.Module
+	for rows.Next() {
+		m := &registryv1.Module{}
+		if err := rows.Scan(
+			&m.Name, &m.Description, &m.Version, &m.Homepage, &m.Repo,
+			&m.License, &m.QualityScore, &m.VerifiedPublisher,
+			&m.Deprecated, &m.ReplacedBy, &m.Archived, pq.Array(&m.Keywords),
+		); err != nil {
+			return nil, err
+		}
+
+		modules = append(modules, m)
+	}
+
+	return modules, rows.Err()
+}
+
+// versionProto converts a ModuleVersion to its protobuf message.
+func versionProto(mv module.ModuleVersion) (*registryv1.ModuleVersion, error) {
+	createdAt, err := ptypes.TimestampProto(mv.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &registryv1.ModuleVersion{
+		Version:      mv.Version,
+		Checksum:     mv.Checksum,
+		ArtifactSize: mv.ArtifactSize,
+		Downloads:    mv.Downloads,
+		Yanked:       mv.Yanked,
+		Verified:     mv.Verified,
+		SdkCompat:    mv.SDKCompat,
+		CreatedAt:    createdAt,
+	}, nil
+}
+
+// toStatus maps an error returned by the model, policy or database layers to
+// its gRPC status, mirroring the error codes of the HTTP API.
+func toStatus(err error) error {
+	var valErr module.ValidationErrors
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.As(err, &valErr):
+		return status.Error(codes.InvalidArgument, valErr.Error())
+
+	case errors.Is(err, hmacauth.ErrUnauthenticated), errors.Is(err, policy.ErrTokenExpired):
+		return status.Error(codes.Unauthenticated, err.Error())
+
+	case errors.Is(err, policy.ErrTokenIPDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+
+	case errors.Is(err, module.ErrVersionConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+
+	case errors.Is(err, module.ErrStaleModule):
+		return status.Error(codes.Aborted, err.Error())
+
+	case errors.Is(err, module.ErrModuleArchived):
+		return status.Error(codes.FailedPrecondition, err.Error())
+
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "resource not found")
+
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// userID returns the ID of an authenticated user, or nil for anonymous
+// requests.
+func userID(u *module.User) interface{} {
+	if u == nil {
+		return nil
+	}
+
+	return u.ID
+}
+
+// peerIP returns the IP address of the gRPC peer, or nil if it is unknown.
+func peerIP(ctx context.Context) net.IP {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if addr, ok := p.Addr.(*net.TCPAddr); ok {
+		return addr.IP
+	}
+
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcards of a search query.
+func escapeLike(q string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q)
+}
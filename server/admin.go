@@ -0,0 +1,451 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/jobs"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+)
+
+const (
+	adminPathPrefix        = "/api/v1/admin/"
+	adminModePath          = adminPathPrefix + "mode"
+	adminMergeUsersPath    = adminPathPrefix + "users/merge"
+	adminMergeKeywordsPath = adminPathPrefix + "keywords/merge"
+	adminTransferPath      = adminPathPrefix + "modules/transfer"
+	adminUsersPathPrefix   = adminPathPrefix + "users/"
+	adminJobsPathPrefix    = adminPathPrefix + "jobs/"
+	adminDeadJobsPath      = adminJobsPathPrefix + "dead"
+
+	defaultDeadJobsLimit = 50
+)
+
+type (
+	// ModeRequest defines the request and response of the operating mode
+	// endpoint.
+	ModeRequest struct {
+		Mode string `json:"mode"`
+	}
+
+	// MergeUsersRequest defines the request of the user merge endpoint, naming
+	// the duplicate user merged into the surviving one.
+	MergeUsersRequest struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+
+	// MergeKeywordsRequest defines the request of the keyword merge endpoint,
+	// naming the keyword merged into the canonical one.
+	MergeKeywordsRequest struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+
+	// TransferRequest defines the request of the ownership transfer endpoint,
+	// naming the module and the user it is transferred to.
+	TransferRequest struct {
+		Module string `json:"module"`
+		To     string `json:"to"`
+	}
+)
+
+// moderator returns the requester, who must be permitted to moderate the
+// registry in order to perform the given action.
+func (s *Server) moderator(r *http.Request, action string) (*module.User, error) {
+	u, err := s.requester(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if u == nil {
+		return nil, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required")
+	}
+
+	if !u.CanModerate() {
+		return nil, NewError(http.StatusForbidden, CodeForbidden, "only administrators may "+action)
+	}
+
+	return u, nil
+}
+
+// serveMode serves the operating mode endpoint, exempt from maintenance so
+// that administrators can switch the registry back to normal:
+//
+//	GET /api/v1/admin/mode returns the current operating mode
+//	PUT /api/v1/admin/mode switches the operating mode
+//
+// Both require a requester permitted to moderate the registry.
+func (s *Server) serveMode(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.moderator(r, "switch the operating mode"); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+
+	case http.MethodPut:
+		var req ModeRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+			return
+		}
+
+		if err := s.maintenance.Set(req.Mode); err != nil {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, err.Error()))
+			return
+		}
+
+	default:
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(ModeRequest{Mode: s.maintenance.Mode()}) // nolint: errcheck
+}
+
+// serveMergeUsers serves POST /api/v1/admin/users/merge, merging a duplicate
+// user, e.g. one imported by name from a manifest, into the surviving user.
+// Every association of the duplicate, from authored modules and grants to
+// reviews, subscriptions and sessions, is reassigned by merge_users in the
+// request's transaction before the duplicate is deleted. It requires a
+// requester permitted to moderate the registry.
+func (s *Server) serveMergeUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	if _, err := s.moderator(r, "merge users"); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	var req MergeUsersRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	if req.Source == req.Target {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "cannot merge a user into itself"))
+		return
+	}
+
+	q := db.Conn(r.Context(), s.primary)
+
+	ids := make([]int, 2)
+	for i, name := range []string{req.Source, req.Target} {
+		err := q.QueryRowContext(r.Context(), `SELECT id FROM users WHERE name = $1`, name).Scan(&ids[i])
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, fmt.Sprintf("user %s not found", name)))
+			return
+
+		case err != nil:
+			WriteError(w, err)
+			return
+		}
+	}
+
+	if _, err := q.ExecContext(r.Context(), `SELECT merge_users($1, $2)`, ids[0], ids[1]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveMergeKeywords serves POST /api/v1/admin/keywords/merge, merging a
+// synonym keyword into the canonical one: module associations are rewritten
+// by merge_keywords in the request's transaction, and the synonym along with
+// its aliases become aliases of the canonical keyword, so that later writes
+// resolve to it. Keyword names are normalized. It requires a requester
+// permitted to moderate the registry.
+func (s *Server) serveMergeKeywords(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	if _, err := s.moderator(r, "merge keywords"); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	var req MergeKeywordsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	source, target := module.NormalizeKeyword(req.Source), module.NormalizeKeyword(req.Target)
+	if source == target {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "cannot merge a keyword into itself"))
+		return
+	}
+
+	q := db.Conn(r.Context(), s.primary)
+
+	ids := make([]int, 2)
+	for i, name := range []string{source, target} {
+		err := q.QueryRowContext(r.Context(), `SELECT id FROM keywords WHERE name = $1`, name).Scan(&ids[i])
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, fmt.Sprintf("keyword %s not found", name)))
+			return
+
+		case err != nil:
+			WriteError(w, err)
+			return
+		}
+	}
+
+	if _, err := q.ExecContext(r.Context(), `SELECT merge_keywords($1, $2)`, ids[0], ids[1]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveTransfer serves POST /api/v1/admin/modules/transfer, forcing the
+// transfer of a module's ownership to another user, e.g. when its author has
+// abandoned it. The new author is no longer listed as a contributor. It
+// requires a requester permitted to moderate the registry.
+func (s *Server) serveTransfer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	u, err := s.moderator(r, "transfer modules")
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	var req TransferRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	var to module.User
+	err = q.QueryRowContext(ctx, `SELECT id, name, banned FROM users WHERE name = $1`, req.To).Scan(&to.ID, &to.Name, &to.Banned)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, fmt.Sprintf("user %s not found", req.To)))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	m, found, err := lockPublishedModule(ctx, q, u, req.Module)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !found {
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found"))
+		return
+	}
+
+	if err := m.TransferOwnership(to); err != nil {
+		WriteError(w, NewError(http.StatusUnprocessableEntity, CodeValidationFailed, err.Error()))
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE modules
+		SET author = $2, lock_version = lock_version + 1
+		WHERE id = $1`,
+		m.ID, m.Author,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `DELETE FROM modules_users WHERE module_id = $1 AND user_id = $2`, m.ID, m.Author); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveJobs serves the dead-letter endpoints of the job queue:
+//
+//	GET /api/v1/admin/jobs/dead?limit=<n> lists the most recent dead jobs
+//	POST /api/v1/admin/jobs/{id}/retry moves a dead job back to the queue
+//
+// Both require a requester permitted to moderate the registry.
+func (s *Server) serveJobs(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.moderator(r, "manage jobs"); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if r.URL.Path == adminDeadJobsPath {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+			return
+		}
+
+		limit, err := parseQueryInt(r, "limit", defaultDeadJobsLimit)
+		if err != nil || limit < 1 || limit > maxModulesLimit {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "limit must be between 1 and 100"))
+			return
+		}
+
+		dead, err := s.queue.Dead(r.Context(), int(limit))
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if dead == nil {
+			dead = []jobs.Job{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(dead) // nolint: errcheck
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, adminJobsPathPrefix)
+	id, err := strconv.ParseInt(strings.TrimSuffix(rest, "/retry"), 10, 64)
+	if err != nil || !strings.HasSuffix(rest, "/retry") {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	err = s.queue.Retry(r.Context(), id)
+	switch {
+	case errors.Is(err, jobs.ErrNoDeadJob):
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, err.Error()))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveQuota serves the publish quota overrides of users:
+//
+//	GET /api/v1/admin/users/{name}/quota returns the user's override
+//	PUT /api/v1/admin/users/{name}/quota replaces the user's override
+//	DELETE /api/v1/admin/users/{name}/quota restores the configured limits
+//
+// A null limit of an override falls back to the configured limit and zero
+// lifts the limit. All require a requester permitted to moderate the registry.
+func (s *Server) serveQuota(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, adminUsersPathPrefix)
+	if !strings.HasSuffix(name, "/quota") || strings.Contains(strings.TrimSuffix(name, "/quota"), "/") {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+		return
+	}
+
+	name = strings.TrimSuffix(name, "/quota")
+
+	if _, err := s.moderator(r, "override quotas"); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	var userID int
+	err := q.QueryRowContext(ctx, `SELECT id FROM users WHERE name = $1`, name).Scan(&userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, fmt.Sprintf("user %s not found", name)))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		_, override, err := queryUsage(ctx, q, userID)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if override == nil {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "no quota override of user "+name))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(override) // nolint: errcheck
+
+	case http.MethodPut:
+		override := policy.QuotaOverride{UserID: userID}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&override); err != nil {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+			return
+		}
+
+		if err := override.Validate(); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if _, err := q.ExecContext(ctx, `
+			INSERT INTO quota_overrides (user_id, modules_per_user, versions_per_day)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO UPDATE
+			SET modules_per_user = EXCLUDED.modules_per_user,
+				versions_per_day = EXCLUDED.versions_per_day,
+				updated_at = NOW()`,
+			userID, override.ModulesPerUser, override.VersionsPerDay,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(override) // nolint: errcheck
+
+	case http.MethodDelete:
+		if _, err := q.ExecContext(ctx, `DELETE FROM quota_overrides WHERE user_id = $1`, userID); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+	}
+}
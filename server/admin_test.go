@@ -0,0 +1,175 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/cosmos/atlas/client"
+	"github.com/cosmos/atlas/server"
+)
+
+// duplicateFixtures gives the duplicate user carol-dup a row in every table
+// referencing users, without conflicting with carol's own rows.
+const duplicateFixtures = `
+INSERT INTO users (name, email, github_access_token, api_token)
+VALUES ('carol-dup', 'carol@users.example.com', '', 'carol-dup-token');
+INSERT INTO modules (name, slug, description, version, homepage, repo, visibility, author)
+SELECT 'escrow', 'escrow', 'Escrow of tokens pending conditions.', '0.1.0', 'https://escrow.example.com',
+	'https://github.com/example/escrow', 'public', id
+FROM users WHERE name = 'carol-dup';
+INSERT INTO module_versions (module_id, version, checksum, artifact_size, downloads, yanked, published_by)
+SELECT m.id, '0.1.0', 'escrow-checksum', 0, 0, FALSE, m.author
+FROM modules m WHERE m.name = 'escrow';
+INSERT INTO modules_users (module_id, user_id)
+SELECT m.id, u.id FROM modules m, users u WHERE m.name = 'oracle' AND u.name = 'carol-dup';
+INSERT INTO module_grants (module_id, user_id)
+SELECT m.id, u.id FROM modules m, users u WHERE m.name = 'oracle' AND u.name = 'carol-dup';
+INSERT INTO module_subscriptions (module_id, user_id)
+SELECT m.id, u.id FROM modules m, users u WHERE m.name = 'liquidity' AND u.name = 'carol-dup';
+INSERT INTO public_keys (user_id, kind, key)
+SELECT id, 'pgp', 'carol-dup-key' FROM users WHERE name = 'carol-dup';
+INSERT INTO publisher_verifications (user_id, kind, subject, token, status)
+SELECT id, 'domain', 'carol.example.com', 'carol-dup-challenge', 'pending' FROM users WHERE name = 'carol-dup';
+INSERT INTO publisher_verifications (user_id, kind, subject, token, status, reviewer_id)
+SELECT a.id, 'domain', 'alice.example.com', 'alice-challenge', 'rejected', d.id
+FROM users a, users d WHERE a.name = 'alice' AND d.name = 'carol-dup';
+INSERT INTO reviews (module_id, user_id, rating)
+SELECT m.id, u.id, 4 FROM modules m, users u WHERE m.name = 'liquidity' AND u.name = 'carol-dup';
+INSERT INTO reviews (module_id, user_id, rating, response, responder_id, responded_at)
+SELECT m.id, b.id, 5, 'Thanks!', d.id, NOW()
+FROM modules m, users b, users d WHERE m.name = 'nft' AND b.name = 'bob' AND d.name = 'carol-dup';
+INSERT INTO questions (module_id, user_id, title, body)
+SELECT m.id, u.id, 'Fees?', 'How are swap fees set?' FROM modules m, users u WHERE m.name = 'liquidity' AND u.name = 'carol-dup';
+INSERT INTO answers (question_id, user_id, body)
+SELECT q.id, u.id, 'By governance.' FROM questions q, users u WHERE q.title = 'Fees?' AND u.name = 'alice';
+INSERT INTO answers (question_id, user_id, body)
+SELECT q.id, u.id, 'Thanks.' FROM questions q, users u WHERE q.title = 'Fees?' AND u.name = 'carol-dup';
+INSERT INTO reports (module_id, reporter_id, reason, status)
+SELECT m.id, u.id, 'spam', 'open' FROM modules m, users u WHERE m.name = 'oracle' AND u.name = 'carol-dup';
+INSERT INTO report_comments (report_id, user_id, body)
+SELECT r.id, r.reporter_id, 'See the readme.' FROM reports r WHERE r.reason = 'spam';
+INSERT INTO report_events (report_id, actor_id, from_status, to_status)
+SELECT r.id, r.reporter_id, 'open', 'dismissed' FROM reports r WHERE r.reason = 'spam';
+INSERT INTO released_names (name, previous_owner)
+SELECT 'old-escrow', id FROM users WHERE name = 'carol-dup';
+INSERT INTO module_sets (public_id, owner_id, name)
+SELECT 'carol-favorites', id, 'favorites' FROM users WHERE name = 'carol';
+INSERT INTO module_sets (public_id, owner_id, name)
+SELECT 'dup-favorites', id, 'favorites' FROM users WHERE name = 'carol-dup';
+INSERT INTO sessions (user_id, token_hash)
+SELECT id, 'carol-dup-session' FROM users WHERE name = 'carol-dup';
+INSERT INTO recovery_codes (user_id, hash)
+SELECT id, 'carol-dup-recovery' FROM users WHERE name = 'carol-dup';
+INSERT INTO quota_overrides (user_id, modules_per_user)
+SELECT id, 50 FROM users WHERE name = 'carol-dup';
+`
+
+func TestMergeUsers(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+
+	if _, err := h.DB.ExecContext(ctx, duplicateFixtures); err != nil {
+		t.Fatal(err)
+	}
+
+	// every column referencing users, so that tables added later are covered
+	rows, err := h.DB.QueryContext(ctx, `
+		SELECT cl.relname, att.attname
+		FROM pg_constraint con
+		JOIN pg_class cl ON cl.oid = con.conrelid
+		JOIN pg_attribute att ON att.attrelid = con.conrelid AND att.attnum = ANY (con.conkey)
+		WHERE con.contype = 'f'
+			AND con.confrelid = 'users'::regclass`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var columns [][2]string
+	for rows.Next() {
+		var c [2]string
+		if err := rows.Scan(&c[0], &c[1]); err != nil {
+			t.Fatal(err)
+		}
+
+		columns = append(columns, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	var src, dst int
+	if err := h.DB.QueryRowContext(ctx, `SELECT id FROM users WHERE name = 'carol-dup'`).Scan(&src); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.DB.QueryRowContext(ctx, `SELECT id FROM users WHERE name = 'carol'`).Scan(&dst); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(c [2]string, userID int) int {
+		var n int
+		if err := h.DB.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s = $1`,
+			pq.QuoteIdentifier(c[0]), pq.QuoteIdentifier(c[1])), userID,
+		).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+
+		return n
+	}
+
+	want := make(map[[2]string]int, len(columns))
+	for _, c := range columns {
+		n := count(c, src)
+		if n == 0 {
+			t.Fatalf("duplicate fixtures have no row in %s.%s", c[0], c[1])
+		}
+
+		want[c] = count(c, dst) + n
+	}
+
+	admin := h.Client(client.WithToken(token(t, f, "alice")))
+	if err := h.Client(client.WithToken(token(t, f, "bob"))).MergeUsers(ctx, "carol-dup", "carol"); !client.HasCode(err, server.CodeForbidden) {
+		t.Fatalf("expected %s for non-admins, got %v", server.CodeForbidden, err)
+	}
+
+	if err := admin.MergeUsers(ctx, "carol", "carol"); !client.HasCode(err, server.CodeBadRequest) {
+		t.Fatalf("expected %s merging a user into itself, got %v", server.CodeBadRequest, err)
+	}
+
+	if err := admin.MergeUsers(ctx, "nobody", "carol"); !client.HasCode(err, server.CodeNotFound) {
+		t.Fatalf("expected %s for unknown users, got %v", server.CodeNotFound, err)
+	}
+
+	if err := admin.MergeUsers(ctx, "carol-dup", "carol"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range columns {
+		if got := count(c, dst); got != want[c] {
+			t.Errorf("expected %d rows of %s.%s reassigned to the surviving user, got %d", want[c], c[0], c[1], got)
+		}
+	}
+
+	var exists bool
+	if err := h.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, src).Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists {
+		t.Error("expected the duplicate user to be deleted")
+	}
+
+	var name string
+	if err := h.DB.QueryRowContext(ctx, `SELECT name FROM module_sets WHERE public_id = 'dup-favorites'`).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "favorites (dup-favorites)" {
+		t.Errorf("expected the clashing set to be renamed, got %q", name)
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+// ModuleAdvisories serves GET /api/v1/modules/{id}/advisories, listing the
+// security advisories published against the module, including resolved ones,
+// oldest first. The caller must have resolved the module and checked that it
+// is readable by the requester.
+func ModuleAdvisories(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int) {
+	advisories, err := queryAdvisories(r.Context(), sqlDB, moduleID, false)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if advisories == nil {
+		advisories = []module.Advisory{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(advisories) // nolint: errcheck
+}
+
+// CreateAdvisory serves POST /api/v1/modules/{id}/advisories, publishing a
+// security advisory against a range of the module's versions. Identifiers are
+// unique per module. Only module owners may publish advisories.
+func (s *Server) CreateAdvisory(w http.ResponseWriter, r *http.Request, m moduleAccess) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may publish advisories"))
+		return
+	}
+
+	var a module.Advisory
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&a); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	a.ModuleID = m.ID
+	a.Resolved = false
+	if err := a.Validate(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	err := db.Conn(r.Context(), s.primary).QueryRowContext(r.Context(), `
+		INSERT INTO advisories (module_id, identifier, affected_versions, severity, description)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (module_id, identifier) DO NOTHING
+		RETURNING id, created_at`,
+		a.ModuleID, a.Identifier, a.AffectedVersions, a.Severity, a.Description,
+	).Scan(&a.ID, &a.CreatedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, module.ValidationErrors{{
+			Field:   "identifier",
+			Code:    module.ErrCodeInvalidValue,
+			Message: "an advisory with this identifier was already published",
+		}})
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a) // nolint: errcheck
+}
+
+// serveResolveAdvisory serves PUT
+// /api/v1/modules/{id}/advisories/{identifier}/resolve, marking an advisory
+// resolved so that it no longer warns about the versions it affects, and
+// DELETE to reopen it. Only module owners may resolve advisories.
+func (s *Server) serveResolveAdvisory(w http.ResponseWriter, r *http.Request, m moduleAccess, identifier string) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may resolve advisories"))
+		return
+	}
+
+	res, err := db.Conn(r.Context(), s.primary).ExecContext(r.Context(), `
+		UPDATE advisories
+		SET resolved = $3
+		WHERE module_id = $1
+			AND identifier = $2`,
+		m.ID, identifier, r.Method == http.MethodPut,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "advisory not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// queryAdvisories returns the advisories published against a module, oldest
+// first, optionally only the open ones.
+func queryAdvisories(ctx context.Context, q db.Querier, moduleID int, open bool) ([]module.Advisory, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT identifier, affected_versions, severity, description, resolved, created_at
+		FROM advisories
+		WHERE module_id = $1
+			AND NOT ($2 AND resolved)
+		ORDER BY created_at, id`,
+		moduleID, open,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var advisories []module.Advisory
+	for rows.Next() {
+		var a module.Advisory
+		if err := rows.Scan(&a.Identifier, &a.AffectedVersions, &a.Severity, &a.Description, &a.Resolved, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		advisories = append(advisories, a)
+	}
+
+	return advisories, rows.Err()
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/cosmos/atlas/db"
+)
+
+// serveArchive serves PUT /api/v1/modules/{id}/archive, archiving a module so
+// that it becomes read-only while its versions remain resolvable, and DELETE
+// to unarchive it. Only module owners may archive a module.
+func (s *Server) serveArchive(w http.ResponseWriter, r *http.Request, m moduleAccess) {
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !u.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may archive a module"))
+		return
+	}
+
+	if _, err := db.Conn(r.Context(), s.primary).ExecContext(r.Context(), `
+		UPDATE modules
+		SET archived = $2, lock_version = lock_version + 1
+		WHERE id = $1
+			AND archived <> $2`,
+		m.ID, r.Method == http.MethodPut,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
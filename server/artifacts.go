@@ -0,0 +1,386 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/scan"
+	"github.com/cosmos/atlas/storage"
+)
+
+const (
+	// HeaderChecksum carries the hex-encoded SHA-256 checksum of an uploaded
+	// or downloaded artifact.
+	HeaderChecksum = "X-Atlas-Checksum"
+
+	// maxArtifactSize bounds the size of an uploaded source tarball.
+	maxArtifactSize = 64 << 20
+
+	adminQuarantinePath = adminPathPrefix + "versions/quarantined"
+	adminClearPath      = adminPathPrefix + "versions/clear"
+)
+
+type (
+	// QuarantinedVersion defines a version quarantined by blocking artifact
+	// scan findings.
+	QuarantinedVersion struct {
+		Module  string `json:"module"`
+		Version string `json:"version"`
+		Reason  string `json:"reason"`
+	}
+
+	// ClearRequest defines the request of the quarantine clearing endpoint,
+	// naming the module and its quarantined version.
+	ClearRequest struct {
+		Module  string `json:"module"`
+		Version string `json:"version"`
+	}
+)
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// UploadArtifact serves PUT /api/v1/modules/{id}/versions/{version}/artifact,
+// storing the request body as the source tarball of a published version. The
+// body must match the SHA-256 checksum carried in HeaderChecksum, as well as
+// the checksum the version was published with, if any. Artifacts are
+// immutable: uploading the same contents again is a no-op, whereas different
+// contents are rejected with CodeVersionConflict. Uploaded artifacts are
+// scanned, and versions with blocking findings are quarantined until an
+// administrator clears them. Only module owners may upload artifacts, and not
+// while a removal request disputes the module.
+func (s *Server) UploadArtifact(w http.ResponseWriter, r *http.Request, m moduleAccess, version string) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may upload artifacts"))
+		return
+	}
+
+	checksum := strings.ToLower(r.Header.Get(HeaderChecksum))
+	if checksum == "" {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, HeaderChecksum+" header is required"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	existing, found, err := lockModule(ctx, q, m.User, "m.id = $1", m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !found {
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found"))
+		return
+	}
+
+	if err := existing.CheckWritable(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if existing.Disputed {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "artifacts of disputed modules cannot be uploaded"))
+		return
+	}
+
+	var mv module.ModuleVersion
+	err = q.QueryRowContext(ctx, `
+		SELECT id, version, COALESCE(checksum, ''), COALESCE(artifact_key, ''), artifact_size, status
+		FROM module_versions
+		WHERE module_id = $1
+			AND version = $2
+		FOR UPDATE`,
+		m.ID, version,
+	).Scan(&mv.ID, &mv.Version, &mv.Checksum, &mv.ArtifactKey, &mv.ArtifactSize, &mv.Status)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	if mv.HasArtifact() {
+		if mv.Checksum != checksum {
+			WriteError(w, NewError(http.StatusConflict, CodeVersionConflict, "an artifact was already uploaded for "+version))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mv) // nolint: errcheck
+		return
+	}
+
+	if mv.Checksum != "" && mv.Checksum != checksum {
+		WriteError(w, fmt.Errorf("%w: %s was published with checksum %s", storage.ErrChecksumMismatch, version, mv.Checksum))
+		return
+	}
+
+	key := storage.ArtifactKey(existing.Name, version)
+	body := &countingReader{r: http.MaxBytesReader(w, r.Body, maxArtifactSize)}
+
+	if err := storage.Upload(ctx, s.store, key, body, r.ContentLength, checksum, storage.ArtifactContentType); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	mv.Checksum, mv.ArtifactKey, mv.ArtifactSize = checksum, key, body.n
+
+	res := scan.Run(ctx, s.cfg.Scanning.Scanners(http.DefaultClient), scan.StoredArtifact(s.store, existing.Name, mv))
+	if err := scan.Apply(&mv, res); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE module_versions
+		SET checksum = $2, artifact_key = $3, artifact_size = $4, status = $5,
+			quarantined_from = NULLIF($6, ''), quarantine_reason = NULLIF($7, '')
+		WHERE id = $1`,
+		mv.ID, mv.Checksum, mv.ArtifactKey, mv.ArtifactSize, mv.Status, mv.QuarantinedFrom, mv.QuarantineReason,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(mv) // nolint: errcheck
+}
+
+// DownloadArtifact serves GET /api/v1/modules/{id}/versions/{version}/artifact,
+// streaming the source tarball of a version along with its checksum in
+// HeaderChecksum, and counting the download. Yanked versions remain
+// downloadable, whereas staged versions are only downloadable by module
+// owners. Downloads of modules disputed by a removal request are refused with
+// 451 Unavailable For Legal Reasons. The caller must have resolved the module
+// and checked that it is readable by the requester.
+func (s *Server) DownloadArtifact(w http.ResponseWriter, r *http.Request, m moduleAccess, version string) {
+	ctx := r.Context()
+
+	var (
+		disputed bool
+		mv       module.ModuleVersion
+	)
+
+	err := s.reader().QueryRowContext(ctx, `
+		SELECT m.disputed, mv.id, mv.version, COALESCE(mv.checksum, ''), COALESCE(mv.artifact_key, ''), mv.artifact_size
+		FROM module_versions mv
+		JOIN modules m ON m.id = mv.module_id
+		WHERE mv.module_id = $1
+			AND mv.version = $2
+			AND (mv.status = 'published' OR $3)`,
+		m.ID, version, m.Owner,
+	).Scan(&disputed, &mv.ID, &mv.Version, &mv.Checksum, &mv.ArtifactKey, &mv.ArtifactSize)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	if disputed {
+		WriteError(w, NewError(http.StatusUnavailableForLegalReasons, CodeForbidden, "downloads of disputed modules are blocked"))
+		return
+	}
+
+	if !mv.HasArtifact() {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "no artifact was uploaded for "+version))
+		return
+	}
+
+	rc, err := s.store.Get(ctx, mv.ArtifactKey)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rc.Close()
+
+	if r.Method == http.MethodGet {
+		if err := recordDownload(r, s.primary, m.ID, mv.ID); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", storage.ArtifactContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(mv.ArtifactSize, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", mv.Version+".tar.gz"))
+	w.Header().Set(HeaderChecksum, mv.Checksum)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	io.Copy(w, rc) // nolint: errcheck
+}
+
+// recordDownload counts a download of the module version in its total, in the
+// module's daily downloads and in the downloads of the requesting client, as
+// attributed by its User-Agent.
+func recordDownload(r *http.Request, sqlDB *sql.DB, moduleID, moduleVersionID int) error {
+	return db.InTx(r.Context(), sqlDB, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(r.Context(), `
+			UPDATE module_versions SET downloads = downloads + 1 WHERE id = $1`,
+			moduleVersionID,
+		); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(r.Context(), `
+			INSERT INTO module_daily_downloads (module_id, day, downloads)
+			VALUES ($1, CURRENT_DATE, 1)
+			ON CONFLICT (module_id, day) DO UPDATE SET downloads = module_daily_downloads.downloads + 1`,
+			moduleID,
+		); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(r.Context(), `
+			INSERT INTO client_downloads (module_id, client, downloads)
+			VALUES ($1, $2, 1)
+			ON CONFLICT (module_id, client) DO UPDATE SET downloads = client_downloads.downloads + 1`,
+			moduleID, module.ClientFromUserAgent(r.UserAgent()),
+		)
+		return err
+	})
+}
+
+// serveQuarantine serves GET /api/v1/admin/versions/quarantined, listing the
+// quarantined versions of every module, by module and version. It requires a
+// requester permitted to moderate the registry.
+func (s *Server) serveQuarantine(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	if _, err := s.moderator(r, "review quarantined versions"); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	rows, err := s.reader().QueryContext(r.Context(), `
+		SELECT m.name, mv.version, COALESCE(mv.quarantine_reason, '')
+		FROM module_versions mv
+		JOIN modules m ON m.id = mv.module_id
+		WHERE mv.status = $1
+		ORDER BY m.name, mv.version`,
+		module.VersionStatusQuarantined,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	versions := []QuarantinedVersion{}
+	for rows.Next() {
+		var qv QuarantinedVersion
+		if err := rows.Scan(&qv.Module, &qv.Version, &qv.Reason); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		versions = append(versions, qv)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions) // nolint: errcheck
+}
+
+// serveClear serves POST /api/v1/admin/versions/clear, lifting the quarantine
+// of a version after review and restoring the status it was quarantined from.
+// It requires a requester permitted to moderate the registry.
+func (s *Server) serveClear(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	u, err := s.moderator(r, "clear quarantined versions")
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	var req ClearRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	mv := module.ModuleVersion{Version: req.Version}
+	err = q.QueryRowContext(ctx, `
+		SELECT mv.id, mv.status, COALESCE(mv.quarantined_from, '')
+		FROM module_versions mv
+		JOIN modules m ON m.id = mv.module_id
+		WHERE m.name = $1
+			AND mv.version = $2
+		FOR UPDATE OF mv`,
+		req.Module, req.Version,
+	).Scan(&mv.ID, &mv.Status, &mv.QuarantinedFrom)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	if err := mv.Clear(*u); err != nil {
+		WriteError(w, NewError(http.StatusConflict, CodeVersionConflict, err.Error()))
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE module_versions
+		SET status = $2, quarantined_from = NULL, quarantine_reason = NULL
+		WHERE id = $1`,
+		mv.ID, mv.Status,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// ModuleChains serves GET /api/v1/modules/{id}/chains, listing the live chains
+// including the module and the version each one runs. The caller must have
+// resolved the module and checked that it is readable by the requester. The
+// list is streamed, as popular modules run on many chains.
+func ModuleChains(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int) {
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT c.name, c.network_id, c.pretty_name, c.network_type, c.repo, c.created_at, c.updated_at,
+			cm.version, cm.source
+		FROM chain_modules cm
+		JOIN chains c ON c.id = cm.chain_id
+		WHERE cm.module_id = $1
+		ORDER BY c.name`,
+		moduleID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	list := newJSONList(w, "")
+	for rows.Next() {
+		var cu module.ChainUsage
+		if err := rows.Scan(
+			&cu.Name, &cu.NetworkID, &cu.PrettyName, &cu.NetworkType, &cu.Repo, &cu.CreatedAt, &cu.UpdatedAt,
+			&cu.Version, &cu.Source,
+		); err != nil {
+			list.Fail(err)
+			return
+		}
+
+		if err := list.Append(cu); err != nil {
+			return
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		list.Fail(err)
+		return
+	}
+
+	list.Close("") // nolint: errcheck
+}
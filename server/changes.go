@@ -0,0 +1,92 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+)
+
+// ChangesPage defines a page of the changes feed. Clients resume from
+// NextCursor, which equals the request cursor when no newer changes exist.
+type ChangesPage struct {
+	Changes    []module.Change `json:"changes"`
+	NextCursor string          `json:"next_cursor"`
+}
+
+// Changes returns the handler of GET /api/v1/changes?since=<cursor>&limit=<n>,
+// serving the registry changes recorded after the cursor in order. Pages are
+// streamed as ChangesPage objects.
+func Changes(sqlDB *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		since, err := parseQueryInt(r, "since", 0)
+		if err != nil || since < 0 {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "since must be a cursor returned by the changes feed"))
+			return
+		}
+
+		limit, err := parseQueryInt(r, "limit", defaultChangesLimit)
+		if err != nil || limit < 1 || limit > maxChangesLimit {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "limit must be between 1 and 1000"))
+			return
+		}
+
+		rows, err := sqlDB.QueryContext(r.Context(), `
+			SELECT id, kind, module_name, COALESCE(version, ''), COALESCE(advisory, ''), created_at
+			FROM changes WHERE id > $1
+			ORDER BY id
+			LIMIT $2`,
+			since, limit,
+		)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		defer rows.Close()
+
+		var (
+			list   = newJSONList(w, `{"changes":`)
+			cursor = since
+		)
+
+		for rows.Next() {
+			var c module.Change
+			if err := rows.Scan(&c.ID, &c.Kind, &c.Module, &c.Version, &c.Advisory, &c.CreatedAt); err != nil {
+				list.Fail(err)
+				return
+			}
+
+			if err := list.Append(c); err != nil {
+				return
+			}
+
+			cursor = c.ID
+		}
+
+		if err := rows.Err(); err != nil {
+			list.Fail(err)
+			return
+		}
+
+		next, _ := json.Marshal(strconv.FormatInt(cursor, 10))
+		list.Close(`,"next_cursor":` + string(next) + `}`) // nolint: errcheck
+	}
+}
+
+// parseQueryInt parses the integer query parameter key, returning def when it
+// is absent.
+func parseQueryInt(r *http.Request, key string, def int64) (int64, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return def, nil
+	}
+
+	return strconv.ParseInt(v, 10, 64)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/cosmos/atlas/module"
+)
+
+var sdkModulesPathRegex = regexp.MustCompile(`^/api/v1/sdk/([^/]+)/modules$`)
+
+type (
+	// SDKModule defines a module compatible with a Cosmos SDK release and its
+	// greatest compatible version.
+	SDKModule struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	// SDKModulesResponse defines the response of the SDK modules endpoint.
+	SDKModulesResponse struct {
+		SDKVersion string      `json:"sdk_version"`
+		Modules    []SDKModule `json:"modules"`
+	}
+
+	// CompatibilityResponse defines the response of the module compatibility
+	// endpoint.
+	CompatibilityResponse struct {
+		SDKReleases []string                  `json:"sdk_releases"`
+		Versions    []module.SDKCompatibility `json:"versions"`
+	}
+)
+
+// SDKModules returns the handler of GET /api/v1/sdk/{version}/modules,
+// listing public modules with a version compatible with the Cosmos SDK
+// release.
+func SDKModules(sqlDB *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m := sdkModulesPathRegex.FindStringSubmatch(r.URL.Path)
+		if m == nil {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+			return
+		}
+
+		if _, err := semver.NewVersion(m[1]); err != nil {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid Cosmos SDK version"))
+			return
+		}
+
+		versions, err := queryCompatVersions(r.Context(), sqlDB, `
+			SELECT m.name, mv.version, mv.sdk_compat, mv.yanked, mv.status
+			FROM module_versions mv
+			JOIN modules m ON m.id = mv.module_id
+			WHERE mv.sdk_compat IS NOT NULL
+				AND m.visibility = 'public'
+				AND NOT m.hidden
+				AND m.deleted_at IS NULL`,
+		)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		resp := SDKModulesResponse{SDKVersion: m[1], Modules: []SDKModule{}}
+		for name, mvs := range versions {
+			mv, err := module.LatestForSDK(m[1], mvs)
+			if err != nil {
+				continue
+			}
+
+			resp.Modules = append(resp.Modules, SDKModule{Name: name, Version: mv.Version})
+		}
+
+		sort.Slice(resp.Modules, func(i, j int) bool { return resp.Modules[i].Name < resp.Modules[j].Name })
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp) // nolint: errcheck
+	}
+}
+
+// Compatibility serves GET /api/v1/modules/{id}/compatibility, returning the
+// Cosmos SDK compatibility matrix of every version of the module against the
+// configured SDK releases. The caller must have resolved the module and
+// checked that it is readable by the requester.
+func Compatibility(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int, sdkReleases []string) {
+	versions, err := queryCompatVersions(r.Context(), sqlDB, `
+		SELECT '', mv.version, COALESCE(mv.sdk_compat, ''), mv.yanked, mv.status
+		FROM module_versions mv
+		WHERE mv.module_id = $1
+			AND mv.status = 'published'
+		ORDER BY mv.created_at`,
+		moduleID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CompatibilityResponse{ // nolint: errcheck
+		SDKReleases: sdkReleases,
+		Versions:    module.CompatibilityMatrix(versions[""], sdkReleases),
+	})
+}
+
+// queryCompatVersions runs a query selecting module name, version, SDK
+// constraint, yanked flag and status, grouping the versions by module name.
+func queryCompatVersions(ctx context.Context, sqlDB *sql.DB, query string, args ...interface{}) (map[string][]module.ModuleVersion, error) {
+	rows, err := sqlDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[string][]module.ModuleVersion)
+	for rows.Next() {
+		var (
+			name string
+			mv   module.ModuleVersion
+		)
+
+		if err := rows.Scan(&name, &mv.Version, &mv.SDKCompat, &mv.Yanked, &mv.Status); err != nil {
+			return nil, err
+		}
+
+		versions[name] = append(versions[name], mv)
+	}
+
+	return versions, rows.Err()
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// minCompressSize defines the size under which responses are sent
+// uncompressed, as compression would not pay for its overhead.
+const minCompressSize = 1024
+
+// Compressor returns a writer compressing to w in a content coding, e.g.
+// gzip. Closing the writer flushes the compressed stream without closing w.
+type Compressor func(w io.Writer) io.WriteCloser
+
+// encoder defines a content coding offered by the compression middleware.
+type encoder struct {
+	encoding  string
+	newWriter Compressor
+}
+
+// WithCompressor makes the Server offer the given content coding, e.g. "zstd",
+// in addition to Brotli and gzip. Codings are preferred in reverse order of
+// registration, then Brotli, then gzip, among those the client accepts with
+// the same quality. Registering "br" or "gzip" replaces the built-in coding.
+func WithCompressor(encoding string, c Compressor) Option {
+	return func(s *Server) {
+		s.encoders = append([]encoder{{encoding: strings.ToLower(encoding), newWriter: c}}, s.encoders...)
+	}
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// pooledGzipWriter returns its gzip.Writer to the pool once closed.
+type pooledGzipWriter struct {
+	*gzip.Writer
+}
+
+func (w pooledGzipWriter) Close() error {
+	err := w.Writer.Close()
+	gzipWriters.Put(w.Writer)
+	return err
+}
+
+func newGzipWriter(w io.Writer) io.WriteCloser {
+	gw := gzipWriters.Get().(*gzip.Writer)
+	gw.Reset(w)
+	return pooledGzipWriter{gw}
+}
+
+var brotliWriters = sync.Pool{
+	New: func() interface{} { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) },
+}
+
+// pooledBrotliWriter returns its brotli.Writer to the pool once closed.
+type pooledBrotliWriter struct {
+	*brotli.Writer
+}
+
+func (w pooledBrotliWriter) Close() error {
+	err := w.Writer.Close()
+	brotliWriters.Put(w.Writer)
+	return err
+}
+
+func newBrotliWriter(w io.Writer) io.WriteCloser {
+	bw := brotliWriters.Get().(*brotli.Writer)
+	bw.Reset(w)
+	return pooledBrotliWriter{bw}
+}
+
+// compress returns middleware compressing responses in the content coding most
+// preferred by the client's Accept-Encoding header among the given encoders.
+// Responses that are small, already encoded, of an incompressible content type
+// or answer HEAD or range requests are sent as is. Streamed responses are
+// compressed as they are flushed.
+func compress(encoders []encoder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			if r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			enc, ok := negotiateEncoding(r.Header.Get("Accept-Encoding"), encoders)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoder: enc, status: http.StatusOK}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding returns the encoder of the content coding with the
+// greatest quality in an Accept-Encoding header, ties going to the earliest
+// encoder.
+func negotiateEncoding(header string, encoders []encoder) (encoder, bool) {
+	if header == "" {
+		return encoder{}, false
+	}
+
+	quality := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		coding, params := part, ""
+		if i := strings.Index(part, ";"); i >= 0 {
+			coding, params = part[:i], part[i+1:]
+		}
+
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			v, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+
+			q = v
+		}
+
+		quality[strings.ToLower(strings.TrimSpace(coding))] = q
+	}
+
+	var (
+		best  encoder
+		bestQ float64
+	)
+
+	wildcard, hasWildcard := quality["*"]
+
+	for _, enc := range encoders {
+		q, ok := quality[enc.encoding]
+		if !ok && hasWildcard {
+			q = wildcard
+		}
+
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+
+	return best, bestQ > 0
+}
+
+// compressibleType returns true if responses of the content type benefit from
+// compression.
+func compressibleType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/javascript", "application/xml", "image/svg+xml":
+		return true
+
+	default:
+		return false
+	}
+}
+
+// compressWriter compresses a response once it exceeds minCompressSize or is
+// flushed, buffering it until then.
+type compressWriter struct {
+	http.ResponseWriter
+	encoder
+
+	status      int
+	wroteHeader bool
+	passthrough bool
+	buf         []byte
+	cw          io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = status
+
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified ||
+		w.Header().Get("Content-Encoding") != "" {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	switch {
+	case w.passthrough:
+		return w.ResponseWriter.Write(p)
+
+	case w.cw != nil:
+		return w.cw.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= minCompressSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush starts compressing a buffered response and flushes the compressed
+// stream, so that streamed responses reach the client as they are written.
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.passthrough && w.cw == nil {
+		if err := w.start(); err != nil {
+			return
+		}
+	}
+
+	if f, ok := w.cw.(interface{ Flush() error }); ok {
+		f.Flush() // nolint: errcheck
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// start writes the response header and the buffered body, compressed if its
+// content type is compressible.
+func (w *compressWriter) start() error {
+	h := w.Header()
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	buf := w.buf
+	w.buf = nil
+
+	if !compressibleType(h.Get("Content-Type")) {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.status)
+		_, err := w.ResponseWriter.Write(buf)
+		return err
+	}
+
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", w.encoding)
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.cw = w.newWriter(w.ResponseWriter)
+	_, err := w.cw.Write(buf)
+	return err
+}
+
+// close completes the response: it closes the compressed stream, or writes a
+// response too small to compress as is.
+func (w *compressWriter) close() {
+	switch {
+	case !w.wroteHeader || w.passthrough:
+
+	case w.cw != nil:
+		w.cw.Close() // nolint: errcheck
+
+	default:
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.Write(w.buf) // nolint: errcheck
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	lastModified := time.Date(2021, 3, 1, 12, 0, 0, 500, time.UTC)
+	etag := weakETag("liquidity", 3)
+
+	testCases := []struct {
+		name   string
+		header map[string]string
+		status int
+	}{
+		{"unconditional", nil, http.StatusOK},
+		{"matching etag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"strong form of the etag", map[string]string{"If-None-Match": etag[2:]}, http.StatusNotModified},
+		{"listed etag", map[string]string{"If-None-Match": `"other", ` + etag}, http.StatusNotModified},
+		{"any etag", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"stale etag", map[string]string{"If-None-Match": weakETag("liquidity", 2)}, http.StatusOK},
+		{"unmodified since", map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)}, http.StatusOK},
+		{"invalid date", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{
+			"stale etag takes precedence",
+			map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/modules/liquidity", nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			if !notModified(rec, req, etag, lastModified) {
+				rec.WriteHeader(http.StatusOK)
+			}
+
+			if rec.Code != tc.status {
+				t.Errorf("expected %d, got %d", tc.status, rec.Code)
+			}
+
+			if rec.Header().Get("ETag") != etag {
+				t.Errorf("expected ETag %s, got %s", etag, rec.Header().Get("ETag"))
+			}
+
+			if rec.Header().Get("Last-Modified") != "Mon, 01 Mar 2021 12:00:00 GMT" {
+				t.Errorf("unexpected Last-Modified %s", rec.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
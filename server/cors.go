@@ -0,0 +1,157 @@
+package server
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/hmacauth"
+	"github.com/cosmos/atlas/module"
+)
+
+const csrfPath = "/api/v1/csrf"
+
+var (
+	// corsAllowedMethods defines the methods allowed to cross-origin requests.
+	corsAllowedMethods = strings.Join([]string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	}, ", ")
+
+	// corsAllowedHeaders defines the request headers allowed to cross-origin
+	// requests, i.e. those of every credential and conditional request.
+	corsAllowedHeaders = strings.Join([]string{
+		"Authorization", "Content-Type", "If-Match", "If-None-Match",
+		hmacauth.HeaderCSRFToken, hmacauth.HeaderKeyID, hmacauth.HeaderTimestamp, hmacauth.HeaderSignature,
+	}, ", ")
+
+	// corsExposedHeaders defines the response headers readable by cross-origin
+	// frontends.
+	corsExposedHeaders = strings.Join([]string{"ETag", "Location", "Retry-After", "X-Request-Id"}, ", ")
+)
+
+// CSRFResponse defines the response of the CSRF token endpoint.
+type CSRFResponse struct {
+	Token string `json:"token"`
+}
+
+// cors returns a middleware applying the CORS policy. Responses to allowed
+// origins carry the CORS headers and preflight requests are answered without
+// reaching the handler. Requests from other origins are served as is, so that
+// browsers deny their frontends access to the response.
+func cors(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if !cfg.AllowsOrigin(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Origin", origin)
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			h.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// sessionRequester returns the User of the session cookie of a request, or nil
+// if it has none. Mutating requests must carry the session's CSRF token and,
+// if they carry an Origin, come from the registry itself or an allowed origin.
+func (s *Server) sessionRequester(r *http.Request) (*module.User, error) {
+	c, err := r.Cookie(hmacauth.SessionCookie)
+	if err != nil || c.Value == "" {
+		return nil, nil
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if origin := r.Header.Get("Origin"); origin != "" && !s.trustedOrigin(origin) {
+			return nil, NewError(http.StatusForbidden, CodeForbidden, "origin not allowed")
+		}
+
+		token := r.Header.Get(hmacauth.HeaderCSRFToken)
+		if token == "" || !hmac.Equal([]byte(token), []byte(module.CSRFToken(c.Value))) {
+			return nil, NewError(http.StatusForbidden, CodeForbidden, "missing or invalid CSRF token")
+		}
+	}
+
+	u, err := s.verifier.VerifySession(r.Context(), c.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// trustedOrigin returns true if the origin is that of the registry's base URL
+// or an origin allowed to send credentialed requests.
+func (s *Server) trustedOrigin(origin string) bool {
+	if base, err := url.Parse(s.cfg.BaseURL); err == nil && strings.EqualFold(origin, base.Scheme+"://"+base.Host) {
+		return true
+	}
+
+	return s.cfg.CORS.AllowCredentials && s.cfg.CORS.AllowsOrigin(origin)
+}
+
+// CSRF serves GET /api/v1/csrf, returning the CSRF token of the requester's
+// session cookie.
+func (s *Server) CSRF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	c, err := r.Cookie(hmacauth.SessionCookie)
+	if err != nil || c.Value == "" {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "session required"))
+		return
+	}
+
+	if _, err := s.verifier.VerifySession(r.Context(), c.Value); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(CSRFResponse{Token: module.CSRFToken(c.Value)}) // nolint: errcheck
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+const adminRestoreModulePath = adminPathPrefix + "modules/restore"
+
+// RestoreModuleRequest defines the request of the module restore endpoint,
+// naming the soft-deleted module to restore.
+type RestoreModuleRequest struct {
+	Name string `json:"name"`
+}
+
+// DeleteModule serves DELETE /api/v1/modules/{id}, soft-deleting the module
+// so that it is excluded from search and lists while retained for the
+// registry's retention window, during which administrators may restore it.
+// Deletion is refused with VERSION_IN_USE while other registered modules depend
+// on the module. Only module owners may delete a module.
+func (s *Server) DeleteModule(w http.ResponseWriter, r *http.Request, m moduleAccess) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may delete a module"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	existing, found, err := lockModule(ctx, q, m.User, "m.id = $1", m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !found {
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found"))
+		return
+	}
+
+	if existing.Mirrored() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "mirrored modules are read-only"))
+		return
+	}
+
+	var dependents int
+	if err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM module_dependencies md
+		JOIN modules d ON d.id = md.module_id
+		WHERE md.dependency_id = $1
+			AND d.deleted_at IS NULL`,
+		existing.ID,
+	).Scan(&dependents); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if dependents > 0 {
+		WriteError(w, NewError(http.StatusConflict, CodeVersionInUse, fmt.Sprintf("%s has %d dependent(s)", existing.Name, dependents)))
+		return
+	}
+
+	existing.Delete(time.Now().UTC())
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE modules
+		SET deleted_at = $2, lock_version = lock_version + 1
+		WHERE id = $1`,
+		existing.ID, existing.DeletedAt,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveRestoreModule serves POST /api/v1/admin/modules/restore, restoring a
+// soft-deleted module that has not been purged yet. It requires a requester
+// permitted to moderate the registry.
+func (s *Server) serveRestoreModule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	if _, err := s.moderator(r, "restore modules"); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	var req RestoreModuleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	var m module.Module
+	err := q.QueryRowContext(ctx, `
+		SELECT id, name, deleted_at
+		FROM modules
+		WHERE slug = $1
+			AND deleted_at IS NOT NULL
+		FOR UPDATE`,
+		module.Slug(req.Name),
+	).Scan(&m.ID, &m.Name, &m.DeletedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "no deleted module named "+req.Name))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	m.Restore()
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE modules
+		SET deleted_at = NULL, lock_version = lock_version + 1
+		WHERE id = $1`,
+		m.ID,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// purgeDeleted hard-deletes the modules soft-deleted longer than the retention
+// window ago, releasing their names to the reclaim cooldown of the publish
+// policy, and returns the number of purged modules.
+func purgeDeleted(ctx context.Context, sqlDB *sql.DB, retention time.Duration, now time.Time) (int, error) {
+	var purged int
+
+	err := db.InTx(ctx, sqlDB, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id, name, COALESCE(author, 0), deleted_at
+			FROM modules
+			WHERE deleted_at IS NOT NULL
+			FOR UPDATE`,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var modules []module.Module
+		for rows.Next() {
+			var m module.Module
+			if err := rows.Scan(&m.ID, &m.Name, &m.Author, &m.DeletedAt); err != nil {
+				return err
+			}
+
+			if m.Purgeable(now, retention) {
+				modules = append(modules, m)
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, m := range modules {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO released_names (name, previous_owner, released_at)
+				VALUES ($1, NULLIF($2, 0), $3)
+				ON CONFLICT (name) DO UPDATE
+				SET previous_owner = EXCLUDED.previous_owner, released_at = EXCLUDED.released_at`,
+				m.Name, m.Author, now,
+			); err != nil {
+				return err
+			}
+
+			// the associations predating cascading deletes are removed explicitly,
+			// along with edges from dependents deleted before the module
+			for _, query := range []string{
+				`DELETE FROM modules_users WHERE module_id = $1`,
+				`DELETE FROM modules_keywords WHERE module_id = $1`,
+				`DELETE FROM module_dependencies WHERE dependency_id = $1`,
+				`DELETE FROM modules WHERE id = $1`,
+			} {
+				if _, err := tx.ExecContext(ctx, query, m.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		purged = len(modules)
+		return nil
+	})
+
+	return purged, err
+}
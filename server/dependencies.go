@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+// ModuleDependencies serves GET /api/v1/modules/{id}/dependencies, listing the
+// registered modules the module depends on and their version constraints, by
+// name. Dependencies unreadable by the requester are omitted. The caller must
+// have resolved the module and checked that it is readable by the requester.
+func ModuleDependencies(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, m moduleAccess) {
+	deps, err := queryDependencyEdges(r.Context(), sqlDB, `
+		SELECT d.name, md.version_constraint
+		FROM module_dependencies md
+		JOIN modules d ON d.id = md.dependency_id
+		WHERE md.module_id = $1
+			AND module_readable(d.id, $2)
+			AND NOT d.hidden
+			AND d.deleted_at IS NULL
+		ORDER BY d.name`,
+		m.ID, requesterID(m.User),
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deps) // nolint: errcheck
+}
+
+// ModuleDependents serves GET /api/v1/modules/{id}/dependents, listing the
+// registered modules depending on the module and their version constraints on
+// it, by name. Dependents unreadable by the requester are omitted. The caller
+// must have resolved the module and checked that it is readable by the
+// requester.
+func ModuleDependents(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, m moduleAccess) {
+	deps, err := queryDependents(r.Context(), sqlDB, m)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deps) // nolint: errcheck
+}
+
+// VersionImpact defines the registered modules whose version constraints on a
+// module resolve to one of its versions.
+type VersionImpact struct {
+	Version    string                    `json:"version"`
+	Dependents []module.ModuleDependency `json:"dependents"`
+}
+
+// ModuleVersionImpact serves GET
+// /api/v1/modules/{id}/versions/{version}/impact, listing the dependents of the
+// module whose constraints resolve to the version, so that its blast radius can
+// be evaluated before yanking it. Dependents unreadable by the requester are
+// omitted. The caller must have resolved the module and checked that it is
+// readable by the requester.
+func ModuleVersionImpact(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, m moduleAccess, version string) {
+	var exists bool
+	if err := sqlDB.QueryRowContext(r.Context(), `
+		SELECT EXISTS (SELECT 1 FROM module_versions WHERE module_id = $1 AND version = $2)`,
+		m.ID, version,
+	).Scan(&exists); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !exists {
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+	}
+
+	deps, err := queryDependents(r.Context(), sqlDB, m)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	impacted, err := module.ImpactedDependencies(version, deps)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	resp := VersionImpact{Version: version, Dependents: impacted}
+	if resp.Dependents == nil {
+		resp.Dependents = []module.ModuleDependency{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp) // nolint: errcheck
+}
+
+// queryDependents returns the dependency edges pointing at the module from
+// modules readable by the requester, named after the dependent module.
+func queryDependents(ctx context.Context, sqlDB *sql.DB, m moduleAccess) ([]module.ModuleDependency, error) {
+	return queryDependencyEdges(ctx, sqlDB, `
+		SELECT d.name, md.version_constraint
+		FROM module_dependencies md
+		JOIN modules d ON d.id = md.module_id
+		WHERE md.dependency_id = $1
+			AND module_readable(d.id, $2)
+			AND NOT d.hidden
+			AND d.deleted_at IS NULL
+		ORDER BY d.name`,
+		m.ID, requesterID(m.User),
+	)
+}
+
+// queryDependencyEdges runs a query selecting a module name and version
+// constraint per dependency edge.
+func queryDependencyEdges(ctx context.Context, q db.Querier, query string, args ...interface{}) ([]module.ModuleDependency, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deps := []module.ModuleDependency{}
+	for rows.Next() {
+		var md module.ModuleDependency
+		if err := rows.Scan(&md.Name, &md.VersionConstraint); err != nil {
+			return nil, err
+		}
+
+		deps = append(deps, md)
+	}
+
+	return deps, rows.Err()
+}
+
+// requesterID returns the ID of the requester as a query argument, NULL for
+// anonymous requests.
+func requesterID(u *module.User) interface{} {
+	if u == nil {
+		return nil
+	}
+
+	return u.ID
+}
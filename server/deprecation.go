@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+// serveDeprecation serves PUT /api/v1/modules/{id}/deprecation, deprecating a
+// module in favor of an optional replacement, and DELETE to lift it. Only
+// module owners may deprecate a module, unless it is archived.
+func (s *Server) serveDeprecation(w http.ResponseWriter, r *http.Request, m moduleAccess) {
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !u.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may deprecate a module"))
+		return
+	}
+
+	q := db.Conn(r.Context(), s.primary)
+
+	switch r.Method {
+	case http.MethodPut:
+		var d module.Deprecation
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&d); err != nil {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+			return
+		}
+
+		deprecated, err := s.deprecate(r.Context(), q, u, m.ID, d)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(deprecated) // nolint: errcheck
+
+	case http.MethodDelete:
+		state, err := queryDeprecationState(r.Context(), q, m.ID)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if err := state.CheckWritable(); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if _, err := q.ExecContext(r.Context(), `
+			UPDATE modules
+			SET deprecated_at = NULL, deprecation_message = NULL, replaced_by = NULL, lock_version = lock_version + 1
+			WHERE id = $1`,
+			m.ID,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// deprecate deprecates the module with the given ID, resolving its replacement
+// as the requester would, and returns the recorded Deprecation.
+func (s *Server) deprecate(ctx context.Context, q db.Querier, u *module.User, moduleID int, d module.Deprecation) (*module.Deprecation, error) {
+	m, err := queryDeprecationState(ctx, q, moduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	var (
+		successor   *module.Module
+		successorID sql.NullInt64
+	)
+
+	if d.ReplacedBy != "" {
+		access, err := s.lookupModule(ctx, u, d.ReplacedBy)
+		if err != nil {
+			if ToError(err).Code == CodeModuleNotFound {
+				return nil, module.ValidationErrors{{Field: "replaced_by", Code: module.ErrCodeInvalidValue, Message: "replacement module not found"}}
+			}
+
+			return nil, err
+		}
+
+		next, err := queryDeprecationState(ctx, q, access.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		successor = &next
+		successorID = sql.NullInt64{Int64: int64(access.ID), Valid: true}
+	}
+
+	if err := m.Deprecate(d, successor, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE modules
+		SET deprecated_at = $2, deprecation_message = $3, replaced_by = $4, lock_version = lock_version + 1
+		WHERE id = $1`,
+		moduleID, m.Deprecation.DeprecatedAt, m.Deprecation.Message, successorID,
+	); err != nil {
+		return nil, err
+	}
+
+	return m.Deprecation, nil
+}
+
+// queryDeprecationState returns the name, visibility, archival and deprecation
+// of the module with the given ID.
+func queryDeprecationState(ctx context.Context, q db.Querier, moduleID int) (module.Module, error) {
+	var (
+		m              module.Module
+		deprecatedAt   sql.NullTime
+		deprecationMsg string
+		replacedBy     sql.NullString
+	)
+
+	err := q.QueryRowContext(ctx, `
+		SELECT m.name, m.visibility, m.archived, m.deprecated_at, COALESCE(m.deprecation_message, ''), r.name
+		FROM modules m
+		LEFT JOIN modules r ON r.id = m.replaced_by AND r.deleted_at IS NULL
+		WHERE m.id = $1`,
+		moduleID,
+	).Scan(&m.Name, &m.Visibility, &m.Archived, &deprecatedAt, &deprecationMsg, &replacedBy)
+	if err != nil {
+		return m, err
+	}
+
+	m.Deprecation = scanDeprecation(deprecatedAt, deprecationMsg, replacedBy)
+	return m, nil
+}
+
+// scanDeprecation returns the Deprecation of a module as scanned from its
+// deprecated_at and deprecation_message columns and the name of its
+// replacement, or nil if the module is not deprecated.
+func scanDeprecation(deprecatedAt sql.NullTime, message string, replacedBy sql.NullString) *module.Deprecation {
+	if !deprecatedAt.Valid {
+		return nil
+	}
+
+	return &module.Deprecation{ReplacedBy: replacedBy.String, Message: message, DeprecatedAt: deprecatedAt.Time}
+}
@@ -0,0 +1,382 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	defaultQuestionsLimit = 20
+	maxQuestionsLimit     = 100
+)
+
+type (
+	// QuestionSummary defines a Question in a module's list of questions.
+	QuestionSummary struct {
+		module.Question
+		Asker   string `json:"asker"`
+		Answers int    `json:"answers"`
+	}
+
+	// AnswerResponse defines an Answer and the name of its author.
+	AnswerResponse struct {
+		module.Answer
+		Author string `json:"author"`
+	}
+
+	// QuestionThread defines a Question and its answers, ordered with the
+	// accepted answer first, followed by maintainer answers.
+	QuestionThread struct {
+		module.Question
+		Asker   string           `json:"asker"`
+		Answers []AnswerResponse `json:"answers"`
+	}
+)
+
+// ModuleQuestions serves GET /api/v1/modules/{id}/questions?limit=<n>&offset=<n>,
+// listing the module's visible questions, newest first. The caller must have
+// resolved the module and checked that it is readable by the requester.
+func ModuleQuestions(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int) {
+	limit, err := parseQueryInt(r, "limit", defaultQuestionsLimit)
+	if err != nil || limit < 1 || limit > maxQuestionsLimit {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "limit must be between 1 and 100"))
+		return
+	}
+
+	offset, err := parseQueryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "offset must not be negative"))
+		return
+	}
+
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT q.id, q.title, q.body, COALESCE(q.accepted_answer_id, 0), q.created_at, q.updated_at,
+			COALESCE(u.name, ''), (SELECT COUNT(*) FROM answers a WHERE a.question_id = q.id AND NOT a.hidden)
+		FROM questions q
+		JOIN users u ON u.id = q.user_id
+		WHERE q.module_id = $1 AND NOT q.hidden
+		ORDER BY q.created_at DESC, q.id DESC
+		LIMIT $2 OFFSET $3`,
+		moduleID, limit, offset,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	questions := []QuestionSummary{}
+	for rows.Next() {
+		var qs QuestionSummary
+		if err := rows.Scan(
+			&qs.ID, &qs.Title, &qs.Body, &qs.AcceptedAnswerID, &qs.CreatedAt, &qs.UpdatedAt,
+			&qs.Asker, &qs.Answers,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		questions = append(questions, qs)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(questions) // nolint: errcheck
+}
+
+// Question serves GET /api/v1/modules/{id}/questions/{question}, returning the
+// question and its visible answers. The caller must have resolved the module
+// and checked that it is readable by the requester.
+func Question(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID, questionID int) {
+	thread := QuestionThread{Answers: []AnswerResponse{}}
+
+	if err := sqlDB.QueryRowContext(r.Context(), `
+		SELECT q.id, q.title, q.body, COALESCE(q.accepted_answer_id, 0), q.created_at, q.updated_at, COALESCE(u.name, '')
+		FROM questions q
+		JOIN users u ON u.id = q.user_id
+		WHERE q.id = $1 AND q.module_id = $2 AND NOT q.hidden`,
+		questionID, moduleID,
+	).Scan(
+		&thread.ID, &thread.Title, &thread.Body, &thread.AcceptedAnswerID, &thread.CreatedAt, &thread.UpdatedAt, &thread.Asker,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT a.id, a.body, a.maintainer, a.created_at, COALESCE(u.name, '')
+		FROM answers a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.question_id = $1 AND NOT a.hidden
+		ORDER BY a.id = $2 DESC, a.maintainer DESC, a.created_at, a.id`,
+		questionID, thread.AcceptedAnswerID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ar AnswerResponse
+		if err := rows.Scan(&ar.ID, &ar.Body, &ar.Maintainer, &ar.CreatedAt, &ar.Author); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		thread.Answers = append(thread.Answers, ar)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(thread) // nolint: errcheck
+}
+
+// serveDiscussions serves the discussion writes of a module, as routed by
+// moduleRoutes, where action is one of:
+//
+//	""         POST /api/v1/modules/{id}/questions                        asks a question
+//	"answers"  POST /api/v1/modules/{id}/questions/{question}/answers     answers a question
+//	"accepted" PUT  /api/v1/modules/{id}/questions/{question}/accepted    accepts an answer, by the asker or an owner
+//
+// Writes run in the request's transaction. Owners and subscribers of the module
+// and the asker of the question are notified by database triggers.
+func (s *Server) serveDiscussions(w http.ResponseWriter, r *http.Request, m moduleAccess, question, action string) {
+	var questionID int
+	if action != "" {
+		id, err := strconv.Atoi(question)
+		if err != nil {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+			return
+		}
+
+		questionID = id
+	}
+
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "banned users may not take part in discussions"))
+		return
+	}
+
+	q := db.Conn(r.Context(), s.primary)
+
+	switch action {
+	case "":
+		askQuestion(w, r, q, m)
+
+	case "answers":
+		answerQuestion(w, r, q, m, questionID)
+
+	case "accepted":
+		acceptAnswer(w, r, q, m, questionID)
+	}
+}
+
+func askQuestion(w http.ResponseWriter, r *http.Request, q db.Querier, m moduleAccess) {
+	var question module.Question
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&question); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	if err := question.Validate(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	question.ModuleID = m.ID
+	question.UserID = m.User.ID
+	question.AcceptedAnswerID = 0
+
+	if err := q.QueryRowContext(r.Context(), `
+		INSERT INTO questions (module_id, user_id, title, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at`,
+		question.ModuleID, question.UserID, question.Title, question.Body,
+	).Scan(&question.ID, &question.CreatedAt, &question.UpdatedAt); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(QuestionSummary{Question: question, Asker: m.User.Name}) // nolint: errcheck
+}
+
+func answerQuestion(w http.ResponseWriter, r *http.Request, q db.Querier, m moduleAccess, questionID int) {
+	var req struct {
+		Body string `json:"body"`
+	}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	question, err := queryQuestion(r.Context(), q, m.ID, questionID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	discussed, contributors, err := queryReviewedModule(r.Context(), q, m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	a, err := module.NewAnswer(*m.User, question, discussed, contributors, req.Body, time.Now().UTC())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := q.QueryRowContext(r.Context(), `
+		INSERT INTO answers (question_id, user_id, body, maintainer)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		a.QuestionID, a.UserID, a.Body, a.Maintainer,
+	).Scan(&a.ID, &a.CreatedAt); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(AnswerResponse{Answer: a, Author: m.User.Name}) // nolint: errcheck
+}
+
+func acceptAnswer(w http.ResponseWriter, r *http.Request, q db.Querier, m moduleAccess, questionID int) {
+	var req struct {
+		AnswerID int `json:"answer_id"`
+	}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	question, err := queryQuestion(r.Context(), q, m.ID, questionID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	a := module.Answer{ID: req.AnswerID}
+	err = q.QueryRowContext(r.Context(), `
+		SELECT question_id
+		FROM answers
+		WHERE id = $1 AND question_id = $2 AND NOT hidden`,
+		req.AnswerID, question.ID,
+	).Scan(&a.QuestionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		WriteError(w, module.ValidationErrors{{
+			Field:   "answer_id",
+			Code:    module.ErrCodeInvalidValue,
+			Message: "not an answer to this question",
+		}})
+		return
+	} else if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	discussed, contributors, err := queryReviewedModule(r.Context(), q, m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := question.Accept(*m.User, discussed, contributors, a, time.Now().UTC()); err != nil {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only the asker or module owners may accept answers"))
+		return
+	}
+
+	if _, err := q.ExecContext(r.Context(), `
+		UPDATE questions
+		SET accepted_answer_id = $2, updated_at = $3
+		WHERE id = $1`,
+		question.ID, question.AcceptedAnswerID, question.UpdatedAt,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(question) // nolint: errcheck
+}
+
+// serveSubscription serves PUT /api/v1/modules/{id}/subscription, subscribing
+// the requester to notifications of the module's discussions, and DELETE to
+// unsubscribe. Both are idempotent.
+func (s *Server) serveSubscription(w http.ResponseWriter, r *http.Request, m moduleAccess) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	q := db.Conn(r.Context(), s.primary)
+
+	var err error
+	if r.Method == http.MethodPut {
+		_, err = q.ExecContext(r.Context(), `
+			INSERT INTO module_subscriptions (module_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT (module_id, user_id) DO NOTHING`,
+			m.ID, m.User.ID,
+		)
+	} else {
+		_, err = q.ExecContext(r.Context(), `
+			DELETE FROM module_subscriptions
+			WHERE module_id = $1 AND user_id = $2`,
+			m.ID, m.User.ID,
+		)
+	}
+
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// queryQuestion returns a visible question of the module with the given ID.
+func queryQuestion(ctx context.Context, q db.Querier, moduleID, questionID int) (module.Question, error) {
+	question := module.Question{ModuleID: moduleID}
+	err := q.QueryRowContext(ctx, `
+		SELECT id, user_id, title, body, COALESCE(accepted_answer_id, 0), created_at, updated_at
+		FROM questions
+		WHERE id = $1 AND module_id = $2 AND NOT hidden`,
+		questionID, moduleID,
+	).Scan(
+		&question.ID, &question.UserID, &question.Title, &question.Body, &question.AcceptedAnswerID,
+		&question.CreatedAt, &question.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return question, NewError(http.StatusNotFound, CodeNotFound, "question not found")
+	}
+
+	return question, err
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// Download export granularities.
+var exportGranularities = map[string]bool{"day": true, "week": true, "month": true}
+
+// ExportDownloads serves GET /api/v1/modules/{id}/stats/export, streaming the
+// download rollups of a module as CSV (format=csv, the default) or NDJSON
+// (format=ndjson) at day, week or month granularity. The caller must have
+// resolved the module and authorized the request as one of its owners.
+func ExportDownloads(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int) {
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	if format != "csv" && format != "ndjson" {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "format must be one of: csv, ndjson"))
+		return
+	}
+
+	granularity := q.Get("granularity")
+	if granularity == "" {
+		granularity = "day"
+	}
+
+	if !exportGranularities[granularity] {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "granularity must be one of: day, week, month"))
+		return
+	}
+
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT DATE_TRUNC($1, day::TIMESTAMP)::DATE AS period, SUM(downloads)
+		FROM module_daily_downloads
+		WHERE module_id = $2
+		GROUP BY 1
+		ORDER BY 1`,
+		granularity, moduleID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("module-%d-downloads-%s.%s", moduleID, granularity, format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var write func(period time.Time, downloads int64) error
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+
+		cw := csv.NewWriter(w)
+		defer cw.Flush()
+
+		if err := cw.Write([]string{"period", "downloads"}); err != nil {
+			return
+		}
+
+		write = func(period time.Time, downloads int64) error {
+			return cw.Write([]string{period.Format("2006-01-02"), strconv.FormatInt(downloads, 10)})
+		}
+
+	default:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+
+		enc := json.NewEncoder(w)
+		write = func(period time.Time, downloads int64) error {
+			return enc.Encode(struct {
+				Period    string `json:"period"`
+				Downloads int64  `json:"downloads"`
+			}{period.Format("2006-01-02"), downloads})
+		}
+	}
+
+	// headers are sent with the first row, so errors past this point can only
+	// truncate the stream
+	for rows.Next() {
+		var (
+			period    time.Time
+			downloads int64
+		)
+
+		if err := rows.Scan(&period, &downloads); err != nil {
+			return
+		}
+
+		if err := write(period, downloads); err != nil {
+			return
+		}
+	}
+}
+
+// ClientDownloads serves GET /api/v1/modules/{id}/stats/clients, breaking the
+// downloads of a module down by the client that fetched them, e.g. the CLI or
+// a Go proxy, most downloads first. The caller must have resolved the module
+// and checked that it is readable by the requester.
+func ClientDownloads(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int) {
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT client, downloads
+		FROM client_downloads
+		WHERE module_id = $1
+		ORDER BY downloads DESC, client`,
+		moduleID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	clients := []module.ClientDownloads{}
+	for rows.Next() {
+		cd := module.ClientDownloads{ModuleID: moduleID}
+		if err := rows.Scan(&cd.Client, &cd.Downloads); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		clients = append(clients, cd)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clients) // nolint: errcheck
+}
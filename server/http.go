@@ -0,0 +1,43 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	idleTimeout       = 2 * time.Minute
+)
+
+// NewHTTPServer returns the HTTP server serving the handler on addr with
+// HTTP/2 enabled. With a TLS configuration, HTTP/2 is negotiated by ALPN;
+// without, e.g. behind a TLS-terminating proxy, cleartext HTTP/2 (h2c) is
+// served alongside HTTP/1.1.
+func NewHTTPServer(addr string, h http.Handler, tlsCfg *tls.Config) (*http.Server, error) {
+	h2s := &http2.Server{IdleTimeout: idleTimeout}
+
+	if tlsCfg == nil {
+		h = h2c.NewHandler(h, h2s)
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		TLSConfig:         tlsCfg,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	if tlsCfg != nil {
+		if err := http2.ConfigureServer(srv, h2s); err != nil {
+			return nil, err
+		}
+	}
+
+	return srv, nil
+}
@@ -0,0 +1,346 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/keyless"
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	keylessTokenPath = "/api/v1/keyless/token"
+
+	// keylessTokenPrefix prefixes the publish credentials minted by the token
+	// exchange, telling them apart from API tokens.
+	keylessTokenPrefix = "atlas_oidc_"
+
+	// keylessTokenTTL defines the lifetime of a minted publish credential.
+	keylessTokenTTL = 15 * time.Minute
+)
+
+type (
+	// IDTokenVerifier verifies an OIDC ID token issued to a CI workflow,
+	// returning its claims. It is implemented by keyless.Verifier.
+	IDTokenVerifier interface {
+		Verify(ctx context.Context, rawToken string) (keyless.Claims, error)
+	}
+
+	// KeylessTokenRequest defines the request of the keyless token exchange,
+	// naming the module to publish along with the workflow's OIDC ID token.
+	KeylessTokenRequest struct {
+		Module  string `json:"module"`
+		IDToken string `json:"id_token"`
+	}
+
+	// KeylessTokenResponse defines the short-lived publish credential minted by
+	// the keyless token exchange, sent as a bearer token to publish versions of
+	// its module until it expires.
+	KeylessTokenResponse struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+)
+
+// WithIDTokenVerifier makes the Server verify the ID tokens of keyless
+// publishing with the given verifier instead of discovering the GitHub Actions
+// OIDC provider for config.KeylessAudience.
+func WithIDTokenVerifier(v IDTokenVerifier) Option {
+	return func(s *Server) { s.idTokens = v }
+}
+
+// idTokenVerifier returns the verifier of keyless publishing ID tokens,
+// discovering the GitHub Actions OIDC provider on first use.
+func (s *Server) idTokenVerifier(ctx context.Context) (IDTokenVerifier, error) {
+	s.idTokensMu.Lock()
+	defer s.idTokensMu.Unlock()
+
+	if s.idTokens == nil {
+		v, err := keyless.NewVerifier(ctx, s.cfg.KeylessAudience)
+		if err != nil {
+			return nil, err
+		}
+
+		s.idTokens = v
+	}
+
+	return s.idTokens, nil
+}
+
+// serveKeylessToken serves POST /api/v1/keyless/token, exchanging the OIDC ID
+// token of a CI workflow for a short-lived credential publishing versions of
+// the requested module. The token's repository, workflow and ref claims must
+// be permitted by one of the module's trust policies. Only served if
+// config.KeylessAudience is set.
+func (s *Server) serveKeylessToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	var req KeylessTokenRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil || req.IDToken == "" {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	ctx := r.Context()
+
+	verifier, err := s.idTokenVerifier(ctx)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	claims, err := verifier.Verify(ctx, req.IDToken)
+	if err != nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, err.Error()))
+		return
+	}
+
+	q := db.Conn(ctx, s.primary)
+
+	var m module.Module
+	err = q.QueryRowContext(ctx, `
+		SELECT id, name, COALESCE(repo, '')
+		FROM modules
+		WHERE name = $1
+			AND deleted_at IS NULL`,
+		req.Module,
+	).Scan(&m.ID, &m.Name, &m.Repo)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found"))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	policies, err := queryTrustPolicies(ctx, q, m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := keyless.Authorize(m, policies, claims); err != nil {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, err.Error()))
+		return
+	}
+
+	bz := make([]byte, tokenSize)
+	if _, err := rand.Read(bz); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	resp := KeylessTokenResponse{
+		Token:     keylessTokenPrefix + hex.EncodeToString(bz),
+		ExpiresAt: time.Now().UTC().Add(keylessTokenTTL).Truncate(time.Second),
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO publish_credentials (module_id, token_hash, repository, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		m.ID, module.HashSessionToken(resp.Token), claims.Repository, resp.ExpiresAt,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp) // nolint: errcheck
+}
+
+// keylessPublisher returns the author of the module a publish credential sent
+// as the request's bearer token was minted for, along with the module's name.
+// It returns a nil user if the request carries no publish credential.
+func (s *Server) keylessPublisher(r *http.Request) (*module.User, string, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !strings.HasPrefix(token, keylessTokenPrefix) {
+		return nil, "", nil
+	}
+
+	var (
+		u    module.User
+		name string
+	)
+
+	err := db.Conn(r.Context(), s.primary).QueryRowContext(r.Context(), `
+		SELECT u.id, COALESCE(u.name, ''), u.email, u.admin, u.banned, m.name
+		FROM publish_credentials pc
+		JOIN modules m ON m.id = pc.module_id
+		JOIN users u ON u.id = m.author
+		WHERE pc.token_hash = $1
+			AND pc.expires_at > NOW()
+			AND m.deleted_at IS NULL`,
+		module.HashSessionToken(token),
+	).Scan(&u.ID, &u.Name, &u.Email, &u.Admin, &u.Banned, &name)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, "", NewError(http.StatusUnauthorized, CodeUnauthorized, "invalid or expired publish credential")
+
+	case err != nil:
+		return nil, "", err
+	}
+
+	setRequester(r, &u)
+	return &u, name, nil
+}
+
+// ModuleTrustPolicies serves GET /api/v1/modules/{id}/trust-policies, listing
+// the trust policies of the module permitting CI workflows to publish it
+// without an API token, oldest first. Only module owners may list them.
+func ModuleTrustPolicies(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, m moduleAccess) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may list trust policies"))
+		return
+	}
+
+	policies, err := queryTrustPolicies(r.Context(), sqlDB, m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policies) // nolint: errcheck
+}
+
+// CreateTrustPolicy serves POST /api/v1/modules/{id}/trust-policies, adding a
+// trust policy permitting the workflows of a repository, optionally restricted
+// to a workflow and a ref pattern, to publish the module. The repository must
+// be that of the module. Only module owners may add trust policies.
+func (s *Server) CreateTrustPolicy(w http.ResponseWriter, r *http.Request, m moduleAccess) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may add trust policies"))
+		return
+	}
+
+	var tp module.TrustPolicy
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&tp); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	existing, found, err := lockModule(ctx, q, m.User, "m.id = $1", m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !found {
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found"))
+		return
+	}
+
+	if err := tp.Validate(existing.Module); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	tp.ModuleID = m.ID
+	if err := q.QueryRowContext(ctx, `
+		INSERT INTO trust_policies (module_id, repository, workflow, ref)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+		RETURNING id, created_at`,
+		tp.ModuleID, tp.Repository, tp.Workflow, tp.Ref,
+	).Scan(&tp.ID, &tp.CreatedAt); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tp) // nolint: errcheck
+}
+
+// DeleteTrustPolicy serves DELETE
+// /api/v1/modules/{id}/trust-policies/{policy}, removing a trust policy of the
+// module. Credentials it permitted remain valid until they expire. Only module
+// owners may remove trust policies.
+func (s *Server) DeleteTrustPolicy(w http.ResponseWriter, r *http.Request, m moduleAccess, policy string) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may remove trust policies"))
+		return
+	}
+
+	id, err := strconv.Atoi(policy)
+	if err != nil {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "trust policy not found"))
+		return
+	}
+
+	res, err := db.Conn(r.Context(), s.primary).ExecContext(r.Context(), `
+		DELETE FROM trust_policies WHERE id = $1 AND module_id = $2`,
+		id, m.ID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "trust policy not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// queryTrustPolicies returns the trust policies of a module, oldest first.
+func queryTrustPolicies(ctx context.Context, q db.Querier, moduleID int) ([]module.TrustPolicy, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, module_id, repository, COALESCE(workflow, ''), COALESCE(ref, ''), created_at
+		FROM trust_policies
+		WHERE module_id = $1
+		ORDER BY id`,
+		moduleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []module.TrustPolicy{}
+	for rows.Next() {
+		var tp module.TrustPolicy
+		if err := rows.Scan(&tp.ID, &tp.ModuleID, &tp.Repository, &tp.Workflow, &tp.Ref, &tp.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, tp)
+	}
+
+	return policies, rows.Err()
+}
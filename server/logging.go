@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+type (
+	// RequestLog defines the structured log of a served request. It never
+	// carries request headers, bodies or queries, which may hold secrets.
+	RequestLog struct {
+		RequestID string
+		Method    string
+		Route     string
+		Status    int
+		Bytes     int64
+		Duration  time.Duration
+		UserID    int
+		User      string
+		Time      time.Time
+	}
+
+	// RequestLogger logs served requests, e.g. to a log aggregator. Log is
+	// called on the request's goroutine once the response was written and
+	// should not block.
+	RequestLogger interface {
+		Log(ctx context.Context, l RequestLog)
+	}
+)
+
+// WithRequestLogger makes the Server log served requests to l instead of
+// writing them to stderr as JSON lines.
+func WithRequestLogger(l RequestLogger) Option {
+	return func(s *Server) { s.logger = l }
+}
+
+// jsonLogger defines the default RequestLogger, writing each request as a
+// line of JSON.
+type jsonLogger struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func newJSONLogger() *jsonLogger {
+	return &jsonLogger{out: os.Stderr}
+}
+
+func (l *jsonLogger) Log(_ context.Context, r RequestLog) {
+	bz, err := json.Marshal(struct {
+		Time       time.Time `json:"time"`
+		RequestID  string    `json:"request_id"`
+		Method     string    `json:"method"`
+		Route      string    `json:"route"`
+		Status     int       `json:"status"`
+		Bytes      int64     `json:"bytes"`
+		DurationMS float64   `json:"duration_ms"`
+		UserID     int       `json:"user_id,omitempty"`
+		User       string    `json:"user,omitempty"`
+	}{
+		r.Time, r.RequestID, r.Method, r.Route, r.Status, r.Bytes,
+		float64(r.Duration) / float64(time.Millisecond), r.UserID, r.User,
+	})
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.out.Write(append(bz, '\n')) // nolint: errcheck
+}
+
+// requestInfoKey keys the requestInfo of a request in its context.
+type requestInfoKey struct{}
+
+// requestInfo collects the fields of a request's log known only to its
+// handler: the matched route and the authenticated requester.
+type requestInfo struct {
+	route string
+	user  *module.User
+}
+
+// setRoute records the route pattern matched by a request, replacing the mux
+// pattern in its log.
+func setRoute(r *http.Request, route string) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		info.route = route
+	}
+}
+
+// setRequester records the authenticated requester of a request in its log.
+func setRequester(r *http.Request, u *module.User) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		info.user = u
+	}
+}
+
+// logRequests returns middleware logging each request once served, along with
+// its ID in HeaderRequestID. Requests are logged under the pattern of the mux
+// handler serving them, refined by the handler with setRoute, so that routes
+// carry no identifiers.
+func logRequests(logger RequestLogger, mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			_, pattern := mux.Handler(r)
+			info := &requestInfo{route: pattern}
+			rw := &statusWriter{ResponseWriter: w}
+
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+
+			l := RequestLog{
+				RequestID: r.Header.Get(HeaderRequestID),
+				Method:    r.Method,
+				Route:     info.route,
+				Status:    rw.status,
+				Bytes:     rw.bytes,
+				Duration:  time.Since(start),
+				Time:      start.UTC(),
+			}
+
+			if info.user != nil {
+				l.UserID = info.user.ID
+				l.User = info.user.Name
+			}
+
+			logger.Log(r.Context(), l)
+		})
+	}
+}
+
+// statusWriter records the status and size of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+type recordingLogger struct {
+	logs []RequestLog
+}
+
+func (l *recordingLogger) Log(_ context.Context, r RequestLog) {
+	l.logs = append(l.logs, r)
+}
+
+func TestLogRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(modulesPathPrefix, func(w http.ResponseWriter, r *http.Request) {
+		setRoute(r, modulesPathPrefix+"{name}/versions")
+		setRequester(r, &module.User{ID: 7, Name: "bob"})
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+	})
+	mux.HandleFunc(csrfPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}")) // nolint: errcheck
+	})
+
+	logger := &recordingLogger{}
+	h := requestIDs(logRequests(logger, mux)(mux))
+
+	for _, path := range []string{"/api/v1/modules/liquidity/versions", csrfPath} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(HeaderRequestID, "req-1")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(logger.logs) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(logger.logs))
+	}
+
+	got := logger.logs[0]
+	if got.RequestID != "req-1" || got.Method != http.MethodGet || got.Route != "/api/v1/modules/{name}/versions" ||
+		got.Status != http.StatusNotFound || got.UserID != 7 || got.User != "bob" || got.Bytes == 0 {
+		t.Errorf("unexpected log of a module route: %+v", got)
+	}
+
+	got = logger.logs[1]
+	if got.Route != csrfPath || got.Status != http.StatusOK || got.Bytes != 2 || got.UserID != 0 {
+		t.Errorf("unexpected log of an anonymous request: %+v", got)
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	(&jsonLogger{out: &buf}).Log(context.Background(), RequestLog{
+		RequestID: "req-1",
+		Method:    http.MethodPut,
+		Route:     "/api/v1/modules/{name}/versions/{version}/yank",
+		Status:    http.StatusNoContent,
+		Duration:  1500 * time.Microsecond,
+		UserID:    7,
+		User:      "bob",
+	})
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("expected a line of JSON, got %q: %v", buf.String(), err)
+	}
+
+	if out["request_id"] != "req-1" || out["status"] != float64(http.StatusNoContent) || out["duration_ms"] != 1.5 || out["user"] != "bob" {
+		t.Errorf("unexpected log %v", out)
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/storage"
+)
+
+var logoPathRegex = regexp.MustCompile(`^/logos/(.+)/([0-9a-f]{64})\.png$`)
+
+// Logo returns the handler of /logos/<module>/<checksum>.png, serving module
+// logos from storage. Logo keys are content addressed, so responses are
+// cacheable indefinitely.
+func Logo(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m := logoPathRegex.FindStringSubmatch(r.URL.Path)
+		if m == nil {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "logo not found"))
+			return
+		}
+
+		etag := `"` + m[2] + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		rc, err := store.Get(r.Context(), module.LogoKey(m[1], m[2]))
+		if err != nil {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "logo not found"))
+			return
+		}
+		defer rc.Close()
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("ETag", etag)
+		io.Copy(w, rc) // nolint: errcheck
+	}
+}
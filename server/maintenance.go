@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cosmos/atlas/config"
+)
+
+// Maintenance defines middleware switching the server between its operating
+// modes (see config.ModeNormal) at runtime, rejecting disallowed requests with
+// 503 Service Unavailable. Paths with an exempt prefix (e.g. health checks
+// and the admin API used to switch modes back) are always served.
+type Maintenance struct {
+	mode   atomic.Value
+	exempt []string
+}
+
+// NewMaintenance returns Maintenance middleware starting in the given mode.
+func NewMaintenance(mode string, exempt ...string) (*Maintenance, error) {
+	m := &Maintenance{exempt: exempt}
+	if err := m.Set(mode); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Mode returns the current operating mode.
+func (m *Maintenance) Mode() string {
+	return m.mode.Load().(string)
+}
+
+// Set switches the operating mode.
+func (m *Maintenance) Set(mode string) error {
+	switch mode {
+	case config.ModeNormal, config.ModeReadOnly, config.ModeMaintenance:
+		m.mode.Store(mode)
+		return nil
+
+	default:
+		return fmt.Errorf("invalid mode %q, must be one of: %s, %s, %s", mode, config.ModeNormal, config.ModeReadOnly, config.ModeMaintenance)
+	}
+}
+
+// Handler wraps next, rejecting requests not permitted in the current mode.
+func (m *Maintenance) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.allowed(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", "300")
+		WriteError(w, NewError(http.StatusServiceUnavailable, CodeUnavailable, m.message()))
+	})
+}
+
+func (m *Maintenance) allowed(r *http.Request) bool {
+	for _, prefix := range m.exempt {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+
+	switch m.Mode() {
+	case config.ModeReadOnly:
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return true
+		}
+
+		return false
+
+	case config.ModeMaintenance:
+		return false
+
+	default:
+		return true
+	}
+}
+
+func (m *Maintenance) message() string {
+	if m.Mode() == config.ModeReadOnly {
+		return "the registry is in read-only mode for maintenance; please retry later"
+	}
+
+	return "the registry is down for maintenance; please retry later"
+}
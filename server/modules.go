@@ -0,0 +1,100 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// ModuleDetail serves GET /api/v1/modules/{id}, returning the module along
+// with its bug tracker, its deprecation, its open security advisories and,
+// once polled, the issue statistics of its repository as a maintenance signal. Requests conditional
+// on the ETag or Last-Modified validators of an unchanged module are answered
+// with 304 Not Modified. The caller must have resolved the module and checked
+// that it is readable by the requester.
+func ModuleDetail(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int) {
+	var (
+		m              module.Module
+		manifest       module.Manifest
+		bugURL         sql.NullString
+		bugContact     sql.NullString
+		linksCheckedAt sql.NullTime
+		openIssues     sql.NullInt64
+		issuesActiveAt sql.NullTime
+		issuesChecked  sql.NullTime
+		deprecatedAt   sql.NullTime
+		deprecationMsg string
+		replacedBy     sql.NullString
+		updatedAt      time.Time
+	)
+
+	if err := sqlDB.QueryRowContext(r.Context(), `
+		SELECT m.name, COALESCE(m.description, ''), m.version, m.homepage, m.repo,
+			COALESCE(m.license, ''), m.visibility, m.disputed, m.hidden, m.archived,
+			COALESCE(m.link_status, ''), m.links_checked_at, m.quality_score,
+			m.rating_average, m.rating_count, COALESCE(verified_publisher(m.author), false),
+			COALESCE(m.origin, ''), m.lock_version, b.url, b.contact,
+			m.open_issues, m.issues_active_at, m.issues_checked_at,
+			m.deprecated_at, COALESCE(m.deprecation_message, ''), r.name, m.updated_at
+		FROM modules m
+		LEFT JOIN bugs b ON b.id = m.bug_id
+		LEFT JOIN modules r ON r.id = m.replaced_by AND r.deleted_at IS NULL
+		WHERE m.id = $1`,
+		moduleID,
+	).Scan(
+		&m.Name, &m.Description, &m.Version, &m.Homepage, &m.Repo,
+		&m.License, &m.Visibility, &m.Disputed, &m.Hidden, &m.Archived,
+		&m.LinkStatus, &linksCheckedAt, &m.QualityScore,
+		&m.Rating.Average, &m.Rating.Count, &m.VerifiedPublisher,
+		&m.Origin, &m.LockVersion, &bugURL, &bugContact,
+		&openIssues, &issuesActiveAt, &issuesChecked,
+		&deprecatedAt, &deprecationMsg, &replacedBy, &updatedAt,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	m.LinksCheckedAt = linksCheckedAt.Time
+
+	// modules registered without a bug tracker get one derived from their
+	// repository, if hosted on a supported provider
+	manifest.Repo = m.Repo
+	if bugURL.Valid {
+		manifest.Bugs = &module.Bug{URL: bugURL.String, Contact: bugContact.String}
+	}
+	m.Bugs = manifest.BugTracker()
+
+	if issuesChecked.Valid && openIssues.Valid {
+		m.Issues = &module.IssueStats{
+			OpenIssues:     int(openIssues.Int64),
+			LastActivityAt: issuesActiveAt.Time,
+			CheckedAt:      issuesChecked.Time,
+		}
+	}
+
+	m.Deprecation = scanDeprecation(deprecatedAt, deprecationMsg, replacedBy)
+
+	advisories, err := queryAdvisories(r.Context(), sqlDB, moduleID, true)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	m.Advisories = advisories
+
+	bz, err := json.Marshal(m)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if notModified(w, r, weakETag(bz), updatedAt) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(bz, '\n')) // nolint: errcheck
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+// PatchModule serves PATCH /api/v1/modules/{id}, applying a sparse update of
+// the module's description, homepage and keywords as a module.ModulePatch.
+// Versions are left untouched. Only module owners may patch a module, unless
+// it is archived or mirrored. A patch carrying the lock version the client
+// read is rejected with MODULE_CONFLICT if the module was updated since. The
+// changed fields are logged for auditing.
+func (s *Server) PatchModule(w http.ResponseWriter, r *http.Request, m moduleAccess) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may edit a module"))
+		return
+	}
+
+	var patch module.ModulePatch
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&patch); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	existing, found, err := lockModule(ctx, q, m.User, "m.id = $1", m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !found {
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found"))
+		return
+	}
+
+	if existing.Mirrored() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "mirrored modules are read-only"))
+		return
+	}
+
+	if err := existing.CheckWritable(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if patch.LockVersion != nil {
+		if err := existing.CheckLock(*patch.LockVersion); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	patched := existing.Module
+	changed, err := patch.Apply(&patched)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if len(changed) == 0 {
+		writePublished(w, http.StatusOK, patched)
+		return
+	}
+
+	if err := q.QueryRowContext(ctx, `
+		UPDATE modules
+		SET description = $2, homepage = $3, lock_version = lock_version + 1
+		WHERE id = $1
+		RETURNING lock_version`,
+		patched.ID, patched.Description, patched.Homepage,
+	).Scan(&patched.LockVersion); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if patch.Keywords != nil {
+		if err := writeKeywords(ctx, q, patched.ID, *patch.Keywords); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	log.Printf("user %s patched module %s: %s", m.User.Name, patched.Name, strings.Join(changed, ", "))
+	writePublished(w, http.StatusOK, patched)
+}
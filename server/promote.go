@@ -0,0 +1,138 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/jobs"
+	"github.com/cosmos/atlas/module"
+)
+
+// PromoteVersion serves POST
+// /api/v1/modules/{id}/versions/{version}/promote, promoting a pre-release to
+// the stable version of the same major, minor and patch, e.g. 1.2.0 from
+// 1.2.0-rc.1. The stable version shares the pre-release's artifact and
+// manifest, but is not signed, and its tag is verified anew by a KindVerifyTag
+// job. Only module owners may promote a version.
+func (s *Server) PromoteVersion(w http.ResponseWriter, r *http.Request, m moduleAccess, version string) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may promote a version"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	existing, found, err := lockModule(ctx, q, m.User, "m.id = $1", m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !found {
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found"))
+		return
+	}
+
+	if err := existing.CheckWritable(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT version, COALESCE(checksum, ''), COALESCE(artifact_key, ''), artifact_size, yanked, status,
+			manifest, COALESCE(readme, ''), COALESCE(rendered_readme, ''), COALESCE(sdk_compat, ''),
+			COALESCE(changelog, '')
+		FROM module_versions
+		WHERE module_id = $1`,
+		m.ID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	var (
+		versions []module.ModuleVersion
+		pre      = -1
+	)
+
+	for rows.Next() {
+		var mv module.ModuleVersion
+		if err := rows.Scan(
+			&mv.Version, &mv.Checksum, &mv.ArtifactKey, &mv.ArtifactSize, &mv.Yanked, &mv.Status,
+			&mv.Manifest, &mv.Readme, &mv.RenderedReadme, &mv.SDKCompat, &mv.Changelog,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if mv.Version == version {
+			pre = len(versions)
+		}
+
+		versions = append(versions, mv)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if pre < 0 {
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+	}
+
+	promoted, err := versions[pre].Promote(versions)
+	if err != nil {
+		WriteError(w, NewError(http.StatusConflict, CodeVersionConflict, err.Error()))
+		return
+	}
+
+	promoted.PublishedBy = m.User.ID
+
+	if err := q.QueryRowContext(ctx, `
+		INSERT INTO module_versions (module_id, version, checksum, artifact_key, artifact_size, downloads, yanked,
+			manifest, readme, rendered_readme, sdk_compat, changelog, license, published_by)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, 0, FALSE, $6, NULLIF($7, ''), NULLIF($8, ''),
+			NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), $12)
+		RETURNING id, status, created_at`,
+		m.ID, promoted.Version, promoted.Checksum, promoted.ArtifactKey, promoted.ArtifactSize,
+		promoted.Manifest, promoted.Readme, promoted.RenderedReadme, promoted.SDKCompat, promoted.Changelog,
+		promoted.Manifest.License, promoted.PublishedBy,
+	).Scan(&promoted.ID, &promoted.Status, &promoted.CreatedAt); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	payload := jobs.VerifyTagPayload{ModuleVersionID: promoted.ID, Promoted: true}
+	if _, err := s.queue.Enqueue(ctx, jobs.KindVerifyTag, payload, time.Now()); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if newer(promoted.Version, existing.Version) {
+		if _, err := q.ExecContext(ctx, `
+			UPDATE modules
+			SET version = $2, lock_version = lock_version + 1
+			WHERE id = $1`,
+			m.ID, promoted.Version,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(promoted) // nolint: errcheck
+}
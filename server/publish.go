@@ -0,0 +1,620 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lib/pq"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/jobs"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+	"github.com/cosmos/atlas/totp"
+)
+
+const (
+	modulesPath = "/api/v1/modules"
+
+	// HeaderOTP carries the TOTP or recovery code proving the second factor
+	// of a request performing a sensitive operation, e.g. publishing.
+	HeaderOTP = "X-Atlas-OTP"
+)
+
+// maxManifestBytes bounds the size of a published manifest, regardless of
+// the configured policy limit.
+const maxManifestBytes = 1 << 20
+
+// serveModules serves /api/v1/modules: GET lists the modules readable by the
+// requester, as ModuleList, and POST publishes the module version described by
+// the manifest in the request body. Other methods are not allowed.
+func (s *Server) serveModules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s.listModules(w, r, "")
+
+	case http.MethodPost:
+		s.Publish(w, r)
+
+	default:
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+	}
+}
+
+// Publish publishes the module version described by the manifest in the
+// request body, whose format is taken from the Content-Type or detected. The
+// manifest is validated and checked against the publish policy, quotas and
+// two-factor requirement of the requester before the module and version are
+// written, all within the request's transaction. Versions of existing modules
+// may only be published by their owners. Republishing an existing version
+// with identical contents is a no-op responding 200; replacing a yanked
+// version requires the force query parameter. Otherwise, it responds 201 with
+// the published module. With the stage query parameter, a new version of an
+// existing module is staged instead: only the version is written, visible to
+// the module's owners alone, and the module is left untouched until the
+// version is released. CI workflows may publish without an API token using a
+// credential minted by the keyless token exchange.
+func (s *Server) Publish(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// publish credentials minted for CI workflows publish as the author of
+	// their module, and only that module
+	u, keylessModule, err := s.keylessPublisher(r)
+	if err == nil && u == nil {
+		u, err = s.requester(r)
+	}
+
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "publishing requires an API token"))
+		return
+	}
+
+	if !u.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "banned users may not publish modules"))
+		return
+	}
+
+	bz, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxManifestBytes))
+	if err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	manifest, err := module.ParseManifest(bz, module.FormatFromContentType(r.Header.Get("Content-Type")))
+	if err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("invalid manifest: %v", err)))
+		return
+	}
+
+	if err := manifest.Validate(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if keylessModule != "" && manifest.Name != keylessModule {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "the publish credential only publishes versions of "+keylessModule))
+		return
+	}
+
+	q := db.Conn(ctx, s.primary)
+
+	existing, found, err := lockPublishedModule(ctx, q, u, manifest.Name)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if found {
+		if !existing.owner {
+			WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may publish versions of "+manifest.Name))
+			return
+		}
+
+		if existing.Mirrored() {
+			WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "mirrored modules are read-only"))
+			return
+		}
+
+		if err := existing.CheckWritable(); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	if err := s.checkPolicy(ctx, *u, manifest); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := s.checkDependencies(ctx, u, manifest.Dependencies); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	usage, override, err := queryUsage(ctx, q, u.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := s.cfg.Policy.CheckQuota(manifest, len(bz), !found, usage, override); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	// the workflow's ID token stands in for the second factor of keyless
+	// publishes
+	if keylessModule == "" {
+		verified, err := s.verifyTwoFactor(ctx, u, r.Header.Get(HeaderOTP))
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if err := s.cfg.Policy.CheckTwoFactor(*u, policy.OpPublish, verified); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	next := module.ModuleVersion{
+		Version:     manifest.Version,
+		Manifest:    manifest,
+		SDKCompat:   manifest.SDKCompat,
+		PublishedBy: u.ID,
+	}
+	next.SetReadme(manifest.Readme)
+
+	stage := r.URL.Query().Get("stage") == "true"
+	if stage {
+		if !found {
+			WriteError(w, NewError(http.StatusConflict, CodeModuleConflict, "only versions of existing modules may be staged"))
+			return
+		}
+
+		next.Status = module.VersionStatusStaged
+	}
+
+	status := http.StatusCreated
+	if found {
+		prev, published, err := queryPublishedVersion(ctx, q, existing.ID, manifest.Version)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if published && (stage || prev.Staged()) {
+			WriteError(w, NewError(http.StatusConflict, CodeVersionConflict, fmt.Sprintf("version %s already exists; release or discard it", manifest.Version)))
+			return
+		}
+
+		if published {
+			force := r.URL.Query().Get("force") == "true"
+			if err := prev.Republish(next, force); err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			if !prev.Yanked || !force {
+				writePublished(w, http.StatusOK, existing.Module)
+				return
+			}
+
+			next.ID = prev.ID
+			status = http.StatusOK
+		}
+	}
+
+	if stage {
+		if _, err := writeVersion(ctx, q, existing.ID, next); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		writePublished(w, http.StatusAccepted, existing.Module)
+		return
+	}
+
+	m, err := writeModule(ctx, q, u, manifest, existing, found)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	versionID, err := writeVersion(ctx, q, m.ID, next)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	// verify the version and extract its documentation once the publish commits
+	if _, err := s.queue.Enqueue(ctx, jobs.KindVerifyTag, jobs.VerifyTagPayload{ModuleVersionID: versionID}, time.Now()); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := s.queue.Enqueue(ctx, jobs.KindExtractDocs, jobs.DocsPayload{ModuleVersionID: versionID}, time.Now()); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	writePublished(w, status, m)
+}
+
+// publishedModule defines an existing module locked for a publish, along with
+// whether the publisher owns it.
+type publishedModule struct {
+	module.Module
+	owner bool
+}
+
+// lockPublishedModule returns the module of the given name, locking it for the
+// rest of the transaction so that concurrent publishes are serialized. It
+// returns false if no such module exists, including soft-deleted ones, whose
+// names are held by checkPolicy instead.
+func lockPublishedModule(ctx context.Context, q db.Querier, u *module.User, name string) (publishedModule, bool, error) {
+	return lockModule(ctx, q, u, "m.slug = $1", module.Slug(name))
+}
+
+// lockModule locks the module matching the condition on its first query
+// argument for update, reporting whether the user owns it and whether it was
+// found. Deleted modules are not found.
+func lockModule(ctx context.Context, q db.Querier, u *module.User, cond string, arg interface{}) (publishedModule, bool, error) {
+	var m publishedModule
+
+	err := q.QueryRowContext(ctx, `
+		SELECT m.id, m.name, COALESCE(m.description, ''), m.version, m.homepage, m.repo,
+			COALESCE(m.license, ''), m.visibility, COALESCE(m.author, 0), m.archived,
+			COALESCE(m.origin, ''), m.lock_version,
+			m.author = $2 OR EXISTS (SELECT 1 FROM modules_users mu WHERE mu.module_id = m.id AND mu.user_id = $2)
+		FROM modules m
+		WHERE `+cond+`
+			AND m.deleted_at IS NULL
+		FOR UPDATE`,
+		arg, u.ID,
+	).Scan(
+		&m.ID, &m.Name, &m.Description, &m.Version, &m.Homepage, &m.Repo,
+		&m.License, &m.Visibility, &m.Author, &m.Archived,
+		&m.Origin, &m.LockVersion, &m.owner,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return publishedModule{}, false, nil
+	}
+
+	if err != nil {
+		return publishedModule{}, false, err
+	}
+
+	return m, true, nil
+}
+
+// queryUsage returns the publishing activity of a user counted against its
+// quotas, along with its QuotaOverride, if any.
+func queryUsage(ctx context.Context, q db.Querier, userID int) (policy.Usage, *policy.QuotaOverride, error) {
+	var usage policy.Usage
+
+	if err := q.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM modules WHERE author = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM module_versions WHERE published_by = $1 AND created_at > NOW() - INTERVAL '24 hours')`,
+		userID,
+	).Scan(&usage.Modules, &usage.VersionsToday); err != nil {
+		return policy.Usage{}, nil, err
+	}
+
+	var (
+		override                       = policy.QuotaOverride{UserID: userID}
+		modulesPerUser, versionsPerDay sql.NullInt64
+	)
+
+	err := q.QueryRowContext(ctx, `
+		SELECT modules_per_user, versions_per_day
+		FROM quota_overrides
+		WHERE user_id = $1`,
+		userID,
+	).Scan(&modulesPerUser, &versionsPerDay)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return usage, nil, nil
+
+	case err != nil:
+		return policy.Usage{}, nil, err
+	}
+
+	if modulesPerUser.Valid {
+		n := int(modulesPerUser.Int64)
+		override.ModulesPerUser = &n
+	}
+
+	if versionsPerDay.Valid {
+		n := int(versionsPerDay.Int64)
+		override.VersionsPerDay = &n
+	}
+
+	return usage, &override, nil
+}
+
+// verifyTwoFactor loads the two-factor enrollment of the User and reports
+// whether code is a valid TOTP or unused recovery code of it. A matched
+// recovery code is consumed, unless the request's transaction is rolled back,
+// and TOTP codes are rejected once a code of the same or a later time step was
+// accepted, so that an intercepted code cannot be replayed.
+func (s *Server) verifyTwoFactor(ctx context.Context, u *module.User, code string) (bool, error) {
+	q := db.Conn(ctx, s.primary)
+
+	var (
+		secret   sql.NullString
+		lastStep int64
+	)
+
+	if err := q.QueryRowContext(ctx, `
+		SELECT totp_secret, totp_enabled, totp_last_step
+		FROM users
+		WHERE id = $1`,
+		u.ID,
+	).Scan(&secret, &u.TOTPEnabled, &lastStep); err != nil {
+		return false, err
+	}
+
+	u.TOTPSecret = secret.String
+	if !u.TOTPEnabled || code == "" {
+		return false, nil
+	}
+
+	if step, ok := totp.ValidateAfter(u.TOTPSecret, code, time.Now(), lastStep); ok {
+		return acceptTOTPStep(ctx, q, u.ID, step)
+	}
+
+	res, err := q.ExecContext(ctx, `
+		UPDATE recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1
+			AND hash = $2
+			AND used_at IS NULL`,
+		u.ID, totp.HashRecoveryCode(code),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// acceptTOTPStep records the time step of an accepted TOTP code of the user,
+// returning false if a concurrent request accepted a code of the same or a
+// later step first.
+func acceptTOTPStep(ctx context.Context, q db.Querier, userID int, step int64) (bool, error) {
+	res, err := q.ExecContext(ctx, `
+		UPDATE users
+		SET totp_last_step = $2
+		WHERE id = $1
+			AND totp_last_step < $2`,
+		userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// queryPublishedVersion returns the given version of a module, and false if it
+// was never published.
+func queryPublishedVersion(ctx context.Context, q db.Querier, moduleID int, version string) (module.ModuleVersion, bool, error) {
+	var mv module.ModuleVersion
+
+	err := q.QueryRowContext(ctx, `
+		SELECT id, version, COALESCE(checksum, ''), COALESCE(signature, ''), COALESCE(changelog, ''),
+			COALESCE(readme, ''), COALESCE(sdk_compat, ''), manifest, yanked, status
+		FROM module_versions
+		WHERE module_id = $1
+			AND version = $2`,
+		moduleID, version,
+	).Scan(
+		&mv.ID, &mv.Version, &mv.Checksum, &mv.Signature.Value, &mv.Changelog,
+		&mv.Readme, &mv.SDKCompat, &mv.Manifest, &mv.Yanked, &mv.Status,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return module.ModuleVersion{}, false, nil
+	}
+
+	if err != nil {
+		return module.ModuleVersion{}, false, err
+	}
+
+	return mv, true, nil
+}
+
+// writeModule registers the manifest's module, or updates the existing one
+// with the manifest's metadata, replacing its keywords, bug tracker and
+// dependencies. The module's version is only advanced by greater versions.
+func writeModule(ctx context.Context, q db.Querier, u *module.User, manifest module.Manifest, existing publishedModule, found bool) (module.Module, error) {
+	m := manifest.Module()
+	m.Slug = module.Slug(m.Name)
+	m.Author = u.ID
+
+	var bugID interface{}
+	if manifest.Bugs != nil {
+		var id int
+		if err := q.QueryRowContext(ctx, `
+			INSERT INTO bugs (url, contact)
+			VALUES ($1, $2)
+			RETURNING id`,
+			manifest.Bugs.URL, manifest.Bugs.Contact,
+		).Scan(&id); err != nil {
+			return module.Module{}, err
+		}
+
+		bugID = id
+	}
+
+	if !found {
+		if err := q.QueryRowContext(ctx, `
+			INSERT INTO modules (name, slug, description, version, homepage, repo, license, visibility, author, bug_id)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10)
+			RETURNING id, lock_version`,
+			m.Name, m.Slug, m.Description, m.Version, m.Homepage, m.Repo, m.License, m.Visibility, u.ID, bugID,
+		).Scan(&m.ID, &m.LockVersion); err != nil {
+			return module.Module{}, err
+		}
+	} else {
+		m.ID = existing.ID
+		m.Author = existing.Author
+		if !newer(m.Version, existing.Version) {
+			m.Version = existing.Version
+		}
+
+		if err := q.QueryRowContext(ctx, `
+			UPDATE modules
+			SET description = $2, version = $3, homepage = $4, repo = $5, license = NULLIF($6, ''),
+				visibility = $7, bug_id = $8, lock_version = lock_version + 1
+			WHERE id = $1
+			RETURNING lock_version`,
+			m.ID, m.Description, m.Version, m.Homepage, m.Repo, m.License, m.Visibility, bugID,
+		).Scan(&m.LockVersion); err != nil {
+			return module.Module{}, err
+		}
+	}
+
+	if err := writeKeywords(ctx, q, m.ID, manifest.Keywords); err != nil {
+		return module.Module{}, err
+	}
+
+	if _, err := q.ExecContext(ctx, `DELETE FROM module_dependencies WHERE module_id = $1`, m.ID); err != nil {
+		return module.Module{}, err
+	}
+
+	for _, dep := range manifest.Dependencies {
+		if _, err := q.ExecContext(ctx, `
+			INSERT INTO module_dependencies (module_id, dependency_id, version_constraint)
+			SELECT $1, id, $3
+			FROM modules
+			WHERE slug = $2
+				AND deleted_at IS NULL`,
+			m.ID, module.Slug(dep.Name), dep.VersionConstraint,
+		); err != nil {
+			return module.Module{}, err
+		}
+	}
+
+	return m, nil
+}
+
+// writeKeywords replaces the keywords of the module with the given ID. Keywords
+// are normalized and resolved through their aliases to the canonical keyword.
+func writeKeywords(ctx context.Context, q db.Querier, moduleID int, keywords []string) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM modules_keywords WHERE module_id = $1`, moduleID); err != nil {
+		return err
+	}
+
+	normalized := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		normalized[i] = module.NormalizeKeyword(keyword)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT ka.alias, k.name
+		FROM keyword_aliases ka
+		JOIN keywords k ON k.id = ka.keyword_id
+		WHERE ka.alias = ANY($1)`,
+		pq.Array(normalized),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	aliases := make(map[string]string)
+	for rows.Next() {
+		var alias, canonical string
+		if err := rows.Scan(&alias, &canonical); err != nil {
+			return err
+		}
+
+		aliases[alias] = canonical
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, keyword := range keywords {
+		if _, err := q.ExecContext(ctx, `
+			WITH k AS (
+				INSERT INTO keywords (name)
+				VALUES ($2)
+				ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+				RETURNING id
+			)
+			INSERT INTO modules_keywords (module_id, keyword_id)
+			SELECT $1, id FROM k
+			ON CONFLICT DO NOTHING`,
+			moduleID, module.CanonicalKeyword(keyword, aliases),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeVersion stores a published or staged version of a module, replacing the
+// yanked version of the same ID, if set, and returns its ID.
+func writeVersion(ctx context.Context, q db.Querier, moduleID int, mv module.ModuleVersion) (int, error) {
+	if mv.ID != 0 {
+		_, err := q.ExecContext(ctx, `
+			UPDATE module_versions
+			SET manifest = $2, readme = NULLIF($3, ''), rendered_readme = NULLIF($4, ''), sdk_compat = NULLIF($5, ''),
+				license = NULLIF($6, ''), published_by = $7, yanked = FALSE, verified = FALSE, created_at = NOW()
+			WHERE id = $1`,
+			mv.ID, mv.Manifest, mv.Readme, mv.RenderedReadme, mv.SDKCompat, mv.Manifest.License, mv.PublishedBy,
+		)
+
+		return mv.ID, err
+	}
+
+	var id int
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO module_versions (module_id, version, checksum, artifact_size, downloads, yanked,
+			manifest, readme, rendered_readme, sdk_compat, license, published_by, status)
+		VALUES ($1, $2, $3, 0, 0, FALSE, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9,
+			COALESCE(NULLIF($10, ''), 'published'))
+		RETURNING id`,
+		moduleID, mv.Version, mv.Checksum, mv.Manifest, mv.Readme, mv.RenderedReadme, mv.SDKCompat, mv.Manifest.License, mv.PublishedBy,
+		mv.Status,
+	).Scan(&id)
+
+	return id, err
+}
+
+// newer returns true if version is a greater semantic version than current,
+// or current is not a valid semantic version.
+func newer(version, current string) bool {
+	cur, err := semver.NewVersion(current)
+	if err != nil {
+		return true
+	}
+
+	v, err := semver.NewVersion(version)
+	return err == nil && v.GreaterThan(cur)
+}
+
+func writePublished(w http.ResponseWriter, status int, m module.Module) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(m) // nolint: errcheck
+}
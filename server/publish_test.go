@@ -0,0 +1,341 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cosmos/atlas/client"
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+	"github.com/cosmos/atlas/seed"
+	"github.com/cosmos/atlas/server"
+	"github.com/cosmos/atlas/testutil"
+	"github.com/cosmos/atlas/totp"
+)
+
+// violations returns the field codes of a VALIDATION_FAILED error, failing
+// the test on any other error.
+func violations(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != server.CodeValidationFailed {
+		t.Fatalf("expected %s, got %v", server.CodeValidationFailed, err)
+	}
+
+	codes := make(map[string]string, len(apiErr.Details))
+	for _, d := range apiErr.Details {
+		codes[d.Field] = d.Code
+	}
+
+	return codes
+}
+
+func dexManifest() module.Manifest {
+	return module.Manifest{
+		Name:         "dex",
+		Description:  "Order book exchange built on liquidity pools.",
+		Version:      "0.1.0",
+		Repo:         "https://github.com/example/dex",
+		License:      "Apache-2.0",
+		Keywords:     []string{"exchange"},
+		Dependencies: []module.ModuleDependency{{Name: "liquidity", VersionConstraint: "^1.1"}},
+	}
+}
+
+func TestPublish(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	if _, err := h.Client().PublishManifest(ctx, dexManifest()); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s for anonymous publishes, got %v", server.CodeUnauthorized, err)
+	}
+
+	if _, err := bob.Publish(ctx, []byte("name = "), module.FormatTOML); !client.HasCode(err, server.CodeBadRequest) {
+		t.Errorf("expected %s for an unparsable manifest, got %v", server.CodeBadRequest, err)
+	}
+
+	invalid := dexManifest()
+	invalid.Repo = "not a url"
+	if _, err := bob.PublishManifest(ctx, invalid); violations(t, err)["repo"] == "" {
+		t.Errorf("expected a violation on repo, got %v", err)
+	}
+
+	reserved := dexManifest()
+	reserved.Name = "x/bank"
+	if codes := violations(t, func() error { _, err := bob.PublishManifest(ctx, reserved); return err }()); codes["name"] != policy.ErrCodeNameReserved {
+		t.Errorf("expected %s, got %+v", policy.ErrCodeNameReserved, codes)
+	}
+
+	short := dexManifest()
+	short.Description = "DEX"
+	if codes := violations(t, func() error { _, err := bob.PublishManifest(ctx, short); return err }()); codes["description"] != policy.ErrCodeDescriptionTooShort {
+		t.Errorf("expected %s, got %+v", policy.ErrCodeDescriptionTooShort, codes)
+	}
+
+	m, err := bob.PublishManifest(ctx, dexManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Name != "dex" || m.Version != "0.1.0" {
+		t.Errorf("expected dex at 0.1.0, got %s at %s", m.Name, m.Version)
+	}
+
+	if _, err := bob.GetModule(ctx, "dex"); err != nil {
+		t.Errorf("expected the module to be published: %v", err)
+	}
+
+	// republishing identical contents is a no-op
+	if _, err := bob.PublishManifest(ctx, dexManifest()); err != nil {
+		t.Errorf("expected an identical republish to succeed: %v", err)
+	}
+
+	changed := dexManifest()
+	changed.Readme = "# DEX"
+	if _, err := bob.PublishManifest(ctx, changed); !client.HasCode(err, server.CodeVersionConflict) {
+		t.Errorf("expected %s republishing different contents, got %v", server.CodeVersionConflict, err)
+	}
+
+	changed.Version = "0.2.0"
+	if m, err := bob.PublishManifest(ctx, changed); err != nil || m.Version != "0.2.0" {
+		t.Fatalf("expected dex to advance to 0.2.0, got %s (%v)", m.Version, err)
+	}
+
+	versions, err := bob.ListVersions(ctx, "dex")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 2 {
+		t.Errorf("expected 2 versions, got %d", len(versions))
+	}
+
+	oracle := dexManifest()
+	oracle.Name = "oracle"
+	oracle.Version = "9.0.0"
+	if _, err := h.Client(client.WithToken(token(t, f, "carol"))).PublishManifest(ctx, oracle); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s publishing a module owned by another user, got %v", server.CodeForbidden, err)
+	}
+
+	if err := bob.Archive(ctx, "dex"); err != nil {
+		t.Fatal(err)
+	}
+
+	changed.Version = "0.3.0"
+	if _, err := bob.PublishManifest(ctx, changed); !client.HasCode(err, server.CodeModuleArchived) {
+		t.Errorf("expected %s publishing to an archived module, got %v", server.CodeModuleArchived, err)
+	}
+}
+
+func TestPublishQuota(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+	bob := h.Client(client.WithToken(token(t, f, "bob")))
+
+	admin := h.Client(client.WithToken(token(t, f, "alice")))
+
+	one, negative := 1, -1
+	if _, err := bob.SetQuota(ctx, "bob", policy.QuotaOverride{ModulesPerUser: &one}); !client.HasCode(err, server.CodeForbidden) {
+		t.Errorf("expected %s overriding quotas as a non-administrator, got %v", server.CodeForbidden, err)
+	}
+
+	if _, err := admin.SetQuota(ctx, "bob", policy.QuotaOverride{VersionsPerDay: &negative}); !client.HasCode(err, server.CodeValidationFailed) {
+		t.Errorf("expected %s for a negative limit, got %v", server.CodeValidationFailed, err)
+	}
+
+	// bob already authored oracle
+	if _, err := admin.SetQuota(ctx, "bob", policy.QuotaOverride{ModulesPerUser: &one}); err != nil {
+		t.Fatal(err)
+	}
+
+	if override, err := admin.Quota(ctx, "bob"); err != nil || override.ModulesPerUser == nil || *override.ModulesPerUser != 1 || override.VersionsPerDay != nil {
+		t.Errorf("expected a module quota of 1, got %+v (%v)", override, err)
+	}
+
+	if codes := violations(t, func() error { _, err := bob.PublishManifest(ctx, dexManifest()); return err }()); codes["name"] != policy.ErrCodeModuleQuota {
+		t.Errorf("expected %s, got %+v", policy.ErrCodeModuleQuota, codes)
+	}
+
+	if _, err := bob.GetModule(ctx, "dex"); !client.HasCode(err, server.CodeModuleNotFound) {
+		t.Errorf("expected a rejected publish to write nothing, got %v", err)
+	}
+
+	// versions of existing modules do not count against the module quota
+	oracle := dexManifest()
+	oracle.Name = "oracle"
+	oracle.Version = "0.4.0"
+	if _, err := bob.PublishManifest(ctx, oracle); err != nil {
+		t.Errorf("expected a new version of an existing module to be published: %v", err)
+	}
+
+	// clearing the override restores the configured limits
+	if err := admin.ClearQuota(ctx, "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := admin.Quota(ctx, "bob"); !client.HasCode(err, server.CodeNotFound) {
+		t.Errorf("expected %s for a cleared override, got %v", server.CodeNotFound, err)
+	}
+
+	if _, err := bob.PublishManifest(ctx, dexManifest()); err != nil {
+		t.Errorf("expected the configured limits to apply again: %v", err)
+	}
+}
+
+func TestPublishTwoFactor(t *testing.T) {
+	cfg := config.Default()
+	cfg.Policy.RequireTwoFactor = true
+
+	h := testutil.NewHarness(t, cfg)
+	f, err := seed.Default(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Seed(f)
+
+	ctx := context.Background()
+	bob := token(t, f, "bob")
+
+	if codes := violations(t, func() error {
+		_, err := h.Client(client.WithToken(bob)).PublishManifest(ctx, dexManifest())
+		return err
+	}()); codes["otp"] != policy.ErrCodeTwoFactorRequired {
+		t.Fatalf("expected %s without enrollment, got %+v", policy.ErrCodeTwoFactorRequired, codes)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.DB.ExecContext(ctx, `
+		UPDATE users SET totp_secret = $1, totp_enabled = TRUE WHERE name = 'bob'`,
+		secret,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Client(client.WithToken(bob), client.WithOTP("000000")).PublishManifest(ctx, dexManifest()); violations(t, err)["otp"] != policy.ErrCodeTwoFactorRequired {
+		t.Errorf("expected an invalid code to be rejected, got %v", err)
+	}
+
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Client(client.WithToken(bob), client.WithOTP(code)).PublishManifest(ctx, dexManifest()); err != nil {
+		t.Errorf("expected a valid code to publish: %v", err)
+	}
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	cfg := config.Default()
+	cfg.Policy.RequireTwoFactor = true
+
+	h := testutil.NewHarness(t, cfg)
+	f, err := seed.Default(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Seed(f)
+
+	ctx := context.Background()
+	bobToken := token(t, f, "bob")
+	bob := h.Client(client.WithToken(bobToken))
+
+	enrollment, err := bob.EnrollTwoFactor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bob.VerifyTwoFactor(ctx, "000000"); violations(t, err)["code"] == "" {
+		t.Errorf("expected an invalid code to be rejected, got %v", err)
+	}
+
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recovery, err := bob.VerifyTwoFactor(ctx, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recovery) != 10 {
+		t.Fatalf("expected 10 recovery codes, got %d", len(recovery))
+	}
+
+	if _, err := bob.EnrollTwoFactor(ctx); !client.HasCode(err, server.CodeTwoFactorConflict) {
+		t.Errorf("expected %s enrolling twice, got %v", server.CodeTwoFactorConflict, err)
+	}
+
+	if _, err := h.Client(client.WithToken(bobToken), client.WithOTP(recovery[0])).PublishManifest(ctx, dexManifest()); err != nil {
+		t.Fatalf("expected a recovery code to publish: %v", err)
+	}
+
+	// the code verifying the enrollment and the consumed recovery code are
+	// not accepted again
+	for _, otp := range []string{code, recovery[0]} {
+		if err := h.Client(client.WithToken(bobToken), client.WithOTP(otp)).Yank(ctx, "dex", "0.1.0"); violations(t, err)["otp"] != policy.ErrCodeTwoFactorRequired {
+			t.Errorf("expected a replayed code to be rejected yanking, got %v", err)
+		}
+	}
+
+	if err := bob.Yank(ctx, "dex", "0.1.0"); violations(t, err)["otp"] != policy.ErrCodeTwoFactorRequired {
+		t.Errorf("expected yanking to require a second factor, got %v", err)
+	}
+
+	if err := h.Client(client.WithToken(bobToken), client.WithOTP(recovery[1])).Yank(ctx, "dex", "0.1.0"); err != nil {
+		t.Errorf("expected a recovery code to yank: %v", err)
+	}
+
+	login := func(otp string) int {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, h.URL+"/api/v1/user/sessions", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+bobToken)
+		if otp != "" {
+			req.Header.Set(server.HeaderOTP, otp)
+		}
+
+		resp, err := h.Server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	if status := login(""); status != http.StatusUnauthorized {
+		t.Errorf("expected %d logging in without a second factor, got %d", http.StatusUnauthorized, status)
+	}
+
+	if status := login(recovery[2]); status != http.StatusCreated {
+		t.Errorf("expected %d logging in with a recovery code, got %d", http.StatusCreated, status)
+	}
+
+	if err := bob.DisableTwoFactor(ctx); !client.HasCode(err, server.CodeUnauthorized) {
+		t.Errorf("expected %s disabling without a second factor, got %v", server.CodeUnauthorized, err)
+	}
+
+	if err := h.Client(client.WithToken(bobToken), client.WithOTP(recovery[3])).DisableTwoFactor(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := login(""); status != http.StatusCreated {
+		t.Errorf("expected %d logging in once disabled, got %d", http.StatusCreated, status)
+	}
+}
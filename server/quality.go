@@ -0,0 +1,44 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/cosmos/atlas/quality"
+)
+
+// ModuleScore serves GET /api/v1/modules/{id}/score, returning the module's
+// quality score and per-rule breakdown as of its last computation. The caller
+// must have resolved the module and checked that it is readable by the
+// requester.
+func ModuleScore(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int) {
+	var (
+		score      quality.Score
+		rules      []byte
+		computedAt sql.NullTime
+	)
+
+	if err := sqlDB.QueryRowContext(r.Context(), `
+		SELECT quality_score, quality_rules, quality_computed_at
+		FROM modules
+		WHERE id = $1`,
+		moduleID,
+	).Scan(&score.Score, &rules, &computedAt); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	score.Rules = []quality.RuleScore{}
+	if len(rules) > 0 {
+		if err := json.Unmarshal(rules, &score.Rules); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	score.ComputedAt = computedAt.Time
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(score) // nolint: errcheck
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"runtime/debug"
+	"time"
+)
+
+// HeaderRequestID carries the ID of a request, echoed in its response and in
+// the error envelope so that failures reported by clients can be traced.
+const HeaderRequestID = "X-Request-Id"
+
+// validRequestID matches request IDs assigned by a trusted proxy, which are
+// kept instead of generating a new one.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// secretParams matches query parameters whose values are redacted from error
+// reports.
+var secretParams = regexp.MustCompile(`(?i)^(?:.*_)?(?:token|secret|password|signature|key|code|state)$`)
+
+type (
+	// ErrorReport defines a panic recovered while serving a request. It never
+	// carries request headers or bodies and its query is scrubbed of
+	// secrets, so that it may be sent to a third-party error tracker.
+	ErrorReport struct {
+		RequestID string
+		Method    string
+		Route     string
+		Query     string
+		Panic     string
+		Stack     []byte
+		Time      time.Time
+	}
+
+	// ErrorReporter reports recovered panics to an error tracker, e.g.
+	// Sentry. Report is called on the request's goroutine and should not
+	// block.
+	ErrorReporter interface {
+		Report(ctx context.Context, r ErrorReport)
+	}
+)
+
+// WithErrorReporter makes the Server report recovered panics to r instead of
+// logging them.
+func WithErrorReporter(r ErrorReporter) Option {
+	return func(s *Server) { s.reporter = r }
+}
+
+// logReporter defines the default ErrorReporter, logging panics along with
+// their stack trace.
+type logReporter struct{}
+
+func (logReporter) Report(_ context.Context, r ErrorReport) {
+	log.Printf("panic serving %s %s (request %s): %s\n%s", r.Method, r.Route, r.RequestID, r.Panic, r.Stack)
+}
+
+// requestIDs returns middleware assigning each request an ID in
+// HeaderRequestID, unless a valid one was set by a proxy, and echoing it in
+// the response.
+func requestIDs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID.MatchString(id) {
+			bz := make([]byte, 16)
+			if _, err := rand.Read(bz); err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			id = hex.EncodeToString(bz)
+			r.Header.Set(HeaderRequestID, id)
+		}
+
+		w.Header().Set(HeaderRequestID, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverPanics returns middleware converting panics into 500 Internal Server
+// Error responses, reported along with their stack trace to the reporter. A
+// panic after the response was started is only reported.
+func recoverPanics(reporter ErrorReporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &startedWriter{ResponseWriter: w}
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				reporter.Report(r.Context(), ErrorReport{
+					RequestID: r.Header.Get(HeaderRequestID),
+					Method:    r.Method,
+					Route:     r.URL.Path,
+					Query:     scrubQuery(r.URL.RawQuery),
+					Panic:     fmt.Sprint(v),
+					Stack:     debug.Stack(),
+					Time:      time.Now().UTC(),
+				})
+
+				if !rw.started {
+					WriteError(rw, NewError(http.StatusInternalServerError, CodeInternal, "internal server error"))
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// scrubQuery returns a raw query with the values of secret parameters
+// redacted.
+func scrubQuery(raw string) string {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return ""
+	}
+
+	for k := range values {
+		if secretParams.MatchString(k) {
+			values[k] = []string{"redacted"}
+		}
+	}
+
+	return values.Encode()
+}
+
+// startedWriter records whether the response was started.
+type startedWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *startedWriter) WriteHeader(status int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *startedWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *startedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.started = true
+		f.Flush()
+	}
+}
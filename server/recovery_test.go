@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingReporter struct {
+	reports []ErrorReport
+}
+
+func (r *recordingReporter) Report(_ context.Context, report ErrorReport) {
+	r.reports = append(r.reports, report)
+}
+
+func TestRecoverPanics(t *testing.T) {
+	reporter := &recordingReporter{}
+	h := requestIDs(recoverPanics(reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/modules/liquidity?q=dex&api_token=secret", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+
+	id := rec.Header().Get(HeaderRequestID)
+	if id == "" {
+		t.Fatal("expected a request ID")
+	}
+
+	var body struct {
+		Error Error `json:"error"`
+	}
+
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Error.Code != CodeInternal || body.Error.RequestID != id {
+		t.Errorf("expected %s with request ID %s, got %+v", CodeInternal, id, body.Error)
+	}
+
+	if len(reporter.reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reporter.reports))
+	}
+
+	report := reporter.reports[0]
+	if report.RequestID != id || report.Panic != "boom" || report.Route != "/api/v1/modules/liquidity" {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if strings.Contains(report.Query, "secret") || !strings.Contains(report.Query, "q=dex") {
+		t.Errorf("expected the token to be scrubbed from the query, got %s", report.Query)
+	}
+
+	if !strings.Contains(string(report.Stack), "TestRecoverPanics") {
+		t.Error("expected the stack trace of the panic")
+	}
+}
+
+func TestRequestIDs(t *testing.T) {
+	h := requestIDs(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+	}))
+
+	for _, tc := range []struct {
+		header string
+		kept   bool
+	}{
+		{header: "edge-1234", kept: true},
+		{header: "not a valid id", kept: false},
+		{header: "", kept: false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderRequestID, tc.header)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(HeaderRequestID)
+		if id == "" || (id == tc.header) != tc.kept {
+			t.Errorf("header %q: unexpected request ID %q", tc.header, id)
+		}
+
+		if !strings.Contains(rec.Body.String(), `"request_id":"`+id+`"`) {
+			t.Errorf("header %q: expected the request ID in the error envelope, got %s", tc.header, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+)
+
+// RelatedModule defines a module related to another module, as precomputed by
+// the recommendations job.
+type RelatedModule struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Score       float64 `json:"score"`
+}
+
+// RelatedModules serves GET /api/v1/modules/{id}/related, listing the modules
+// sharing keywords, authors or dependency edges with the module by descending
+// relatedness, as last precomputed by the recommendations job. Related modules
+// unreadable by the requester are omitted. The caller must have resolved the
+// module and checked that it is readable by the requester.
+func RelatedModules(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, m moduleAccess) {
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT rm.name, COALESCE(rm.description, ''), mr.score
+		FROM module_recommendations mr
+		JOIN modules rm ON rm.id = mr.related_id
+		WHERE mr.module_id = $1
+			AND module_readable(rm.id, $2)
+			AND NOT rm.hidden
+			AND rm.deleted_at IS NULL
+		ORDER BY mr.score DESC, rm.name`,
+		m.ID, requesterID(m.User),
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	related := []RelatedModule{}
+	for rows.Next() {
+		var rm RelatedModule
+		if err := rows.Scan(&rm.Name, &rm.Description, &rm.Score); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		related = append(related, rm)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(related) // nolint: errcheck
+}
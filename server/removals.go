@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+const removalRequestColumns = `id, kind, claimant, contact, reason, COALESCE(counter_notice, ''),
+	status, COALESCE(resolution, ''), created_at, updated_at`
+
+// RemovalRequests serves GET /api/v1/modules/{id}/removal-requests, listing
+// the removal requests filed against the module, newest first. Only module
+// owners and moderators may list them.
+func (s *Server) RemovalRequests(w http.ResponseWriter, r *http.Request, m moduleAccess) {
+	if !m.Owner && (m.User == nil || !m.User.CanModerate()) {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners and administrators may list removal requests"))
+		return
+	}
+
+	rows, err := s.reader().QueryContext(r.Context(), `
+		SELECT `+removalRequestColumns+`
+		FROM removal_requests
+		WHERE module_id = $1
+		ORDER BY created_at DESC, id DESC`,
+		m.ID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	requests := []module.RemovalRequest{}
+	for rows.Next() {
+		var rr module.RemovalRequest
+		if err := rows.Scan(
+			&rr.ID, &rr.Kind, &rr.Claimant, &rr.Contact, &rr.Reason, &rr.CounterNotice,
+			&rr.Status, &rr.Resolution, &rr.CreatedAt, &rr.UpdatedAt,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		requests = append(requests, rr)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(requests) // nolint: errcheck
+}
+
+// FileRemovalRequest serves POST /api/v1/modules/{id}/removal-requests, filing
+// a legal or DMCA takedown request against the module on behalf of the
+// claimant. The request is filed in the moderation queue and the module's
+// owners are notified.
+func (s *Server) FileRemovalRequest(w http.ResponseWriter, r *http.Request, m moduleAccess) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "banned users may not file removal requests"))
+		return
+	}
+
+	var rr module.RemovalRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&rr); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	if err := rr.Validate(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	rr.ModuleID = m.ID
+	rr.Status = module.RemovalStatusFiled
+	rr.CounterNotice, rr.Resolution = "", ""
+
+	if err := q.QueryRowContext(ctx, `
+		INSERT INTO removal_requests (module_id, kind, claimant, contact, reason, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`,
+		rr.ModuleID, rr.Kind, rr.Claimant, rr.Contact, rr.Reason, rr.Status,
+	).Scan(&rr.ID, &rr.CreatedAt, &rr.UpdatedAt); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO reports (module_id, reporter_id, reason, details, status, removal_request_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		m.ID, m.User.ID, module.ReportReasonRemoval, rr.Reason, module.ReportStatusOpen, rr.ID,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := notifyRemoval(ctx, q, rr, module.NotificationRemovalFiled); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rr) // nolint: errcheck
+}
+
+// removalAction defines a transition of a RemovalRequest, applying the request
+// body to it. It reports whether the transition is performed by a moderator,
+// rather than a module owner.
+type removalAction struct {
+	moderator bool
+	apply     func(rr *module.RemovalRequest, body removalActionRequest) error
+}
+
+// removalActionRequest defines the body of a RemovalRequest transition.
+type removalActionRequest struct {
+	CounterNotice string `json:"counter_notice"`
+	Resolution    string `json:"resolution"`
+}
+
+// removalActions defines the transitions of a RemovalRequest by path action.
+var removalActions = map[string]removalAction{
+	// a moderator acknowledges the request, placing the module in a disputed
+	// state
+	"dispute": {moderator: true, apply: func(rr *module.RemovalRequest, _ removalActionRequest) error {
+		return rr.Transition(module.RemovalStatusDisputed)
+	}},
+	// the module owner files a counter-notice against a disputed request
+	"counter-notice": {apply: func(rr *module.RemovalRequest, body removalActionRequest) error {
+		return rr.Counter(body.CounterNotice)
+	}},
+	// a moderator records the final resolution
+	"resolution": {moderator: true, apply: func(rr *module.RemovalRequest, body removalActionRequest) error {
+		return rr.Resolve(body.Resolution)
+	}},
+}
+
+// TransitionRemovalRequest serves POST
+// /api/v1/modules/{id}/removal-requests/{request}/{action}, where action is
+// one of dispute, counter-notice or resolution. Disputing a request blocks the
+// module's downloads while retaining its metadata. Resolving it with removed
+// hides the module, whereas reinstated lifts the dispute unless another request
+// is open; either resolves the request's moderation report and notifies the
+// module's owners.
+func (s *Server) TransitionRemovalRequest(w http.ResponseWriter, r *http.Request, m moduleAccess, request, action string) {
+	act, ok := removalActions[action]
+	if !ok {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+		return
+	}
+
+	requestID, err := strconv.Atoi(request)
+	if err != nil {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+		return
+	}
+
+	switch {
+	case m.User == nil:
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+
+	case act.moderator && !m.User.CanModerate():
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only administrators may "+action+" removal requests"))
+		return
+
+	case !act.moderator && (!m.Owner || !m.User.CanPublish()):
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may file counter-notices"))
+		return
+	}
+
+	var body removalActionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	var rr module.RemovalRequest
+	if err := q.QueryRowContext(ctx, `
+		SELECT `+removalRequestColumns+`
+		FROM removal_requests
+		WHERE id = $1
+			AND module_id = $2
+		FOR UPDATE`,
+		requestID, m.ID,
+	).Scan(
+		&rr.ID, &rr.Kind, &rr.Claimant, &rr.Contact, &rr.Reason, &rr.CounterNotice,
+		&rr.Status, &rr.Resolution, &rr.CreatedAt, &rr.UpdatedAt,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	rr.ModuleID = m.ID
+
+	if err := act.apply(&rr, body); err != nil {
+		WriteError(w, NewError(http.StatusConflict, CodeRemovalConflict, err.Error()))
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE removal_requests
+		SET status = $2, counter_notice = NULLIF($3, ''), resolution = NULLIF($4, ''), updated_at = $5
+		WHERE id = $1`,
+		rr.ID, rr.Status, rr.CounterNotice, rr.Resolution, rr.UpdatedAt,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := applyRemovalStatus(ctx, q, rr); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rr) // nolint: errcheck
+}
+
+// applyRemovalStatus applies the status of a RemovalRequest to its module.
+func applyRemovalStatus(ctx context.Context, q db.Querier, rr module.RemovalRequest) error {
+	switch {
+	case rr.Status == module.RemovalStatusDisputed:
+		_, err := q.ExecContext(ctx, `UPDATE modules SET disputed = TRUE WHERE id = $1`, rr.ModuleID)
+		return err
+
+	case rr.Open():
+		return nil
+	}
+
+	var err error
+	if rr.Resolution == module.RemovalResolutionRemoved {
+		_, err = q.ExecContext(ctx, `UPDATE modules SET hidden = TRUE WHERE id = $1`, rr.ModuleID)
+	} else {
+		_, err = q.ExecContext(ctx, `
+			UPDATE modules
+			SET disputed = EXISTS (
+				SELECT 1 FROM removal_requests
+				WHERE module_id = $1
+					AND status IN ($2, $3)
+			)
+			WHERE id = $1`,
+			rr.ModuleID, module.RemovalStatusDisputed, module.RemovalStatusCountered,
+		)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE reports
+		SET status = $2, updated_at = NOW()
+		WHERE removal_request_id = $1`,
+		rr.ID, module.ReportStatusResolved,
+	); err != nil {
+		return err
+	}
+
+	return notifyRemoval(ctx, q, rr, module.NotificationRemovalResolved)
+}
+
+// notifyRemoval notifies the owners of the module of a RemovalRequest.
+func notifyRemoval(ctx context.Context, q db.Querier, rr module.RemovalRequest, kind string) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, kind, module_id, removal_request_id)
+		SELECT m.author, $2, m.id, $3
+		FROM modules m
+		WHERE m.id = $1 AND m.author IS NOT NULL
+		UNION
+		SELECT mu.user_id, $2, mu.module_id, $3
+		FROM modules_users mu
+		WHERE mu.module_id = $1`,
+		rr.ModuleID, kind, rr.ID,
+	)
+
+	return err
+}
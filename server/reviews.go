@@ -0,0 +1,349 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	defaultReviewsLimit = 20
+	maxReviewsLimit     = 100
+)
+
+type (
+	// ReviewResponse defines a Review and the name of its reviewer.
+	ReviewResponse struct {
+		module.Review
+		Reviewer string `json:"reviewer"`
+	}
+
+	// ReviewsPage defines a page of a module's reviews and its aggregate
+	// rating.
+	ReviewsPage struct {
+		Rating  module.Rating    `json:"rating"`
+		Reviews []ReviewResponse `json:"reviews"`
+	}
+)
+
+// ModuleReviews serves GET /api/v1/modules/{id}/reviews?limit=<n>&offset=<n>,
+// returning the module's aggregate rating and its visible reviews, newest
+// first. The caller must have resolved the module and checked that it is
+// readable by the requester.
+func ModuleReviews(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int) {
+	limit, err := parseQueryInt(r, "limit", defaultReviewsLimit)
+	if err != nil || limit < 1 || limit > maxReviewsLimit {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "limit must be between 1 and 100"))
+		return
+	}
+
+	offset, err := parseQueryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "offset must not be negative"))
+		return
+	}
+
+	page := ReviewsPage{Reviews: []ReviewResponse{}}
+	if err := sqlDB.QueryRowContext(r.Context(), `
+		SELECT rating_average, rating_count
+		FROM modules
+		WHERE id = $1`,
+		moduleID,
+	).Scan(&page.Rating.Average, &page.Rating.Count); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT rv.id, rv.rating, rv.body, COALESCE(rv.response, ''), rv.responded_at,
+			rv.created_at, rv.updated_at, COALESCE(u.name, '')
+		FROM reviews rv
+		JOIN users u ON u.id = rv.user_id
+		WHERE rv.module_id = $1 AND NOT rv.hidden
+		ORDER BY rv.created_at DESC, rv.id DESC
+		LIMIT $2 OFFSET $3`,
+		moduleID, limit, offset,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rr          ReviewResponse
+			respondedAt sql.NullTime
+		)
+
+		if err := rows.Scan(
+			&rr.ID, &rr.Rating, &rr.Body, &rr.Response, &respondedAt,
+			&rr.CreatedAt, &rr.UpdatedAt, &rr.Reviewer,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if respondedAt.Valid {
+			rr.RespondedAt = &respondedAt.Time
+		}
+
+		page.Reviews = append(page.Reviews, rr)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page) // nolint: errcheck
+}
+
+// serveReviews serves the review writes of a module, as routed by
+// moduleRoutes, where action is one of:
+//
+//	""         POST /api/v1/modules/{id}/reviews                     reviews the module
+//	"response" PUT  /api/v1/modules/{id}/reviews/{review}/response   responds to a review, by an owner
+//	"reports"  POST /api/v1/modules/{id}/reviews/{review}/reports    reports a review for abuse
+//
+// Writes run in the request's transaction.
+func (s *Server) serveReviews(w http.ResponseWriter, r *http.Request, m moduleAccess, review, action string) {
+	var reviewID int
+	if action != "" {
+		id, err := strconv.Atoi(review)
+		if err != nil {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+			return
+		}
+
+		reviewID = id
+	}
+
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	q := db.Conn(r.Context(), s.primary)
+
+	switch action {
+	case "":
+		s.createReview(w, r, q, u, m)
+
+	case "response":
+		respondToReview(w, r, q, u, m, reviewID)
+
+	case "reports":
+		reportReview(w, r, q, u, m, reviewID)
+	}
+}
+
+func (s *Server) createReview(w http.ResponseWriter, r *http.Request, q db.Querier, u *module.User, m moduleAccess) {
+	reviewed, _, err := queryReviewedModule(r.Context(), q, m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := module.CanReview(*u, reviewed); err != nil {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, err.Error()))
+		return
+	}
+
+	var rv module.Review
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&rv); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	if err := rv.Validate(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	err = q.QueryRowContext(r.Context(), `
+		INSERT INTO reviews (module_id, user_id, rating, body)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (module_id, user_id) DO NOTHING
+		RETURNING id, created_at, updated_at`,
+		m.ID, u.ID, rv.Rating, rv.Body,
+	).Scan(&rv.ID, &rv.CreatedAt, &rv.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		WriteError(w, NewError(http.StatusConflict, CodeReviewConflict, "you already reviewed this module"))
+		return
+	} else if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ReviewResponse{Review: rv, Reviewer: u.Name}) // nolint: errcheck
+}
+
+func respondToReview(w http.ResponseWriter, r *http.Request, q db.Querier, u *module.User, m moduleAccess, reviewID int) {
+	if !m.Owner || !u.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may respond to reviews"))
+		return
+	}
+
+	var req struct {
+		Response string `json:"response"`
+	}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	rr, err := queryReview(r.Context(), q, m.ID, reviewID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	reviewed, contributors, err := queryReviewedModule(r.Context(), q, m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := rr.Respond(*u, reviewed, contributors, req.Response, time.Now().UTC()); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(r.Context(), `
+		UPDATE reviews
+		SET response = $2, responder_id = $3, responded_at = $4
+		WHERE id = $1`,
+		rr.ID, rr.Response, rr.ResponderID, rr.RespondedAt,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rr) // nolint: errcheck
+}
+
+func reportReview(w http.ResponseWriter, r *http.Request, q db.Querier, u *module.User, m moduleAccess, reviewID int) {
+	if !u.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "banned users may not report reviews"))
+		return
+	}
+
+	var report module.Report
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&report); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	if err := report.Validate(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := queryReview(r.Context(), q, m.ID, reviewID); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	report.ModuleID = m.ID
+	report.ReviewID = reviewID
+	report.ReporterID = u.ID
+	report.Status = module.ReportStatusOpen
+
+	if err := q.QueryRowContext(r.Context(), `
+		INSERT INTO reports (module_id, review_id, reporter_id, reason, details, status)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING id, created_at, updated_at`,
+		report.ModuleID, report.ReviewID, report.ReporterID, report.Reason, report.Details, report.Status,
+	).Scan(&report.ID, &report.CreatedAt, &report.UpdatedAt); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report) // nolint: errcheck
+}
+
+// queryReview returns a visible review of the module with the given ID and
+// the name of its reviewer.
+func queryReview(ctx context.Context, q db.Querier, moduleID, reviewID int) (ReviewResponse, error) {
+	var (
+		rr          ReviewResponse
+		respondedAt sql.NullTime
+	)
+
+	err := q.QueryRowContext(ctx, `
+		SELECT rv.id, rv.module_id, rv.user_id, rv.rating, rv.body, COALESCE(rv.response, ''), rv.responded_at,
+			rv.created_at, rv.updated_at, COALESCE(u.name, '')
+		FROM reviews rv
+		JOIN users u ON u.id = rv.user_id
+		WHERE rv.id = $1 AND rv.module_id = $2 AND NOT rv.hidden`,
+		reviewID, moduleID,
+	).Scan(
+		&rr.ID, &rr.ModuleID, &rr.UserID, &rr.Rating, &rr.Body, &rr.Response, &respondedAt,
+		&rr.CreatedAt, &rr.UpdatedAt, &rr.Reviewer,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rr, NewError(http.StatusNotFound, CodeNotFound, "review not found")
+	} else if err != nil {
+		return rr, err
+	}
+
+	if respondedAt.Valid {
+		rr.RespondedAt = &respondedAt.Time
+	}
+
+	return rr, nil
+}
+
+// queryReviewedModule returns the name, author and contributors of the module
+// with the given ID, as needed to check who may review it and respond to its
+// reviews.
+func queryReviewedModule(ctx context.Context, q db.Querier, moduleID int) (module.Module, module.Contributors, error) {
+	m := module.Module{ID: moduleID}
+	if err := q.QueryRowContext(ctx, `
+		SELECT name, COALESCE(author, 0)
+		FROM modules
+		WHERE id = $1`,
+		moduleID,
+	).Scan(&m.Name, &m.Author); err != nil {
+		return m, nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT user_id FROM modules_users WHERE module_id = $1`, moduleID)
+	if err != nil {
+		return m, nil, err
+	}
+	defer rows.Close()
+
+	var contributors module.Contributors
+	for rows.Next() {
+		var c module.User
+		if err := rows.Scan(&c.ID); err != nil {
+			return m, nil, err
+		}
+
+		contributors = append(contributors, c)
+	}
+
+	return m, contributors, rows.Err()
+}
@@ -0,0 +1,452 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/hmacauth"
+	"github.com/cosmos/atlas/module"
+)
+
+const modulesPathPrefix = "/api/v1/modules/"
+
+// moduleAccess defines a module resolved from a request path, the requester,
+// nil for anonymous requests, and its relation to the module.
+type moduleAccess struct {
+	ID    int
+	User  *module.User
+	Owner bool
+}
+
+// routes registers the handlers of the registry on the Server's mux.
+func (s *Server) routes() {
+	checker := s.readiness()
+
+	s.mux.HandleFunc("/healthz", checker.Liveness)
+	s.mux.HandleFunc("/readyz", checker.Readiness)
+	s.mux.Handle("/api/v1/changes", s.read(Changes))
+	s.mux.Handle("/api/v1/stats", s.read(Stats))
+	s.mux.Handle("/api/v1/sdk/", s.read(SDKModules))
+	s.mux.Handle(teamsPathPrefix, s.read(TeamChangelog))
+	s.mux.HandleFunc(upgradesPath, s.serveUpgrades)
+	s.mux.HandleFunc(csrfPath, s.CSRF)
+	s.mux.HandleFunc(tokenPath, s.serveToken)
+	s.mux.HandleFunc(tokenCIDRsPath, s.serveTokenCIDRs)
+	s.mux.HandleFunc(twoFactorPath, s.serveTwoFactor)
+	s.mux.HandleFunc(twoFactorVerifyPath, s.serveTwoFactorVerify)
+	s.mux.HandleFunc(sessionsPath, s.serveSessions)
+	s.mux.HandleFunc(sessionsPathPrefix, s.serveSessions)
+	s.mux.HandleFunc(adminModePath, s.serveMode)
+	s.mux.HandleFunc(adminMergeUsersPath, s.serveMergeUsers)
+	s.mux.HandleFunc(adminMergeKeywordsPath, s.serveMergeKeywords)
+	s.mux.HandleFunc(adminRestoreModulePath, s.serveRestoreModule)
+	s.mux.HandleFunc(adminTransferPath, s.serveTransfer)
+	s.mux.HandleFunc(adminJobsPathPrefix, s.serveJobs)
+	s.mux.HandleFunc(adminUsersPathPrefix, s.serveQuota)
+	s.mux.HandleFunc(adminQuarantinePath, s.serveQuarantine)
+	s.mux.HandleFunc(adminClearPath, s.serveClear)
+	s.mux.HandleFunc(setsPath, s.serveModuleSets)
+	s.mux.HandleFunc(setsPathPrefix, s.serveModuleSets)
+	s.mux.Handle("/logos/", Logo(s.store))
+	s.mux.HandleFunc(modulesPath, s.serveModules)
+	s.mux.HandleFunc(modulesPathPrefix, s.serveModule)
+	s.mux.HandleFunc(validatePath, s.serveValidate)
+	s.mux.HandleFunc(searchPath, s.serveSearch)
+
+	if s.cfg.KeylessAudience != "" {
+		s.mux.HandleFunc(keylessTokenPath, s.serveKeylessToken)
+	}
+
+	if s.cfg.SumDBKey != "" {
+		s.mux.Handle(sumDBPathPrefix, s.read(ChecksumDB))
+	}
+
+	sitemap := Sitemap(s.store)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/sitemap") {
+			sitemap(w, r)
+			return
+		}
+
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+	})
+}
+
+// read returns a handler serving each request with a handler of the factory
+// bound to a read database, so that reads are spread across replicas.
+func (s *Server) read(factory func(*sql.DB) http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		factory(s.reader())(w, r)
+	}
+}
+
+// moduleHandler defines the handler of an endpoint under a module, given the
+// resolved module and the path parameters of its route.
+type moduleHandler func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string)
+
+// moduleRoute defines an endpoint under /api/v1/modules/{name}/, matched by
+// method and the path following the module name. Pattern segments of the form
+// {param} match any segment, passed to the handler by param name.
+type moduleRoute struct {
+	methods []string
+	pattern string
+	serve   moduleHandler
+}
+
+var readMethods = []string{http.MethodGet, http.MethodHead}
+
+// moduleRoutes defines the endpoints under a module. Writes run in the
+// request's transaction.
+var moduleRoutes = []moduleRoute{
+	{readMethods, "", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleDetail(w, r, s.reader(), m.ID)
+	}},
+	{[]string{http.MethodPatch}, "", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.PatchModule(w, r, m)
+	}},
+	{[]string{http.MethodDelete}, "", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.DeleteModule(w, r, m)
+	}},
+	{readMethods, "advisories", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleAdvisories(w, r, s.reader(), m.ID)
+	}},
+	{[]string{http.MethodPost}, "advisories", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.CreateAdvisory(w, r, m)
+	}},
+	{[]string{http.MethodPut, http.MethodDelete}, "advisories/{advisory}/resolve", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.serveResolveAdvisory(w, r, m, params["advisory"])
+	}},
+	{[]string{http.MethodPut, http.MethodDelete}, "archive", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.serveArchive(w, r, m)
+	}},
+	{readMethods, "chains", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleChains(w, r, s.reader(), m.ID)
+	}},
+	{readMethods, "compatibility", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		Compatibility(w, r, s.reader(), m.ID, s.cfg.SDKReleases)
+	}},
+	{readMethods, "dependencies", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleDependencies(w, r, s.reader(), m)
+	}},
+	{readMethods, "dependents", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleDependents(w, r, s.reader(), m)
+	}},
+	{[]string{http.MethodPut, http.MethodDelete}, "deprecation", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.serveDeprecation(w, r, m)
+	}},
+	{readMethods, "questions", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleQuestions(w, r, s.reader(), m.ID)
+	}},
+	{readMethods, "questions/{question}", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		questionID, err := strconv.Atoi(params["question"])
+		if err != nil {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+			return
+		}
+
+		Question(w, r, s.reader(), m.ID, questionID)
+	}},
+	{[]string{http.MethodPost}, "questions", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.serveDiscussions(w, r, m, "", "")
+	}},
+	{[]string{http.MethodPut}, "questions/{question}/accepted", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.serveDiscussions(w, r, m, params["question"], "accepted")
+	}},
+	{[]string{http.MethodPost}, "questions/{question}/answers", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.serveDiscussions(w, r, m, params["question"], "answers")
+	}},
+	{readMethods, "related", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		RelatedModules(w, r, s.reader(), m)
+	}},
+	{readMethods, "removal-requests", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.RemovalRequests(w, r, m)
+	}},
+	{[]string{http.MethodPost}, "removal-requests", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.FileRemovalRequest(w, r, m)
+	}},
+	{[]string{http.MethodPost}, "removal-requests/{request}/counter-notice", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.TransitionRemovalRequest(w, r, m, params["request"], "counter-notice")
+	}},
+	{[]string{http.MethodPost}, "removal-requests/{request}/dispute", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.TransitionRemovalRequest(w, r, m, params["request"], "dispute")
+	}},
+	{[]string{http.MethodPost}, "removal-requests/{request}/resolution", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.TransitionRemovalRequest(w, r, m, params["request"], "resolution")
+	}},
+	{readMethods, "reviews", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleReviews(w, r, s.reader(), m.ID)
+	}},
+	{[]string{http.MethodPost}, "reviews", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.serveReviews(w, r, m, "", "")
+	}},
+	{[]string{http.MethodPut}, "reviews/{review}/response", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.serveReviews(w, r, m, params["review"], "response")
+	}},
+	{[]string{http.MethodPost}, "reviews/{review}/reports", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.serveReviews(w, r, m, params["review"], "reports")
+	}},
+	{readMethods, "score", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleScore(w, r, s.reader(), m.ID)
+	}},
+	{readMethods, "stats/clients", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ClientDownloads(w, r, s.reader(), m.ID)
+	}},
+	{readMethods, "stats/export", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		if !m.Owner {
+			WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may export download statistics"))
+			return
+		}
+
+		ExportDownloads(w, r, s.reader(), m.ID)
+	}},
+	{[]string{http.MethodPut, http.MethodDelete}, "subscription", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.serveSubscription(w, r, m)
+	}},
+	{readMethods, "trust-policies", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleTrustPolicies(w, r, s.reader(), m)
+	}},
+	{[]string{http.MethodPost}, "trust-policies", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		s.CreateTrustPolicy(w, r, m)
+	}},
+	{[]string{http.MethodDelete}, "trust-policies/{policy}", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.DeleteTrustPolicy(w, r, m, params["policy"])
+	}},
+	{readMethods, "versions", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		ModuleVersions(w, r, s.reader(), m)
+	}},
+	{readMethods, "versions/latest", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, _ map[string]string) {
+		LatestVersion(w, r, s.reader(), m.ID)
+	}},
+	{[]string{http.MethodDelete}, "versions/{version}", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.DeleteVersion(w, r, m, params["version"])
+	}},
+	{[]string{http.MethodPut, http.MethodDelete}, "versions/{version}/yank", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.serveYank(w, r, m, params["version"])
+	}},
+	{readMethods, "versions/{version}/artifact", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.DownloadArtifact(w, r, m, params["version"])
+	}},
+	{[]string{http.MethodPut}, "versions/{version}/artifact", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.UploadArtifact(w, r, m, params["version"])
+	}},
+	{[]string{http.MethodPost}, "versions/{version}/release", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.ReleaseVersion(w, r, m, params["version"])
+	}},
+	{[]string{http.MethodPost}, "versions/{version}/promote", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		s.PromoteVersion(w, r, m, params["version"])
+	}},
+	{readMethods, "versions/{from}/compare/{to}", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		CompareVersions(w, r, s.reader(), m.ID, params["from"], params["to"])
+	}},
+	{readMethods, "versions/{version}/readme", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		ReadmeVersion(w, r, s.reader(), m.ID, params["version"])
+	}},
+	{readMethods, "versions/{version}/docs", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		DocsVersion(w, r, s.reader(), m.ID, params["version"])
+	}},
+	{readMethods, "versions/{version}/impact", func(s *Server, w http.ResponseWriter, r *http.Request, m moduleAccess, params map[string]string) {
+		ModuleVersionImpact(w, r, s.reader(), m, params["version"])
+	}},
+}
+
+// matchModuleRoute returns the route of moduleRoutes serving the method and
+// the escaped path following the module name, along with its unescaped path
+// parameters. It returns a 404 Error if no route matches the path and a 405
+// Error if none of the routes matching it allow the method.
+func matchModuleRoute(method, rest string) (moduleRoute, map[string]string, error) {
+	segments := strings.Split(rest, "/")
+	if rest == "" {
+		segments = nil
+	}
+
+	var pathMatched bool
+	for _, route := range moduleRoutes {
+		params, ok := matchPattern(route.pattern, segments)
+		if !ok {
+			continue
+		}
+
+		pathMatched = true
+		for _, m := range route.methods {
+			if m == method {
+				return route, params, nil
+			}
+		}
+	}
+
+	if pathMatched {
+		return moduleRoute{}, nil, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed")
+	}
+
+	return moduleRoute{}, nil, NewError(http.StatusNotFound, CodeNotFound, "resource not found")
+}
+
+// matchPattern matches the escaped path segments against a route pattern,
+// returning the unescaped values of its parameters.
+func matchPattern(pattern string, segments []string) (map[string]string, bool) {
+	var parts []string
+	if pattern != "" {
+		parts = strings.Split(pattern, "/")
+	}
+
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			v, err := url.PathUnescape(segments[i])
+			if err != nil || v == "" {
+				return nil, false
+			}
+
+			params[strings.Trim(part, "{}")] = v
+			continue
+		}
+
+		if part != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// serveModule serves the endpoints of moduleRoutes under
+// /api/v1/modules/{name}/. Module names may contain slashes, so they are
+// path-escaped by clients. The route is matched before the module is resolved,
+// so that unknown endpoints and methods are rejected without a lookup.
+func (s *Server) serveModule(w http.ResponseWriter, r *http.Request) {
+	segments := strings.SplitN(strings.TrimPrefix(r.URL.EscapedPath(), modulesPathPrefix), "/", 2)
+
+	name, err := url.PathUnescape(segments[0])
+	if err != nil || name == "" {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+		return
+	}
+
+	var rest string
+	if len(segments) > 1 {
+		rest = segments[1]
+	}
+
+	route, params, err := matchModuleRoute(r.Method, rest)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	setRoute(r, strings.TrimSuffix(modulesPathPrefix+"{name}/"+route.pattern, "/"))
+
+	m, err := s.resolveModule(r, name)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	route.serve(s, w, r, m, params)
+}
+
+// resolveModule resolves a module by name, returning CodeModuleNotFound if it
+// does not exist or is not readable by the requester. Hidden modules are only
+// resolved for moderators.
+func (s *Server) resolveModule(r *http.Request, name string) (moduleAccess, error) {
+	u, err := s.requester(r)
+	if err != nil {
+		return moduleAccess{}, err
+	}
+
+	return s.lookupModule(r.Context(), u, name)
+}
+
+// lookupModule resolves a module by name for an authenticated requester, or
+// nil for anonymous requests, as resolveModule does. It reads within the
+// transaction carried by ctx, if any.
+func (s *Server) lookupModule(ctx context.Context, u *module.User, name string) (moduleAccess, error) {
+	var (
+		m           module.Module
+		contributor bool
+		granted     bool
+		uid         interface{}
+	)
+
+	if u != nil {
+		uid = u.ID
+	}
+
+	// join the transaction of a write, whose changes have not reached a
+	// replica, and read from a replica otherwise
+	err := db.Conn(ctx, s.reader()).QueryRowContext(ctx, `
+		SELECT m.id, COALESCE(m.author, 0), m.visibility,
+			EXISTS (SELECT 1 FROM modules_users mu WHERE mu.module_id = m.id AND mu.user_id = $2),
+			EXISTS (SELECT 1 FROM module_grants mg WHERE mg.module_id = m.id AND mg.user_id = $2)
+		FROM modules m
+		WHERE m.slug = $1
+			AND m.deleted_at IS NULL
+			AND (NOT m.hidden OR $3)`,
+		module.Slug(name), uid, u != nil && u.CanModerate(),
+	).Scan(&m.ID, &m.Author, &m.Visibility, &contributor, &granted)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !m.ReadableBy(u, contributor || granted)) {
+		return moduleAccess{}, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found")
+	}
+
+	if err != nil {
+		return moduleAccess{}, err
+	}
+
+	return moduleAccess{ID: m.ID, User: u, Owner: u != nil && (u.ID == m.Author || contributor)}, nil
+}
+
+// requester authenticates the request by its signature, bearer API token or
+// session cookie, returning nil for anonymous requests. The requester is
+// recorded in the request's log.
+func (s *Server) requester(r *http.Request) (*module.User, error) {
+	u, err := s.authenticate(r)
+	if u != nil {
+		setRequester(r, u)
+	}
+
+	return u, err
+}
+
+// authenticate authenticates the request as requester does.
+func (s *Server) authenticate(r *http.Request) (*module.User, error) {
+	if hmacauth.Signed(r) {
+		u, err := s.verifier.Verify(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return &u, nil
+	}
+
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return s.sessionRequester(r)
+	}
+
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if token == auth || token == "" {
+		return nil, NewError(http.StatusUnauthorized, CodeUnauthorized, "authorization must be a bearer API token")
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	u, err := s.verifier.VerifyToken(r.Context(), token, net.ParseIP(host))
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMatchModuleRoute(t *testing.T) {
+	// every route is matched by a concrete path of its pattern
+	for _, route := range moduleRoutes {
+		segments := strings.Split(route.pattern, "/")
+		for i, s := range segments {
+			if strings.HasPrefix(s, "{") {
+				segments[i] = "7"
+			}
+		}
+
+		for _, method := range route.methods {
+			got, _, err := matchModuleRoute(method, strings.Join(segments, "/"))
+			if err != nil {
+				t.Errorf("%s %q: %v", method, route.pattern, err)
+				continue
+			}
+
+			if got.pattern != route.pattern {
+				t.Errorf("%s %q: matched %q", method, route.pattern, got.pattern)
+			}
+		}
+	}
+
+	testCases := []struct {
+		method  string
+		rest    string
+		pattern string
+		params  map[string]string
+		status  int
+	}{
+		{http.MethodGet, "", "", nil, 0},
+		{http.MethodPatch, "", "", nil, 0},
+		{http.MethodDelete, "", "", nil, 0},
+		{http.MethodHead, "versions", "versions", nil, 0},
+		{http.MethodGet, "dependencies", "dependencies", nil, 0},
+		{http.MethodGet, "dependents", "dependents", nil, 0},
+		{http.MethodGet, "related", "related", nil, 0},
+		{http.MethodPost, "reviews", "reviews", nil, 0},
+		{http.MethodPut, "reviews/12/response", "reviews/{review}/response", map[string]string{"review": "12"}, 0},
+		{http.MethodPost, "removal-requests", "removal-requests", nil, 0},
+		{http.MethodPost, "removal-requests/3/dispute", "removal-requests/{request}/dispute", map[string]string{"request": "3"}, 0},
+		{http.MethodPost, "removal-requests/3/resolution", "removal-requests/{request}/resolution", map[string]string{"request": "3"}, 0},
+		{http.MethodGet, "questions/a%2Fb", "questions/{question}", map[string]string{"question": "a/b"}, 0},
+		{http.MethodPost, "questions", "questions", nil, 0},
+		{http.MethodPost, "questions/4/answers", "questions/{question}/answers", map[string]string{"question": "4"}, 0},
+		{http.MethodPut, "questions/4/accepted", "questions/{question}/accepted", map[string]string{"question": "4"}, 0},
+		{http.MethodPut, "subscription", "subscription", nil, 0},
+		{http.MethodDelete, "subscription", "subscription", nil, 0},
+		{http.MethodGet, "versions/1.2.0/impact", "versions/{version}/impact", map[string]string{"version": "1.2.0"}, 0},
+		{http.MethodDelete, "versions/1.2.0", "versions/{version}", map[string]string{"version": "1.2.0"}, 0},
+		{http.MethodPut, "versions/1.2.0/yank", "versions/{version}/yank", map[string]string{"version": "1.2.0"}, 0},
+		{http.MethodGet, "stats/clients", "stats/clients", nil, 0},
+		{http.MethodGet, "advisories", "advisories", nil, 0},
+		{http.MethodPost, "advisories", "advisories", nil, 0},
+		{http.MethodPut, "advisories/CVE-2021-1234/resolve", "advisories/{advisory}/resolve", map[string]string{"advisory": "CVE-2021-1234"}, 0},
+		{http.MethodPost, "advisories/CVE-2021-1234/resolve", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodGet, "trust-policies", "trust-policies", nil, 0},
+		{http.MethodPost, "trust-policies", "trust-policies", nil, 0},
+		{http.MethodDelete, "trust-policies/2", "trust-policies/{policy}", map[string]string{"policy": "2"}, 0},
+		{http.MethodGet, "versions/latest", "versions/latest", nil, 0},
+		{http.MethodGet, "versions/1.0.0/compare/1.2.0", "versions/{from}/compare/{to}", map[string]string{"from": "1.0.0", "to": "1.2.0"}, 0},
+		{http.MethodGet, "versions/1.2.0/readme", "versions/{version}/readme", map[string]string{"version": "1.2.0"}, 0},
+		{http.MethodGet, "versions/1.3.0/artifact", "versions/{version}/artifact", map[string]string{"version": "1.3.0"}, 0},
+		{http.MethodPut, "versions/1.3.0/artifact", "versions/{version}/artifact", map[string]string{"version": "1.3.0"}, 0},
+		{http.MethodPost, "versions/1.3.0/artifact", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodPost, "versions/1.3.0/release", "versions/{version}/release", map[string]string{"version": "1.3.0"}, 0},
+		{http.MethodPost, "versions/1.2.0-rc.1/promote", "versions/{version}/promote", map[string]string{"version": "1.2.0-rc.1"}, 0},
+		{http.MethodGet, "versions/1.2.0-rc.1/promote", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodGet, "versions/1.2.0/docs", "versions/{version}/docs", map[string]string{"version": "1.2.0"}, 0},
+		{http.MethodGet, "unknown", "", nil, http.StatusNotFound},
+		{http.MethodGet, "versions/latest/extra", "", nil, http.StatusNotFound},
+		{http.MethodGet, "questions/", "", nil, http.StatusNotFound},
+		{http.MethodPut, "reviews/12/unknown", "", nil, http.StatusNotFound},
+		{http.MethodDelete, "versions", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodPost, "", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodGet, "reviews/12/response", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodPut, "questions", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodGet, "questions/4/accepted", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodGet, "subscription", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodPost, "versions/1.2.0/impact", "", nil, http.StatusMethodNotAllowed},
+		{http.MethodPost, "removal-requests/3/unknown", "", nil, http.StatusNotFound},
+		{http.MethodGet, "removal-requests/3/dispute", "", nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		route, params, err := matchModuleRoute(tc.method, tc.rest)
+		if tc.status != 0 {
+			if err == nil || ToError(err).Status != tc.status {
+				t.Errorf("%s %q: expected %d, got %v", tc.method, tc.rest, tc.status, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s %q: %v", tc.method, tc.rest, err)
+			continue
+		}
+
+		if route.pattern != tc.pattern {
+			t.Errorf("%s %q: expected %q, got %q", tc.method, tc.rest, tc.pattern, route.pattern)
+		}
+
+		for k, v := range tc.params {
+			if params[k] != v {
+				t.Errorf("%s %q: expected %s=%q, got %q", tc.method, tc.rest, k, v, params[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	searchPath = modulesPathPrefix + "search"
+
+	defaultModulesLimit = 20
+	maxModulesLimit     = 100
+)
+
+// ModulesPage defines a page of modules returned by the list and search
+// endpoints.
+type ModulesPage struct {
+	Modules []module.Module `json:"modules"`
+}
+
+// serveSearch serves GET /api/v1/modules/search?q=<query>, as ModuleList.
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	s.listModules(w, r, r.URL.Query().Get("q"))
+}
+
+// listModules serves a page of the modules matching query as the requester.
+func (s *Server) listModules(w http.ResponseWriter, r *http.Request, query string) {
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	ModuleList(w, r, s.reader(), u, query)
+}
+
+// ModuleList serves a page of the modules readable by the requester, nil for
+// anonymous requests, whose name, description or keywords contain the query,
+// if not empty (license=<spdx>&limit=<n>&offset=<n>). A license filter matches
+// modules whose SPDX license expression, declared by their manifest or detected
+// from their repository's LICENSE file, equals it. Modules are ranked by quality score
+// with deprecated modules last; hidden and deleted modules are never listed.
+// Modules are listed along with their aggregate rating. Requests conditional on the ETag of an unchanged page are answered with 304
+// Not Modified.
+func ModuleList(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, u *module.User, query string) {
+	limit, err := parseQueryInt(r, "limit", defaultModulesLimit)
+	if err != nil || limit < 1 || limit > maxModulesLimit {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "limit must be between 1 and 100"))
+		return
+	}
+
+	offset, err := parseQueryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "offset must not be negative"))
+		return
+	}
+
+	license := r.URL.Query().Get("license")
+	if license != "" && !module.ValidLicense(license) {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "license must be a valid SPDX license expression"))
+		return
+	}
+
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT m.name, COALESCE(m.description, ''), m.version, m.homepage, m.repo,
+			COALESCE(m.license, ''), m.visibility, m.disputed, m.archived, m.quality_score,
+			m.rating_average, m.rating_count, COALESCE(verified_publisher(m.author), false), COALESCE(m.origin, ''), m.lock_version,
+			m.deprecated_at, COALESCE(m.deprecation_message, ''), rm.name
+		FROM modules m
+		LEFT JOIN modules rm ON rm.id = m.replaced_by AND rm.deleted_at IS NULL
+		WHERE module_readable(m.id, $1)
+			AND NOT m.hidden
+			AND m.deleted_at IS NULL
+			AND (
+				$2 = ''
+				OR m.name ILIKE $3
+				OR m.description ILIKE $3
+				OR EXISTS (
+					SELECT 1 FROM modules_keywords mk
+					JOIN keywords k ON k.id = mk.keyword_id
+					WHERE mk.module_id = m.id AND k.name ILIKE $3
+				)
+			)
+			AND ($6 = '' OR m.license = $6)
+		ORDER BY m.deprecated_at IS NOT NULL, m.quality_score DESC, m.name
+		LIMIT $4 OFFSET $5`,
+		requesterID(u), query, "%"+escapeLike(query)+"%", limit, offset, license,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	page := ModulesPage{Modules: []module.Module{}}
+	for rows.Next() {
+		var (
+			m              module.Module
+			deprecatedAt   sql.NullTime
+			deprecationMsg string
+			replacedBy     sql.NullString
+		)
+
+		if err := rows.Scan(
+			&m.Name, &m.Description, &m.Version, &m.Homepage, &m.Repo,
+			&m.License, &m.Visibility, &m.Disputed, &m.Archived, &m.QualityScore,
+			&m.Rating.Average, &m.Rating.Count, &m.VerifiedPublisher, &m.Origin, &m.LockVersion,
+			&deprecatedAt, &deprecationMsg, &replacedBy,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		m.Deprecation = scanDeprecation(deprecatedAt, deprecationMsg, replacedBy)
+		page.Modules = append(page.Modules, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	bz, err := json.Marshal(page)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	// modules leaving the page leave no modification time behind, so pages
+	// are only validated by their ETag
+	if notModified(w, r, weakETag(bz), time.Time{}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(bz, '\n')) // nolint: errcheck
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(q string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q)
+}
@@ -0,0 +1,446 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cosmos/atlas/anomaly"
+	"github.com/cosmos/atlas/chainregistry"
+	"github.com/cosmos/atlas/checksumdb"
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/docs"
+	"github.com/cosmos/atlas/health"
+	"github.com/cosmos/atlas/hmacauth"
+	"github.com/cosmos/atlas/issuetracker"
+	"github.com/cosmos/atlas/jobs"
+	"github.com/cosmos/atlas/quality"
+	"github.com/cosmos/atlas/recommendations"
+	"github.com/cosmos/atlas/sitemap"
+	"github.com/cosmos/atlas/storage"
+	"github.com/cosmos/atlas/tagverify"
+)
+
+const (
+	replicaMonitorInterval = 10 * time.Second
+	readinessTimeout       = 2 * time.Second
+)
+
+// Hook defines a lifecycle hook of a Server, run by Start or Shutdown.
+type Hook func(ctx context.Context) error
+
+// Option defines a functional option of a Server.
+type Option func(*Server)
+
+// WithDB makes the Server use an existing primary database, e.g. one shared
+// with the embedding program, instead of opening config.Database. The Server
+// does not close it on Shutdown.
+func WithDB(primary *sql.DB) Option {
+	return func(s *Server) { s.primary = primary }
+}
+
+// WithStorage makes the Server use the given artifact storage instead of the
+// one configured by config.Storage.
+func WithStorage(store storage.Storage) Option {
+	return func(s *Server) { s.store = store }
+}
+
+// WithJobHandler registers the handler of a job kind, replacing the built-in
+// handler of the kind, if any.
+func WithJobHandler(kind string, h jobs.Handler) Option {
+	return func(s *Server) { s.jobHandlers[kind] = h }
+}
+
+// WithoutWorkers disables the job workers and scheduler, e.g. for replicas
+// that only serve the API while another process runs background jobs.
+func WithoutWorkers() Option {
+	return func(s *Server) { s.workers = false }
+}
+
+// WithMiddleware wraps the Server's handler in the given middleware, outermost
+// last.
+func WithMiddleware(mw ...func(http.Handler) http.Handler) Option {
+	return func(s *Server) { s.middleware = append(s.middleware, mw...) }
+}
+
+// OnStart registers a hook run by Start before the background workers start.
+func OnStart(h Hook) Option {
+	return func(s *Server) { s.onStart = append(s.onStart, h) }
+}
+
+// OnShutdown registers a hook run by Shutdown after the background workers
+// stop and before the database is closed.
+func OnShutdown(h Hook) Option {
+	return func(s *Server) { s.onShutdown = append(s.onShutdown, h) }
+}
+
+// Server defines an Atlas registry: its HTTP API and the background job
+// workers and scheduler maintaining it. A Server is either run by the start
+// command or embedded in another Go program, which mounts Handler on its own
+// mux (e.g. under http.StripPrefix) and drives the background work with Start
+// and Shutdown.
+type Server struct {
+	cfg         config.Config
+	resolver    *db.Resolver
+	primary     *sql.DB
+	store       storage.Storage
+	maintenance *Maintenance
+	verifier    *hmacauth.Verifier
+	idTokens    IDTokenVerifier
+	idTokensMu  sync.Mutex
+	queue       *jobs.Queue
+	mux         *http.ServeMux
+	handler     http.Handler
+
+	workers     bool
+	jobHandlers map[string]jobs.Handler
+	middleware  []func(http.Handler) http.Handler
+	encoders    []encoder
+	reporter    ErrorReporter
+	logger      RequestLogger
+	onStart     []Hook
+	onShutdown  []Hook
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// New returns a Server of the given configuration, which must be valid and
+// define no tenants. It opens the configured database and storage unless
+// provided with WithDB and WithStorage, but starts no background work until
+// Start is called.
+func New(cfg config.Config, opts ...Option) (*Server, error) {
+	if len(cfg.Tenants) > 0 {
+		return nil, errors.New("configuration defines tenants, which are served by NewTenants")
+	}
+
+	s := &Server{
+		cfg:         cfg,
+		workers:     true,
+		jobHandlers: make(map[string]jobs.Handler),
+		reporter:    logReporter{},
+		logger:      newJSONLogger(),
+		encoders: []encoder{
+			{encoding: "br", newWriter: newBrotliWriter},
+			{encoding: "gzip", newWriter: newGzipWriter},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	validate := cfg.Validate
+	if s.primary != nil {
+		validate = cfg.ValidateEmbedded
+	}
+
+	if err := validate(); err != nil {
+		return nil, err
+	}
+
+	if s.primary == nil {
+		resolver, err := db.Open(cfg.Database.URL, cfg.Database.ReplicaURLs)
+		if err != nil {
+			return nil, err
+		}
+
+		s.resolver = resolver
+		s.primary = resolver.Primary()
+	}
+
+	if s.store == nil {
+		store, err := newStorage(cfg.Storage)
+		if err != nil {
+			s.closeDB()
+			return nil, err
+		}
+
+		s.store = store
+	}
+
+	maintenance, err := NewMaintenance(cfg.Mode, "/healthz", "/readyz", adminPathPrefix)
+	if err != nil {
+		s.closeDB()
+		return nil, err
+	}
+
+	s.maintenance = maintenance
+	s.verifier = hmacauth.NewVerifier(s.primary, cfg.Policy.Tokens)
+	s.queue = jobs.NewQueue(s.primary)
+	s.mux = http.NewServeMux()
+	s.routes()
+
+	var h http.Handler = compress(s.encoders)(s.maintenance.Handler(Transactions(s.primary)(s.mux)))
+	h = recoverPanics(s.reporter)(h)
+	if cfg.CORS.Enabled() {
+		h = cors(cfg.CORS)(h)
+	}
+
+	h = logRequests(s.logger, s.mux)(h)
+	h = requestIDs(h)
+
+	for _, mw := range s.middleware {
+		h = mw(h)
+	}
+
+	s.handler = h
+	return s, nil
+}
+
+// Handler returns the HTTP handler serving the registry.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Handle registers an additional handler on the Server's mux, e.g. for
+// endpoints of the embedding program. It must be called before serving.
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// DB returns the primary database of the Server.
+func (s *Server) DB() *sql.DB {
+	return s.primary
+}
+
+// Queue returns the job queue of the Server, e.g. to enqueue jobs.
+func (s *Server) Queue() *jobs.Queue {
+	return s.queue
+}
+
+// Maintenance returns the Server's maintenance middleware, to switch its
+// operating mode at runtime.
+func (s *Server) Maintenance() *Maintenance {
+	return s.maintenance
+}
+
+// Start runs the OnStart hooks and then starts the background work of the
+// Server in goroutines: replica health monitoring and, unless disabled with
+// WithoutWorkers, the job workers and scheduler. It returns once they are
+// started; they run until Shutdown.
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return errors.New("server already started")
+	}
+
+	for _, h := range s.onStart {
+		if err := h(ctx); err != nil {
+			return err
+		}
+	}
+
+	var scheduler *jobs.Scheduler
+	if s.workers {
+		var err error
+		if scheduler, err = jobs.NewScheduler(s.queue, s.cfg.Schedules); err != nil {
+			return err
+		}
+	}
+
+	bgCtx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	if s.resolver != nil {
+		s.goBackground(func() { s.resolver.Monitor(bgCtx, replicaMonitorInterval) })
+	}
+
+	if s.workers {
+		pool := jobs.NewPool(s.queue, s.cfg.Workers)
+		for kind, h := range s.builtinJobHandlers() {
+			pool.Register(kind, h)
+		}
+
+		for kind, h := range s.jobHandlers {
+			pool.Register(kind, h)
+		}
+
+		s.goBackground(func() { pool.Run(bgCtx) })
+		s.goBackground(func() {
+			if err := scheduler.Run(bgCtx); err != nil {
+				log.Printf("job scheduler stopped: %v", err)
+			}
+		})
+	}
+
+	return nil
+}
+
+// Shutdown stops the background work of the Server, waiting for in-flight
+// jobs to finish until the context is done, then runs the OnShutdown hooks
+// and closes the database unless it was provided with WithDB. It does not
+// stop the HTTP server serving Handler, which the caller shuts down first.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+
+		stopped := make(chan struct{})
+		go func() {
+			s.done.Wait()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for background work to stop: %w", ctx.Err())
+		}
+	}
+
+	var errs []error
+	for _, h := range s.onShutdown {
+		if err := h(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := s.closeDB(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+func (s *Server) goBackground(fn func()) {
+	s.done.Add(1)
+
+	go func() {
+		defer s.done.Done()
+		fn()
+	}()
+}
+
+// closeDB closes the database if the Server opened it.
+func (s *Server) closeDB() error {
+	if s.resolver == nil {
+		return nil
+	}
+
+	return s.resolver.Close()
+}
+
+// reader returns the database for read-only queries.
+func (s *Server) reader() *sql.DB {
+	if s.resolver == nil {
+		return s.primary
+	}
+
+	return s.resolver.Reader()
+}
+
+// builtinJobHandlers returns the handlers of the job kinds implemented by the
+// registry. Kinds without a built-in handler, e.g. repository health checks
+// and upstream syncs, are provided with WithJobHandler.
+func (s *Server) builtinJobHandlers() map[string]jobs.Handler {
+	handlers := map[string]jobs.Handler{
+		jobs.KindAggregateStats: func(ctx context.Context, _ jobs.Job) error {
+			return db.RefreshMaterializedViews(ctx, s.primary)
+		},
+		jobs.KindComputeScores: func(ctx context.Context, _ jobs.Job) error {
+			return quality.RecomputeAll(ctx, s.primary, quality.NewEngine(quality.DefaultRules()...))
+		},
+		jobs.KindComputeRecommendations: func(ctx context.Context, _ jobs.Job) error {
+			return recommendations.PrecomputeAll(ctx, s.primary, recommendations.DefaultLimit)
+		},
+		jobs.KindExtractDocs: func(ctx context.Context, j jobs.Job) error {
+			var payload jobs.DocsPayload
+			if err := json.Unmarshal(j.Payload, &payload); err != nil {
+				return err
+			}
+
+			return docs.Generate(ctx, s.primary, payload.ModuleVersionID)
+		},
+		jobs.KindVerifyTag: func(ctx context.Context, j jobs.Job) error {
+			var payload jobs.VerifyTagPayload
+			if err := json.Unmarshal(j.Payload, &payload); err != nil {
+				return err
+			}
+
+			verifier := tagverify.NewVerifier(http.DefaultClient, s.cfg.Issues.GitHubToken)
+			return verifier.VerifyVersion(ctx, s.primary, payload.ModuleVersionID, payload.Promoted)
+		},
+		jobs.KindDetectAnomalies: func(ctx context.Context, _ jobs.Job) error {
+			_, err := anomaly.NewAnalyzer(s.primary, s.cfg.Anomalies).Run(ctx)
+			return err
+		},
+		jobs.KindSyncChains: func(ctx context.Context, _ jobs.Job) error {
+			return chainregistry.Sync(ctx, s.primary, chainregistry.NewClient(http.DefaultClient, "", ""))
+		},
+		jobs.KindPurgeDeleted: func(ctx context.Context, _ jobs.Job) error {
+			_, err := purgeDeleted(ctx, s.primary, s.cfg.Policy.DeletedRetention, time.Now().UTC())
+			return err
+		},
+		jobs.KindGenerateSitemap: func(ctx context.Context, _ jobs.Job) error {
+			_, err := sitemap.Generate(ctx, s.reader(), s.store, s.cfg.BaseURL)
+			return err
+		},
+	}
+
+	if s.cfg.Issues.Enabled {
+		handlers[jobs.KindPollIssues] = func(ctx context.Context, _ jobs.Job) error {
+			return issuetracker.Sync(ctx, s.primary, issuetracker.NewClient(http.DefaultClient, s.cfg.Issues.GitHubToken, s.cfg.Issues.GitLabToken))
+		}
+	}
+
+	if s.cfg.SumDBKey != "" {
+		handlers[jobs.KindLogChecksums] = func(ctx context.Context, _ jobs.Job) error {
+			l, err := checksumdb.NewLog(s.primary, s.cfg.SumDBKey)
+			if err != nil {
+				return err
+			}
+
+			_, err = l.Sync(ctx)
+			return err
+		}
+	}
+
+	return handlers
+}
+
+// readiness returns the readiness checker of the Server.
+func (s *Server) readiness() *health.Checker {
+	return health.NewChecker(readinessTimeout,
+		health.Database("primary", s.primary),
+		health.JobQueue(s.primary),
+	)
+}
+
+// newStorage returns the configured artifact storage.
+func newStorage(cfg config.StorageConfig) (storage.Storage, error) {
+	var (
+		s   storage.Storage
+		err error
+	)
+
+	switch cfg.Backend {
+	case config.StorageS3:
+		s, err = storage.NewS3(cfg.S3)
+
+	default:
+		s, err = storage.NewLocal(cfg.LocalRoot)
+	}
+
+	if err != nil || cfg.Prefix == "" {
+		return s, err
+	}
+
+	return storage.NewPrefixed(s, cfg.Prefix), nil
+}
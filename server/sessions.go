@@ -0,0 +1,290 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/hmacauth"
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	sessionsPath       = "/api/v1/user/sessions"
+	sessionsPathPrefix = sessionsPath + "/"
+)
+
+type (
+	// LoginResponse defines the session created by a login along with the CSRF
+	// token that mutating requests authenticated by its cookie must carry. The
+	// raw session token is only handed out in the session cookie.
+	LoginResponse struct {
+		Session   module.Session `json:"session"`
+		CSRFToken string         `json:"csrf_token"`
+	}
+
+	// SessionResponse defines an active session of the requester, marking the
+	// session the request was authenticated by.
+	SessionResponse struct {
+		module.Session
+		Current bool `json:"current"`
+	}
+)
+
+// serveSessions serves the session management endpoints of the requester:
+//
+//	POST /api/v1/user/sessions logs in, exchanging the requester's API token or
+//	  signature for a session cookie
+//	GET /api/v1/user/sessions lists the requester's active sessions
+//	DELETE /api/v1/user/sessions revokes every session but the current one
+//	DELETE /api/v1/user/sessions/{id} revokes a single session
+//
+// Revoked sessions are rejected by every later request carrying their cookie.
+func (s *Server) serveSessions(w http.ResponseWriter, r *http.Request) {
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if r.URL.Path != sessionsPath {
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, sessionsPathPrefix))
+		if err != nil {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+			return
+		}
+
+		if r.Method != http.MethodDelete {
+			WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+			return
+		}
+
+		s.revokeSession(w, r, u, id)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.login(w, r, u)
+
+	case http.MethodGet, http.MethodHead:
+		s.listSessions(w, r, u)
+
+	case http.MethodDelete:
+		s.revokeOtherSessions(w, r, u)
+
+	default:
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+	}
+}
+
+// login creates a session of the user on the requesting device and sets its
+// cookie. Users having two-factor authentication enabled must send a valid
+// code of their second factor.
+func (s *Server) login(w http.ResponseWriter, r *http.Request, u *module.User) {
+	verified, err := s.verifyTwoFactor(r.Context(), u, r.Header.Get(HeaderOTP))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u.TOTPEnabled && !verified {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "a valid two-factor code is required"))
+		return
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	session, token, err := module.NewSession(u.ID, r.UserAgent(), host, time.Now().UTC())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := db.Conn(r.Context(), s.primary).QueryRowContext(r.Context(), `
+		INSERT INTO sessions (user_id, token_hash, user_agent, ip, created_at, last_seen_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6)
+		RETURNING id`,
+		session.UserID, session.TokenHash, session.UserAgent, session.IP, session.CreatedAt, session.LastSeenAt,
+	).Scan(&session.ID); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	http.SetCookie(w, s.sessionCookie(token, int(s.verifier.SessionMaxAge.Seconds())))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(LoginResponse{Session: session, CSRFToken: module.CSRFToken(token)}) // nolint: errcheck
+}
+
+// listSessions serves the active sessions of the user, most recently seen
+// first.
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request, u *module.User) {
+	sessions, err := s.querySessions(r, db.Conn(r.Context(), s.primary), u, false)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	current := currentSessionHash(r)
+
+	resp := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, SessionResponse{Session: session, Current: session.TokenHash == current})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(resp) // nolint: errcheck
+}
+
+// revokeSession revokes a single session of the user, clearing the session
+// cookie if it is the current one.
+func (s *Server) revokeSession(w http.ResponseWriter, r *http.Request, u *module.User, id int) {
+	q := db.Conn(r.Context(), s.primary)
+
+	var session module.Session
+	err := q.QueryRowContext(r.Context(), `
+		SELECT id, token_hash
+		FROM sessions
+		WHERE id = $1
+			AND user_id = $2
+			AND revoked_at IS NULL
+		FOR UPDATE`,
+		id, u.ID,
+	).Scan(&session.ID, &session.TokenHash)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "session not found"))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	session.Revoke(time.Now().UTC())
+
+	if _, err := q.ExecContext(r.Context(), `UPDATE sessions SET revoked_at = $2 WHERE id = $1`, session.ID, session.RevokedAt); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if session.TokenHash == currentSessionHash(r) {
+		http.SetCookie(w, s.sessionCookie("", -1))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeOtherSessions revokes every active session of the user but the one
+// the request was authenticated by, if any.
+func (s *Server) revokeOtherSessions(w http.ResponseWriter, r *http.Request, u *module.User) {
+	q := db.Conn(r.Context(), s.primary)
+
+	sessions, err := s.querySessions(r, q, u, true)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	var currentID int
+	current := currentSessionHash(r)
+	for _, session := range sessions {
+		if session.TokenHash == current {
+			currentID = session.ID
+		}
+	}
+
+	for _, session := range module.RevokeOthers(sessions, currentID, time.Now().UTC()) {
+		if _, err := q.ExecContext(r.Context(), `UPDATE sessions SET revoked_at = $2 WHERE id = $1`, session.ID, session.RevokedAt); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// querySessions returns the active sessions of the user, most recently seen
+// first, locking them for update if requested. Sessions past their maximum age
+// or idle timeout are not returned.
+func (s *Server) querySessions(r *http.Request, q db.Querier, u *module.User, lock bool) ([]module.Session, error) {
+	query := `
+		SELECT id, token_hash, COALESCE(user_agent, ''), COALESCE(ip, ''), created_at, last_seen_at
+		FROM sessions
+		WHERE user_id = $1
+			AND revoked_at IS NULL
+			AND ($2 = 0 OR created_at > NOW() - $2 * INTERVAL '1 second')
+			AND ($3 = 0 OR last_seen_at > NOW() - $3 * INTERVAL '1 second')
+		ORDER BY last_seen_at DESC, id DESC`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	rows, err := q.QueryContext(r.Context(), query,
+		u.ID, s.verifier.SessionMaxAge.Seconds(), s.verifier.SessionIdleTimeout.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []module.Session
+	for rows.Next() {
+		session := module.Session{UserID: u.ID}
+		if err := rows.Scan(
+			&session.ID, &session.TokenHash, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// sessionCookie returns the session cookie carrying the token, expiring after
+// maxAge seconds, or immediately if negative. It is only sent over HTTPS if the
+// registry is served over HTTPS.
+func (s *Server) sessionCookie(token string, maxAge int) *http.Cookie {
+	base, err := url.Parse(s.cfg.BaseURL)
+
+	return &http.Cookie{
+		Name:     hmacauth.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   err == nil && base.Scheme == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// currentSessionHash returns the token hash of the request's session cookie,
+// or an empty string if it has none.
+func currentSessionHash(r *http.Request) string {
+	c, err := r.Cookie(hmacauth.SessionCookie)
+	if err != nil || c.Value == "" {
+		return ""
+	}
+
+	return module.HashSessionToken(c.Value)
+}
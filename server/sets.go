@@ -0,0 +1,372 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	setsPath       = "/api/v1/sets"
+	setsPathPrefix = setsPath + "/"
+)
+
+// serveModuleSets serves the module set endpoints:
+//
+//	POST   /api/v1/sets      creates a set owned by the requester
+//	GET    /api/v1/sets/{id} resolves a set's pinned versions
+//	PUT    /api/v1/sets/{id} replaces a set, by its owner
+//	DELETE /api/v1/sets/{id} deletes a set, by its owner
+//
+// Writes run in the request's transaction.
+func (s *Server) serveModuleSets(w http.ResponseWriter, r *http.Request) {
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if r.URL.Path == setsPath {
+		if r.Method != http.MethodPost {
+			WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+			return
+		}
+
+		s.createModuleSet(w, r, u)
+		return
+	}
+
+	publicID := strings.TrimPrefix(r.URL.Path, setsPathPrefix)
+	if publicID == "" || strings.Contains(publicID, "/") {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		set, err := queryModuleSet(r.Context(), s.reader(), u, publicID)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		writeModuleSet(w, http.StatusOK, set)
+
+	case http.MethodPut:
+		s.updateModuleSet(w, r, u, publicID)
+
+	case http.MethodDelete:
+		set, err := s.ownedModuleSet(r.Context(), u, publicID)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if _, err := db.Conn(r.Context(), s.primary).ExecContext(r.Context(), `DELETE FROM module_sets WHERE id = $1`, set.ID); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+	}
+}
+
+func (s *Server) createModuleSet(w http.ResponseWriter, r *http.Request, u *module.User) {
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !u.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "banned users may not create module sets"))
+		return
+	}
+
+	set, err := decodeModuleSet(w, r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if set.PublicID, err = module.NewModuleSetID(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	set.OwnerID = u.ID
+	q := db.Conn(r.Context(), s.primary)
+
+	versionIDs, err := s.resolvePins(r.Context(), q, u, set)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := q.QueryRowContext(r.Context(), `
+		INSERT INTO module_sets (public_id, owner_id, name, description, visibility)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		set.PublicID, set.OwnerID, set.Name, set.Description, set.Visibility,
+	).Scan(&set.ID); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := insertPins(r.Context(), q, set.ID, versionIDs); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	created, err := queryModuleSet(r.Context(), q, u, set.PublicID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	writeModuleSet(w, http.StatusCreated, created)
+}
+
+func (s *Server) updateModuleSet(w http.ResponseWriter, r *http.Request, u *module.User, publicID string) {
+	existing, err := s.ownedModuleSet(r.Context(), u, publicID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	set, err := decodeModuleSet(w, r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	set.ID, set.PublicID, set.OwnerID = existing.ID, existing.PublicID, existing.OwnerID
+	q := db.Conn(r.Context(), s.primary)
+
+	versionIDs, err := s.resolvePins(r.Context(), q, u, set)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(r.Context(), `
+		UPDATE module_sets
+		SET name = $2, description = $3, visibility = $4, updated_at = NOW()
+		WHERE id = $1`,
+		set.ID, set.Name, set.Description, set.Visibility,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(r.Context(), `DELETE FROM module_set_versions WHERE set_id = $1`, set.ID); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := insertPins(r.Context(), q, set.ID, versionIDs); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	updated, err := queryModuleSet(r.Context(), q, u, set.PublicID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	writeModuleSet(w, http.StatusOK, updated)
+}
+
+// ownedModuleSet returns the module set with the given public ID, which the
+// requester must own.
+func (s *Server) ownedModuleSet(ctx context.Context, u *module.User, publicID string) (module.ModuleSet, error) {
+	if u == nil {
+		return module.ModuleSet{}, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required")
+	}
+
+	set, err := queryModuleSet(ctx, db.Conn(ctx, s.primary), u, publicID)
+	if err != nil {
+		return module.ModuleSet{}, err
+	}
+
+	if set.OwnerID != u.ID {
+		return module.ModuleSet{}, NewError(http.StatusForbidden, CodeForbidden, "only the owner may modify a module set")
+	}
+
+	return set, nil
+}
+
+// decodeModuleSet decodes and validates the module set of a request body.
+func decodeModuleSet(w http.ResponseWriter, r *http.Request) (module.ModuleSet, error) {
+	var set module.ModuleSet
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&set); err != nil {
+		return set, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body")
+	}
+
+	if set.Visibility == "" {
+		set.Visibility = module.VisibilityPublic
+	}
+
+	return set, set.Validate()
+}
+
+// resolvePins resolves the published versions pinned by a module set, whose
+// modules must be readable by the requester, returning their IDs keyed by
+// module ID in pin order. It also checks that the requester has no other set
+// of the same name.
+func (s *Server) resolvePins(ctx context.Context, q db.Querier, u *module.User, set module.ModuleSet) ([][2]int, error) {
+	var (
+		errs module.ValidationErrors
+		ids  = make([][2]int, 0, len(set.Pins))
+	)
+
+	var taken bool
+	if err := q.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM module_sets WHERE owner_id = $1 AND name = $2 AND id <> $3)`,
+		set.OwnerID, set.Name, set.ID,
+	).Scan(&taken); err != nil {
+		return nil, err
+	}
+
+	if taken {
+		errs = append(errs, module.FieldError{Field: "name", Code: module.ErrCodeInvalidValue, Message: "another of your module sets has this name"})
+	}
+
+	for i, p := range set.Pins {
+		field := fmt.Sprintf("pins[%d]", i)
+
+		m, err := s.lookupModule(ctx, u, p.Module)
+		if err != nil {
+			if ToError(err).Code == CodeModuleNotFound {
+				errs = append(errs, module.FieldError{Field: field + ".module", Code: module.ErrCodeInvalidValue, Message: "module not found"})
+				continue
+			}
+
+			return nil, err
+		}
+
+		var versionID int
+		err = q.QueryRowContext(ctx, `
+			SELECT id
+			FROM module_versions
+			WHERE module_id = $1
+				AND version = $2
+				AND status = 'published'`,
+			m.ID, p.Version,
+		).Scan(&versionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			errs = append(errs, module.FieldError{Field: field + ".version", Code: module.ErrCodeInvalidValue, Message: "version not published"})
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, [2]int{m.ID, versionID})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return ids, nil
+}
+
+// insertPins inserts the pinned versions of a module set, as returned by
+// resolvePins.
+func insertPins(ctx context.Context, q db.Querier, setID int, versionIDs [][2]int) error {
+	for i, ids := range versionIDs {
+		if _, err := q.ExecContext(ctx, `
+			INSERT INTO module_set_versions (set_id, module_id, module_version_id, position)
+			VALUES ($1, $2, $3, $4)`,
+			setID, ids[0], ids[1], i,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryModuleSet returns the module set with the given public ID if readable
+// by the requester, resolving its pinned versions. Pins of modules that are no
+// longer readable by the requester are omitted.
+func queryModuleSet(ctx context.Context, q db.Querier, u *module.User, publicID string) (module.ModuleSet, error) {
+	var set module.ModuleSet
+
+	err := q.QueryRowContext(ctx, `
+		SELECT s.id, s.public_id, s.owner_id, COALESCE(u.name, ''), s.name, s.description,
+			s.visibility, s.created_at, s.updated_at
+		FROM module_sets s
+		JOIN users u ON u.id = s.owner_id
+		WHERE s.public_id = $1`,
+		publicID,
+	).Scan(&set.ID, &set.PublicID, &set.OwnerID, &set.Owner, &set.Name, &set.Description,
+		&set.Visibility, &set.CreatedAt, &set.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !set.ReadableBy(u)) {
+		return module.ModuleSet{}, NewError(http.StatusNotFound, CodeNotFound, "module set not found")
+	}
+
+	if err != nil {
+		return module.ModuleSet{}, err
+	}
+
+	var uid interface{}
+	if u != nil {
+		uid = u.ID
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT m.name, mv.version, mv.checksum, mv.artifact_size, mv.yanked, mv.status,
+			COALESCE(mv.sdk_compat, ''), mv.created_at
+		FROM module_set_versions sv
+		JOIN modules m ON m.id = sv.module_id
+		JOIN module_versions mv ON mv.id = sv.module_version_id
+		WHERE sv.set_id = $1
+			AND m.deleted_at IS NULL
+			AND NOT m.hidden
+			AND module_readable(m.id, $2)
+		ORDER BY sv.position`,
+		set.ID, uid,
+	)
+	if err != nil {
+		return module.ModuleSet{}, err
+	}
+	defer rows.Close()
+
+	set.Pins = []module.ModuleSetPin{}
+	for rows.Next() {
+		var (
+			p  module.ModuleSetPin
+			mv module.ModuleVersion
+		)
+
+		if err := rows.Scan(&p.Module, &mv.Version, &mv.Checksum, &mv.ArtifactSize, &mv.Yanked, &mv.Status,
+			&mv.SDKCompat, &mv.CreatedAt); err != nil {
+			return module.ModuleSet{}, err
+		}
+
+		p.Version = mv.Version
+		p.Resolved = &mv
+		set.Pins = append(set.Pins, p)
+	}
+
+	return set, rows.Err()
+}
+
+func writeModuleSet(w http.ResponseWriter, status int, set module.ModuleSet) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(set) // nolint: errcheck
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/cosmos/atlas/sitemap"
+	"github.com/cosmos/atlas/storage"
+)
+
+var sitemapPageRegex = regexp.MustCompile(`^/sitemap-([1-9][0-9]*)\.xml$`)
+
+// Sitemap returns the handler of /sitemap.xml and its /sitemap-<n>.xml pages,
+// serving the files last generated by the sitemap job.
+func Sitemap(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := sitemap.IndexKey
+		if m := sitemapPageRegex.FindStringSubmatch(r.URL.Path); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			key = sitemap.PageKey(n)
+		} else if r.URL.Path != "/sitemap.xml" {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "sitemap not found"))
+			return
+		}
+
+		rc, err := store.Get(r.Context(), key)
+		if err != nil {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "sitemap not found"))
+			return
+		}
+		defer rc.Close()
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		io.Copy(w, rc) // nolint: errcheck
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/jobs"
+)
+
+// ReleaseVersion serves POST /api/v1/modules/{id}/versions/{version}/release,
+// releasing a staged version: the module is updated with the metadata of the
+// version's manifest and the version becomes resolvable, atomically within the
+// request's transaction. Discarding a staged version instead is done with
+// DeleteVersion. Only module owners may release a version.
+func (s *Server) ReleaseVersion(w http.ResponseWriter, r *http.Request, m moduleAccess, version string) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may release a version"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	existing, found, err := lockModule(ctx, q, m.User, "m.id = $1", m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !found {
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found"))
+		return
+	}
+
+	if err := existing.CheckWritable(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	mv, published, err := queryPublishedVersion(ctx, q, m.ID, version)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !published {
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+	}
+
+	if err := mv.Release(); err != nil {
+		WriteError(w, NewError(http.StatusConflict, CodeVersionConflict, err.Error()))
+		return
+	}
+
+	released, err := writeModule(ctx, q, m.User, mv.Manifest, existing, true)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `UPDATE module_versions SET status = $2 WHERE id = $1`, mv.ID, mv.Status); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := s.queue.Enqueue(ctx, jobs.KindVerifyTag, jobs.VerifyTagPayload{ModuleVersionID: mv.ID}, time.Now()); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := s.queue.Enqueue(ctx, jobs.KindExtractDocs, jobs.DocsPayload{ModuleVersionID: mv.ID}, time.Now()); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	writePublished(w, http.StatusOK, released)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/cosmos/atlas/module"
+)
+
+const statsTopLimit = 10
+
+// Stats returns the handler of GET /api/v1/stats, serving the registry-wide
+// metrics last computed by the stats rollup job.
+func Stats(sqlDB *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := queryStats(r.Context(), sqlDB)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		json.NewEncoder(w).Encode(stats) // nolint: errcheck
+	}
+}
+
+func queryStats(ctx context.Context, sqlDB *sql.DB) (module.RegistryStats, error) {
+	stats := module.RegistryStats{
+		WeeklyPublishes: []module.WeeklyPublishes{},
+		TopKeywords:     []module.KeywordPopularity{},
+		TopPublishers:   []module.PublisherStats{},
+	}
+
+	err := sqlDB.QueryRowContext(ctx, `SELECT modules, versions, publishers, downloads FROM registry_stats`).
+		Scan(&stats.Modules, &stats.Versions, &stats.Publishers, &stats.Downloads)
+	if err != nil {
+		return stats, err
+	}
+
+	rows, err := sqlDB.QueryContext(ctx, `SELECT week, publishes FROM registry_weekly_publishes ORDER BY week`)
+	if err != nil {
+		return stats, err
+	}
+	for rows.Next() {
+		var wp module.WeeklyPublishes
+		if err := rows.Scan(&wp.Week, &wp.Publishes); err != nil {
+			rows.Close()
+			return stats, err
+		}
+
+		stats.WeeklyPublishes = append(stats.WeeklyPublishes, wp)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	rows, err = sqlDB.QueryContext(ctx, `
+		SELECT name, module_count, recent_publishes FROM keyword_popularity
+		WHERE module_count > 0
+		ORDER BY module_count DESC, name
+		LIMIT $1`,
+		statsTopLimit,
+	)
+	if err != nil {
+		return stats, err
+	}
+	for rows.Next() {
+		var kp module.KeywordPopularity
+		if err := rows.Scan(&kp.Name, &kp.ModuleCount, &kp.RecentPublishes); err != nil {
+			rows.Close()
+			return stats, err
+		}
+
+		stats.TopKeywords = append(stats.TopKeywords, kp)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	rows, err = sqlDB.QueryContext(ctx, `
+		SELECT u.name, COUNT(*) AS modules
+		FROM modules m
+		JOIN users u ON u.id = m.author
+		WHERE m.visibility = 'public'
+			AND m.deleted_at IS NULL
+			AND NOT m.hidden
+		GROUP BY u.name
+		ORDER BY modules DESC, u.name
+		LIMIT $1`,
+		statsTopLimit,
+	)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ps module.PublisherStats
+		if err := rows.Scan(&ps.Name, &ps.Modules); err != nil {
+			return stats, err
+		}
+
+		stats.TopPublishers = append(stats.TopPublishers, ps)
+	}
+
+	return stats, rows.Err()
+}
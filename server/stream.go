@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// streamFlushInterval defines the number of elements after which a streamed
+// list is flushed to the client.
+const streamFlushInterval = 100
+
+// jsonList streams a JSON array to a response element by element, so that
+// large lists are encoded without being built in memory. The array may be
+// enclosed in an object, e.g. {"changes":[...],"next_cursor":"..."}, by
+// passing the JSON preceding and following it.
+//
+// The response header is only written with the first element or on Close, so
+// errors returned before then may still be written with WriteError. Once
+// streaming has started, a failure can only be signaled by aborting the
+// response, as Fail does.
+type jsonList struct {
+	w       http.ResponseWriter
+	prefix  string
+	n       int
+	started bool
+}
+
+func newJSONList(w http.ResponseWriter, prefix string) *jsonList {
+	return &jsonList{w: w, prefix: prefix}
+}
+
+// Started returns true once the response header has been written.
+func (l *jsonList) Started() bool {
+	return l.started
+}
+
+func (l *jsonList) start() error {
+	if l.started {
+		return nil
+	}
+
+	l.started = true
+	l.w.Header().Set("Content-Type", "application/json")
+	_, err := io.WriteString(l.w, l.prefix+"[")
+	return err
+}
+
+// Append encodes an element of the array.
+func (l *jsonList) Append(v interface{}) error {
+	if err := l.start(); err != nil {
+		return err
+	}
+
+	bz, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if l.n > 0 {
+		bz = append([]byte{','}, bz...)
+	}
+
+	if _, err := l.w.Write(bz); err != nil {
+		return err
+	}
+
+	l.n++
+	if l.n%streamFlushInterval == 0 {
+		if f, ok := l.w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	return nil
+}
+
+// Close ends the array followed by the given JSON, e.g. the remaining fields
+// of an enclosing object.
+func (l *jsonList) Close(suffix string) error {
+	if err := l.start(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(l.w, "]"+suffix+"\n")
+	return err
+}
+
+// Fail writes err if streaming has not started yet, and otherwise aborts the
+// response so that the client sees a truncated rather than a partial but
+// valid list.
+func (l *jsonList) Fail(err error) {
+	if !l.started {
+		WriteError(l.w, err)
+		return
+	}
+
+	panic(http.ErrAbortHandler)
+}
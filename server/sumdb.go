@@ -0,0 +1,152 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/sumdb/tlog"
+
+	"github.com/cosmos/atlas/checksumdb"
+)
+
+const sumDBPathPrefix = "/api/v1/sumdb/"
+
+var sumDBLookupRegex = regexp.MustCompile(`^lookup/(.+)@([^@/]+)$`)
+
+// ChecksumDB returns the handler of /api/v1/sumdb/, serving the checksum
+// transparency log over the Go checksum database protocol: /latest serves the
+// signed latest tree head, /lookup/{module}@{version} the record of a module
+// version and /tile/... the tiles of the log. Clients verifying tree heads
+// against the registry's public key can detect any rewrite of a published
+// version.
+func ChecksumDB(sqlDB *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, sumDBPathPrefix)
+
+		switch {
+		case path == "latest":
+			_, signed, err := checksumdb.Latest(r.Context(), sqlDB)
+			if err != nil {
+				writeSumDBError(w, err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+			w.Write(signed) // nolint: errcheck
+
+		case strings.HasPrefix(path, "lookup/"):
+			m := sumDBLookupRegex.FindStringSubmatch(path)
+			if m == nil {
+				WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "lookup path must be {module}@{version}"))
+				return
+			}
+
+			sumDBLookup(w, r, sqlDB, m[1], m[2])
+
+		case strings.HasPrefix(path, "tile/"):
+			sumDBTile(w, r, sqlDB, path)
+
+		default:
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+		}
+	}
+}
+
+// sumDBLookup serves the record ID and data of a logged module version,
+// followed by the signed latest tree head.
+func sumDBLookup(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, name, version string) {
+	id, err := checksumdb.Lookup(r.Context(), sqlDB, name, version)
+	if err != nil {
+		writeSumDBError(w, err)
+		return
+	}
+
+	records, err := checksumdb.ReadRecords(r.Context(), sqlDB, id, 1)
+	if err != nil {
+		writeSumDBError(w, err)
+		return
+	}
+
+	_, signed, err := checksumdb.Latest(r.Context(), sqlDB)
+	if err != nil {
+		writeSumDBError(w, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d\n", id)
+	buf.Write(records[0])
+	buf.WriteString("\n")
+	buf.Write(signed)
+
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	w.Write(buf.Bytes()) // nolint: errcheck
+}
+
+// sumDBTile serves a hash or data tile of the log. Tiles beyond the latest tree
+// head do not exist yet and are not found; full tiles never change and may be
+// cached indefinitely.
+func sumDBTile(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, path string) {
+	t, err := tlog.ParseTilePath(path)
+	if err != nil || t.H != checksumdb.TileHeight {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+		return
+	}
+
+	size, _, err := checksumdb.Latest(r.Context(), sqlDB)
+	if err != nil {
+		writeSumDBError(w, err)
+		return
+	}
+
+	level := t.L
+	if level < 0 {
+		level = 0
+	}
+
+	// the number of records needed for the tile's last hash to be stored
+	needed := ((t.N << uint(t.H)) + int64(t.W)) << uint(level*t.H)
+	if needed > size {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+		return
+	}
+
+	var data []byte
+	if t.L < 0 {
+		records, err := checksumdb.ReadRecords(r.Context(), sqlDB, t.N<<uint(t.H), int64(t.W))
+		if err != nil {
+			writeSumDBError(w, err)
+			return
+		}
+
+		data = bytes.Join(records, nil)
+	} else {
+		if data, err = checksumdb.ReadTileData(r.Context(), sqlDB, t); err != nil {
+			writeSumDBError(w, err)
+			return
+		}
+	}
+
+	if t.W == 1<<uint(t.H) {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data) // nolint: errcheck
+}
+
+// writeSumDBError writes the error envelope of a checksum database error,
+// mapping modules and records missing from the log to not found.
+func writeSumDBError(w http.ResponseWriter, err error) {
+	if errors.Is(err, checksumdb.ErrNotLogged) {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, err.Error()))
+		return
+	}
+
+	WriteError(w, err)
+}
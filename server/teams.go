@@ -0,0 +1,134 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	teamsPathPrefix = "/api/v1/teams/"
+
+	defaultChangelogLimit = 50
+	maxChangelogLimit     = 200
+)
+
+type (
+	// TeamChangelogEntry defines the release notes of a version of one of a
+	// team's modules.
+	TeamChangelogEntry struct {
+		Module      string    `json:"module"`
+		Version     string    `json:"version"`
+		Changelog   string    `json:"changelog"`
+		Yanked      bool      `json:"yanked"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+
+	// TeamChangelogPage defines a page of a team's changelog feed.
+	TeamChangelogPage struct {
+		Team    string               `json:"team"`
+		Entries []TeamChangelogEntry `json:"entries"`
+	}
+)
+
+// TeamChangelog returns the handler of
+// GET /api/v1/teams/{team}/changelog?limit=<n>&offset=<n>, aggregating the
+// release notes of every published version of a team's public modules, newest
+// first. A team is the GitHub organization or domain of an approved publisher
+// verification, and its modules are those authored by the verified user, so
+// that chains tracking a vendor get a single upgrade feed.
+func TeamChangelog(sqlDB *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), teamsPathPrefix), "/")
+		if len(segments) != 2 || segments[1] != "changelog" {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+			return
+		}
+
+		team, err := url.PathUnescape(segments[0])
+		if err != nil || team == "" {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "resource not found"))
+			return
+		}
+
+		limit, err := parseQueryInt(r, "limit", defaultChangelogLimit)
+		if err != nil || limit < 1 || limit > maxChangelogLimit {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "limit must be between 1 and 200"))
+			return
+		}
+
+		offset, err := parseQueryInt(r, "offset", 0)
+		if err != nil || offset < 0 {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "offset must not be negative"))
+			return
+		}
+
+		var (
+			subject string
+			userID  int
+		)
+
+		err = sqlDB.QueryRowContext(r.Context(), `
+			SELECT subject, user_id
+			FROM publisher_verifications
+			WHERE LOWER(subject) = LOWER($1)
+				AND status = $2
+			ORDER BY kind
+			LIMIT 1`,
+			team, module.VerificationApproved,
+		).Scan(&subject, &userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "team not found"))
+			return
+		}
+
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		rows, err := sqlDB.QueryContext(r.Context(), `
+			SELECT m.name, mv.version, COALESCE(mv.changelog, ''), mv.yanked, mv.created_at
+			FROM module_versions mv
+			JOIN modules m ON m.id = mv.module_id
+			WHERE m.author = $1
+				AND m.visibility = 'public'
+				AND NOT m.hidden
+				AND m.deleted_at IS NULL
+				AND mv.status = 'published'
+			ORDER BY mv.created_at DESC, mv.id DESC
+			LIMIT $2 OFFSET $3`,
+			userID, limit, offset,
+		)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		defer rows.Close()
+
+		page := TeamChangelogPage{Team: subject, Entries: []TeamChangelogEntry{}}
+		for rows.Next() {
+			var e TeamChangelogEntry
+			if err := rows.Scan(&e.Module, &e.Version, &e.Changelog, &e.Yanked, &e.PublishedAt); err != nil {
+				WriteError(w, err)
+				return
+			}
+
+			page.Entries = append(page.Entries, e)
+		}
+
+		if err := rows.Err(); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page) // nolint: errcheck
+	}
+}
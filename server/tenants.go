@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/jobs"
+)
+
+// Tenants defines a multi-tenant deployment serving an isolated registry per
+// configured tenant, each run by its own Server on the tenant's schema and
+// storage prefix. Requests are routed to a tenant by their Host header or,
+// failing that, by their longest matching path prefix, which is stripped.
+type Tenants struct {
+	names    []string
+	servers  map[string]*Server
+	hosts    map[string]*Server
+	prefixes []tenantPrefix
+}
+
+type tenantPrefix struct {
+	prefix  string
+	handler http.Handler
+}
+
+// NewTenants returns the Servers of every tenant of the given configuration,
+// which must be valid and define tenants. The options are applied to every
+// tenant's Server; WithDB and WithStorage are rejected as tenants must not
+// share a database or storage.
+func NewTenants(cfg config.Config, opts ...Option) (*Tenants, error) {
+	if len(cfg.Tenants) == 0 {
+		return nil, errors.New("configuration defines no tenants")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	probe := &Server{jobHandlers: make(map[string]jobs.Handler)}
+	for _, opt := range opts {
+		opt(probe)
+	}
+
+	if probe.primary != nil || probe.store != nil {
+		return nil, errors.New("tenants must use their configured database and storage")
+	}
+
+	t := &Tenants{
+		servers: make(map[string]*Server, len(cfg.Tenants)),
+		hosts:   make(map[string]*Server),
+	}
+
+	for _, tenant := range cfg.Tenants {
+		tcfg, err := cfg.ForTenant(tenant)
+		if err != nil {
+			t.closeDBs()
+			return nil, err
+		}
+
+		s, err := New(tcfg, opts...)
+		if err != nil {
+			t.closeDBs()
+			return nil, fmt.Errorf("tenant %s: %w", tenant.Name, err)
+		}
+
+		t.names = append(t.names, tenant.Name)
+		t.servers[tenant.Name] = s
+
+		for _, h := range tenant.Hosts {
+			t.hosts[strings.ToLower(h)] = s
+		}
+
+		if tenant.PathPrefix != "" {
+			t.prefixes = append(t.prefixes, tenantPrefix{
+				prefix:  tenant.PathPrefix,
+				handler: http.StripPrefix(tenant.PathPrefix, s.Handler()),
+			})
+		}
+	}
+
+	// match the longest prefix first, e.g. /acme/labs before /acme
+	sort.Slice(t.prefixes, func(i, j int) bool {
+		return len(t.prefixes[i].prefix) > len(t.prefixes[j].prefix)
+	})
+
+	return t, nil
+}
+
+// Tenant returns the Server of the named tenant, or nil if there is none.
+func (t *Tenants) Tenant(name string) *Server {
+	return t.servers[name]
+}
+
+// Handler returns the HTTP handler routing requests to the tenants' Servers.
+// Requests matching no tenant are rejected with a 404.
+func (t *Tenants) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		if s, ok := t.hosts[strings.ToLower(host)]; ok {
+			s.Handler().ServeHTTP(w, r)
+			return
+		}
+
+		for _, p := range t.prefixes {
+			if r.URL.Path == p.prefix || strings.HasPrefix(r.URL.Path, p.prefix+"/") {
+				p.handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "unknown registry"))
+	})
+}
+
+// Start starts every tenant's Server. If a tenant fails to start, the tenants
+// already started are left running for the caller to Shutdown.
+func (t *Tenants) Start(ctx context.Context) error {
+	for _, name := range t.names {
+		if err := t.servers[name].Start(ctx); err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Shutdown shuts down every tenant's Server, returning the first error.
+func (t *Tenants) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, name := range t.names {
+		if err := t.servers[name].Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+func (t *Tenants) closeDBs() {
+	for _, s := range t.servers {
+		s.closeDB() // nolint: errcheck
+	}
+}
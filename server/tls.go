@@ -0,0 +1,44 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/cosmos/atlas/config"
+)
+
+// NewTLSConfig returns the TLS configuration of the server, loading the
+// configured certificate/key pair or provisioning and renewing certificates
+// from Let's Encrypt for the autocert domains.
+//
+// In autocert mode the returned handler must be served on port 80 to answer
+// HTTP-01 challenges; it redirects all other requests to HTTPS. It is nil
+// when serving a static certificate.
+func NewTLSConfig(cfg config.TLSConfig) (*tls.Config, http.Handler, error) {
+	if len(cfg.AutocertDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+
+		tlsCfg := m.TLSConfig()
+		tlsCfg.MinVersion = tls.VersionTLS12
+
+		return tlsCfg, m.HTTPHandler(nil), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil, nil
+}
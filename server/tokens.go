@@ -0,0 +1,145 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/lib/pq"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+)
+
+const (
+	tokenPath      = "/api/v1/me/token"
+	tokenCIDRsPath = tokenPath + "/cidrs"
+
+	// tokenSize defines the number of random bytes of a generated API token.
+	tokenSize = 32
+)
+
+// TokenResponse defines the API token of the requester and its IP allowlist.
+// The token is only returned when it was just generated.
+type TokenResponse struct {
+	Token string   `json:"token,omitempty"`
+	CIDRs []string `json:"cidrs"`
+}
+
+// serveToken serves POST /api/v1/me/token, replacing the requester's API token
+// with a newly generated one while keeping its IP allowlist. Creating a token
+// is subject to the two-factor policy. Other methods are not allowed.
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	verified, err := s.verifyTwoFactor(r.Context(), u, r.Header.Get(HeaderOTP))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := s.cfg.Policy.CheckTwoFactor(*u, policy.OpTokenCreate, verified); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	bz := make([]byte, tokenSize)
+	if _, err := rand.Read(bz); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	resp := TokenResponse{Token: hex.EncodeToString(bz)}
+	if err := db.Conn(r.Context(), s.primary).QueryRowContext(r.Context(), `
+		UPDATE users
+		SET api_token = $2
+		WHERE id = $1
+		RETURNING api_token_cidrs`,
+		u.ID, resp.Token,
+	).Scan(pq.Array(&resp.CIDRs)); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	writeToken(w, resp)
+}
+
+// serveTokenCIDRs serves PUT /api/v1/me/token/cidrs, replacing the IP
+// allowlist of the requester's API token with the CIDR ranges of the request
+// body. An empty list lifts the restriction, unless the token policy requires
+// an allowlist. Other methods are not allowed.
+func (s *Server) serveTokenCIDRs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	var req TokenResponse
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	if err := module.ValidateCIDRs(req.CIDRs); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if len(req.CIDRs) == 0 && s.cfg.Policy.Tokens.RequireAllowlist {
+		WriteError(w, module.ValidationErrors{{
+			Field:   "cidrs",
+			Code:    module.ErrCodeRequired,
+			Message: "API tokens must be restricted to an IP allowlist",
+		}})
+		return
+	}
+
+	resp := TokenResponse{CIDRs: req.CIDRs}
+	if resp.CIDRs == nil {
+		resp.CIDRs = []string{}
+	}
+
+	if _, err := db.Conn(r.Context(), s.primary).ExecContext(r.Context(), `
+		UPDATE users
+		SET api_token_cidrs = $2
+		WHERE id = $1`,
+		u.ID, pq.Array(resp.CIDRs),
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	writeToken(w, resp)
+}
+
+func writeToken(w http.ResponseWriter, t TokenResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t) // nolint: errcheck
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/totp"
+)
+
+const (
+	twoFactorPath       = "/api/v1/me/two-factor"
+	twoFactorVerifyPath = twoFactorPath + "/verify"
+
+	// twoFactorIssuer defines the issuer shown by authenticator apps for the
+	// registry's TOTP secrets.
+	twoFactorIssuer = "Atlas"
+
+	// recoveryCodeCount defines the number of recovery codes generated when
+	// two-factor authentication is enabled.
+	recoveryCodeCount = 10
+)
+
+type (
+	// TwoFactorEnrollment defines a pending TOTP secret of the requester along
+	// with the otpauth URI enrolling it in an authenticator app.
+	TwoFactorEnrollment struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+
+	// TwoFactorVerifyRequest defines a code of the pending TOTP secret,
+	// proving it was enrolled in an authenticator app.
+	TwoFactorVerifyRequest struct {
+		Code string `json:"code"`
+	}
+
+	// TwoFactorRecoveryCodes defines the single-use recovery codes generated
+	// when two-factor authentication is enabled. They are only returned once.
+	TwoFactorRecoveryCodes struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+)
+
+// serveTwoFactor serves the two-factor authentication endpoints of the
+// requester:
+//
+//	POST /api/v1/me/two-factor generates a pending TOTP secret
+//	DELETE /api/v1/me/two-factor disables two-factor authentication, requiring
+//	  a valid code of the second factor
+//
+// The pending secret takes effect once verified through
+// POST /api/v1/me/two-factor/verify.
+func (s *Server) serveTwoFactor(w http.ResponseWriter, r *http.Request) {
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.enrollTwoFactor(w, r, u)
+
+	case http.MethodDelete:
+		s.disableTwoFactor(w, r, u)
+
+	default:
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+	}
+}
+
+// enrollTwoFactor stores a new pending TOTP secret of the user, replacing any
+// earlier pending one. Users having two-factor authentication enabled must
+// disable it first.
+func (s *Server) enrollTwoFactor(w http.ResponseWriter, r *http.Request, u *module.User) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	res, err := db.Conn(r.Context(), s.primary).ExecContext(r.Context(), `
+		UPDATE users
+		SET totp_secret = $2
+		WHERE id = $1
+			AND NOT totp_enabled`,
+		u.ID, secret,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		WriteError(w, NewError(http.StatusConflict, CodeTwoFactorConflict, "two-factor authentication is already enabled"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(TwoFactorEnrollment{ // nolint: errcheck
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(twoFactorIssuer, u.Email, secret),
+	})
+}
+
+// disableTwoFactor disables two-factor authentication of the user, removing
+// its TOTP secret and recovery codes. The request must carry a valid code of
+// the second factor.
+func (s *Server) disableTwoFactor(w http.ResponseWriter, r *http.Request, u *module.User) {
+	verified, err := s.verifyTwoFactor(r.Context(), u, r.Header.Get(HeaderOTP))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !u.TOTPEnabled {
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "two-factor authentication is not enabled"))
+		return
+	}
+
+	if !verified {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "a valid two-factor code is required"))
+		return
+	}
+
+	q := db.Conn(r.Context(), s.primary)
+
+	if _, err := q.ExecContext(r.Context(), `
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled = FALSE
+		WHERE id = $1`,
+		u.ID,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(r.Context(), `DELETE FROM recovery_codes WHERE user_id = $1`, u.ID); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveTwoFactorVerify serves POST /api/v1/me/two-factor/verify, enabling
+// two-factor authentication of the requester once the request body carries a
+// valid code of its pending TOTP secret. Any earlier recovery codes are
+// replaced by newly generated ones, returned once. Other methods are not
+// allowed.
+func (s *Server) serveTwoFactorVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	var req TwoFactorVerifyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	var secret sql.NullString
+	if err := q.QueryRowContext(ctx, `
+		SELECT totp_secret, totp_enabled
+		FROM users
+		WHERE id = $1
+		FOR UPDATE`,
+		u.ID,
+	).Scan(&secret, &u.TOTPEnabled); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	switch {
+	case u.TOTPEnabled:
+		WriteError(w, NewError(http.StatusConflict, CodeTwoFactorConflict, "two-factor authentication is already enabled"))
+		return
+
+	case !secret.Valid:
+		WriteError(w, NewError(http.StatusNotFound, CodeNotFound, "no pending two-factor enrollment"))
+		return
+	}
+
+	step, ok := totp.ValidateAfter(secret.String, req.Code, time.Now(), 0)
+	if !ok {
+		WriteError(w, module.ValidationErrors{{
+			Field:   "code",
+			Code:    module.ErrCodeInvalidValue,
+			Message: "invalid two-factor code",
+		}})
+		return
+	}
+
+	codes, hashes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE users
+		SET totp_enabled = TRUE, totp_last_step = $2
+		WHERE id = $1`,
+		u.ID, step,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, u.ID); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	for _, hash := range hashes {
+		if _, err := q.ExecContext(ctx, `
+			INSERT INTO recovery_codes (user_id, hash)
+			VALUES ($1, $2)`,
+			u.ID, hash,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(TwoFactorRecoveryCodes{RecoveryCodes: codes}) // nolint: errcheck
+}
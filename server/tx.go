@@ -0,0 +1,88 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/cosmos/atlas/db"
+)
+
+// Transactions returns a middleware running every mutating request (i.e. not
+// GET, HEAD or OPTIONS) in a transaction of the primary database, carried by
+// the request's context so that every query made through db.Conn or db.InTx
+// joins it. The transaction is committed if the handler responds with a
+// status below 400 and rolled back otherwise, or if the handler panics. The
+// response is buffered until the transaction is committed, so clients never
+// observe the success of a request whose changes were lost.
+func Transactions(primary *sql.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tx, err := primary.BeginTx(r.Context(), nil)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+			defer tx.Rollback() // nolint: errcheck
+
+			bw := &bufferedWriter{header: w.Header().Clone()}
+			next.ServeHTTP(bw, r.WithContext(db.WithTx(r.Context(), tx)))
+
+			if bw.status == 0 {
+				bw.status = http.StatusOK
+			}
+
+			if bw.status < http.StatusBadRequest {
+				if err := tx.Commit(); err != nil {
+					log.Printf("failed to commit %s %s (request %s): %v", r.Method, r.URL.Path, r.Header.Get(HeaderRequestID), err)
+					WriteError(w, err)
+					return
+				}
+			}
+
+			bw.flush(w)
+		})
+	}
+}
+
+// bufferedWriter defines an http.ResponseWriter buffering the response until
+// it is flushed to the underlying writer.
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (bw *bufferedWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *bufferedWriter) WriteHeader(status int) {
+	if bw.status == 0 {
+		bw.status = status
+	}
+}
+
+func (bw *bufferedWriter) Write(p []byte) (int, error) {
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+
+	return bw.body.Write(p)
+}
+
+func (bw *bufferedWriter) flush(w http.ResponseWriter) {
+	for k, v := range bw.header {
+		w.Header()[k] = v
+	}
+
+	w.WriteHeader(bw.status)
+	w.Write(bw.body.Bytes()) // nolint: errcheck
+}
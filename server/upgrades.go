@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/cosmos/atlas/chainregistry"
+	"github.com/cosmos/atlas/module"
+)
+
+const (
+	upgradesPath = "/api/v1/upgrades"
+
+	// maxUpgradeDependencies bounds the dependencies of a single upgrade
+	// request.
+	maxUpgradeDependencies = 500
+)
+
+type (
+	// UpgradeDependency defines a dependency pinned to its current version. The
+	// module is identified by its name or, e.g. for requirements of a go.mod,
+	// by a Go module path matched against the repositories of registered
+	// modules.
+	UpgradeDependency struct {
+		Name    string `json:"name,omitempty"`
+		Path    string `json:"path,omitempty"`
+		Version string `json:"version"`
+	}
+
+	// UpgradesRequest defines the request of the upgrade advisor endpoint.
+	UpgradesRequest struct {
+		Dependencies []UpgradeDependency `json:"dependencies"`
+	}
+
+	// UpgradeResult defines the upgrade advice of a single dependency, or the
+	// error preventing it, e.g. an unknown module.
+	UpgradeResult struct {
+		Name    string `json:"name,omitempty"`
+		Path    string `json:"path,omitempty"`
+		Version string `json:"version"`
+		Error   *Error `json:"error,omitempty"`
+		*module.UpgradeAdvice
+	}
+
+	// UpgradesResponse defines the response of the upgrade advisor endpoint,
+	// with a result per requested dependency in request order.
+	UpgradesResponse struct {
+		Results []UpgradeResult `json:"results"`
+	}
+)
+
+// serveUpgrades serves POST /api/v1/upgrades, advising the available patch,
+// minor and major upgrades of a set of dependencies pinned to their current
+// versions, with yanked, advisory and Cosmos SDK compatibility warnings.
+// Dependencies on modules unknown to or not readable by the requester get a
+// per-dependency error rather than failing the request. Upgrades link to
+// their release notes on the module's Git provider.
+func (s *Server) serveUpgrades(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	var req UpgradesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	if len(req.Dependencies) == 0 || len(req.Dependencies) > maxUpgradeDependencies {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "dependencies must list between 1 and 500 modules"))
+		return
+	}
+
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	sqlDB := s.reader()
+	resp := UpgradesResponse{Results: make([]UpgradeResult, len(req.Dependencies))}
+
+	var paths *goModulePaths
+	for i, dep := range req.Dependencies {
+		resp.Results[i] = UpgradeResult{Name: dep.Name, Path: dep.Path, Version: dep.Version}
+
+		if _, err := semver.NewVersion(dep.Version); err != nil {
+			resp.Results[i].Error = NewError(http.StatusBadRequest, CodeBadRequest, "version must be a valid semantic version")
+			continue
+		}
+
+		if dep.Name == "" && dep.Path != "" {
+			if paths == nil {
+				if paths, err = queryGoModulePaths(r.Context(), sqlDB); err != nil {
+					WriteError(w, err)
+					return
+				}
+			}
+
+			if dep.Name = paths.match(dep.Path, dep.Version); dep.Name == "" {
+				resp.Results[i].Error = NewError(http.StatusNotFound, CodeModuleNotFound, "no registered module matches the path")
+				continue
+			}
+
+			resp.Results[i].Name = dep.Name
+		}
+
+		m, err := s.lookupModule(r.Context(), u, dep.Name)
+		if err == nil {
+			var advice module.UpgradeAdvice
+			if advice, err = adviseUpgrade(r.Context(), sqlDB, m.ID, dep.Version); err == nil {
+				resp.Results[i].UpgradeAdvice = &advice
+				continue
+			}
+		}
+
+		apiErr := ToError(err)
+		if apiErr.Status >= http.StatusInternalServerError {
+			WriteError(w, err)
+			return
+		}
+
+		resp.Results[i].Error = apiErr
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp) // nolint: errcheck
+}
+
+// adviseUpgrade returns the upgrade advice of a module's current version,
+// including the module's archival and deprecation.
+func adviseUpgrade(ctx context.Context, sqlDB *sql.DB, moduleID int, current string) (module.UpgradeAdvice, error) {
+	versions, err := queryCompatVersions(ctx, sqlDB, `
+		SELECT '', version, COALESCE(sdk_compat, ''), yanked, status
+		FROM module_versions
+		WHERE module_id = $1`,
+		moduleID,
+	)
+	if err != nil {
+		return module.UpgradeAdvice{}, err
+	}
+
+	advisories, err := queryAdvisories(ctx, sqlDB, moduleID, true)
+	if err != nil {
+		return module.UpgradeAdvice{}, err
+	}
+
+	var (
+		repo           string
+		archived       bool
+		deprecatedAt   sql.NullTime
+		deprecationMsg string
+		replacedBy     sql.NullString
+	)
+
+	if err := sqlDB.QueryRowContext(ctx, `
+		SELECT m.repo, m.archived, m.deprecated_at, COALESCE(m.deprecation_message, ''), r.name
+		FROM modules m
+		LEFT JOIN modules r ON r.id = m.replaced_by AND r.deleted_at IS NULL
+		WHERE m.id = $1`,
+		moduleID,
+	).Scan(&repo, &archived, &deprecatedAt, &deprecationMsg, &replacedBy); err != nil {
+		return module.UpgradeAdvice{}, err
+	}
+
+	advice, err := module.AdviseUpgrade(current, versions[""], advisories)
+	if err != nil {
+		return advice, err
+	}
+
+	advice.Archived = archived
+	advice.Deprecation = scanDeprecation(deprecatedAt, deprecationMsg, replacedBy)
+
+	if hr, ok := module.ParseHostedRepo(repo); ok {
+		for i := range advice.Upgrades {
+			advice.Upgrades[i].ChangelogURL = hr.ReleaseURL(advice.Upgrades[i].Version)
+		}
+	}
+
+	return advice, nil
+}
+
+// goModulePaths maps the Go module paths of registered modules' repositories
+// to their names.
+type goModulePaths struct {
+	ids   map[string]int
+	names map[int]string
+}
+
+// queryGoModulePaths returns the Go module paths of every live module.
+func queryGoModulePaths(ctx context.Context, sqlDB *sql.DB) (*goModulePaths, error) {
+	rows, err := sqlDB.QueryContext(ctx, `
+		SELECT id, name, repo
+		FROM modules
+		WHERE deleted_at IS NULL`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paths := &goModulePaths{ids: make(map[string]int), names: make(map[int]string)}
+	for rows.Next() {
+		var (
+			id         int
+			name, repo string
+		)
+
+		if err := rows.Scan(&id, &name, &repo); err != nil {
+			return nil, err
+		}
+
+		if path := module.GoModulePath(repo); path != "" {
+			paths.ids[path] = id
+			paths.names[id] = name
+		}
+	}
+
+	return paths, rows.Err()
+}
+
+// match returns the name of the module whose repository matches a Go module
+// path, ignoring its major version suffix, or an empty string if none does.
+func (p *goModulePaths) match(path, version string) string {
+	for id := range chainregistry.Match([]chainregistry.Requirement{{Path: path, Version: version}}, p.ids) {
+		return p.names[id]
+	}
+
+	return ""
+}
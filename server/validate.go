@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+)
+
+const validatePath = modulesPathPrefix + "validate"
+
+// serveValidate serves POST /api/v1/modules/validate, a dry run of publishing
+// the manifest in the request body. The manifest format is taken from the
+// Content-Type, or detected if it is not a manifest type. It runs manifest
+// validation, the publish policy and dependency resolution as the requester,
+// writing nothing, and returns the parsed manifest or every violation as
+// field-level errors. Other methods are not allowed.
+func (s *Server) serveValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, NewError(http.StatusMethodNotAllowed, CodeBadRequest, "method not allowed"))
+		return
+	}
+
+	u, err := s.requester(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if u == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	bz, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	manifest, err := module.ParseManifest(bz, module.FormatFromContentType(r.Header.Get("Content-Type")))
+	if err != nil {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("invalid manifest: %v", err)))
+		return
+	}
+
+	var errs module.ValidationErrors
+	for _, check := range []func() error{
+		manifest.Validate,
+		func() error { return s.checkPolicy(r.Context(), *u, manifest) },
+		func() error { return s.checkDependencies(r.Context(), u, manifest.Dependencies) },
+	} {
+		err := check()
+
+		var valErr module.ValidationErrors
+		switch {
+		case err == nil:
+
+		case errors.As(err, &valErr):
+			errs = append(errs, valErr...)
+
+		default:
+			WriteError(w, err)
+			return
+		}
+	}
+
+	if len(errs) > 0 {
+		WriteError(w, errs)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(manifest) // nolint: errcheck
+}
+
+// checkPolicy enforces the publish-time policy on the manifest's module as if
+// it was published by the given User. The names of soft-deleted modules are
+// held until they are purged.
+func (s *Server) checkPolicy(ctx context.Context, u module.User, manifest module.Manifest) error {
+	var deleted bool
+	if err := db.Conn(ctx, s.reader()).QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM modules WHERE slug = $1 AND deleted_at IS NOT NULL)`,
+		module.Slug(manifest.Name),
+	).Scan(&deleted); err != nil {
+		return err
+	}
+
+	if deleted {
+		return module.ValidationErrors{{
+			Field:   "name",
+			Code:    policy.ErrCodeNameCooldown,
+			Message: fmt.Sprintf("module name %s is held by a deleted module", manifest.Name),
+		}}
+	}
+
+	var released module.ReleasedName
+
+	err := db.Conn(ctx, s.reader()).QueryRowContext(ctx, `
+		SELECT name, COALESCE(previous_owner, 0), released_at
+		FROM released_names
+		WHERE name = $1`,
+		manifest.Name,
+	).Scan(&released.Name, &released.PreviousOwner, &released.ReleasedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return s.cfg.Policy.Check(manifest.Module(), u, nil, time.Now().UTC())
+
+	case err != nil:
+		return err
+
+	default:
+		return s.cfg.Policy.Check(manifest.Module(), u, &released, time.Now().UTC())
+	}
+}
+
+// checkDependencies checks that every dependency names a module readable by
+// the requester with a resolvable version satisfying its constraint.
+// Dependencies failing validation are left to Manifest.Validate.
+func (s *Server) checkDependencies(ctx context.Context, u *module.User, deps []module.ModuleDependency) error {
+	var errs module.ValidationErrors
+
+	for i, dep := range deps {
+		if dep.Validate() != nil {
+			continue
+		}
+
+		m, err := s.lookupModule(ctx, u, dep.Name)
+		if err != nil {
+			if ToError(err).Code == CodeModuleNotFound {
+				errs = append(errs, module.FieldError{
+					Field:   fmt.Sprintf("dependencies[%d].name", i),
+					Code:    module.ErrCodeInvalidValue,
+					Message: fmt.Sprintf("module %s not found", dep.Name),
+				})
+
+				continue
+			}
+
+			return err
+		}
+
+		versions, err := queryResolvableVersions(ctx, db.Conn(ctx, s.reader()), m.ID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := module.Resolve(dep.VersionConstraint, versions); err != nil {
+			errs = append(errs, module.FieldError{
+				Field:   fmt.Sprintf("dependencies[%d].version", i),
+				Code:    module.ErrCodeInvalidValue,
+				Message: fmt.Sprintf("no version of %s satisfies %s", dep.Name, dep.VersionConstraint),
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// queryResolvableVersions returns the versions of the module with the given
+// ID along with the state module.Resolve needs to skip unresolvable ones.
+func queryResolvableVersions(ctx context.Context, q db.Querier, moduleID int) ([]module.ModuleVersion, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT version, yanked, status
+		FROM module_versions
+		WHERE module_id = $1`,
+		moduleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []module.ModuleVersion
+	for rows.Next() {
+		var mv module.ModuleVersion
+		if err := rows.Scan(&mv.Version, &mv.Yanked, &mv.Status); err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, mv)
+	}
+
+	return versions, rows.Err()
+}
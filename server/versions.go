@@ -0,0 +1,484 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/cosmos/atlas/db"
+	"github.com/cosmos/atlas/docs"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/policy"
+)
+
+// LatestVersion serves GET /api/v1/modules/{id}/versions/latest?channel=<c>,
+// returning the greatest resolvable version in the channel (stable by
+// default) or any more stable channel. The caller must have resolved the
+// module and checked that it is readable by the requester.
+func LatestVersion(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int) {
+	channel := r.URL.Query().Get("channel")
+	if channel == "" {
+		channel = module.ChannelStable
+	}
+
+	if !module.ValidChannel(channel) {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "channel must be one of: stable, rc, beta, alpha"))
+		return
+	}
+
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT version, checksum, artifact_size, downloads, yanked, status, verified,
+			COALESCE(signature_kind, ''), COALESCE(signature, ''), COALESCE(signature_key_id, 0), created_at
+		FROM module_versions
+		WHERE module_id = $1`,
+		moduleID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	var versions []module.ModuleVersion
+	for rows.Next() {
+		var mv module.ModuleVersion
+		if err := rows.Scan(
+			&mv.Version, &mv.Checksum, &mv.ArtifactSize, &mv.Downloads, &mv.Yanked, &mv.Status, &mv.Verified,
+			&mv.Signature.Kind, &mv.Signature.Value, &mv.Signature.KeyID, &mv.CreatedAt,
+		); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		versions = append(versions, mv)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	latest, err := module.Latest(channel, versions)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(latest) // nolint: errcheck
+}
+
+// ModuleVersions serves GET /api/v1/modules/{id}/versions?include_yanked=<b>,
+// listing the published versions of the module in publish order along with
+// their signature metadata and the open security advisories affecting them,
+// excluding yanked versions unless requested. Staged versions are only listed to the
+// module's owners. The list is streamed, as popular modules have many
+// versions. Requests conditional on the ETag or Last-Modified validators of an
+// unchanged list are answered with 304 Not Modified. The caller must have
+// resolved the module and checked that it is readable by the requester.
+func ModuleVersions(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, m moduleAccess) {
+	includeYanked, err := strconv.ParseBool(r.URL.Query().Get("include_yanked"))
+	if err != nil && r.URL.Query().Get("include_yanked") != "" {
+		WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, "include_yanked must be a boolean"))
+		return
+	}
+
+	// the number of listed versions and the last modification of any version
+	// identify the list without querying it, as it is streamed; deletions
+	// modify the module
+	var (
+		count        int
+		lastModified time.Time
+	)
+
+	if err := sqlDB.QueryRowContext(r.Context(), `
+		SELECT
+			COUNT(mv.id) FILTER (
+				WHERE (mv.status = 'published' OR ($3 AND mv.status = 'staged'))
+					AND ($2 OR NOT mv.yanked)
+			),
+			GREATEST(MAX(mv.updated_at), m.updated_at)
+		FROM modules m
+		LEFT JOIN module_versions mv ON mv.module_id = m.id
+		WHERE m.id = $1
+		GROUP BY m.id`,
+		m.ID, includeYanked, m.Owner,
+	).Scan(&count, &lastModified); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if notModified(w, r, weakETag(m.ID, includeYanked, m.Owner, count, lastModified.UnixNano()), lastModified) {
+		return
+	}
+
+	advisories, err := queryAdvisories(r.Context(), sqlDB, m.ID, true)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT version, checksum, artifact_size, downloads, yanked, status, verified,
+			COALESCE(sdk_compat, ''), COALESCE(changelog, ''),
+			COALESCE(signature_kind, ''), COALESCE(signature, ''), COALESCE(signature_key_id, 0), created_at
+		FROM module_versions
+		WHERE module_id = $1
+			AND (status = 'published' OR ($3 AND status = 'staged'))
+			AND ($2 OR NOT yanked)
+		ORDER BY created_at, id`,
+		m.ID, includeYanked, m.Owner,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	list := newJSONList(w, "")
+	for rows.Next() {
+		var mv module.ModuleVersion
+		if err := rows.Scan(
+			&mv.Version, &mv.Checksum, &mv.ArtifactSize, &mv.Downloads, &mv.Yanked, &mv.Status, &mv.Verified,
+			&mv.SDKCompat, &mv.Changelog,
+			&mv.Signature.Kind, &mv.Signature.Value, &mv.Signature.KeyID, &mv.CreatedAt,
+		); err != nil {
+			list.Fail(err)
+			return
+		}
+
+		if mv.Advisories, err = module.Affecting(mv.Version, advisories); err != nil {
+			list.Fail(err)
+			return
+		}
+
+		if err := list.Append(mv); err != nil {
+			return
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		list.Fail(err)
+		return
+	}
+
+	list.Close("") // nolint: errcheck
+}
+
+// serveYank serves PUT /api/v1/modules/{id}/versions/{version}/yank, yanking
+// a version so that it is no longer resolved while remaining downloadable by
+// exact version, and DELETE to unyank it. Only module owners may yank a
+// version, subject to the two-factor policy.
+func (s *Server) serveYank(w http.ResponseWriter, r *http.Request, m moduleAccess, version string) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may yank a version"))
+		return
+	}
+
+	verified, err := s.verifyTwoFactor(r.Context(), m.User, r.Header.Get(HeaderOTP))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := s.cfg.Policy.CheckTwoFactor(*m.User, policy.OpYank, verified); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	res, err := db.Conn(r.Context(), s.primary).ExecContext(r.Context(), `
+		UPDATE module_versions
+		SET yanked = $3
+		WHERE module_id = $1
+			AND version = $2`,
+		m.ID, version, r.Method == http.MethodPut,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteVersion serves DELETE /api/v1/modules/{id}/versions/{version},
+// deleting a version along with its docs, interfaces and set pins. Deletion is
+// refused with VERSION_IN_USE while any registered module's constraint
+// resolves to the version, unless it was yanked first, and for the module's
+// only version. The module's current version falls back to the greatest
+// remaining published one. Deleting a staged version discards it. Only module
+// owners may delete a version.
+func (s *Server) DeleteVersion(w http.ResponseWriter, r *http.Request, m moduleAccess, version string) {
+	if m.User == nil {
+		WriteError(w, NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if !m.Owner || !m.User.CanPublish() {
+		WriteError(w, NewError(http.StatusForbidden, CodeForbidden, "only module owners may delete a version"))
+		return
+	}
+
+	ctx := r.Context()
+	q := db.Conn(ctx, s.primary)
+
+	existing, found, err := lockModule(ctx, q, m.User, "m.id = $1", m.ID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !found {
+		WriteError(w, NewError(http.StatusNotFound, CodeModuleNotFound, "module not found"))
+		return
+	}
+
+	if err := existing.CheckWritable(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	mv, published, err := queryPublishedVersion(ctx, q, m.ID, version)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if !published {
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+	}
+
+	// discarding a staged version leaves the module untouched, as it was never
+	// resolvable
+	if mv.Staged() {
+		if _, err := q.ExecContext(ctx, `DELETE FROM module_versions WHERE id = $1`, mv.ID); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// every dependent counts, including those unreadable by the requester
+	dependents, err := queryDependencyEdges(ctx, q, `
+		SELECT d.name, md.version_constraint
+		FROM module_dependencies md
+		JOIN modules d ON d.id = md.module_id
+		WHERE md.dependency_id = $1
+			AND d.deleted_at IS NULL`,
+		m.ID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := mv.CanDelete(dependents); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT version FROM module_versions
+		WHERE module_id = $1
+			AND id <> $2
+			AND status = 'published'`,
+		m.ID, mv.ID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	var current string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if current == "" || newer(v, current) {
+			current = v
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if current == "" {
+		WriteError(w, NewError(http.StatusConflict, CodeVersionInUse, "the only version of a module may not be deleted; delete the module instead"))
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `DELETE FROM module_versions WHERE id = $1`, mv.ID); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		UPDATE modules
+		SET version = $2, lock_version = lock_version + 1
+		WHERE id = $1`,
+		m.ID, current,
+	); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CompareVersions serves GET /api/v1/modules/{id}/versions/{a}/compare/{b},
+// returning the changes in dependencies, authors and changelog between two
+// published versions of the module as a module.VersionDiff. The caller must
+// have resolved the module and checked that it is readable by the requester.
+func CompareVersions(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int, a, b string) {
+	for _, v := range []string{a, b} {
+		if _, err := semver.NewVersion(v); err != nil {
+			WriteError(w, NewError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("invalid version %q", v)))
+			return
+		}
+	}
+
+	rows, err := sqlDB.QueryContext(r.Context(), `
+		SELECT version, COALESCE(changelog, ''), manifest
+		FROM module_versions
+		WHERE module_id = $1
+			AND status = 'published'`,
+		moduleID,
+	)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	var versions []module.ModuleVersion
+	for rows.Next() {
+		var mv module.ModuleVersion
+		if err := rows.Scan(&mv.Version, &mv.Changelog, &mv.Manifest); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		versions = append(versions, mv)
+	}
+
+	if err := rows.Err(); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	diff, err := module.Compare(versions, a, b)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff) // nolint: errcheck
+}
+
+// VersionReadme defines the raw Markdown readme of a module version along with
+// its sanitized HTML rendering.
+type VersionReadme struct {
+	Version        string `json:"version"`
+	Readme         string `json:"readme"`
+	RenderedReadme string `json:"rendered_readme"`
+}
+
+// ReadmeVersion serves GET /api/v1/modules/{id}/versions/{version}/readme,
+// returning the version's readme both raw and rendered. Readmes are rendered
+// once at publish time; those of versions published before are rendered on
+// read. The caller must have resolved the module and checked that it is
+// readable by the requester.
+func ReadmeVersion(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int, version string) {
+	resp := VersionReadme{Version: version}
+	err := sqlDB.QueryRowContext(r.Context(), `
+		SELECT COALESCE(readme, ''), COALESCE(rendered_readme, '')
+		FROM module_versions
+		WHERE module_id = $1
+			AND version = $2
+			AND status = 'published'`,
+		moduleID, version,
+	).Scan(&resp.Readme, &resp.RenderedReadme)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	if resp.RenderedReadme == "" && resp.Readme != "" {
+		resp.RenderedReadme = module.RenderReadme(resp.Readme)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp) // nolint: errcheck
+}
+
+// VersionDocs defines the extracted package documentation of a module version.
+type VersionDocs struct {
+	Version  string         `json:"version"`
+	Packages []docs.Package `json:"packages"`
+}
+
+// DocsVersion serves GET /api/v1/modules/{id}/versions/{version}/docs,
+// returning the package documentation extracted from the version's tagged
+// release. Documentation is extracted by a job enqueued at publish time, so
+// that packages is empty until the job has run. The caller must have resolved
+// the module and checked that it is readable by the requester.
+func DocsVersion(w http.ResponseWriter, r *http.Request, sqlDB *sql.DB, moduleID int, version string) {
+	var versionID int
+	err := sqlDB.QueryRowContext(r.Context(), `
+		SELECT id
+		FROM module_versions
+		WHERE module_id = $1
+			AND version = $2
+			AND status = 'published'`,
+		moduleID, version,
+	).Scan(&versionID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		WriteError(w, NewError(http.StatusNotFound, CodeVersionNotFound, "version not found"))
+		return
+
+	case err != nil:
+		WriteError(w, err)
+		return
+	}
+
+	pkgs, err := docs.Load(r.Context(), sqlDB, versionID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(VersionDocs{Version: version, Packages: pkgs}) // nolint: errcheck
+}
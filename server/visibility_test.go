@@ -0,0 +1,190 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	registryv1 "github.com/cosmos/atlas/api/registry/v1"
+	"github.com/cosmos/atlas/client"
+	"github.com/cosmos/atlas/module"
+	"github.com/cosmos/atlas/rpc"
+	"github.com/cosmos/atlas/server"
+)
+
+const (
+	privateModule  = "treasury"
+	privateKeyword = "treasury"
+	bannedToken    = "banned-member-token"
+)
+
+func TestPrivateModulesUnreadable(t *testing.T) {
+	h, f := newSeededHarness(t)
+	ctx := context.Background()
+
+	// mallory is a banned contributor and grantee of the private module
+	if _, err := h.DB.ExecContext(ctx, `
+		WITH u AS (
+			INSERT INTO users (name, email, github_access_token, api_token, banned)
+			VALUES ('mallory', 'mallory@example.com', '', $1, TRUE)
+			RETURNING id
+		), c AS (
+			INSERT INTO modules_users (module_id, user_id)
+			SELECT m.id, u.id FROM modules m, u WHERE m.name = $2
+		)
+		INSERT INTO module_grants (module_id, user_id)
+		SELECT m.id, u.id FROM modules m, u WHERE m.name = $2`,
+		bannedToken, privateModule,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// requesters that must not read the private module, by description
+	denied := map[string]string{
+		"anonymous":     "",
+		"non-member":    token(t, f, "bob"),
+		"banned member": bannedToken,
+	}
+
+	svc := rpc.NewService(h.DB, h.Config.Policy.Tokens, nil)
+	rpcContext := func(token string) context.Context {
+		if token == "" {
+			return ctx
+		}
+
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+	}
+
+	httpClient := func(token string) *client.Client {
+		if token == "" {
+			return h.Client()
+		}
+
+		return h.Client(client.WithToken(token))
+	}
+
+	// the owner creates a public set pinning a public and the private module
+	owner := token(t, f, "carol")
+	set, err := httpClient(owner).CreateSet(ctx, module.ModuleSet{
+		Name: "baseline",
+		Pins: []module.ModuleSetPin{
+			{Module: "nft", Version: "0.1.0"},
+			{Module: privateModule, Version: "0.1.0"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("owner failed to create a set pinning the private module: %v", err)
+	}
+
+	if len(set.Pins) != 2 {
+		t.Fatalf("expected the owner to read both pins, got %d", len(set.Pins))
+	}
+
+	for name, tok := range denied {
+		tok := tok
+		t.Run(name, func(t *testing.T) {
+			c := httpClient(tok)
+
+			if _, err := c.GetModule(ctx, privateModule); !client.HasCode(err, server.CodeModuleNotFound) {
+				t.Errorf("module detail: expected %s, got %v", server.CodeModuleNotFound, err)
+			}
+
+			if _, err := c.ListVersions(ctx, privateModule); !client.HasCode(err, server.CodeModuleNotFound) {
+				t.Errorf("versions: expected %s, got %v", server.CodeModuleNotFound, err)
+			}
+
+			got, err := c.GetSet(ctx, set.PublicID)
+			if err != nil {
+				t.Fatalf("set: %v", err)
+			}
+
+			for _, p := range got.Pins {
+				if p.Module == privateModule {
+					t.Errorf("set: pin of the private module is readable")
+				}
+			}
+
+			if tok != "" {
+				_, err := c.CreateSet(ctx, module.ModuleSet{
+					Name: "leak",
+					Pins: []module.ModuleSetPin{{Module: privateModule, Version: "0.1.0"}},
+				})
+				if !client.HasCode(err, server.CodeValidationFailed) && !client.HasCode(err, server.CodeForbidden) {
+					t.Errorf("set creation: expected the private module to be rejected, got %v", err)
+				}
+			}
+
+			rctx := rpcContext(tok)
+
+			for _, query := range []string{privateModule, ""} {
+				resp, err := svc.SearchModules(rctx, &registryv1.SearchModulesRequest{Query: query, PageSize: 100})
+				if err != nil {
+					t.Fatalf("search %q: %v", query, err)
+				}
+
+				for _, m := range resp.Modules {
+					if m.Name == privateModule {
+						t.Errorf("search %q: private module listed", query)
+					}
+				}
+			}
+
+			if _, err := svc.GetModule(rctx, &registryv1.GetModuleRequest{Name: privateModule}); status.Code(err) != codes.NotFound {
+				t.Errorf("rpc module: expected NotFound, got %v", err)
+			}
+
+			if _, err := svc.ListModuleVersions(rctx, &registryv1.ListModuleVersionsRequest{Name: privateModule}); status.Code(err) != codes.NotFound {
+				t.Errorf("rpc versions: expected NotFound, got %v", err)
+			}
+		})
+	}
+
+	t.Run("owner", func(t *testing.T) {
+		if _, err := httpClient(owner).GetModule(ctx, privateModule); err != nil {
+			t.Errorf("module detail: %v", err)
+		}
+
+		resp, err := svc.SearchModules(rpcContext(owner), &registryv1.SearchModulesRequest{Query: privateModule})
+		if err != nil || len(resp.Modules) != 1 {
+			t.Errorf("search: expected the private module, got %v (%v)", resp, err)
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		resp, err := h.Server.Client().Get(h.URL + "/api/v1/stats")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+
+		var stats module.RegistryStats
+		if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+			t.Fatal(err)
+		}
+
+		if stats.Modules != 3 {
+			t.Errorf("expected 3 public modules, got %d", stats.Modules)
+		}
+
+		for _, k := range stats.TopKeywords {
+			if k.Name == privateKeyword {
+				t.Errorf("keyword %s of the private module is listed", privateKeyword)
+			}
+		}
+
+		for _, p := range stats.TopPublishers {
+			if p.Name == "carol" && p.Modules != 1 {
+				t.Errorf("expected carol to publish 1 public module, got %d", p.Modules)
+			}
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// Supported signature kinds.
+const (
+	KindPGP    = "pgp"
+	KindCosign = "cosign"
+)
+
+// Verify verifies that sig is a valid signature of payload produced by the
+// private key corresponding to publicKey. The kind determines the encoding of
+// both the key and signature:
+//
+// - pgp: an ASCII-armored public key and an ASCII-armored detached signature.
+// - cosign: a PEM-encoded ECDSA public key and a base64-encoded ASN.1 signature
+// over the SHA-256 digest of the payload, as produced by `cosign sign-blob`.
+func Verify(kind, publicKey, sig string, payload []byte) error {
+	switch kind {
+	case KindPGP:
+		return verifyPGP(publicKey, sig, payload)
+
+	case KindCosign:
+		return verifyCosign(publicKey, sig, payload)
+
+	default:
+		return fmt.Errorf("unsupported signature kind: %s", kind)
+	}
+}
+
+func verifyPGP(publicKey, sig string, payload []byte) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+	if err != nil {
+		return fmt.Errorf("failed to read PGP public key: %w", err)
+	}
+
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(payload), strings.NewReader(sig)); err != nil {
+		return fmt.Errorf("failed to verify PGP signature: %w", err)
+	}
+
+	return nil
+}
+
+func verifyCosign(publicKey, sig string, payload []byte) error {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return fmt.Errorf("failed to decode PEM public key")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	rawSig, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	digest := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(ecdsaPub, digest[:], rawSig) {
+		return fmt.Errorf("failed to verify cosign signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+var payload = []byte("x/liquidity v1.2.0 sha256:8e2f2c0a\n")
+
+func TestVerifyPGP(t *testing.T) {
+	newKey := func() (*openpgp.Entity, string) {
+		t.Helper()
+
+		e, err := openpgp.NewEntity("alice", "", "alice@example.com", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := e.Serialize(w); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		return e, buf.String()
+	}
+
+	sign := func(e *openpgp.Entity, data []byte) string {
+		t.Helper()
+
+		var buf bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&buf, e, bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.String()
+	}
+
+	signer, key := newKey()
+	other, _ := newKey()
+
+	testCases := []struct {
+		name    string
+		key     string
+		sig     string
+		payload []byte
+		valid   bool
+	}{
+		{"valid", key, sign(signer, payload), payload, true},
+		{"surrounding whitespace", "\n" + key + "\n", "\n" + sign(signer, payload) + "\n", payload, true},
+		{"tampered payload", key, sign(signer, payload), []byte("x/liquidity v1.2.0 sha256:5b1d7e93\n"), false},
+		{"other signer", key, sign(other, payload), payload, false},
+		{"not armored", key, "c2lnbmF0dXJl", payload, false},
+		{"invalid key", "not a key", sign(signer, payload), payload, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(KindPGP, tc.key, tc.sig, tc.payload)
+			if tc.valid != (err == nil) {
+				t.Errorf("expected valid: %v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestVerifyCosign(t *testing.T) {
+	newKey := func() (*ecdsa.PrivateKey, string) {
+		t.Helper()
+
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return priv, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+
+	sign := func(priv *ecdsa.PrivateKey, data []byte) string {
+		t.Helper()
+
+		digest := sha256.Sum256(data)
+		sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	signer, key := newKey()
+	other, _ := newKey()
+
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edDER, err := x509.MarshalPKIXPublicKey(edPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: edDER}))
+	sig := sign(signer, payload)
+
+	testCases := []struct {
+		name    string
+		key     string
+		sig     string
+		payload []byte
+		valid   bool
+	}{
+		{"valid", key, sig, payload, true},
+		{"trailing newline", key, sig + "\n", payload, true},
+		{"wrapped", key, sig[:32] + "\n" + sig[32:], payload, true},
+		{"tampered payload", key, sig, []byte("x/liquidity v1.2.0 sha256:5b1d7e93\n"), false},
+		{"other signer", key, sign(other, payload), payload, false},
+		{"not base64", key, strings.Repeat("!", 16), payload, false},
+		{"not PEM", "not a key", sig, payload, false},
+		{"not ECDSA", edKey, sig, payload, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(KindCosign, tc.key, tc.sig, tc.payload)
+			if tc.valid != (err == nil) {
+				t.Errorf("expected valid: %v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestVerifyUnsupportedKind(t *testing.T) {
+	if err := Verify("minisign", "key", "sig", payload); err == nil {
+		t.Error("expected an unsupported signature kind to be rejected")
+	}
+}
@@ -0,0 +1,147 @@
+package sitemap
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/storage"
+)
+
+// MaxURLs defines the maximum number of URLs per sitemap file allowed by the
+// sitemaps protocol.
+const MaxURLs = 50000
+
+// IndexKey defines the storage key of the sitemap index served at
+// /sitemap.xml.
+const IndexKey = "sitemaps/sitemap.xml"
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type (
+	// URL defines a sitemap entry.
+	URL struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod,omitempty"`
+	}
+
+	urlSet struct {
+		XMLName xml.Name `xml:"urlset"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		URLs    []URL    `xml:"url"`
+	}
+
+	sitemapIndex struct {
+		XMLName  xml.Name `xml:"sitemapindex"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Sitemaps []URL    `xml:"sitemap"`
+	}
+)
+
+// PageKey returns the storage key of the nth (1-indexed) sitemap page, served
+// at /sitemap-<n>.xml.
+func PageKey(n int) string {
+	return fmt.Sprintf("sitemaps/sitemap-%d.xml", n)
+}
+
+// ModuleURL returns the canonical URL of a module's page.
+func ModuleURL(baseURL, name string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/modules/" + name
+}
+
+// Generate writes the sitemap pages and index of every public, listed module
+// to the Storage, returning the number of pages. Private, hidden, deleted and
+// mirrored modules are excluded, the latter since their canonical page is on
+// the upstream registry.
+func Generate(ctx context.Context, sqlDB *sql.DB, store storage.Storage, baseURL string) (int, error) {
+	rows, err := sqlDB.QueryContext(ctx, `
+		SELECT m.name, MAX(v.created_at)
+		FROM modules m
+		LEFT JOIN module_versions v ON v.module_id = m.id
+		WHERE m.visibility = 'public'
+			AND NOT m.hidden
+			AND m.deleted_at IS NULL
+			AND m.origin IS NULL
+		GROUP BY m.name
+		ORDER BY m.name`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query modules: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		pages int
+		page  []URL
+		now   = time.Now().UTC().Format("2006-01-02")
+	)
+
+	flush := func() error {
+		pages++
+		return put(ctx, store, PageKey(pages), urlSet{Xmlns: xmlns, URLs: page})
+	}
+
+	for rows.Next() {
+		var (
+			name    string
+			lastMod sql.NullTime
+		)
+
+		if err := rows.Scan(&name, &lastMod); err != nil {
+			return pages, err
+		}
+
+		u := URL{Loc: ModuleURL(baseURL, name)}
+		if lastMod.Valid {
+			u.LastMod = lastMod.Time.UTC().Format("2006-01-02")
+		}
+
+		page = append(page, u)
+		if len(page) == MaxURLs {
+			if err := flush(); err != nil {
+				return pages, err
+			}
+
+			page = nil
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return pages, err
+	}
+
+	if len(page) > 0 || pages == 0 {
+		if err := flush(); err != nil {
+			return pages, err
+		}
+	}
+
+	index := sitemapIndex{Xmlns: xmlns}
+	for n := 1; n <= pages; n++ {
+		index.Sitemaps = append(index.Sitemaps, URL{
+			Loc:     fmt.Sprintf("%s/sitemap-%d.xml", strings.TrimSuffix(baseURL, "/"), n),
+			LastMod: now,
+		})
+	}
+
+	return pages, put(ctx, store, IndexKey, index)
+}
+
+func put(ctx context.Context, store storage.Storage, key string, v interface{}) error {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	if err := xml.NewEncoder(&buf).Encode(v); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	if err := store.Put(ctx, key, &buf, int64(buf.Len()), "application/xml"); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	return nil
+}
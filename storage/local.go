@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ Storage = (*Local)(nil)
+
+// Local defines a Storage implementation backed by a directory on local disk.
+type Local struct {
+	root string
+}
+
+// NewLocal returns a Local storage rooted at the given directory, creating it
+// if it does not exist.
+func NewLocal(root string) (*Local, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory %s: %w", root, err)
+	}
+
+	return &Local{root: root}, nil
+}
+
+// Put writes the object to a temporary file and atomically moves it into place.
+// Content types are not stored; they are implied by the keys.
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the object stored under key.
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+// Delete removes the object stored under key. It is not an error to delete a
+// missing object.
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Move atomically renames the object stored under src to dst.
+func (l *Local) Move(_ context.Context, src, dst string) error {
+	srcPath, err := l.path(src)
+	if err != nil {
+		return err
+	}
+
+	dstPath, err := l.path(dst)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(srcPath, dstPath)
+}
+
+// path resolves a storage key to a path under the root directory, rejecting
+// keys that would escape it.
+func (l *Local) path(key string) (string, error) {
+	path := filepath.Join(l.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, l.root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid storage key: %s", key)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"path"
+)
+
+var _ Storage = (*Prefixed)(nil)
+
+// Prefixed defines a Storage storing every object of another Storage under a
+// key prefix, e.g. to isolate the artifacts of registries sharing a bucket.
+type Prefixed struct {
+	s      Storage
+	prefix string
+}
+
+// NewPrefixed returns a Prefixed storage storing objects of s under prefix.
+func NewPrefixed(s Storage, prefix string) *Prefixed {
+	return &Prefixed{s: s, prefix: prefix}
+}
+
+// Put writes the object under the prefixed key.
+func (p *Prefixed) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	return p.s.Put(ctx, p.key(key), r, size, contentType)
+}
+
+// Get returns a reader for the object stored under the prefixed key.
+func (p *Prefixed) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return p.s.Get(ctx, p.key(key))
+}
+
+// Delete removes the object stored under the prefixed key.
+func (p *Prefixed) Delete(ctx context.Context, key string) error {
+	return p.s.Delete(ctx, p.key(key))
+}
+
+// Move moves the object between the prefixed keys.
+func (p *Prefixed) Move(ctx context.Context, src, dst string) error {
+	return p.s.Move(ctx, p.key(src), p.key(dst))
+}
+
+// key returns the prefixed key, cleaning key so that it cannot escape the
+// prefix.
+func (p *Prefixed) key(key string) string {
+	return path.Join(p.prefix, path.Clean("/"+key))
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var _ Storage = (*S3)(nil)
+
+// S3Config defines the configuration of an S3-compatible (e.g. AWS S3, MinIO)
+// storage backend.
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	Region          string `yaml:"region"`
+	UseSSL          bool   `yaml:"use_ssl"`
+}
+
+// S3 defines a Storage implementation backed by an S3-compatible object store.
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3 returns an S3 storage backend for the given configuration.
+func NewS3(cfg S3Config) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	return &S3{client: client, bucket: cfg.Bucket}, nil
+}
+
+// Put uploads the object to the configured bucket.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+// Get returns a reader for the object stored in the configured bucket.
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+}
+
+// Delete removes the object from the configured bucket.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// Move copies the object to dst within the configured bucket, preserving its
+// metadata, then removes the source object.
+func (s *S3) Move(ctx context.Context, src, dst string) error {
+	if _, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucket, Object: dst},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: src},
+	); err != nil {
+		return err
+	}
+
+	return s.client.RemoveObject(ctx, s.bucket, src, minio.RemoveObjectOptions{})
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrChecksumMismatch is returned when the contents of an uploaded artifact
+// do not match the checksum supplied by the publisher.
+var ErrChecksumMismatch = errors.New("artifact checksum mismatch")
+
+// ArtifactContentType defines the content type of module source tarballs.
+const ArtifactContentType = "application/gzip"
+
+// Storage defines an abstraction over a blob store used to persist module
+// artifacts (e.g. source tarballs) keyed by an opaque path.
+type Storage interface {
+	// Put writes the contents of r under key with the given content type,
+	// replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get returns a reader for the object stored under key. The caller is
+	// responsible for closing it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object stored under key, if any.
+	Delete(ctx context.Context, key string) error
+
+	// Move moves the object stored under src to dst, replacing any existing
+	// object.
+	Move(ctx context.Context, src, dst string) error
+}
+
+// ArtifactKey returns the storage key of the source tarball for a given module
+// version.
+func ArtifactKey(name, version string) string {
+	return fmt.Sprintf("modules/%s/%s.tar.gz", name, version)
+}
+
+// Upload writes the contents of r to the given Storage under key while
+// computing its SHA-256 checksum. The contents are written to a temporary key
+// and only moved to key once they match the expected hex-encoded checksum, so
+// that a failed upload never replaces an existing object. Otherwise, the
+// temporary object is removed and ErrChecksumMismatch is returned.
+func Upload(ctx context.Context, s Storage, key string, r io.Reader, size int64, checksum, contentType string) error {
+	bz := make([]byte, 8)
+	if _, err := rand.Read(bz); err != nil {
+		return err
+	}
+
+	var (
+		h   = sha256.New()
+		tmp = key + ".upload-" + hex.EncodeToString(bz)
+	)
+
+	if err := s.Put(ctx, tmp, io.TeeReader(r, h), size, contentType); err != nil {
+		return fmt.Errorf("failed to store artifact %s: %w", key, err)
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != checksum {
+		if err := s.Delete(ctx, tmp); err != nil {
+			return fmt.Errorf("failed to remove artifact %s after checksum mismatch: %w", key, err)
+		}
+
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, checksum, sum)
+	}
+
+	if err := s.Move(ctx, tmp, key); err != nil {
+		s.Delete(ctx, tmp) // nolint: errcheck
+		return fmt.Errorf("failed to store artifact %s: %w", key, err)
+	}
+
+	return nil
+}
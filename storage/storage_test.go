@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPath(t *testing.T) {
+	l, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		key   string
+		path  string
+		valid bool
+	}{
+		{"modules/liquidity/v1.2.0.tar.gz", "modules/liquidity/v1.2.0.tar.gz", true},
+		{"modules/x/liquidity/v1.2.0.tar.gz", "modules/x/liquidity/v1.2.0.tar.gz", true},
+		{"modules/../escape.tar.gz", "escape.tar.gz", true},
+		{"/absolute.tar.gz", "absolute.tar.gz", true},
+		{"../escape.tar.gz", "", false},
+		{"modules/../../escape.tar.gz", "", false},
+		{"..", "", false},
+		{"", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			path, err := l.path(tc.key)
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("expected key to be rejected, got %s", path)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := filepath.Join(l.root, filepath.FromSlash(tc.path)); path != want {
+				t.Errorf("expected %s, got %s", want, path)
+			}
+		})
+	}
+}
+
+func TestPrefixedKey(t *testing.T) {
+	p := NewPrefixed(nil, "tenants/acme")
+
+	testCases := []struct {
+		key, prefixed string
+	}{
+		{"modules/liquidity/v1.2.0.tar.gz", "tenants/acme/modules/liquidity/v1.2.0.tar.gz"},
+		{"/modules/liquidity/v1.2.0.tar.gz", "tenants/acme/modules/liquidity/v1.2.0.tar.gz"},
+		{"../other/modules/liquidity/v1.2.0.tar.gz", "tenants/acme/other/modules/liquidity/v1.2.0.tar.gz"},
+		{"modules/../../../escape.tar.gz", "tenants/acme/escape.tar.gz"},
+	}
+
+	for _, tc := range testCases {
+		if got := p.key(tc.key); got != tc.prefixed {
+			t.Errorf("%s: expected %s, got %s", tc.key, tc.prefixed, got)
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := ArtifactKey("liquidity", "v1.2.0")
+	if err := l.Put(ctx, key, strings.NewReader("tarball"), 7, ArtifactContentType); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Move(ctx, key, ArtifactKey("liquidity", "v1.3.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.Get(ctx, key); !os.IsNotExist(err) {
+		t.Errorf("expected the moved object to be gone, got %v", err)
+	}
+
+	rc, err := l.Get(ctx, ArtifactKey("liquidity", "v1.3.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bz, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(bz) != "tarball" {
+		t.Errorf("expected the stored contents, got %q, %v", bz, err)
+	}
+
+	if err := l.Delete(ctx, ArtifactKey("liquidity", "v1.3.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Delete(ctx, ArtifactKey("liquidity", "v1.3.0")); err != nil {
+		t.Errorf("expected deleting a missing object to succeed, got %v", err)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := ArtifactKey("liquidity", "v1.2.0")
+	contents := []byte("tarball")
+	sum := sha256.Sum256(contents)
+	checksum := hex.EncodeToString(sum[:])
+
+	if err := Upload(ctx, l, key, bytes.NewReader(contents), int64(len(contents)), checksum, ArtifactContentType); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Upload(ctx, l, key, strings.NewReader("tampered"), 8, checksum, ArtifactContentType)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected %v, got %v", ErrChecksumMismatch, err)
+	}
+
+	rc, err := l.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	if bz, err := ioutil.ReadAll(rc); err != nil || !bytes.Equal(bz, contents) {
+		t.Errorf("expected a failed upload not to replace the artifact, got %q, %v", bz, err)
+	}
+
+	entries, err := ioutil.ReadDir(filepath.Join(l.root, "modules", "liquidity"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("expected the temporary upload to be removed, got %d objects", len(entries))
+	}
+}
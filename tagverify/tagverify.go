@@ -0,0 +1,209 @@
+package tagverify
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cosmos/atlas/module"
+)
+
+// DefaultTimeout defines the default timeout of a single tag verification.
+const DefaultTimeout = 30 * time.Second
+
+// maxManifestSize bounds the size of a manifest fetched from a repository.
+const maxManifestSize = 1 << 20
+
+var (
+	// ErrUnsupportedRepo is returned when the module repository is not hosted
+	// on a supported Git provider.
+	ErrUnsupportedRepo = errors.New("unsupported repository host")
+
+	// ErrTagNotFound is returned when the published version has no matching
+	// tag in the module repository.
+	ErrTagNotFound = errors.New("version tag not found in repository")
+
+	// ErrManifestMismatch is returned when the manifest at the version tag does
+	// not match the submitted manifest.
+	ErrManifestMismatch = errors.New("manifest at tag does not match submitted manifest")
+)
+
+// manifestFiles defines the manifest file names looked up at a tag, in order.
+var manifestFiles = []string{"atlas.toml", "atlas.yaml", "atlas.yml", "atlas.json"}
+
+// Verifier verifies published versions against the tags of their GitHub
+// repository.
+type Verifier struct {
+	client *http.Client
+	token  string
+	apiURL string
+	rawURL string
+}
+
+// NewVerifier returns a Verifier using the given HTTP client. The token, if
+// non-empty, authenticates GitHub API requests to raise rate limits and allow
+// access to private repositories.
+func NewVerifier(client *http.Client, token string) *Verifier {
+	return &Verifier{
+		client: client,
+		token:  token,
+		apiURL: "https://api.github.com",
+		rawURL: "https://raw.githubusercontent.com",
+	}
+}
+
+// Verify checks that the version of the submitted manifest exists as a tag in
+// the manifest's repository and that the manifest committed at that tag
+// matches the submitted one. It returns nil if the version is verified.
+func (v *Verifier) Verify(ctx context.Context, submitted module.Manifest) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	slug, err := githubSlug(submitted.Repo)
+	if err != nil {
+		return err
+	}
+
+	tag := url.PathEscape(submitted.Version)
+
+	code, _, err := v.get(ctx, fmt.Sprintf("%s/repos/%s/git/ref/tags/%s", v.apiURL, slug, tag))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case code == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrTagNotFound, submitted.Version)
+	case code != http.StatusOK:
+		return fmt.Errorf("failed to look up tag %s: GitHub responded %d", submitted.Version, code)
+	}
+
+	for _, file := range manifestFiles {
+		code, bz, err := v.get(ctx, fmt.Sprintf("%s/%s/%s/%s", v.rawURL, slug, tag, file))
+		if err != nil {
+			return err
+		}
+
+		if code == http.StatusNotFound {
+			continue
+		} else if code != http.StatusOK {
+			return fmt.Errorf("failed to fetch %s at %s: GitHub responded %d", file, submitted.Version, code)
+		}
+
+		tagged, err := module.ParseManifest(bz, module.FormatFromFilename(file))
+		if err != nil {
+			return fmt.Errorf("%w: %s at %s is invalid: %v", ErrManifestMismatch, file, submitted.Version, err)
+		}
+
+		return compare(tagged, submitted)
+	}
+
+	return fmt.Errorf("%w: no manifest at %s", ErrManifestMismatch, submitted.Version)
+}
+
+// VerifyPromoted verifies a version promoted from a pre-release against its
+// stable tag, setting its Verified flag accordingly. It returns the reason the
+// version could not be verified, if any.
+func (v *Verifier) VerifyPromoted(ctx context.Context, promoted *module.ModuleVersion) error {
+	err := v.Verify(ctx, promoted.Manifest)
+	promoted.Verified = err == nil
+
+	return err
+}
+
+func (v *Verifier) get(ctx context.Context, u string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if v.token != "" {
+		req.Header.Set("Authorization", "token "+v.token)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	bz, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	return resp.StatusCode, bz, err
+}
+
+// compare returns ErrManifestMismatch unless both manifests have the same
+// canonical JSON encoding, so that differences in format or field order are
+// ignored.
+func compare(tagged, submitted module.Manifest) error {
+	a, err := json.Marshal(tagged)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(submitted)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(a, b) {
+		return ErrManifestMismatch
+	}
+
+	return nil
+}
+
+// githubSlug returns the owner/name slug of a GitHub repository URL.
+func githubSlug(repo string) (string, error) {
+	u, err := url.Parse(repo)
+	if err != nil || !strings.EqualFold(u.Host, "github.com") {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedRepo, repo)
+	}
+
+	slug := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if strings.Count(slug, "/") != 1 {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedRepo, repo)
+	}
+
+	return slug, nil
+}
+
+// VerifyVersion verifies the published module version of the given ID and
+// stores the outcome in its verified flag. Versions failing verification
+// definitively, e.g. because their tag is missing or their repository is not
+// hosted on GitHub, are stored as unverified without error; any other failure
+// is returned so that verification can be retried.
+func (v *Verifier) VerifyVersion(ctx context.Context, db *sql.DB, moduleVersionID int, promoted bool) error {
+	mv := module.ModuleVersion{ID: moduleVersionID}
+	if err := db.QueryRowContext(ctx, `
+		SELECT version, manifest FROM module_versions WHERE id = $1`,
+		moduleVersionID,
+	).Scan(&mv.Version, &mv.Manifest); err != nil {
+		return err
+	}
+
+	var err error
+	if promoted {
+		err = v.VerifyPromoted(ctx, &mv)
+	} else {
+		err = v.Verify(ctx, mv.Manifest)
+		mv.Verified = err == nil
+	}
+
+	switch {
+	case err == nil, errors.Is(err, ErrUnsupportedRepo), errors.Is(err, ErrTagNotFound), errors.Is(err, ErrManifestMismatch):
+	default:
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `UPDATE module_versions SET verified = $2 WHERE id = $1`, mv.ID, mv.Verified)
+	return err
+}
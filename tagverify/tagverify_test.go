@@ -0,0 +1,89 @@
+package tagverify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cosmos/atlas/module"
+)
+
+func testManifest() module.Manifest {
+	return module.Manifest{
+		Name:        "x/liquidity",
+		Description: "Constant product automated market maker pools.",
+		Version:     "v1.2.0",
+		Repo:        "https://github.com/example/liquidity",
+		License:     "Apache-2.0",
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tagged := testManifest()
+
+	changed := testManifest()
+	changed.Description = "Order book exchange."
+
+	other := testManifest()
+	other.Repo = "https://gitlab.com/example/liquidity"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/repos/example/liquidity/git/ref/tags/v1.2.0", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/raw/example/liquidity/v1.2.0/atlas.json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tagged) // nolint: errcheck
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	v := NewVerifier(srv.Client(), "")
+	v.apiURL = srv.URL + "/api"
+	v.rawURL = srv.URL + "/raw"
+
+	missing := testManifest()
+	missing.Version = "v1.3.0"
+
+	testCases := []struct {
+		name     string
+		manifest module.Manifest
+		err      error
+	}{
+		{"matching", testManifest(), nil},
+		{"mismatched", changed, ErrManifestMismatch},
+		{"missing tag", missing, ErrTagNotFound},
+		{"unsupported host", other, ErrUnsupportedRepo},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := v.Verify(context.Background(), tc.manifest)
+			switch {
+			case tc.err == nil && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+
+			case tc.err != nil && !errors.Is(err, tc.err):
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyPromoted(t *testing.T) {
+	v := NewVerifier(http.DefaultClient, "")
+
+	promoted := module.ModuleVersion{Version: "v1.2.0", Manifest: testManifest(), Verified: true}
+	promoted.Manifest.Repo = "https://example.com/liquidity"
+
+	if err := v.VerifyPromoted(context.Background(), &promoted); !errors.Is(err, ErrUnsupportedRepo) {
+		t.Fatalf("expected %v, got %v", ErrUnsupportedRepo, err)
+	}
+
+	if promoted.Verified {
+		t.Error("expected the promoted version to be unverified")
+	}
+}
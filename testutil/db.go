@@ -0,0 +1,104 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/lib/pq"
+
+	"github.com/cosmos/atlas/db"
+)
+
+// DatabaseURLEnv defines the environment variable holding the URL of the
+// Postgres server on which NewDB creates temporary databases. The user must be
+// allowed to create databases.
+const DatabaseURLEnv = "ATLAS_TEST_DATABASE_URL"
+
+// MigrationsDir returns the directory of the registry's SQL migrations,
+// regardless of the working directory of the test.
+func MigrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", filepath.FromSlash(db.DefaultMigrationsDir))
+}
+
+// NewDB creates a temporary database on the Postgres server at DatabaseURLEnv,
+// applies every migration to it and returns it along with its URL. The
+// database is dropped when the test completes. The test is skipped if
+// DatabaseURLEnv is not set.
+func NewDB(t testing.TB) (*sql.DB, string) {
+	t.Helper()
+
+	serverURL := os.Getenv(DatabaseURLEnv)
+	if serverURL == "" {
+		t.Skipf("%s is not set", DatabaseURLEnv)
+	}
+
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", DatabaseURLEnv, err)
+	}
+
+	bz := make([]byte, 8)
+	if _, err := rand.Read(bz); err != nil {
+		t.Fatal(err)
+	}
+
+	name := "atlas_test_" + hex.EncodeToString(bz)
+	admin, err := sql.Open("postgres", serverURL)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", DatabaseURLEnv, err)
+	}
+
+	if _, err := admin.Exec(fmt.Sprintf(`CREATE DATABASE %s`, pq.QuoteIdentifier(name))); err != nil {
+		admin.Close()
+		t.Fatalf("failed to create database %s: %v", name, err)
+	}
+
+	u.Path = "/" + name
+	databaseURL := u.String()
+
+	sqlDB, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		admin.Close()
+		t.Fatalf("failed to open database %s: %v", name, err)
+	}
+
+	t.Cleanup(func() {
+		sqlDB.Close()
+
+		if _, err := admin.Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS %s`, pq.QuoteIdentifier(name))); err != nil {
+			t.Errorf("failed to drop database %s: %v", name, err)
+		}
+
+		admin.Close()
+	})
+
+	if err := migrateUp(databaseURL); err != nil {
+		t.Fatal(err)
+	}
+
+	return sqlDB, databaseURL
+}
+
+func migrateUp(databaseURL string) error {
+	m, err := db.NewMigrator(databaseURL, MigrationsDir())
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return nil
+}
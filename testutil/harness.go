@@ -0,0 +1,103 @@
+// Package testutil provides a harness serving the full registry router from
+// an httptest server against a temporary database, so that integrations and
+// endpoints can be covered end to end.
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cosmos/atlas/client"
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/seed"
+	"github.com/cosmos/atlas/server"
+	"github.com/cosmos/atlas/storage"
+)
+
+// Harness defines a registry serving the full router from an httptest server
+// against a temporary database created by NewDB, with artifacts stored in a
+// temporary directory.
+type Harness struct {
+	*httptest.Server
+
+	Registry *server.Server
+	DB       *sql.DB
+	Config   config.Config
+
+	t testing.TB
+}
+
+// NewHarness starts a Harness serving a registry of the given configuration,
+// e.g. config.Default(), whose base URL, database and storage are overridden
+// by the harness's. Background workers are disabled unless started with
+// Registry.Start. The harness is shut down when the test completes.
+func NewHarness(t testing.TB, cfg config.Config, opts ...server.Option) *Harness {
+	t.Helper()
+
+	sqlDB, databaseURL := NewDB(t)
+
+	dir := t.TempDir()
+	store, err := storage.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Harness{DB: sqlDB, t: t}
+
+	// the registry is created once the server's URL is known, to be used as
+	// its base URL
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Registry.Handler().ServeHTTP(w, r)
+	}))
+	t.Cleanup(h.Server.Close)
+
+	cfg.BaseURL = h.Server.URL
+	cfg.Tenants = nil
+	cfg.Database.URL = databaseURL
+	cfg.Database.ReplicaURLs = nil
+	cfg.Storage = config.StorageConfig{Backend: config.StorageLocal, LocalRoot: dir}
+
+	opts = append([]server.Option{server.WithDB(sqlDB), server.WithStorage(store), server.WithoutWorkers()}, opts...)
+	if h.Registry, err = server.New(cfg, opts...); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Config = cfg
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := h.Registry.Shutdown(ctx); err != nil {
+			t.Errorf("failed to shut down registry: %v", err)
+		}
+	})
+
+	return h
+}
+
+// Seed inserts the fixtures into the harness's database, failing the test on
+// error.
+func (h *Harness) Seed(f seed.Fixtures) {
+	h.t.Helper()
+
+	if err := seed.Insert(context.Background(), h.DB, f, time.Now()); err != nil {
+		h.t.Fatalf("failed to seed fixtures: %v", err)
+	}
+}
+
+// Client returns a client of the harness's registry. Its API token, if any,
+// is set with client.WithToken or client.WithSignedRequests.
+func (h *Harness) Client(opts ...client.Option) *client.Client {
+	h.t.Helper()
+
+	c, err := client.New(h.Server.URL, append([]client.Option{client.WithHTTPClient(h.Server.Client())}, opts...)...)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return c
+}
@@ -0,0 +1,143 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period defines the validity period of a single code.
+	Period = 30 * time.Second
+
+	// Digits defines the number of digits of a code.
+	Digits = 6
+
+	// Skew defines the number of periods before and after the current one in
+	// which a code is still accepted, tolerating clock drift.
+	Skew = 1
+
+	secretSize       = 20
+	recoveryCodeSize = 5
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random base32-encoded TOTP secret.
+func GenerateSecret() (string, error) {
+	bz := make([]byte, secretSize)
+	if _, err := rand.Read(bz); err != nil {
+		return "", err
+	}
+
+	return b32.EncodeToString(bz), nil
+}
+
+// ProvisioningURI returns the otpauth URI used to enroll the secret in an
+// authenticator app, typically rendered as a QR code.
+func ProvisioningURI(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("digits", fmt.Sprint(Digits))
+	v.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	return fmt.Sprintf("otpauth://totp/%s:%s?%s", url.PathEscape(issuer), url.PathEscape(account), v.Encode())
+}
+
+// Code returns the code of the given secret at time t as defined by RFC 6238.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := b32.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	return code(key, uint64(Step(t))), nil
+}
+
+// Validate returns true if code is valid for the secret at time t, within the
+// allowed clock skew.
+func Validate(secret, code string, t time.Time) bool {
+	_, ok := ValidateAfter(secret, code, t, 0)
+	return ok
+}
+
+// Step returns the time step of time t, i.e. the counter its code is derived
+// from.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// ValidateAfter returns the time step for which code is valid for the secret
+// at time t, within the allowed clock skew. Codes of steps up to lastStep, the
+// step of the last code accepted, are rejected so that a code is never
+// accepted twice.
+func ValidateAfter(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	for i := -Skew; i <= Skew; i++ {
+		at := t.Add(time.Duration(i) * Period)
+
+		expected, err := Code(secret, at)
+		if err != nil {
+			return 0, false
+		}
+
+		if step := Step(at); step > lastStep && subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// GenerateRecoveryCodes returns n single-use recovery codes along with their
+// hashes. Only the hashes are stored; the codes are shown to the user once.
+func GenerateRecoveryCodes(n int) (codes, hashes []string, err error) {
+	for i := 0; i < n; i++ {
+		bz := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(bz); err != nil {
+			return nil, nil, err
+		}
+
+		c := hex.EncodeToString(bz)
+		c = c[:5] + "-" + c[5:]
+
+		codes = append(codes, c)
+		hashes = append(hashes, HashRecoveryCode(c))
+	}
+
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns the hash under which a recovery code is stored.
+func HashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+func code(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg) // nolint: errcheck
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, value%mod)
+}
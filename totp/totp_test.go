@@ -0,0 +1,105 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the base32 encoding of the SHA1 seed of the RFC 6238 test
+// vectors, "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// the RFC 6238 SHA1 test vectors, truncated to six digits
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range testCases {
+		code, err := Code(rfcSecret, time.Unix(tc.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != tc.code {
+			t.Errorf("expected code %s at %d, got %s", tc.code, tc.unix, code)
+		}
+	}
+
+	if _, err := Code("not base32!", time.Now()); err == nil {
+		t.Error("expected an invalid secret to fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	code, err := Code(rfcSecret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !Validate(rfcSecret, code, now.Add(Period)) {
+		t.Error("expected a code of the previous period to be valid")
+	}
+
+	if Validate(rfcSecret, code, now.Add(2*Period)) {
+		t.Error("expected a code beyond the allowed skew to be invalid")
+	}
+
+	if Validate(rfcSecret, "000000", now) {
+		t.Error("expected a wrong code to be invalid")
+	}
+}
+
+func TestValidateAfter(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	code, err := Code(rfcSecret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := ValidateAfter(rfcSecret, code, now, 0)
+	if !ok || step != Step(now) {
+		t.Fatalf("expected the code to be accepted at step %d, got %d, %v", Step(now), step, ok)
+	}
+
+	if _, ok := ValidateAfter(rfcSecret, code, now, step); ok {
+		t.Error("expected a replayed code to be rejected")
+	}
+
+	later, err := Code(rfcSecret, now.Add(Period))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ValidateAfter(rfcSecret, later, now, step); !ok {
+		t.Error("expected a code of a later step to be accepted")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codes) != 3 || len(hashes) != 3 {
+		t.Fatalf("expected 3 codes and hashes, got %d and %d", len(codes), len(hashes))
+	}
+
+	for i, c := range codes {
+		if HashRecoveryCode(" "+c+" ") != hashes[i] {
+			t.Errorf("expected the hash of %s to ignore surrounding whitespace", c)
+		}
+	}
+}